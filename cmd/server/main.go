@@ -9,18 +9,28 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 	"time"
 
+	"github.com/cmellojr/modo-locadora/internal/auth"
 	"github.com/cmellojr/modo-locadora/internal/config"
 	"github.com/cmellojr/modo-locadora/internal/database"
 	"github.com/cmellojr/modo-locadora/internal/handlers"
+	"github.com/cmellojr/modo-locadora/internal/igdb"
 	"github.com/cmellojr/modo-locadora/internal/jobs"
+	"github.com/cmellojr/modo-locadora/internal/mailer"
+	"github.com/cmellojr/modo-locadora/internal/media"
+	"github.com/cmellojr/modo-locadora/internal/metadata"
 	"github.com/cmellojr/modo-locadora/internal/middleware"
+	"github.com/cmellojr/modo-locadora/internal/models"
+	"github.com/cmellojr/modo-locadora/internal/openapi"
 )
 
 func main() {
 	seedFlag := flag.Bool("seed", false, "Populate database with sample data and exit")
+	mirrorFlag := flag.Bool("mirror-covers", false, "Copy remote and old uploaded covers into media storage with thumbnails and exit")
+	migrateMediaFlag := flag.String("migrate-media-from", "", "Copy covers and badges from the named storage (local or s3) into MEDIA_STORAGE, point the database at the copies and exit")
 	flag.Parse()
 
 	config.LoadConfig()
@@ -69,6 +79,23 @@ func main() {
 			migrationsDir + "009_clubs.sql",
 			migrationsDir + "010_rename_status_english.sql",
 			migrationsDir + "011_verdict_popularity.sql",
+			migrationsDir + "012_club_challenges.sql",
+			migrationsDir + "013_club_league.sql",
+			migrationsDir + "014_sessions.sql",
+			migrationsDir + "015_staff_roles.sql",
+			migrationsDir + "016_email_tokens.sql",
+			migrationsDir + "017_login_protection.sql",
+			migrationsDir + "018_signup.sql",
+			migrationsDir + "019_account_deletion.sql",
+			migrationsDir + "020_two_factor.sql",
+			migrationsDir + "021_api_tokens.sql",
+			migrationsDir + "022_webhooks.sql",
+			migrationsDir + "023_calendar_feeds.sql",
+			migrationsDir + "024_game_metadata.sql",
+			migrationsDir + "025_metadata_source.sql",
+			migrationsDir + "026_cover_mirror.sql",
+			migrationsDir + "027_platforms.sql",
+			migrationsDir + "028_challenge_first_finisher.sql",
 		}
 		for _, f := range sqlFiles {
 			data, err := os.ReadFile(f)
@@ -84,22 +111,98 @@ func main() {
 		return
 	}
 
-	cookieSecret := os.Getenv("COOKIE_SECRET")
-	if cookieSecret == "" {
-		log.Println("Warning: COOKIE_SECRET not set. Using insecure default. Set it in production!")
-		cookieSecret = "modo-locadora-dev-secret-change-me"
+	storage, err := media.StorageFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to configure media storage: %v", err)
+	}
+	log.Printf("Media storage: %s", storage.Name())
+
+	if *migrateMediaFlag != "" {
+		if store == nil {
+			log.Fatal("Cannot migrate media: no database connection. Set DATABASE_URL.")
+		}
+		migrateMedia(ctx, store, *migrateMediaFlag, storage)
+		return
+	}
+
+	covers := media.NewImages(media.CoverKind, storage, "covers")
+	badges := media.NewImages(media.BadgeKind, storage, "clubs")
+
+	if *mirrorFlag {
+		if store == nil {
+			log.Fatal("Cannot mirror covers: no database connection. Set DATABASE_URL.")
+		}
+		mirrorCovers(ctx, store, covers)
+		return
 	}
 
+	// APP_ENV=production refuses to start with weak or default signing keys.
+	production := os.Getenv("APP_ENV") == "production"
+
+	keys, err := auth.KeyringFromEnv()
+	if err != nil {
+		log.Fatalf("failed to configure signing keys: %v", err)
+	}
+	if err := keys.CheckProduction(); err != nil {
+		if production {
+			log.Fatalf("refusing to start in production: %v", err)
+		}
+		log.Printf("Warning: %v. Fine for development, never in production!", err)
+	}
+	log.Printf("System: signing with key %q (%d key(s) accepted).", keys.Primary().ID, len(keys.Keys()))
+
 	adminEmail := os.Getenv("ADMIN_EMAIL")
 	if adminEmail == "" {
-		log.Println("Warning: ADMIN_EMAIL not set. Admin routes will be inaccessible.")
+		log.Println("Warning: ADMIN_EMAIL not set. No owner will be bootstrapped; staff must already exist.")
 	}
 
-	h := handlers.NewHandler(store, cookieSecret, adminEmail)
+	// One-time bootstrap: ADMIN_EMAIL becomes the owner while the store has none.
+	// If that member hasn't signed up yet, it happens on their first login.
+	if store != nil && adminEmail != "" {
+		granted, err := store.BootstrapOwner(ctx, adminEmail)
+		if err != nil {
+			log.Printf("Warning: failed to bootstrap owner: %v", err)
+		} else if granted {
+			log.Printf("System: %s is now the store owner.", adminEmail)
+		}
+	}
+
+	mail, err := mailer.FromEnv()
+	if err != nil {
+		log.Fatalf("failed to configure mailer: %v", err)
+	}
 
-	// Start the overdue rental checker background job.
+	signupMode := os.Getenv("SIGNUP_MODE")
+	if signupMode == "" {
+		signupMode = models.SignupModeOpen
+	}
+	if !models.IsSignupMode(signupMode) {
+		log.Fatalf("invalid SIGNUP_MODE %q: use open, invite or approval", signupMode)
+	}
+
+	games := igdb.FromEnv()
+	if games == nil {
+		log.Println("Warning: TWITCH_CLIENT_ID/TWITCH_CLIENT_SECRET not set. IGDB search is disabled.")
+	}
+
+	catalogDir := os.Getenv("CATALOG_DIR")
+	if catalogDir == "" {
+		catalogDir = filepath.Join("data", "catalog")
+	}
+	catalog, err := metadata.LoadCatalog(catalogDir)
+	if err != nil {
+		log.Printf("Warning: local catalog: %v", err)
+	}
+	log.Printf("System: local catalog has %d games (%s).", catalog.Len(), catalogDir)
+
+	h := handlers.NewHandler(store, mail, games, catalog, covers, badges, keys, adminEmail, os.Getenv("BASE_URL"), signupMode)
+
+	// Start the overdue rental checker, session sweeper and webhook
+	// dispatcher background jobs.
 	if store != nil {
 		jobs.StartOverdueChecker(ctx, store, 5*time.Minute)
+		jobs.StartSessionSweeper(ctx, store, time.Hour)
+		jobs.StartWebhookDispatcher(ctx, store, handlers.FormatActivityMessage, 10*time.Second)
 	}
 
 	layout := "web/templates/layout.html"
@@ -129,6 +232,11 @@ func main() {
 		log.Fatalf("failed to parse admin stock template: %v", err)
 	}
 
+	adminBulkStockTmpl, err := template.ParseFiles(layout, "web/templates/admin_stock_bulk.html")
+	if err != nil {
+		log.Fatalf("failed to parse admin bulk stock template: %v", err)
+	}
+
 	adminInventoryTmpl, err := template.ParseFiles(layout, "web/templates/admin_inventory.html")
 	if err != nil {
 		log.Fatalf("failed to parse admin inventory template: %v", err)
@@ -164,42 +272,265 @@ func main() {
 		log.Fatalf("failed to parse club form template: %v", err)
 	}
 
+	leagueTmpl, err := template.ParseFiles(layout, "web/templates/league.html")
+	if err != nil {
+		log.Fatalf("failed to parse league template: %v", err)
+	}
+
+	adminLeagueTmpl, err := template.ParseFiles(layout, "web/templates/admin_league.html")
+	if err != nil {
+		log.Fatalf("failed to parse admin league template: %v", err)
+	}
+
+	adminMembersTmpl, err := template.ParseFiles(layout, "web/templates/admin_members.html")
+	if err != nil {
+		log.Fatalf("failed to parse admin members template: %v", err)
+	}
+
+	adminStaffTmpl, err := template.ParseFiles(layout, "web/templates/admin_staff.html")
+	if err != nil {
+		log.Fatalf("failed to parse admin staff template: %v", err)
+	}
+
+	adminFeedTmpl, err := template.ParseFiles(layout, "web/templates/admin_feed.html")
+	if err != nil {
+		log.Fatalf("failed to parse admin feed template: %v", err)
+	}
+
+	profileTmpl, err := template.ParseFiles(layout, "web/templates/profile.html")
+	if err != nil {
+		log.Fatalf("failed to parse profile template: %v", err)
+	}
+
+	twoFactorTmpl, err := template.ParseFiles(layout, "web/templates/twofactor.html")
+	if err != nil {
+		log.Fatalf("failed to parse two-factor template: %v", err)
+	}
+
+	apiTokensTmpl, err := template.ParseFiles(layout, "web/templates/apitokens.html")
+	if err != nil {
+		log.Fatalf("failed to parse API tokens template: %v", err)
+	}
+
+	calendarTmpl, err := template.ParseFiles(layout, "web/templates/calendar.html")
+	if err != nil {
+		log.Fatalf("failed to parse calendar template: %v", err)
+	}
+
+	webhooksTmpl, err := template.ParseFiles(layout, "web/templates/webhooks.html")
+	if err != nil {
+		log.Fatalf("failed to parse webhooks template: %v", err)
+	}
+
+	webhookTmpl, err := template.ParseFiles(layout, "web/templates/webhook.html")
+	if err != nil {
+		log.Fatalf("failed to parse webhook template: %v", err)
+	}
+
+	login2FATmpl, err := template.ParseFiles(layout, "web/templates/login_2fa.html")
+	if err != nil {
+		log.Fatalf("failed to parse login two-factor template: %v", err)
+	}
+
+	signupTmpl, err := template.ParseFiles(layout, "web/templates/signup.html")
+	if err != nil {
+		log.Fatalf("failed to parse signup template: %v", err)
+	}
+
+	adminInvitesTmpl, err := template.ParseFiles(layout, "web/templates/admin_invites.html")
+	if err != nil {
+		log.Fatalf("failed to parse admin invites template: %v", err)
+	}
+
+	passwordForgotTmpl, err := template.ParseFiles(layout, "web/templates/password_forgot.html")
+	if err != nil {
+		log.Fatalf("failed to parse password forgot template: %v", err)
+	}
+
+	passwordResetTmpl, err := template.ParseFiles(layout, "web/templates/password_reset.html")
+	if err != nil {
+		log.Fatalf("failed to parse password reset template: %v", err)
+	}
+
+	adminSecurityTmpl, err := template.ParseFiles(layout, "web/templates/admin_security.html")
+	if err != nil {
+		log.Fatalf("failed to parse admin security template: %v", err)
+	}
+
+	csrfErrorTmpl, err := template.ParseFiles(layout, "web/templates/csrf_error.html")
+	if err != nil {
+		log.Fatalf("failed to parse csrf error template: %v", err)
+	}
+
 	mux := http.NewServeMux()
 	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
 		h.HandleIndex(w, r, indexTmpl)
 	})
 	mux.HandleFunc("POST /login", h.Login)
+	mux.HandleFunc("GET /login/2fa", func(w http.ResponseWriter, r *http.Request) {
+		h.LoginTwoFactorPage(w, r, login2FATmpl)
+	})
+	mux.HandleFunc("POST /login/2fa", h.LoginTwoFactor)
 	mux.HandleFunc("POST /logout", h.Logout)
+	mux.HandleFunc("GET /signup", func(w http.ResponseWriter, r *http.Request) {
+		h.SignupPage(w, r, signupTmpl)
+	})
+	mux.HandleFunc("POST /signup", func(w http.ResponseWriter, r *http.Request) {
+		h.Signup(w, r, signupTmpl)
+	})
+	mux.HandleFunc("GET /password/forgot", func(w http.ResponseWriter, r *http.Request) {
+		h.ForgotPasswordPage(w, r, passwordForgotTmpl)
+	})
+	mux.HandleFunc("POST /password/forgot", h.RequestPasswordReset)
+	mux.HandleFunc("GET /password/reset", func(w http.ResponseWriter, r *http.Request) {
+		h.ResetPasswordPage(w, r, passwordResetTmpl)
+	})
+	mux.HandleFunc("POST /password/reset", func(w http.ResponseWriter, r *http.Request) {
+		h.ResetPassword(w, r, passwordResetTmpl)
+	})
+	mux.HandleFunc("GET /verify-email", h.VerifyEmail)
 	mux.HandleFunc("GET /games", func(w http.ResponseWriter, r *http.Request) {
 		h.ListGames(w, r, platformsTmpl, gamesTmpl)
 	})
-	mux.HandleFunc("GET /search", h.SearchGame)
 
-	// Admin routes — protected by RequireAdmin middleware.
-	mux.HandleFunc("GET /admin/stock", middleware.RequireAdmin(cookieSecret, adminEmail, store, func(w http.ResponseWriter, r *http.Request) {
+	// Staff routes — protected by RequirePermission middleware.
+	mux.HandleFunc("GET /admin/stock", middleware.RequirePermission(keys, store, models.PermCatalog, func(w http.ResponseWriter, r *http.Request) {
 		h.AdminStock(w, r, adminStockTmpl)
 	}))
-	mux.HandleFunc("POST /admin/purchase", middleware.RequireAdmin(cookieSecret, adminEmail, store, h.PurchaseGame))
-	mux.HandleFunc("GET /admin/inventory", middleware.RequireAdmin(cookieSecret, adminEmail, store, func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("POST /admin/purchase", middleware.RequirePermission(keys, store, models.PermCatalog, h.PurchaseGame))
+	mux.HandleFunc("GET /admin/stock/bulk", middleware.RequirePermission(keys, store, models.PermCatalog, func(w http.ResponseWriter, r *http.Request) {
+		h.BulkStockPage(w, r, adminBulkStockTmpl)
+	}))
+	mux.HandleFunc("POST /admin/stock/bulk/review", middleware.RequirePermission(keys, store, models.PermCatalog, func(w http.ResponseWriter, r *http.Request) {
+		h.BulkStockReview(w, r, adminBulkStockTmpl)
+	}))
+	mux.HandleFunc("POST /admin/stock/bulk", middleware.RequirePermission(keys, store, models.PermCatalog, func(w http.ResponseWriter, r *http.Request) {
+		h.BulkStock(w, r, adminBulkStockTmpl)
+	}))
+	mux.HandleFunc("POST /admin/catalog", middleware.RequirePermission(keys, store, models.PermCatalog, func(w http.ResponseWriter, r *http.Request) {
+		h.UploadCatalog(w, r, adminStockTmpl)
+	}))
+	mux.HandleFunc("POST /admin/catalog/delete", middleware.RequirePermission(keys, store, models.PermCatalog, h.RemoveCatalog))
+	mux.HandleFunc("GET /admin/inventory", middleware.RequirePermission(keys, store, models.PermCatalog, func(w http.ResponseWriter, r *http.Request) {
 		h.AdminInventory(w, r, adminInventoryTmpl)
 	}))
-	mux.HandleFunc("GET /admin/edit/{id}", middleware.RequireAdmin(cookieSecret, adminEmail, store, func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("GET /admin/edit/{id}", middleware.RequirePermission(keys, store, models.PermCatalog, func(w http.ResponseWriter, r *http.Request) {
 		h.EditGame(w, r, adminEditTmpl)
 	}))
-	mux.HandleFunc("POST /admin/update-game", middleware.RequireAdmin(cookieSecret, adminEmail, store, h.UpdateGame))
-	mux.HandleFunc("GET /admin/returns", middleware.RequireAdmin(cookieSecret, adminEmail, store, func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("POST /admin/update-game", middleware.RequirePermission(keys, store, models.PermCatalog, func(w http.ResponseWriter, r *http.Request) {
+		h.UpdateGame(w, r, adminEditTmpl)
+	}))
+	mux.HandleFunc("POST /admin/edit/{id}/sync", middleware.RequirePermission(keys, store, models.PermCatalog, h.SyncGameMetadata))
+	mux.HandleFunc("GET /admin/returns", middleware.RequirePermission(keys, store, models.PermRentals, func(w http.ResponseWriter, r *http.Request) {
 		h.AdminReturns(w, r, adminReturnsTmpl)
 	}))
-	mux.HandleFunc("POST /admin/return-game", middleware.RequireAdmin(cookieSecret, adminEmail, store, h.ReturnGame))
+	mux.HandleFunc("POST /admin/return-game", middleware.RequirePermission(keys, store, models.PermRentals, h.ReturnGame))
+	mux.HandleFunc("GET /admin/league", middleware.RequirePermission(keys, store, models.PermModeration, func(w http.ResponseWriter, r *http.Request) {
+		h.AdminLeague(w, r, adminLeagueTmpl)
+	}))
+	mux.HandleFunc("POST /admin/league/seasons", middleware.RequirePermission(keys, store, models.PermModeration, h.CreateLeagueSeason))
+	mux.HandleFunc("POST /admin/league/seasons/{id}/archive", middleware.RequirePermission(keys, store, models.PermModeration, h.ArchiveLeagueSeason))
+	mux.HandleFunc("POST /admin/league/rules", middleware.RequirePermission(keys, store, models.PermModeration, h.UpdateScoringRules))
+	mux.HandleFunc("GET /admin/members", middleware.RequirePermission(keys, store, models.PermStaff, func(w http.ResponseWriter, r *http.Request) {
+		h.AdminMembers(w, r, adminMembersTmpl)
+	}))
+	mux.HandleFunc("POST /admin/members/{id}/revoke-sessions", middleware.RequirePermission(keys, store, models.PermStaff, h.AdminRevokeMemberSessions))
+	mux.HandleFunc("POST /admin/members/{id}/reset-2fa", middleware.RequirePermission(keys, store, models.PermStaff, h.AdminResetTwoFactor))
+	mux.HandleFunc("GET /admin/staff", middleware.RequirePermission(keys, store, models.PermStaff, func(w http.ResponseWriter, r *http.Request) {
+		h.AdminStaff(w, r, adminStaffTmpl)
+	}))
+	mux.HandleFunc("POST /admin/staff", middleware.RequirePermission(keys, store, models.PermStaff, h.GrantStaffRole))
+	mux.HandleFunc("POST /admin/staff/{id}/revoke", middleware.RequirePermission(keys, store, models.PermStaff, h.RevokeStaffRole))
+	mux.HandleFunc("POST /admin/members/{id}/approve", middleware.RequirePermission(keys, store, models.PermStaff, h.ApproveMember))
+	mux.HandleFunc("POST /admin/members/{id}/reject", middleware.RequirePermission(keys, store, models.PermStaff, h.RejectMember))
+	mux.HandleFunc("GET /admin/invites", middleware.RequirePermission(keys, store, models.PermStaff, func(w http.ResponseWriter, r *http.Request) {
+		h.AdminInvites(w, r, adminInvitesTmpl)
+	}))
+	mux.HandleFunc("POST /admin/invites", middleware.RequirePermission(keys, store, models.PermStaff, h.CreateInviteCode))
+	mux.HandleFunc("POST /admin/invites/{id}/revoke", middleware.RequirePermission(keys, store, models.PermStaff, h.RevokeInviteCode))
+	mux.HandleFunc("GET /admin/security", middleware.RequirePermission(keys, store, models.PermStaff, func(w http.ResponseWriter, r *http.Request) {
+		h.AdminSecurity(w, r, adminSecurityTmpl)
+	}))
+	mux.HandleFunc("POST /admin/security/{id}/unlock", middleware.RequirePermission(keys, store, models.PermStaff, h.UnlockMember))
+	mux.HandleFunc("GET /admin/webhooks", middleware.RequirePermission(keys, store, models.PermStaff, func(w http.ResponseWriter, r *http.Request) {
+		h.WebhooksPage(w, r, webhooksTmpl)
+	}))
+	mux.HandleFunc("POST /admin/webhooks", middleware.RequirePermission(keys, store, models.PermStaff, func(w http.ResponseWriter, r *http.Request) {
+		h.CreateWebhook(w, r, webhooksTmpl, webhookTmpl)
+	}))
+	mux.HandleFunc("GET /admin/webhooks/{hookID}", middleware.RequirePermission(keys, store, models.PermStaff, func(w http.ResponseWriter, r *http.Request) {
+		h.WebhookPage(w, r, webhookTmpl)
+	}))
+	mux.HandleFunc("POST /admin/webhooks/{hookID}", middleware.RequirePermission(keys, store, models.PermStaff, func(w http.ResponseWriter, r *http.Request) {
+		h.UpdateWebhook(w, r, webhookTmpl)
+	}))
+	mux.HandleFunc("POST /admin/webhooks/{hookID}/enable", middleware.RequirePermission(keys, store, models.PermStaff, func(w http.ResponseWriter, r *http.Request) {
+		h.ToggleWebhook(w, r, true)
+	}))
+	mux.HandleFunc("POST /admin/webhooks/{hookID}/disable", middleware.RequirePermission(keys, store, models.PermStaff, func(w http.ResponseWriter, r *http.Request) {
+		h.ToggleWebhook(w, r, false)
+	}))
+	mux.HandleFunc("POST /admin/webhooks/{hookID}/rotate-secret", middleware.RequirePermission(keys, store, models.PermStaff, func(w http.ResponseWriter, r *http.Request) {
+		h.RotateWebhookSecret(w, r, webhookTmpl)
+	}))
+	mux.HandleFunc("POST /admin/webhooks/{hookID}/delete", middleware.RequirePermission(keys, store, models.PermStaff, h.DeleteWebhook))
+	mux.HandleFunc("POST /admin/webhooks/{hookID}/deliveries/{deliveryID}/retry", middleware.RequirePermission(keys, store, models.PermStaff, h.RetryWebhookDelivery))
+	mux.HandleFunc("GET /admin/feed", middleware.RequirePermission(keys, store, models.PermModeration, func(w http.ResponseWriter, r *http.Request) {
+		h.AdminFeed(w, r, adminFeedTmpl)
+	}))
+	mux.HandleFunc("POST /admin/feed/{id}/delete", middleware.RequirePermission(keys, store, models.PermModeration, h.DeleteActivity))
 
 	// Member routes — protected by RequireAuth middleware.
-	mux.HandleFunc("GET /membership", middleware.RequireAuth(cookieSecret, func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("GET /membership", middleware.RequireAuth(keys, store, func(w http.ResponseWriter, r *http.Request) {
 		h.Membership(w, r, membershipTmpl)
 	}))
-	mux.HandleFunc("POST /rent", middleware.RequireAuth(cookieSecret, h.RentGame))
-	mux.HandleFunc("POST /membership/notes", middleware.RequireAuth(cookieSecret, h.SavePasswordNotes))
-	mux.HandleFunc("POST /membership/redeem", middleware.RequireAuth(cookieSecret, h.HandleRedeem))
-	mux.HandleFunc("POST /membership/return", middleware.RequireAuth(cookieSecret, h.HandleMemberReturn))
+	mux.HandleFunc("POST /rent", middleware.RequireAuth(keys, store, h.RentGame))
+	mux.HandleFunc("POST /membership/notes", middleware.RequireAuth(keys, store, h.SavePasswordNotes))
+	mux.HandleFunc("POST /membership/redeem", middleware.RequireAuth(keys, store, h.HandleRedeem))
+	mux.HandleFunc("POST /membership/return", middleware.RequireAuth(keys, store, h.HandleMemberReturn))
+	mux.HandleFunc("POST /membership/primary-club", middleware.RequireAuth(keys, store, h.SetPrimaryClub))
+	mux.HandleFunc("POST /membership/verify-email", middleware.RequireAuth(keys, store, h.ResendVerification))
+	mux.HandleFunc("GET /membership/profile", middleware.RequireAuth(keys, store, func(w http.ResponseWriter, r *http.Request) {
+		h.ProfilePage(w, r, profileTmpl)
+	}))
+	mux.HandleFunc("POST /membership/profile", middleware.RequireAuth(keys, store, func(w http.ResponseWriter, r *http.Request) {
+		h.UpdateProfile(w, r, profileTmpl)
+	}))
+	mux.HandleFunc("POST /membership/password", middleware.RequireAuth(keys, store, func(w http.ResponseWriter, r *http.Request) {
+		h.ChangePassword(w, r, profileTmpl)
+	}))
+	mux.HandleFunc("POST /membership/delete", middleware.RequireAuth(keys, store, func(w http.ResponseWriter, r *http.Request) {
+		h.DeleteAccount(w, r, profileTmpl)
+	}))
+	mux.HandleFunc("GET /membership/2fa", middleware.RequireAuth(keys, store, func(w http.ResponseWriter, r *http.Request) {
+		h.TwoFactorPage(w, r, twoFactorTmpl)
+	}))
+	mux.HandleFunc("POST /membership/2fa/enable", middleware.RequireAuth(keys, store, func(w http.ResponseWriter, r *http.Request) {
+		h.EnableTwoFactor(w, r, twoFactorTmpl)
+	}))
+	mux.HandleFunc("POST /membership/2fa/recovery-codes", middleware.RequireAuth(keys, store, func(w http.ResponseWriter, r *http.Request) {
+		h.RegenerateRecoveryCodes(w, r, twoFactorTmpl)
+	}))
+	mux.HandleFunc("POST /membership/2fa/disable", middleware.RequireAuth(keys, store, func(w http.ResponseWriter, r *http.Request) {
+		h.DisableTwoFactor(w, r, twoFactorTmpl)
+	}))
+	mux.HandleFunc("GET /membership/tokens", middleware.RequireAuth(keys, store, func(w http.ResponseWriter, r *http.Request) {
+		h.APITokensPage(w, r, apiTokensTmpl)
+	}))
+	mux.HandleFunc("POST /membership/tokens", middleware.RequireAuth(keys, store, func(w http.ResponseWriter, r *http.Request) {
+		h.CreateAPIToken(w, r, apiTokensTmpl)
+	}))
+	mux.HandleFunc("POST /membership/tokens/{id}/revoke", middleware.RequireAuth(keys, store, h.RevokeAPIToken))
+	mux.HandleFunc("GET /membership/calendar", middleware.RequireAuth(keys, store, func(w http.ResponseWriter, r *http.Request) {
+		h.CalendarPage(w, r, calendarTmpl)
+	}))
+	mux.HandleFunc("POST /membership/calendar", middleware.RequireAuth(keys, store, func(w http.ResponseWriter, r *http.Request) {
+		h.CreateCalendarFeed(w, r, calendarTmpl)
+	}))
+	mux.HandleFunc("POST /membership/calendar/settings", middleware.RequireAuth(keys, store, h.UpdateCalendarFeed))
+	mux.HandleFunc("POST /membership/calendar/delete", middleware.RequireAuth(keys, store, h.DeleteCalendarFeed))
+	mux.HandleFunc("GET /calendar/{file}", h.CalendarFeed)
+	mux.HandleFunc("POST /membership/sessions/revoke-all", middleware.RequireAuth(keys, store, h.RevokeAllSessions))
 
 	// Serve static files from web/static
 	fileServer := http.FileServer(http.Dir("web/static"))
@@ -209,36 +540,122 @@ func main() {
 	mux.HandleFunc("GET /clubs", func(w http.ResponseWriter, r *http.Request) {
 		h.ListClubs(w, r, clubsTmpl)
 	})
-	mux.HandleFunc("GET /clubs/new", middleware.RequireAuth(cookieSecret, func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("GET /clubs/new", middleware.RequireAuth(keys, store, func(w http.ResponseWriter, r *http.Request) {
 		h.ClubFormPage(w, r, clubFormTmpl, false)
 	}))
-	mux.HandleFunc("POST /clubs", middleware.RequireAuth(cookieSecret, h.CreateClub))
+	mux.HandleFunc("POST /clubs", middleware.RequireAuth(keys, store, func(w http.ResponseWriter, r *http.Request) {
+		h.CreateClub(w, r, clubFormTmpl)
+	}))
 	mux.HandleFunc("GET /clubs/{id}", func(w http.ResponseWriter, r *http.Request) {
 		h.ClubDetail(w, r, clubDetailTmpl)
 	})
-	mux.HandleFunc("GET /clubs/{id}/edit", middleware.RequireAuth(cookieSecret, func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("GET /clubs/{id}/edit", middleware.RequireAuth(keys, store, func(w http.ResponseWriter, r *http.Request) {
 		h.ClubFormPage(w, r, clubFormTmpl, true)
 	}))
-	mux.HandleFunc("POST /clubs/{id}/edit", middleware.RequireAuth(cookieSecret, h.UpdateClub))
-	mux.HandleFunc("POST /clubs/{id}/join", middleware.RequireAuth(cookieSecret, h.JoinClub))
-	mux.HandleFunc("POST /clubs/{id}/leave", middleware.RequireAuth(cookieSecret, h.LeaveClub))
-	mux.HandleFunc("POST /clubs/{id}/promote", middleware.RequireAuth(cookieSecret, h.PromoteClubMember))
-	mux.HandleFunc("POST /clubs/{id}/remove", middleware.RequireAuth(cookieSecret, h.RemoveClubMember))
-	mux.HandleFunc("POST /clubs/{id}/delete", middleware.RequireAuth(cookieSecret, h.DeleteClub))
+	mux.HandleFunc("POST /clubs/{id}/edit", middleware.RequireAuth(keys, store, func(w http.ResponseWriter, r *http.Request) {
+		h.UpdateClub(w, r, clubFormTmpl)
+	}))
+	mux.HandleFunc("GET /clubs/{id}/webhooks", middleware.RequireAuth(keys, store, func(w http.ResponseWriter, r *http.Request) {
+		h.WebhooksPage(w, r, webhooksTmpl)
+	}))
+	mux.HandleFunc("POST /clubs/{id}/webhooks", middleware.RequireAuth(keys, store, func(w http.ResponseWriter, r *http.Request) {
+		h.CreateWebhook(w, r, webhooksTmpl, webhookTmpl)
+	}))
+	mux.HandleFunc("GET /clubs/{id}/webhooks/{hookID}", middleware.RequireAuth(keys, store, func(w http.ResponseWriter, r *http.Request) {
+		h.WebhookPage(w, r, webhookTmpl)
+	}))
+	mux.HandleFunc("POST /clubs/{id}/webhooks/{hookID}", middleware.RequireAuth(keys, store, func(w http.ResponseWriter, r *http.Request) {
+		h.UpdateWebhook(w, r, webhookTmpl)
+	}))
+	mux.HandleFunc("POST /clubs/{id}/webhooks/{hookID}/enable", middleware.RequireAuth(keys, store, func(w http.ResponseWriter, r *http.Request) {
+		h.ToggleWebhook(w, r, true)
+	}))
+	mux.HandleFunc("POST /clubs/{id}/webhooks/{hookID}/disable", middleware.RequireAuth(keys, store, func(w http.ResponseWriter, r *http.Request) {
+		h.ToggleWebhook(w, r, false)
+	}))
+	mux.HandleFunc("POST /clubs/{id}/webhooks/{hookID}/rotate-secret", middleware.RequireAuth(keys, store, func(w http.ResponseWriter, r *http.Request) {
+		h.RotateWebhookSecret(w, r, webhookTmpl)
+	}))
+	mux.HandleFunc("POST /clubs/{id}/webhooks/{hookID}/delete", middleware.RequireAuth(keys, store, h.DeleteWebhook))
+	mux.HandleFunc("POST /clubs/{id}/webhooks/{hookID}/deliveries/{deliveryID}/retry", middleware.RequireAuth(keys, store, h.RetryWebhookDelivery))
+	mux.HandleFunc("POST /clubs/{id}/join", middleware.RequireAuth(keys, store, h.JoinClub))
+	mux.HandleFunc("POST /clubs/{id}/leave", middleware.RequireAuth(keys, store, h.LeaveClub))
+	mux.HandleFunc("POST /clubs/{id}/promote", middleware.RequireAuth(keys, store, h.PromoteClubMember))
+	mux.HandleFunc("POST /clubs/{id}/remove", middleware.RequireAuth(keys, store, h.RemoveClubMember))
+	mux.HandleFunc("POST /clubs/{id}/delete", middleware.RequireAuth(keys, store, h.DeleteClub))
+	mux.HandleFunc("POST /clubs/{id}/challenges", middleware.RequireAuth(keys, store, h.CreateClubChallenge))
+	mux.HandleFunc("POST /clubs/{id}/challenges/{challengeID}/join", middleware.RequireAuth(keys, store, h.JoinClubChallenge))
+	mux.HandleFunc("POST /clubs/{id}/challenges/{challengeID}/give-up", middleware.RequireAuth(keys, store, h.GiveUpClubChallenge))
+
+	// League routes — public standings.
+	mux.HandleFunc("GET /league", func(w http.ResponseWriter, r *http.Request) {
+		h.LeaguePage(w, r, leagueTmpl)
+	})
+	mux.HandleFunc("GET /league/{id}", func(w http.ResponseWriter, r *http.Request) {
+		h.LeaguePage(w, r, leagueTmpl)
+	})
 
-	mux.HandleFunc("POST /members", h.CreateMember)
+	// JSON endpoints, including the /api/v1 API, come from the handlers'
+	// route table so each one has an OpenAPI entry. The document is checked
+	// here; API_VALIDATE_RESPONSES=true also checks every response against it
+	// and logs JSON routes missing from it, as a development aid. The tests in
+	// internal/handlers fail on the same checks.
+	spec, err := h.OpenAPI()
+	if err != nil {
+		log.Fatalf("failed to build OpenAPI document: %v", err)
+	}
+	validateResponses := os.Getenv("API_VALIDATE_RESPONSES") == "true"
+	for _, rt := range h.APIRoutes() {
+		handler := rt.Handler
+		if validateResponses {
+			handler = spec.ValidateResponses(rt.Method, rt.Path, handler, openapi.LogMismatch)
+		}
+		mux.HandleFunc(rt.Method+" "+rt.Path, handler)
+	}
+	mux.HandleFunc("/api/", h.APINotFound)
 	mux.HandleFunc("GET /games/{id}", func(w http.ResponseWriter, r *http.Request) {
 		h.GameDetailPage(w, r, gameDetailTmpl)
 	})
 
+	// Atom/RSS feeds ({format} is "atom" or "rss").
+	mux.HandleFunc("GET /feeds/activity/{format}", h.ActivityFeed)
+	mux.HandleFunc("GET /feeds/new-arrivals/{format}", h.NewArrivalsFeed)
+	mux.HandleFunc("GET /feeds/platforms/{platform}/{format}", h.PlatformFeed)
+	mux.HandleFunc("GET /feeds/members/{name}/{format}", h.MemberFeed)
+	mux.HandleFunc("GET /feeds/clubs/{id}/{format}", h.ClubFeed)
+
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 	}
 
+	// Every state-changing request must carry a CSRF token. POST /members is
+	// a cookie-less JSON endpoint and is exempt. API writes send the token in
+	// the X-CSRF-Token header and get a JSON 403 without it; requests with a
+	// bearer API token need none.
+	csrfFailure := func(w http.ResponseWriter, r *http.Request) {
+		h.CSRFFailure(w, r, csrfErrorTmpl)
+	}
+	csrfExempt := []string{"/members"}
+
+	var routes http.Handler = mux
+	if validateResponses {
+		routes = spec.ValidateRoutes(mux, openapi.LogMismatch)
+	}
+
+	// Behind a reverse proxy, the client address comes from the proxy's
+	// header, so rate limits are per client rather than shared.
+	proxies, err := middleware.TrustedProxiesFromEnv()
+	if err != nil {
+		log.Fatalf("failed to configure trusted proxies: %v", err)
+	}
+	if proxies.Header != "" {
+		log.Printf("System: client address from %s sent by %d trusted proxy range(s).", proxies.Header, len(proxies.Nets))
+	}
+
 	srv := &http.Server{
 		Addr:    ":" + port,
-		Handler: mux,
+		Handler: middleware.RealIP(proxies, middleware.APITokens(store, h.APIInvalidToken, middleware.CSRF(keys, store, csrfFailure, csrfExempt, routes))),
 	}
 
 	go func() {
@@ -266,3 +683,101 @@ func main() {
 
 	fmt.Println("Server gracefully stopped")
 }
+
+// mirrorCovers copies every cover without a thumbnail into media storage:
+// remote covers are downloaded and covers uploaded before the media pipeline
+// are processed again from storage. Failures are logged and skipped, so the
+// command can be run again later.
+func mirrorCovers(ctx context.Context, store database.Store, covers *media.Images) {
+	games, err := store.ListGames(ctx)
+	if err != nil {
+		log.Fatalf("Failed to list games: %v", err)
+	}
+
+	var mirrored, failed int
+	for _, g := range games {
+		if g.CoverURL == "" || g.CoverThumbURL != "" {
+			continue
+		}
+
+		var cover media.Image
+		switch {
+		case media.IsRemote(g.CoverURL):
+			cover, err = covers.Mirror(ctx, g.ID.String(), g.CoverURL)
+		case covers.Owns(g.CoverURL):
+			var data []byte
+			if data, err = covers.Read(ctx, g.CoverURL); err == nil {
+				cover, err = covers.Save(ctx, g.ID.String(), data)
+			}
+		default:
+			continue
+		}
+		if err == nil {
+			err = store.SetGameCover(ctx, g.ID, cover.URL, cover.ThumbURL, cover.SourceURL)
+		}
+		if err != nil {
+			log.Printf("Cover of %q (%s): %v", g.Title, g.CoverURL, err)
+			failed++
+			continue
+		}
+
+		// The old upload was replaced by the processed files.
+		if covers.Owns(g.CoverURL) && g.CoverURL != cover.URL {
+			if err := covers.Remove(ctx, g.CoverURL); err != nil {
+				log.Printf("Cover of %q: %v", g.Title, err)
+			}
+		}
+		log.Printf("Mirrored: %s -> %s", g.Title, cover.URL)
+		mirrored++
+	}
+	log.Printf("Covers mirrored: %d, failed: %d.", mirrored, failed)
+}
+
+// mediaNamespaces are the storage key prefixes migrateMedia copies.
+var mediaNamespaces = []string{"covers", "clubs"}
+
+// migrateMedia copies every cover and badge from the storage named from into
+// dst, then rewrites the URLs in the database to the copies. Source files are
+// kept, so the command can be run again and the old storage removed by hand
+// once the pages look right.
+func migrateMedia(ctx context.Context, store database.Store, from string, dst media.Storage) {
+	src, err := media.OpenStorage(from)
+	if err != nil {
+		log.Fatalf("Failed to open source storage: %v", err)
+	}
+	if src.Name() == dst.Name() {
+		log.Fatalf("Source and destination are both %s storage. Set MEDIA_STORAGE to the new backend.", dst.Name())
+	}
+
+	var copied, failed int
+	for _, ns := range mediaNamespaces {
+		keys, err := src.List(ctx, ns+"/")
+		if err != nil {
+			log.Fatalf("Failed to list %s in %s storage: %v", ns, src.Name(), err)
+		}
+		for _, key := range keys {
+			data, err := src.Get(ctx, key)
+			if err == nil {
+				err = dst.Put(ctx, key, data)
+			}
+			if err != nil {
+				log.Printf("Copy %s: %v", key, err)
+				failed++
+				continue
+			}
+			copied++
+		}
+	}
+	log.Printf("Files copied: %d, failed: %d.", copied, failed)
+	if failed > 0 {
+		log.Fatal("Database left unchanged. Fix the errors above and run the command again.")
+	}
+
+	for _, ns := range mediaNamespaces {
+		n, err := store.ReplaceMediaURLPrefix(ctx, src.URL(ns+"/"), dst.URL(ns+"/"))
+		if err != nil {
+			log.Fatalf("Failed to update %s URLs: %v", ns, err)
+		}
+		log.Printf("URLs updated in %s: %d.", ns, n)
+	}
+}
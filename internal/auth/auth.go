@@ -2,14 +2,32 @@ package auth
 
 import (
 	"crypto/hmac"
+	"crypto/rand"
 	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
 	"errors"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 )
 
-const cookieName = "session_member"
+// SessionCookieName is the cookie holding the signed session.
+const SessionCookieName = "session_member"
+
+const pendingCookieName = "login_2fa"
+
+// Session lifetimes. SessionMaxAge is the absolute limit from login;
+// SessionIdleTimeout ends a session that has not been used for that long.
+const (
+	SessionMaxAge      = 7 * 24 * time.Hour
+	SessionIdleTimeout = 48 * time.Hour
+)
+
+// PendingLoginTTL is how long a member has to type the two-factor code after
+// the password was accepted.
+const PendingLoginTTL = 5 * time.Minute
 
 // ErrInvalidSignature is returned when a cookie signature does not match.
 var ErrInvalidSignature = errors.New("invalid cookie signature")
@@ -44,27 +62,80 @@ func VerifyCookie(signed, secret string) (string, error) {
 	return value, nil
 }
 
-// SetSessionCookie writes a signed session cookie with the member ID.
-func SetSessionCookie(w http.ResponseWriter, memberID, secret string) {
+// NewToken returns a random opaque token for sessions and e-mail links.
+func NewToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// APITokenPrefix starts every personal API token, so leaked tokens are easy
+// to recognize in logs and by secret scanners.
+const APITokenPrefix = "mlk_"
+
+// NewAPIToken returns a random personal API token.
+func NewAPIToken() (string, error) {
+	token, err := NewToken()
+	if err != nil {
+		return "", err
+	}
+	return APITokenPrefix + token, nil
+}
+
+// WebhookSecretPrefix marks webhook signing secrets.
+const WebhookSecretPrefix = "whsec_"
+
+// NewWebhookSecret returns a random webhook signing secret.
+func NewWebhookSecret() (string, error) {
+	secret, err := NewToken()
+	if err != nil {
+		return "", err
+	}
+	return WebhookSecretPrefix + secret, nil
+}
+
+// SignWebhook returns the X-Locadora-Signature value of a webhook body sent
+// at the given Unix time: "v1=" and the hex HMAC-SHA256 of
+// "<timestamp>.<body>" keyed with the endpoint's secret.
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return "v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// HashToken returns the hex SHA-256 of a token, the form in which tokens
+// are stored server-side.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// SetSessionCookie writes a session cookie with the token, signed with the
+// keyring's primary key.
+func SetSessionCookie(w http.ResponseWriter, token string, keys *Keyring) {
 	http.SetCookie(w, &http.Cookie{
-		Name:     cookieName,
-		Value:    SignCookie(memberID, secret),
+		Name:     SessionCookieName,
+		Value:    keys.Sign(token),
 		Path:     "/",
-		MaxAge:   86400 * 7, // 7 days
+		MaxAge:   int(SessionMaxAge.Seconds()),
 		HttpOnly: true,
 		SameSite: http.SameSiteStrictMode,
 	})
 }
 
-// GetSessionMemberID extracts and verifies the member ID from the session
-// cookie. Returns an empty string if the cookie is missing or invalid.
-func GetSessionMemberID(r *http.Request, secret string) string {
-	c, err := r.Cookie(cookieName)
+// GetSessionToken extracts and verifies the session token from the session
+// cookie, accepting any key in the keyring. Returns an empty string if the
+// cookie is missing or invalid.
+func GetSessionToken(r *http.Request, keys *Keyring) string {
+	c, err := r.Cookie(SessionCookieName)
 	if err != nil {
 		return ""
 	}
 
-	value, err := VerifyCookie(c.Value, secret)
+	value, err := keys.Verify(c.Value)
 	if err != nil {
 		return ""
 	}
@@ -75,9 +146,58 @@ func GetSessionMemberID(r *http.Request, secret string) string {
 // ClearSessionCookie removes the session cookie.
 func ClearSessionCookie(w http.ResponseWriter) {
 	http.SetCookie(w, &http.Cookie{
-		Name:   cookieName,
-		Value:  "",
-		Path:   "/",
-		MaxAge: -1,
+		Name:     SessionCookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// SetPendingLoginCookie remembers, for PendingLoginTTL, a member whose password
+// was accepted but who still has to pass the two-factor step.
+func SetPendingLoginCookie(w http.ResponseWriter, memberID string, keys *Keyring) {
+	expires := time.Now().Add(PendingLoginTTL).Unix()
+	http.SetCookie(w, &http.Cookie{
+		Name:     pendingCookieName,
+		Value:    keys.Sign(memberID + "|" + strconv.FormatInt(expires, 10)),
+		Path:     "/login",
+		MaxAge:   int(PendingLoginTTL.Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// GetPendingLogin returns the member ID from a valid, unexpired pending login
+// cookie, or an empty string.
+func GetPendingLogin(r *http.Request, keys *Keyring) string {
+	c, err := r.Cookie(pendingCookieName)
+	if err != nil {
+		return ""
+	}
+
+	value, err := keys.Verify(c.Value)
+	if err != nil {
+		return ""
+	}
+
+	memberID, exp, ok := strings.Cut(value, "|")
+	expires, err := strconv.ParseInt(exp, 10, 64)
+	if !ok || err != nil || time.Now().Unix() > expires {
+		return ""
+	}
+	return memberID
+}
+
+// ClearPendingLoginCookie removes the pending login cookie.
+func ClearPendingLoginCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     pendingCookieName,
+		Value:    "",
+		Path:     "/login",
+		MaxAge:   -1,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
 	})
 }
@@ -0,0 +1,163 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// MinSecretLength is the shortest signing secret accepted in production.
+const MinSecretLength = 32
+
+// InsecureDevSecret is the signing secret used when none is configured. It is
+// public, so it is only good for local development.
+const InsecureDevSecret = "modo-locadora-dev-secret-change-me"
+
+// Key is a signing secret and the short ID that names it inside signed values.
+type Key struct {
+	ID     string
+	Secret string
+}
+
+// Keyring holds the signing keys for cookies and e-mail links. The first key
+// is the primary one and signs everything; the others are only accepted when
+// verifying, so values signed before a rotation keep working until they expire.
+type Keyring struct {
+	keys []Key
+}
+
+// NewKeyring builds a keyring from keys, primary first. Key IDs must be
+// unique and use only letters, digits, "-" and "_"; secrets can't be empty.
+func NewKeyring(keys ...Key) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("keyring needs at least one key")
+	}
+
+	seen := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		if !validKeyID(k.ID) {
+			return nil, fmt.Errorf("invalid key ID %q: use letters, digits, - and _", k.ID)
+		}
+		if seen[k.ID] {
+			return nil, fmt.Errorf("duplicate key ID %q", k.ID)
+		}
+		if k.Secret == "" {
+			return nil, fmt.Errorf("key %q has an empty secret", k.ID)
+		}
+		seen[k.ID] = true
+	}
+
+	return &Keyring{keys: append([]Key(nil), keys...)}, nil
+}
+
+// ParseKeyring parses a comma-separated "id:secret" list, primary first,
+// e.g. "2026b:new-secret,2026a:old-secret".
+func ParseKeyring(spec string) (*Keyring, error) {
+	var keys []Key
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, secret, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid key entry %q: want id:secret", entry)
+		}
+		keys = append(keys, Key{ID: strings.TrimSpace(id), Secret: secret})
+	}
+	return NewKeyring(keys...)
+}
+
+// KeyringFromEnv builds the keyring from COOKIE_SECRETS ("id:secret" list,
+// primary first) or, when unset, from the single COOKIE_SECRET. With neither
+// set it falls back to InsecureDevSecret; CheckProduction rejects that.
+func KeyringFromEnv() (*Keyring, error) {
+	if spec := os.Getenv("COOKIE_SECRETS"); spec != "" {
+		return ParseKeyring(spec)
+	}
+	secret := os.Getenv("COOKIE_SECRET")
+	if secret == "" {
+		secret = InsecureDevSecret
+	}
+	return NewKeyring(Key{ID: "k1", Secret: secret})
+}
+
+// Primary returns the key used for signing.
+func (k *Keyring) Primary() Key {
+	return k.keys[0]
+}
+
+// Keys returns every key, primary first.
+func (k *Keyring) Keys() []Key {
+	return append([]Key(nil), k.keys...)
+}
+
+// CheckProduction reports the first key that must not be used in production:
+// the public development secret or one shorter than MinSecretLength.
+func (k *Keyring) CheckProduction() error {
+	for _, key := range k.keys {
+		if key.Secret == InsecureDevSecret {
+			return fmt.Errorf("key %q is the insecure development default; set COOKIE_SECRET or COOKIE_SECRETS", key.ID)
+		}
+		if len(key.Secret) < MinSecretLength {
+			return fmt.Errorf("key %q is %d characters long; secrets need at least %d", key.ID, len(key.Secret), MinSecretLength)
+		}
+	}
+	return nil
+}
+
+// Sign returns "value.keyID.hmac_hex", signed with the primary key. The MAC
+// covers the key ID, so a signature can't be moved to another key.
+func (k *Keyring) Sign(value string) string {
+	key := k.Primary()
+	return value + "." + key.ID + "." + keyedMAC(key, value)
+}
+
+// Verify checks a value produced by Sign with any key in the ring and returns
+// the original value. Values in the older "value.hmac_hex" format, signed
+// before key IDs existed, are checked against every key. Returns
+// ErrInvalidSignature if no key matches.
+func (k *Keyring) Verify(signed string) (string, error) {
+	if idx := strings.LastIndex(signed, "."); idx >= 0 {
+		rest, sig := signed[:idx], signed[idx+1:]
+		if j := strings.LastIndex(rest, "."); j >= 0 {
+			value, id := rest[:j], rest[j+1:]
+			for _, key := range k.keys {
+				if key.ID == id && hmac.Equal([]byte(sig), []byte(keyedMAC(key, value))) {
+					return value, nil
+				}
+			}
+		}
+	}
+
+	for _, key := range k.keys {
+		if value, err := VerifyCookie(signed, key.Secret); err == nil {
+			return value, nil
+		}
+	}
+	return "", ErrInvalidSignature
+}
+
+// keyedMAC returns the hex HMAC-SHA256 of the key ID and value.
+func keyedMAC(key Key, value string) string {
+	mac := hmac.New(sha256.New, []byte(key.Secret))
+	mac.Write([]byte(key.ID + "|" + value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func validKeyID(id string) bool {
+	if id == "" || len(id) > 32 {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,190 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/cmellojr/modo-locadora/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ── Account methods ─────────────────────────────────────────────────────────
+
+// ErrOpenRentals is returned when deleting an account that still has tapes out.
+var ErrOpenRentals = errors.New("member still has unreturned rentals")
+
+// clubNameEvents are activity types whose member_name holds a club name.
+const clubNameEvents = `('challenge_created', 'league_champion')`
+
+// UpdateMemberProfile updates the member's editable contact fields.
+func (s *PostgresStore) UpdateMemberProfile(ctx context.Context, memberID uuid.UUID, address, phone, favoriteConsole string) error {
+	_, err := s.pool.Exec(ctx,
+		`UPDATE members SET address = $2, phone = $3, favorite_console = $4 WHERE id = $1`,
+		memberID, address, phone, favoriteConsole)
+	if err != nil {
+		return fmt.Errorf("failed to update member profile: %w", err)
+	}
+	return nil
+}
+
+// ListMemberRentalRecords returns every rental of a member, newest first.
+func (s *PostgresStore) ListMemberRentalRecords(ctx context.Context, memberID uuid.UUID) ([]MemberRentalRecord, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT r.id, g.id, g.title, g.platform, r.rented_at, r.due_at, r.returned_at,
+		        COALESCE(r.personal_note, ''), COALESCE(r.public_legacy, '')
+		 FROM rentals r
+		 JOIN game_copies gc ON gc.id = r.copy_id
+		 JOIN games g ON g.id = gc.game_id
+		 WHERE r.member_id = $1
+		 ORDER BY r.rented_at DESC`, memberID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query member rentals: %w", err)
+	}
+	defer rows.Close()
+
+	var result []MemberRentalRecord
+	for rows.Next() {
+		var rec MemberRentalRecord
+		if err := rows.Scan(&rec.RentalID, &rec.GameID, &rec.GameTitle, &rec.Platform, &rec.RentedAt, &rec.DueAt,
+			&rec.ReturnedAt, &rec.PersonalNote, &rec.Verdict); err != nil {
+			return nil, fmt.Errorf("failed to scan member rental: %w", err)
+		}
+		result = append(result, rec)
+	}
+	return result, nil
+}
+
+// ListMemberActivities returns the feed events about a member, newest first.
+func (s *PostgresStore) ListMemberActivities(ctx context.Context, profileName string) ([]ActivityEntry, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT id, event_type, member_name, game_title, created_at
+		 FROM activities
+		 WHERE member_name = $1 AND event_type NOT IN `+clubNameEvents+`
+		 ORDER BY created_at DESC`, profileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query member activities: %w", err)
+	}
+	defer rows.Close()
+
+	var result []ActivityEntry
+	for rows.Next() {
+		var a ActivityEntry
+		if err := rows.Scan(&a.ID, &a.EventType, &a.MemberName, &a.GameTitle, &a.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan member activity: %w", err)
+		}
+		result = append(result, a)
+	}
+	return result, nil
+}
+
+// DeleteMemberAccount removes a member's personal data while keeping their
+// history. The member row becomes an anonymous tombstone that rentals, clubs
+// and challenges keep pointing at; feed events get models.DeletedMemberName.
+// Sessions, tokens, staff roles and club memberships are deleted, and a club
+// left without an admin passes to its longest-standing member.
+// Returns ErrOpenRentals while tapes are out and ErrLastOwner for the only owner.
+func (s *PostgresStore) DeleteMemberAccount(ctx context.Context, memberID uuid.UUID) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var profileName string
+	err = tx.QueryRow(ctx,
+		`SELECT profile_name FROM members WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`,
+		memberID).Scan(&profileName)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return fmt.Errorf("member not found")
+		}
+		return fmt.Errorf("failed to lock member: %w", err)
+	}
+
+	var openRentals int
+	if err := tx.QueryRow(ctx,
+		`SELECT COUNT(*) FROM rentals WHERE member_id = $1 AND returned_at IS NULL`,
+		memberID).Scan(&openRentals); err != nil {
+		return fmt.Errorf("failed to count open rentals: %w", err)
+	}
+	if openRentals > 0 {
+		return ErrOpenRentals
+	}
+
+	var isOwner bool
+	var owners int
+	if err := tx.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM staff_roles WHERE member_id = $1 AND role = 'owner'),
+		        (SELECT COUNT(*) FROM staff_roles WHERE role = 'owner')`,
+		memberID).Scan(&isOwner, &owners); err != nil {
+		return fmt.Errorf("failed to count owners: %w", err)
+	}
+	if isOwner && owners <= 1 {
+		return ErrLastOwner
+	}
+
+	// Hand each club the member was the only admin of to its oldest member.
+	if _, err := tx.Exec(ctx,
+		`UPDATE club_members cm SET role = 'admin'
+		 FROM (
+		     SELECT DISTINCT ON (o.club_id) o.club_id, o.member_id
+		     FROM club_members o
+		     JOIN club_members me ON me.club_id = o.club_id AND me.member_id = $1 AND me.role = 'admin'
+		     WHERE o.member_id <> $1
+		       AND NOT EXISTS (
+		           SELECT 1 FROM club_members a
+		           WHERE a.club_id = o.club_id AND a.role = 'admin' AND a.member_id <> $1)
+		     ORDER BY o.club_id, o.joined_at, o.member_id
+		 ) heir
+		 WHERE cm.club_id = heir.club_id AND cm.member_id = heir.member_id`, memberID); err != nil {
+		return fmt.Errorf("failed to hand over clubs: %w", err)
+	}
+
+	for _, q := range []string{
+		`DELETE FROM club_members WHERE member_id = $1`,
+		`DELETE FROM sessions WHERE member_id = $1`,
+		`DELETE FROM member_tokens WHERE member_id = $1`,
+		`DELETE FROM staff_roles WHERE member_id = $1`,
+		`DELETE FROM recovery_codes WHERE member_id = $1`,
+		`DELETE FROM api_tokens WHERE member_id = $1`,
+		`DELETE FROM calendar_feeds WHERE member_id = $1`,
+		`UPDATE rentals SET personal_note = NULL WHERE member_id = $1`,
+	} {
+		if _, err := tx.Exec(ctx, q, memberID); err != nil {
+			return fmt.Errorf("failed to delete member data: %w", err)
+		}
+	}
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE activities SET member_name = $2
+		 WHERE member_name = $1 AND event_type NOT IN `+clubNameEvents,
+		profileName, models.DeletedMemberName); err != nil {
+		return fmt.Errorf("failed to anonymize activities: %w", err)
+	}
+
+	tombstone := "ex-socio-" + memberID.String()[:8]
+	if _, err := tx.Exec(ctx,
+		`UPDATE security_events SET profile_name = $2, ip = '' WHERE member_id = $1`,
+		memberID, tombstone); err != nil {
+		return fmt.Errorf("failed to anonymize security events: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE members SET
+		     profile_name = $2, email = $3, password_hash = '', favorite_console = '',
+		     address = NULL, phone = NULL, password_notes = NULL, primary_club_id = NULL,
+		     email_verified_at = NULL, failed_logins = 0, locked_until = NULL,
+		     pending_approval = FALSE, totp_secret = '', totp_enabled_at = NULL, totp_last_step = 0,
+		     deleted_at = NOW()
+		 WHERE id = $1`,
+		memberID, tombstone, memberID.String()+"@deleted.invalid"); err != nil {
+		return fmt.Errorf("failed to anonymize member: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
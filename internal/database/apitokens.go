@@ -0,0 +1,103 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cmellojr/modo-locadora/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ── API token methods ───────────────────────────────────────────────────────
+
+// apiTokenUse is written at most once a minute, however busy the bot.
+var apiTokenUse = lastUse{table: "api_tokens", column: "last_used_at", key: "id", interval: time.Minute}
+
+const apiTokenColumns = `id, member_id, name, token_hash, scopes,
+	created_at, expires_at, last_used_at, revoked_at`
+
+func scanAPIToken(row pgx.Row) (*models.APIToken, error) {
+	var t models.APIToken
+	err := row.Scan(&t.ID, &t.MemberID, &t.Name, &t.TokenHash, &t.Scopes,
+		&t.CreatedAt, &t.ExpiresAt, &t.LastUsedAt, &t.RevokedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+// CreateAPIToken persists a new personal API token.
+func (s *PostgresStore) CreateAPIToken(ctx context.Context, token *models.APIToken) error {
+	_, err := s.pool.Exec(ctx,
+		`INSERT INTO api_tokens (id, member_id, name, token_hash, scopes, created_at, expires_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		token.ID, token.MemberID, token.Name, token.TokenHash, token.Scopes,
+		token.CreatedAt, token.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to create API token: %w", err)
+	}
+	return nil
+}
+
+// GetActiveAPIToken returns the usable token for a hash and records its use.
+// Returns nil, nil for unknown, revoked or expired tokens and for tokens of
+// deleted members.
+func (s *PostgresStore) GetActiveAPIToken(ctx context.Context, tokenHash string) (*models.APIToken, error) {
+	t, err := scanAPIToken(s.pool.QueryRow(ctx,
+		`SELECT `+apiTokenColumns+` FROM api_tokens t
+		 WHERE token_hash = $1 AND revoked_at IS NULL
+		   AND (expires_at IS NULL OR expires_at > NOW())
+		   AND EXISTS (SELECT 1 FROM members m WHERE m.id = t.member_id AND m.deleted_at IS NULL)`,
+		tokenHash))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API token: %w", err)
+	}
+	if t == nil {
+		return nil, nil
+	}
+
+	if t.LastUsedAt, err = s.touch(ctx, apiTokenUse, t.ID, t.LastUsedAt); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// ListMemberAPITokens returns a member's unrevoked tokens, newest first.
+// Expired tokens are included so the member can see and remove them.
+func (s *PostgresStore) ListMemberAPITokens(ctx context.Context, memberID uuid.UUID) ([]models.APIToken, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT `+apiTokenColumns+` FROM api_tokens
+		 WHERE member_id = $1 AND revoked_at IS NULL
+		 ORDER BY created_at DESC`, memberID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query API tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var result []models.APIToken
+	for rows.Next() {
+		t, err := scanAPIToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan API token: %w", err)
+		}
+		result = append(result, *t)
+	}
+	return result, nil
+}
+
+// RevokeAPIToken revokes one of the member's tokens. Returns false when the
+// token does not exist, belongs to someone else or was already revoked.
+func (s *PostgresStore) RevokeAPIToken(ctx context.Context, tokenID, memberID uuid.UUID) (bool, error) {
+	tag, err := s.pool.Exec(ctx,
+		`UPDATE api_tokens SET revoked_at = NOW()
+		 WHERE id = $1 AND member_id = $2 AND revoked_at IS NULL`, tokenID, memberID)
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke API token: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
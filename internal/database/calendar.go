@@ -0,0 +1,151 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cmellojr/modo-locadora/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ── Calendar feed methods ───────────────────────────────────────────────────
+
+// calendarFeedUse is written hourly: calendar apps poll every few minutes.
+var calendarFeedUse = lastUse{table: "calendar_feeds", column: "last_used_at", key: "member_id", interval: time.Hour}
+
+// calendarChallengeWindow keeps recently ended challenges in the feed.
+const calendarChallengeWindow = 30 * 24 * time.Hour
+
+const calendarFeedColumns = `member_id, token_hash, include_challenges, created_at, last_used_at`
+
+func scanCalendarFeed(row pgx.Row) (*models.CalendarFeed, error) {
+	var f models.CalendarFeed
+	err := row.Scan(&f.MemberID, &f.TokenHash, &f.IncludeChallenges, &f.CreatedAt, &f.LastUsedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &f, nil
+}
+
+// SaveCalendarFeed creates the member's calendar feed, or replaces its token
+// and settings, which invalidates the previous URL.
+func (s *PostgresStore) SaveCalendarFeed(ctx context.Context, f *models.CalendarFeed) error {
+	_, err := s.pool.Exec(ctx,
+		`INSERT INTO calendar_feeds (member_id, token_hash, include_challenges, created_at)
+		 VALUES ($1, $2, $3, $4)
+		 ON CONFLICT (member_id) DO UPDATE
+		 SET token_hash = EXCLUDED.token_hash, include_challenges = EXCLUDED.include_challenges,
+		     created_at = EXCLUDED.created_at, last_used_at = NULL`,
+		f.MemberID, f.TokenHash, f.IncludeChallenges, f.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save calendar feed: %w", err)
+	}
+	return nil
+}
+
+// GetCalendarFeed returns the member's calendar feed, or nil if it has none.
+func (s *PostgresStore) GetCalendarFeed(ctx context.Context, memberID uuid.UUID) (*models.CalendarFeed, error) {
+	f, err := scanCalendarFeed(s.pool.QueryRow(ctx,
+		`SELECT `+calendarFeedColumns+` FROM calendar_feeds WHERE member_id = $1`, memberID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get calendar feed: %w", err)
+	}
+	return f, nil
+}
+
+// GetCalendarFeedByToken returns the feed for a token hash and records its
+// use. Returns nil, nil for unknown tokens and for deleted members.
+func (s *PostgresStore) GetCalendarFeedByToken(ctx context.Context, tokenHash string) (*models.CalendarFeed, error) {
+	f, err := scanCalendarFeed(s.pool.QueryRow(ctx,
+		`SELECT `+calendarFeedColumns+` FROM calendar_feeds f
+		 WHERE token_hash = $1
+		   AND EXISTS (SELECT 1 FROM members m WHERE m.id = f.member_id AND m.deleted_at IS NULL)`,
+		tokenHash))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get calendar feed: %w", err)
+	}
+	if f == nil {
+		return nil, nil
+	}
+
+	if f.LastUsedAt, err = s.touch(ctx, calendarFeedUse, f.MemberID, f.LastUsedAt); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// SetCalendarFeedChallenges switches the club challenges of the feed on or off.
+func (s *PostgresStore) SetCalendarFeedChallenges(ctx context.Context, memberID uuid.UUID, include bool) error {
+	_, err := s.pool.Exec(ctx,
+		`UPDATE calendar_feeds SET include_challenges = $2 WHERE member_id = $1`, memberID, include)
+	if err != nil {
+		return fmt.Errorf("failed to update calendar feed: %w", err)
+	}
+	return nil
+}
+
+// DeleteCalendarFeed removes the member's calendar feed; its URL stops working.
+func (s *PostgresStore) DeleteCalendarFeed(ctx context.Context, memberID uuid.UUID) error {
+	_, err := s.pool.Exec(ctx, `DELETE FROM calendar_feeds WHERE member_id = $1`, memberID)
+	if err != nil {
+		return fmt.Errorf("failed to delete calendar feed: %w", err)
+	}
+	return nil
+}
+
+// ListCalendarRentals returns the member's active rentals, soonest due first.
+func (s *PostgresStore) ListCalendarRentals(ctx context.Context, memberID uuid.UUID) ([]CalendarRental, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT r.id, g.id, g.title, g.platform, r.rented_at, r.due_at
+		 FROM rentals r
+		 JOIN game_copies gc ON gc.id = r.copy_id
+		 JOIN games g ON g.id = gc.game_id
+		 WHERE r.member_id = $1 AND r.returned_at IS NULL
+		 ORDER BY r.due_at ASC`, memberID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query calendar rentals: %w", err)
+	}
+	defer rows.Close()
+
+	var result []CalendarRental
+	for rows.Next() {
+		var c CalendarRental
+		if err := rows.Scan(&c.RentalID, &c.GameID, &c.GameTitle, &c.Platform, &c.RentedAt, &c.DueAt); err != nil {
+			return nil, fmt.Errorf("failed to scan calendar rental: %w", err)
+		}
+		result = append(result, c)
+	}
+	return result, nil
+}
+
+// ListCalendarChallenges returns the challenges of the member's clubs that
+// have not ended or ended in the last 30 days, soonest first.
+func (s *PostgresStore) ListCalendarChallenges(ctx context.Context, memberID uuid.UUID) ([]CalendarChallenge, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT ch.id, c.id, c.name, g.title, g.platform, ch.starts_at, ch.ends_at
+		 FROM club_challenges ch
+		 JOIN clubs c ON c.id = ch.club_id
+		 JOIN club_members cm ON cm.club_id = c.id AND cm.member_id = $1
+		 JOIN games g ON g.id = ch.game_id
+		 WHERE ch.ends_at > $2
+		 ORDER BY ch.ends_at ASC`, memberID, time.Now().Add(-calendarChallengeWindow))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query calendar challenges: %w", err)
+	}
+	defer rows.Close()
+
+	var result []CalendarChallenge
+	for rows.Next() {
+		var c CalendarChallenge
+		if err := rows.Scan(&c.ChallengeID, &c.ClubID, &c.ClubName, &c.GameTitle, &c.Platform, &c.StartsAt, &c.EndsAt); err != nil {
+			return nil, fmt.Errorf("failed to scan calendar challenge: %w", err)
+		}
+		result = append(result, c)
+	}
+	return result, nil
+}
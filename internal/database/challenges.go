@@ -0,0 +1,255 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/cmellojr/modo-locadora/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ── Club challenge methods ──────────────────────────────────────────────────
+
+// CreateClubChallenge persists a new challenge for a club.
+func (s *PostgresStore) CreateClubChallenge(ctx context.Context, c *models.ClubChallenge) error {
+	_, err := s.pool.Exec(ctx,
+		`INSERT INTO club_challenges (id, club_id, game_id, starts_at, ends_at, created_by, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		c.ID, c.ClubID, c.GameID, c.StartsAt, c.EndsAt, c.CreatedBy, c.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create club challenge: %w", err)
+	}
+	return nil
+}
+
+// GetClubChallenge retrieves a challenge by its UUID.
+func (s *PostgresStore) GetClubChallenge(ctx context.Context, id uuid.UUID) (*models.ClubChallenge, error) {
+	var c models.ClubChallenge
+	err := s.pool.QueryRow(ctx,
+		`SELECT id, club_id, game_id, starts_at, ends_at, created_by, created_at
+		 FROM club_challenges WHERE id = $1`, id).Scan(
+		&c.ID, &c.ClubID, &c.GameID, &c.StartsAt, &c.EndsAt, &c.CreatedBy, &c.CreatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get club challenge: %w", err)
+	}
+	return &c, nil
+}
+
+// ListClubChallenges returns a club's challenges with availability and participants, newest first.
+func (s *PostgresStore) ListClubChallenges(ctx context.Context, clubID uuid.UUID) ([]ClubChallengeView, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT ch.id, ch.club_id, ch.game_id, ch.starts_at, ch.ends_at, ch.created_by, ch.created_at,
+		        g.title, COALESCE(NULLIF(g.cover_thumb_url, ''), g.cover_url, ''), g.platform,
+		        (SELECT COUNT(*) FROM game_copies gc WHERE gc.game_id = g.id) AS total_copies,
+		        (SELECT COUNT(*) FROM game_copies gc WHERE gc.game_id = g.id AND gc.status = 'available') AS available_copies,
+		        COALESCE(ff.profile_name, '')
+		 FROM club_challenges ch
+		 JOIN games g ON g.id = ch.game_id
+		 LEFT JOIN members ff ON ff.id = ch.first_finisher_id
+		 WHERE ch.club_id = $1
+		 ORDER BY ch.starts_at DESC`, clubID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query club challenges: %w", err)
+	}
+
+	var result []ClubChallengeView
+	for rows.Next() {
+		var v ClubChallengeView
+		c := &v.Challenge
+		if err := rows.Scan(&c.ID, &c.ClubID, &c.GameID, &c.StartsAt, &c.EndsAt, &c.CreatedBy, &c.CreatedAt,
+			&v.GameTitle, &v.CoverURL, &v.Platform, &v.TotalCopies, &v.AvailableCopies, &v.FirstFinisher); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan club challenge: %w", err)
+		}
+		result = append(result, v)
+	}
+	rows.Close()
+
+	ids := make([]uuid.UUID, len(result))
+	for i := range result {
+		ids[i] = result[i].Challenge.ID
+	}
+	participants, err := s.listChallengeParticipants(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range result {
+		result[i].Participants = participants[result[i].Challenge.ID]
+	}
+	return result, nil
+}
+
+// listChallengeParticipants returns the participants of the given challenges
+// in one query, keyed by challenge and in join order, numbering the ones
+// still waiting for a copy as each challenge's rental queue.
+func (s *PostgresStore) listChallengeParticipants(ctx context.Context, challengeIDs []uuid.UUID) (map[uuid.UUID][]ChallengeParticipantView, error) {
+	result := make(map[uuid.UUID][]ChallengeParticipantView, len(challengeIDs))
+	if len(challengeIDs) == 0 {
+		return result, nil
+	}
+	rows, err := s.pool.Query(ctx,
+		`SELECT p.challenge_id, m.id, m.profile_name, p.status, p.joined_at, p.finished_at
+		 FROM club_challenge_participants p
+		 JOIN members m ON m.id = p.member_id
+		 WHERE p.challenge_id = ANY($1)
+		 ORDER BY p.challenge_id, p.joined_at ASC`, challengeIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query challenge participants: %w", err)
+	}
+	defer rows.Close()
+
+	queues := make(map[uuid.UUID]int, len(challengeIDs))
+	for rows.Next() {
+		var challengeID uuid.UUID
+		var p ChallengeParticipantView
+		if err := rows.Scan(&challengeID, &p.MemberID, &p.ProfileName, &p.Status, &p.JoinedAt, &p.FinishedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan challenge participant: %w", err)
+		}
+		if p.Status == models.ChallengeStatusJoined {
+			queues[challengeID]++
+			p.QueuePosition = queues[challengeID]
+		}
+		result[challengeID] = append(result[challengeID], p)
+	}
+	return result, nil
+}
+
+// JoinClubChallenge enrolls a member in a challenge that has not ended yet.
+func (s *PostgresStore) JoinClubChallenge(ctx context.Context, challengeID, memberID uuid.UUID) error {
+	tag, err := s.pool.Exec(ctx,
+		`INSERT INTO club_challenge_participants (challenge_id, member_id, status, joined_at)
+		 SELECT id, $2, 'joined', NOW() FROM club_challenges
+		 WHERE id = $1 AND ends_at > NOW()
+		 ON CONFLICT (challenge_id, member_id) DO NOTHING`,
+		challengeID, memberID)
+	if err != nil {
+		return fmt.Errorf("failed to join club challenge: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		var exists bool
+		_ = s.pool.QueryRow(ctx,
+			`SELECT EXISTS (SELECT 1 FROM club_challenge_participants WHERE challenge_id = $1 AND member_id = $2)`,
+			challengeID, memberID).Scan(&exists)
+		if !exists {
+			return fmt.Errorf("challenge not found or already ended")
+		}
+	}
+	return nil
+}
+
+// GiveUpClubChallenge marks a participant as having given up on a challenge.
+func (s *PostgresStore) GiveUpClubChallenge(ctx context.Context, challengeID, memberID uuid.UUID) error {
+	tag, err := s.pool.Exec(ctx,
+		`UPDATE club_challenge_participants SET status = 'gave_up', finished_at = NOW()
+		 WHERE challenge_id = $1 AND member_id = $2 AND status IN ('joined', 'rented')`,
+		challengeID, memberID)
+	if err != nil {
+		return fmt.Errorf("failed to give up club challenge: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("not an active participant of this challenge")
+	}
+	return nil
+}
+
+// UpdateChallengeProgress moves a member's open challenges for a game to the given status.
+// Only challenges whose window contains NOW() are touched, and final statuses are never
+// overwritten. Returns the challenges that reached "completed" or "gave_up".
+func (s *PostgresStore) UpdateChallengeProgress(ctx context.Context, memberID, gameID uuid.UUID, status string) ([]ChallengeFinish, error) {
+	var from []string
+	switch status {
+	case models.ChallengeStatusRented:
+		from = []string{models.ChallengeStatusJoined}
+	case models.ChallengeStatusCompleted, models.ChallengeStatusGaveUp:
+		from = []string{models.ChallengeStatusJoined, models.ChallengeStatusRented}
+	default:
+		return nil, fmt.Errorf("invalid challenge status: %s", status)
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx,
+		`UPDATE club_challenge_participants p
+		 SET status = $3,
+		     finished_at = CASE WHEN $3 IN ('completed', 'gave_up') THEN NOW() ELSE p.finished_at END
+		 FROM club_challenges ch
+		 WHERE ch.id = p.challenge_id
+		   AND p.member_id = $1 AND ch.game_id = $2
+		   AND ch.starts_at <= NOW() AND ch.ends_at > NOW()
+		   AND p.status = ANY($4)
+		 RETURNING ch.id`,
+		memberID, gameID, status, from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update challenge progress: %w", err)
+	}
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan challenge id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	var finishes []ChallengeFinish
+	if status != models.ChallengeStatusRented {
+		// The first member to complete a challenge is recorded on it. The
+		// conditional UPDATE locks the challenge row, and under READ
+		// COMMITTED a second finisher waiting on that lock re-checks the
+		// condition once the first commits, so only one member is ever first
+		// and the club page names the same one as the feed. Challenges are
+		// locked in a fixed order so two members finishing several
+		// challenges cannot deadlock.
+		slices.SortFunc(ids, func(a, b uuid.UUID) int { return bytes.Compare(a[:], b[:]) })
+		for _, id := range ids {
+			f := ChallengeFinish{ChallengeID: id}
+			if status == models.ChallengeStatusCompleted {
+				tag, err := tx.Exec(ctx,
+					`UPDATE club_challenges SET first_finisher_id = $2
+					 WHERE id = $1 AND first_finisher_id IS NULL`, id, memberID)
+				if err != nil {
+					return nil, fmt.Errorf("failed to record first finisher of challenge %s: %w", id, err)
+				}
+				f.IsFirst = tag.RowsAffected() == 1
+			}
+			err := tx.QueryRow(ctx,
+				`SELECT g.title FROM club_challenges ch
+				 JOIN games g ON g.id = ch.game_id
+				 WHERE ch.id = $1`, id).Scan(&f.GameTitle)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load challenge %s: %w", id, err)
+			}
+			finishes = append(finishes, f)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit challenge progress: %w", err)
+	}
+	return finishes, nil
+}
+
+// GetRentalGameID returns the game ID associated with a rental.
+func (s *PostgresStore) GetRentalGameID(ctx context.Context, rentalID uuid.UUID) (uuid.UUID, error) {
+	var gameID uuid.UUID
+	err := s.pool.QueryRow(ctx,
+		`SELECT gc.game_id FROM rentals r
+		 JOIN game_copies gc ON gc.id = r.copy_id
+		 WHERE r.id = $1`, rentalID).Scan(&gameID)
+	if err != nil {
+		return uuid.UUID{}, fmt.Errorf("failed to get rental game id: %w", err)
+	}
+	return gameID, nil
+}
@@ -0,0 +1,65 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ── Feed methods ────────────────────────────────────────────────────────────
+
+// ListActivities returns the most recent feed events matching the filter,
+// newest first. Activities only keep the game title, so the platform filter
+// matches events about any game of that platform with the same title.
+func (s *PostgresStore) ListActivities(ctx context.Context, f ActivityFilter, limit int) ([]ActivityEntry, error) {
+	var where []string
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	if len(f.EventTypes) > 0 {
+		where = append(where, "a.event_type = ANY("+arg(f.EventTypes)+")")
+	}
+	if f.Platform != "" {
+		where = append(where, `a.event_type NOT IN `+clubNameEvents+`
+			AND EXISTS (SELECT 1 FROM games g WHERE g.title = a.game_title AND g.platform = `+arg(f.Platform)+`)`)
+	}
+	if f.MemberName != "" {
+		where = append(where, "a.member_name = "+arg(f.MemberName)+" AND a.event_type NOT IN "+clubNameEvents)
+	}
+	if f.ClubID != nil {
+		club := arg(*f.ClubID)
+		where = append(where, `(
+			EXISTS (SELECT 1 FROM clubs c WHERE c.id = `+club+` AND (
+				(a.event_type IN ('club_created', 'club_joined') AND c.name = a.game_title)
+				OR (a.event_type IN `+clubNameEvents+` AND c.name = a.member_name)))
+			OR (a.event_type NOT IN `+clubNameEvents+` AND EXISTS (
+				SELECT 1 FROM club_members cm JOIN members m ON m.id = cm.member_id
+				WHERE cm.club_id = `+club+` AND m.profile_name = a.member_name)))`)
+	}
+
+	query := `SELECT a.id, a.event_type, a.member_name, a.game_title, a.created_at FROM activities a`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY a.created_at DESC LIMIT " + arg(limit)
+
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query activities: %w", err)
+	}
+	defer rows.Close()
+
+	var result []ActivityEntry
+	for rows.Next() {
+		var a ActivityEntry
+		if err := rows.Scan(&a.ID, &a.EventType, &a.MemberName, &a.GameTitle, &a.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan activity: %w", err)
+		}
+		result = append(result, a)
+	}
+	return result, nil
+}
@@ -0,0 +1,291 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cmellojr/modo-locadora/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ── League methods ──────────────────────────────────────────────────────────
+
+// leagueStandingsQuery computes live club standings for the window [$1, $2).
+// Each member's events are credited to their primary club, or to every club
+// they belong to when no primary club is set. Rule points come from
+// league_scoring_rules, so editing a rule rescores the running season.
+const leagueStandingsQuery = `
+	WITH events AS (
+		SELECT r.member_id, 'completed' AS rule_key FROM rentals r
+		 WHERE r.public_legacy = 'completed' AND r.returned_at >= $1 AND r.returned_at < $2
+		UNION ALL
+		SELECT r.member_id, 'on_time_return' FROM rentals r
+		 WHERE r.returned_at IS NOT NULL AND r.returned_at <= r.due_at
+		   AND r.returned_at >= $1 AND r.returned_at < $2
+		UNION ALL
+		SELECT p.member_id, 'challenge_completed' FROM club_challenge_participants p
+		 WHERE p.status = 'completed' AND p.finished_at >= $1 AND p.finished_at < $2
+		UNION ALL
+		SELECT r.member_id, 'penalty' FROM rentals r
+		 WHERE r.public_legacy = 'auto_return' AND r.returned_at >= $1 AND r.returned_at < $2
+	),
+	attribution AS (
+		SELECT cm.club_id, cm.member_id
+		FROM club_members cm
+		JOIN members m ON m.id = cm.member_id
+		WHERE m.primary_club_id IS NULL OR m.primary_club_id = cm.club_id
+	)
+	SELECT c.id, c.name, COALESCE(c.badge_url, ''),
+	       COALESCE(SUM(sr.points), 0) AS points,
+	       COUNT(e.rule_key) FILTER (WHERE e.rule_key = 'completed'),
+	       COUNT(e.rule_key) FILTER (WHERE e.rule_key = 'on_time_return'),
+	       COUNT(e.rule_key) FILTER (WHERE e.rule_key = 'challenge_completed'),
+	       COUNT(e.rule_key) FILTER (WHERE e.rule_key = 'penalty')
+	FROM clubs c
+	LEFT JOIN attribution a ON a.club_id = c.id
+	LEFT JOIN events e ON e.member_id = a.member_id
+	LEFT JOIN league_scoring_rules sr ON sr.rule_key = e.rule_key
+	GROUP BY c.id
+	ORDER BY points DESC, c.name ASC`
+
+// CreateLeagueSeason starts a new league season. Fails if another season is active.
+func (s *PostgresStore) CreateLeagueSeason(ctx context.Context, ls *models.LeagueSeason) error {
+	_, err := s.pool.Exec(ctx,
+		`INSERT INTO league_seasons (id, name, starts_at, ends_at, status, created_at)
+		 VALUES ($1, $2, $3, $4, 'active', $5)`,
+		ls.ID, ls.Name, ls.StartsAt, ls.EndsAt, ls.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create league season: %w", err)
+	}
+	return nil
+}
+
+const seasonColumns = `id, name, starts_at, ends_at, status, archived_at, created_at`
+
+func scanSeason(row pgx.Row) (*models.LeagueSeason, error) {
+	var ls models.LeagueSeason
+	err := row.Scan(&ls.ID, &ls.Name, &ls.StartsAt, &ls.EndsAt, &ls.Status, &ls.ArchivedAt, &ls.CreatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &ls, nil
+}
+
+// GetLeagueSeason retrieves a league season by its UUID.
+func (s *PostgresStore) GetLeagueSeason(ctx context.Context, id uuid.UUID) (*models.LeagueSeason, error) {
+	ls, err := scanSeason(s.pool.QueryRow(ctx,
+		`SELECT `+seasonColumns+` FROM league_seasons WHERE id = $1`, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get league season: %w", err)
+	}
+	return ls, nil
+}
+
+// GetActiveLeagueSeason returns the running season, or nil if there is none.
+func (s *PostgresStore) GetActiveLeagueSeason(ctx context.Context) (*models.LeagueSeason, error) {
+	ls, err := scanSeason(s.pool.QueryRow(ctx,
+		`SELECT `+seasonColumns+` FROM league_seasons WHERE status = 'active' LIMIT 1`))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active league season: %w", err)
+	}
+	return ls, nil
+}
+
+// ListLeagueSeasons returns all seasons, newest first.
+func (s *PostgresStore) ListLeagueSeasons(ctx context.Context) ([]models.LeagueSeason, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT `+seasonColumns+` FROM league_seasons ORDER BY starts_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query league seasons: %w", err)
+	}
+	defer rows.Close()
+
+	var result []models.LeagueSeason
+	for rows.Next() {
+		ls, err := scanSeason(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan league season: %w", err)
+		}
+		result = append(result, *ls)
+	}
+	return result, nil
+}
+
+// GetLeagueStandings returns live standings for an active season or the frozen results of an archived one.
+func (s *PostgresStore) GetLeagueStandings(ctx context.Context, ls *models.LeagueSeason) ([]LeagueStanding, error) {
+	if ls.Status == models.SeasonStatusArchived {
+		return s.listLeagueResults(ctx, ls.ID)
+	}
+	return s.computeLeagueStandings(ctx, s.pool, ls)
+}
+
+// queryer is the subset of pgxpool.Pool and pgx.Tx used by read helpers that
+// must run either standalone or inside a transaction.
+type queryer interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+func (s *PostgresStore) computeLeagueStandings(ctx context.Context, q queryer, ls *models.LeagueSeason) ([]LeagueStanding, error) {
+	rows, err := q.Query(ctx, leagueStandingsQuery, ls.StartsAt, ls.EndsAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute league standings: %w", err)
+	}
+	defer rows.Close()
+
+	var result []LeagueStanding
+	for rows.Next() {
+		var st LeagueStanding
+		var clubID uuid.UUID
+		if err := rows.Scan(&clubID, &st.ClubName, &st.BadgeURL, &st.Points,
+			&st.CompletedCount, &st.OnTimeCount, &st.ChallengeCount, &st.PenaltyCount); err != nil {
+			return nil, fmt.Errorf("failed to scan league standing: %w", err)
+		}
+		st.ClubID = &clubID
+		st.Rank = len(result) + 1
+		// Tied clubs share a rank.
+		if prev := len(result) - 1; prev >= 0 && result[prev].Points == st.Points {
+			st.Rank = result[prev].Rank
+		}
+		result = append(result, st)
+	}
+	return result, nil
+}
+
+func (s *PostgresStore) listLeagueResults(ctx context.Context, seasonID uuid.UUID) ([]LeagueStanding, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT rank, club_id, club_name, badge_url, points,
+		        completed_count, on_time_count, challenge_count, penalty_count
+		 FROM league_results WHERE season_id = $1
+		 ORDER BY rank ASC, club_name ASC`, seasonID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query league results: %w", err)
+	}
+	defer rows.Close()
+
+	var result []LeagueStanding
+	for rows.Next() {
+		var st LeagueStanding
+		if err := rows.Scan(&st.Rank, &st.ClubID, &st.ClubName, &st.BadgeURL, &st.Points,
+			&st.CompletedCount, &st.OnTimeCount, &st.ChallengeCount, &st.PenaltyCount); err != nil {
+			return nil, fmt.Errorf("failed to scan league result: %w", err)
+		}
+		result = append(result, st)
+	}
+	return result, nil
+}
+
+// ArchiveLeagueSeason freezes the season's standings and marks it as archived.
+func (s *PostgresStore) ArchiveLeagueSeason(ctx context.Context, seasonID uuid.UUID) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	ls, err := scanSeason(tx.QueryRow(ctx,
+		`SELECT `+seasonColumns+` FROM league_seasons WHERE id = $1 AND status = 'active' FOR UPDATE`, seasonID))
+	if err != nil {
+		return fmt.Errorf("failed to lock league season: %w", err)
+	}
+	if ls == nil {
+		return fmt.Errorf("season not found or already archived")
+	}
+
+	standings, err := s.computeLeagueStandings(ctx, tx, ls)
+	if err != nil {
+		return err
+	}
+
+	for _, st := range standings {
+		_, err = tx.Exec(ctx,
+			`INSERT INTO league_results (season_id, club_id, club_name, badge_url, rank, points,
+			                             completed_count, on_time_count, challenge_count, penalty_count)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+			seasonID, st.ClubID, st.ClubName, st.BadgeURL, st.Rank, st.Points,
+			st.CompletedCount, st.OnTimeCount, st.ChallengeCount, st.PenaltyCount)
+		if err != nil {
+			return fmt.Errorf("failed to store league result: %w", err)
+		}
+	}
+
+	_, err = tx.Exec(ctx,
+		`UPDATE league_seasons SET status = 'archived', archived_at = NOW() WHERE id = $1`, seasonID)
+	if err != nil {
+		return fmt.Errorf("failed to archive league season: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ListScoringRules returns the league scoring rules.
+func (s *PostgresStore) ListScoringRules(ctx context.Context) ([]models.ScoringRule, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT rule_key, label, points FROM league_scoring_rules ORDER BY points DESC, rule_key ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query scoring rules: %w", err)
+	}
+	defer rows.Close()
+
+	var result []models.ScoringRule
+	for rows.Next() {
+		var sr models.ScoringRule
+		if err := rows.Scan(&sr.Key, &sr.Label, &sr.Points); err != nil {
+			return nil, fmt.Errorf("failed to scan scoring rule: %w", err)
+		}
+		result = append(result, sr)
+	}
+	return result, nil
+}
+
+// UpdateScoringRule sets the points awarded for a scoring rule.
+func (s *PostgresStore) UpdateScoringRule(ctx context.Context, key string, points int) error {
+	tag, err := s.pool.Exec(ctx,
+		`UPDATE league_scoring_rules SET points = $2 WHERE rule_key = $1`, key, points)
+	if err != nil {
+		return fmt.Errorf("failed to update scoring rule: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("scoring rule not found: %s", key)
+	}
+	return nil
+}
+
+// SetPrimaryClub sets the club that receives a member's league points (nil = every club).
+// The member must belong to the club.
+func (s *PostgresStore) SetPrimaryClub(ctx context.Context, memberID uuid.UUID, clubID *uuid.UUID) error {
+	if clubID == nil {
+		_, err := s.pool.Exec(ctx, `UPDATE members SET primary_club_id = NULL WHERE id = $1`, memberID)
+		if err != nil {
+			return fmt.Errorf("failed to clear primary club: %w", err)
+		}
+		return nil
+	}
+
+	tag, err := s.pool.Exec(ctx,
+		`UPDATE members SET primary_club_id = $2
+		 WHERE id = $1 AND EXISTS (
+		     SELECT 1 FROM club_members WHERE club_id = $2 AND member_id = $1
+		 )`, memberID, *clubID)
+	if err != nil {
+		return fmt.Errorf("failed to set primary club: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("member does not belong to this club")
+	}
+	return nil
+}
+
+// clearPrimaryClub resets a member's primary club after they leave it.
+func (s *PostgresStore) clearPrimaryClub(ctx context.Context, clubID, memberID uuid.UUID) error {
+	_, err := s.pool.Exec(ctx,
+		`UPDATE members SET primary_club_id = NULL WHERE id = $1 AND primary_club_id = $2`,
+		memberID, clubID)
+	if err != nil {
+		return fmt.Errorf("failed to clear primary club: %w", err)
+	}
+	return nil
+}
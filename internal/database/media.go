@@ -0,0 +1,46 @@
+package database
+
+import (
+	"context"
+	"fmt"
+)
+
+// mediaURLColumns lists every column holding the URL of a stored image.
+var mediaURLColumns = []struct{ table, column string }{
+	{"games", "cover_url"},
+	{"games", "cover_thumb_url"},
+	{"clubs", "badge_url"},
+	{"league_results", "badge_url"},
+}
+
+// ReplaceMediaURLPrefix rewrites image URLs starting with oldPrefix to start
+// with newPrefix instead, in one transaction, and returns how many values
+// changed. It is used when media moves to another storage backend.
+func (s *PostgresStore) ReplaceMediaURLPrefix(ctx context.Context, oldPrefix, newPrefix string) (int64, error) {
+	if oldPrefix == "" || oldPrefix == newPrefix {
+		return 0, nil
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var total int64
+	for _, c := range mediaURLColumns {
+		// Table and column names come from the list above, never from input.
+		tag, err := tx.Exec(ctx, fmt.Sprintf(
+			`UPDATE %[1]s SET %[2]s = $2 || substr(%[2]s, length($1) + 1) WHERE starts_with(%[2]s, $1)`,
+			c.table, c.column), oldPrefix, newPrefix)
+		if err != nil {
+			return 0, fmt.Errorf("failed to rewrite %s.%s: %w", c.table, c.column, err)
+		}
+		total += tag.RowsAffected()
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit media URLs: %w", err)
+	}
+	return total, nil
+}
@@ -0,0 +1,119 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cmellojr/modo-locadora/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ── Game metadata methods ───────────────────────────────────────────────────
+
+// saveGameTaxonomyTx replaces the genres, developers and publishers of the
+// game, creating the names that do not exist yet.
+func saveGameTaxonomyTx(ctx context.Context, tx pgx.Tx, g *models.Game) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM game_genres WHERE game_id = $1`, g.ID); err != nil {
+		return fmt.Errorf("failed to clear game genres: %w", err)
+	}
+	if len(g.Genres) > 0 {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO genres (name) SELECT DISTINCT unnest($1::text[])
+			ON CONFLICT (name) DO NOTHING`, g.Genres); err != nil {
+			return fmt.Errorf("failed to save genres: %w", err)
+		}
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO game_genres (game_id, genre_id)
+			SELECT $1, id FROM genres WHERE name = ANY($2)`, g.ID, g.Genres); err != nil {
+			return fmt.Errorf("failed to link game genres: %w", err)
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM game_companies WHERE game_id = $1`, g.ID); err != nil {
+		return fmt.Errorf("failed to clear game companies: %w", err)
+	}
+	for role, names := range map[string][]string{"developer": g.Developers, "publisher": g.Publishers} {
+		if len(names) == 0 {
+			continue
+		}
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO companies (name) SELECT DISTINCT unnest($1::text[])
+			ON CONFLICT (name) DO NOTHING`, names); err != nil {
+			return fmt.Errorf("failed to save companies: %w", err)
+		}
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO game_companies (game_id, company_id, role)
+			SELECT $1, id, $3 FROM companies WHERE name = ANY($2)`, g.ID, names, role); err != nil {
+			return fmt.Errorf("failed to link game companies: %w", err)
+		}
+	}
+	return nil
+}
+
+// loadGameTaxonomy fills the genres, developers and publishers of the game.
+func (s *PostgresStore) loadGameTaxonomy(ctx context.Context, g *models.Game) error {
+	query := `
+		SELECT
+			ARRAY(SELECT ge.name FROM game_genres gg JOIN genres ge ON ge.id = gg.genre_id
+			      WHERE gg.game_id = $1 ORDER BY ge.name),
+			ARRAY(SELECT co.name FROM game_companies gco JOIN companies co ON co.id = gco.company_id
+			      WHERE gco.game_id = $1 AND gco.role = 'developer' ORDER BY co.name),
+			ARRAY(SELECT co.name FROM game_companies gco JOIN companies co ON co.id = gco.company_id
+			      WHERE gco.game_id = $1 AND gco.role = 'publisher' ORDER BY co.name)`
+
+	err := s.pool.QueryRow(ctx, query, g.ID).Scan(&g.Genres, &g.Developers, &g.Publishers)
+	if err != nil {
+		return fmt.Errorf("failed to load game metadata: %w", err)
+	}
+	return nil
+}
+
+// ListGameFacets returns the genres, developers and release years found
+// among the games of a platform (all games when platform is empty).
+func (s *PostgresStore) ListGameFacets(ctx context.Context, platform string) (*GameFacets, error) {
+	query := `
+		SELECT
+			ARRAY(SELECT DISTINCT ge.name FROM genres ge
+			      JOIN game_genres gg ON gg.genre_id = ge.id
+			      JOIN games g ON g.id = gg.game_id
+			      WHERE $1 = '' OR g.platform = $1
+			      ORDER BY ge.name),
+			ARRAY(SELECT DISTINCT co.name FROM companies co
+			      JOIN game_companies gco ON gco.company_id = co.id AND gco.role = 'developer'
+			      JOIN games g ON g.id = gco.game_id
+			      WHERE $1 = '' OR g.platform = $1
+			      ORDER BY co.name),
+			ARRAY(SELECT DISTINCT EXTRACT(YEAR FROM g.release_date)::int FROM games g
+			      WHERE g.release_date IS NOT NULL AND ($1 = '' OR g.platform = $1)
+			      ORDER BY 1)`
+
+	var f GameFacets
+	if err := s.pool.QueryRow(ctx, query, platform).Scan(&f.Genres, &f.Developers, &f.Years); err != nil {
+		return nil, fmt.Errorf("failed to list game facets: %w", err)
+	}
+	return &f, nil
+}
+
+// nonNil turns a nil slice into an empty one, so NOT NULL array columns
+// receive '{}' instead of NULL.
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+// SetGameCover replaces a game's cover, thumbnail and cover source URLs.
+func (s *PostgresStore) SetGameCover(ctx context.Context, gameID uuid.UUID, coverURL, thumbURL, sourceURL string) error {
+	tag, err := s.pool.Exec(ctx,
+		`UPDATE games SET cover_url = $2, cover_thumb_url = $3, cover_source_url = $4 WHERE id = $1`,
+		gameID, coverURL, thumbURL, sourceURL)
+	if err != nil {
+		return fmt.Errorf("failed to set game cover: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("game not found: %s", gameID)
+	}
+	return nil
+}
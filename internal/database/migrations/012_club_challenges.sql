@@ -0,0 +1,28 @@
+-- Migration 012: Club challenges ("clube do jogo").
+-- A club admin picks one game and a date window; members join and the club
+-- page tracks who rented, completed or gave up on the cartridge.
+
+CREATE TABLE IF NOT EXISTS club_challenges (
+    id         UUID PRIMARY KEY,
+    club_id    UUID NOT NULL REFERENCES clubs(id) ON DELETE CASCADE,
+    game_id    UUID NOT NULL REFERENCES games(id) ON DELETE CASCADE,
+    starts_at  TIMESTAMPTZ NOT NULL,
+    ends_at    TIMESTAMPTZ NOT NULL,
+    created_by UUID NOT NULL REFERENCES members(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (ends_at > starts_at)
+);
+
+-- status: 'joined' -> 'rented' -> 'completed' | 'gave_up'.
+CREATE TABLE IF NOT EXISTS club_challenge_participants (
+    challenge_id UUID NOT NULL REFERENCES club_challenges(id) ON DELETE CASCADE,
+    member_id    UUID NOT NULL REFERENCES members(id) ON DELETE CASCADE,
+    status       TEXT NOT NULL DEFAULT 'joined',
+    joined_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at  TIMESTAMPTZ,
+    PRIMARY KEY (challenge_id, member_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_club_challenges_club ON club_challenges(club_id, starts_at DESC);
+CREATE INDEX IF NOT EXISTS idx_club_challenges_game ON club_challenges(game_id);
+CREATE INDEX IF NOT EXISTS idx_challenge_participants_member ON club_challenge_participants(member_id);
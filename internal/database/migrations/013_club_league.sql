@@ -0,0 +1,50 @@
+-- Migration 013: Inter-club league ("Gincana das Turmas").
+-- Seasons with a date window, scoring rules editable by the Tio, archived
+-- standings, and an optional primary club per member for point attribution.
+
+CREATE TABLE IF NOT EXISTS league_seasons (
+    id          UUID PRIMARY KEY,
+    name        TEXT NOT NULL,
+    starts_at   TIMESTAMPTZ NOT NULL,
+    ends_at     TIMESTAMPTZ NOT NULL,
+    status      TEXT NOT NULL DEFAULT 'active', -- 'active' or 'archived'
+    archived_at TIMESTAMPTZ,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (ends_at > starts_at)
+);
+
+-- Only one season can be running at a time.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_league_seasons_one_active
+    ON league_seasons ((status)) WHERE status = 'active';
+
+-- Points awarded per event type. Negative values are penalties.
+CREATE TABLE IF NOT EXISTS league_scoring_rules (
+    rule_key TEXT PRIMARY KEY,
+    label    TEXT NOT NULL,
+    points   INTEGER NOT NULL
+);
+
+INSERT INTO league_scoring_rules (rule_key, label, points) VALUES
+    ('completed',           'Jogo zerado',              10),
+    ('on_time_return',      'Devolucao no prazo',        3),
+    ('challenge_completed', 'Desafio de turma zerado',  15),
+    ('penalty',             'Penalidade por atraso',    -5)
+ON CONFLICT (rule_key) DO NOTHING;
+
+-- Final standings, frozen when a season is archived.
+CREATE TABLE IF NOT EXISTS league_results (
+    season_id           UUID NOT NULL REFERENCES league_seasons(id) ON DELETE CASCADE,
+    club_id             UUID REFERENCES clubs(id) ON DELETE SET NULL,
+    club_name           TEXT NOT NULL,
+    badge_url           TEXT NOT NULL DEFAULT '',
+    rank                INTEGER NOT NULL,
+    points              INTEGER NOT NULL,
+    completed_count     INTEGER NOT NULL DEFAULT 0,
+    on_time_count       INTEGER NOT NULL DEFAULT 0,
+    challenge_count     INTEGER NOT NULL DEFAULT 0,
+    penalty_count       INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (season_id, rank, club_name)
+);
+
+-- NULL means the member's points go to every club they belong to.
+ALTER TABLE members ADD COLUMN IF NOT EXISTS primary_club_id UUID REFERENCES clubs(id) ON DELETE SET NULL;
@@ -0,0 +1,19 @@
+-- Migration 014: Server-side sessions.
+-- The session cookie carries an opaque random token; only its SHA-256 hash is
+-- stored here. Sessions expire absolutely (expires_at) and after inactivity
+-- (last_seen_at), and can be revoked individually or per member.
+
+CREATE TABLE IF NOT EXISTS sessions (
+    id           UUID PRIMARY KEY,
+    member_id    UUID NOT NULL REFERENCES members(id) ON DELETE CASCADE,
+    token_hash   TEXT NOT NULL UNIQUE,
+    user_agent   TEXT NOT NULL DEFAULT '',
+    ip_address   TEXT NOT NULL DEFAULT '',
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at   TIMESTAMPTZ NOT NULL,
+    revoked_at   TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_member ON sessions(member_id) WHERE revoked_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_sessions_expires ON sessions(expires_at);
@@ -0,0 +1,14 @@
+-- Migration 015: Staff roles.
+-- Replaces the single ADMIN_EMAIL check with roles stored per member:
+-- owner (the Tio), attendant, curator and moderator. A member may hold
+-- several roles. The first owner is bootstrapped from ADMIN_EMAIL.
+
+CREATE TABLE IF NOT EXISTS staff_roles (
+    member_id  UUID NOT NULL REFERENCES members(id) ON DELETE CASCADE,
+    role       TEXT NOT NULL CHECK (role IN ('owner', 'attendant', 'curator', 'moderator')),
+    granted_by UUID REFERENCES members(id) ON DELETE SET NULL,
+    granted_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (member_id, role)
+);
+
+CREATE INDEX IF NOT EXISTS idx_staff_roles_role ON staff_roles(role);
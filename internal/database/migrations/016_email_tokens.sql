@@ -0,0 +1,26 @@
+-- Migration 016: E-mail verification and password reset tokens.
+-- Tokens are single-use and expiring; only their SHA-256 hash is stored.
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_name = 'members' AND column_name = 'email_verified_at'
+    ) THEN
+        ALTER TABLE members ADD COLUMN email_verified_at TIMESTAMPTZ;
+        -- Members who joined before verification existed keep their rights.
+        UPDATE members SET email_verified_at = joined_at;
+    END IF;
+END $$;
+
+CREATE TABLE IF NOT EXISTS member_tokens (
+    id         UUID PRIMARY KEY,
+    member_id  UUID NOT NULL REFERENCES members(id) ON DELETE CASCADE,
+    purpose    TEXT NOT NULL CHECK (purpose IN ('verify_email', 'password_reset')),
+    token_hash TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at    TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_member_tokens_member ON member_tokens(member_id, purpose);
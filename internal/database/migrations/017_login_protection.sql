@@ -0,0 +1,20 @@
+-- Migration 017: Brute-force protection for the login counter.
+-- Failed logins lock the member with exponential backoff, and lockouts are
+-- kept as security events for the staff.
+
+ALTER TABLE members ADD COLUMN IF NOT EXISTS failed_logins INT NOT NULL DEFAULT 0;
+ALTER TABLE members ADD COLUMN IF NOT EXISTS locked_until TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_members_locked_until ON members(locked_until) WHERE locked_until IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS security_events (
+    id           UUID PRIMARY KEY,
+    kind         TEXT NOT NULL,
+    member_id    UUID REFERENCES members(id) ON DELETE SET NULL,
+    profile_name TEXT NOT NULL DEFAULT '',
+    ip           TEXT NOT NULL DEFAULT '',
+    detail       TEXT NOT NULL DEFAULT '',
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_security_events_created ON security_events(created_at DESC);
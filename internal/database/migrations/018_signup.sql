@@ -0,0 +1,29 @@
+-- Migration 018: Self-registration.
+-- Profile names become unique regardless of case (login looks members up by
+-- name), members can wait for staff approval, and closed communities can
+-- require an invite code to sign up.
+
+-- Rename case-insensitive duplicates before adding the unique index: the
+-- oldest member keeps the name, later ones get a short suffix from their ID.
+UPDATE members m
+SET profile_name = m.profile_name || '-' || LEFT(m.id::text, 4)
+WHERE EXISTS (
+    SELECT 1 FROM members older
+    WHERE LOWER(older.profile_name) = LOWER(m.profile_name)
+      AND (older.joined_at, older.id) < (m.joined_at, m.id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_members_profile_name_lower ON members (LOWER(profile_name));
+
+ALTER TABLE members ADD COLUMN IF NOT EXISTS pending_approval BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS invite_codes (
+    id         UUID PRIMARY KEY,
+    code       TEXT NOT NULL UNIQUE,
+    max_uses   INT NOT NULL DEFAULT 1 CHECK (max_uses > 0),
+    uses       INT NOT NULL DEFAULT 0,
+    created_by UUID REFERENCES members(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ
+);
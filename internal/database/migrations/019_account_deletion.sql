@@ -0,0 +1,6 @@
+-- Migration 019: Account deletion.
+-- A deleted member is scrubbed of personal data but the row stays as an
+-- anonymous tombstone, so rentals, clubs and league history keep their
+-- references and the store's stats stay intact.
+
+ALTER TABLE members ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
@@ -0,0 +1,18 @@
+-- Migration 020: Two-factor authentication (TOTP, RFC 6238).
+-- The secret is stored when enrollment starts and only counts once
+-- totp_enabled_at is set. totp_last_step blocks replaying a code inside its
+-- 30-second window. Recovery codes are kept as SHA-256 hashes.
+
+ALTER TABLE members ADD COLUMN IF NOT EXISTS totp_secret TEXT NOT NULL DEFAULT '';
+ALTER TABLE members ADD COLUMN IF NOT EXISTS totp_enabled_at TIMESTAMPTZ;
+ALTER TABLE members ADD COLUMN IF NOT EXISTS totp_last_step BIGINT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id         UUID PRIMARY KEY,
+    member_id  UUID NOT NULL REFERENCES members(id) ON DELETE CASCADE,
+    code_hash  TEXT NOT NULL,
+    used_at    TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_member ON recovery_codes(member_id);
@@ -0,0 +1,18 @@
+-- Migration 021: Personal API tokens.
+-- Members create named tokens for scripts and bots. The raw token is shown
+-- once; only its SHA-256 hash is stored. Scopes limit what each token can do
+-- on /api/v1, and tokens may expire and can be revoked.
+
+CREATE TABLE IF NOT EXISTS api_tokens (
+    id           UUID PRIMARY KEY,
+    member_id    UUID NOT NULL REFERENCES members(id) ON DELETE CASCADE,
+    name         TEXT NOT NULL,
+    token_hash   TEXT NOT NULL UNIQUE,
+    scopes       TEXT[] NOT NULL DEFAULT '{}',
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at   TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    revoked_at   TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_tokens_member ON api_tokens(member_id) WHERE revoked_at IS NULL;
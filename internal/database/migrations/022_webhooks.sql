@@ -0,0 +1,39 @@
+-- Migration 022: Outgoing webhooks for feed events.
+-- Staff endpoints (club_id NULL) and club endpoints subscribe to activity
+-- event types. Each new activity queues one delivery per matching endpoint
+-- in the same statement, and a background job sends them with HMAC-signed
+-- JSON, retrying with exponential backoff. Endpoints are switched off after
+-- too many failures in a row.
+
+CREATE TABLE IF NOT EXISTS webhook_endpoints (
+    id                   UUID PRIMARY KEY,
+    club_id              UUID REFERENCES clubs(id) ON DELETE CASCADE,
+    url                  TEXT NOT NULL,
+    description          TEXT NOT NULL DEFAULT '',
+    secret               TEXT NOT NULL,
+    event_types          TEXT[] NOT NULL DEFAULT '{}',
+    enabled              BOOLEAN NOT NULL DEFAULT TRUE,
+    consecutive_failures INT NOT NULL DEFAULT 0,
+    disabled_at          TIMESTAMPTZ,
+    created_by           UUID REFERENCES members(id) ON DELETE SET NULL,
+    created_at           TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_endpoints_club ON webhook_endpoints(club_id);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id               UUID PRIMARY KEY,
+    endpoint_id      UUID NOT NULL REFERENCES webhook_endpoints(id) ON DELETE CASCADE,
+    activity_id      UUID NOT NULL REFERENCES activities(id) ON DELETE CASCADE,
+    event_type       TEXT NOT NULL,
+    status           TEXT NOT NULL DEFAULT 'pending',
+    attempts         INT NOT NULL DEFAULT 0,
+    last_status_code INT NOT NULL DEFAULT 0,
+    last_error       TEXT NOT NULL DEFAULT '',
+    next_attempt_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    delivered_at     TIMESTAMPTZ,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_endpoint ON webhook_deliveries(endpoint_id, created_at DESC);
@@ -0,0 +1,12 @@
+-- Migration 023: Private calendar feeds.
+-- Each member may publish one iCalendar feed of their due dates at a secret
+-- URL. Only the SHA-256 hash of the URL token is stored; creating a new link
+-- replaces the old one.
+
+CREATE TABLE IF NOT EXISTS calendar_feeds (
+    member_id          UUID PRIMARY KEY REFERENCES members(id) ON DELETE CASCADE,
+    token_hash         TEXT NOT NULL UNIQUE,
+    include_challenges BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_used_at       TIMESTAMPTZ
+);
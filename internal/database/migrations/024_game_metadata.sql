@@ -0,0 +1,37 @@
+-- Migration 024: Rich game metadata imported from IGDB.
+-- Release date and screenshots live on games; genres and companies get their
+-- own tables so the shelf can filter on them. edited_fields lists the fields
+-- staff changed by hand, which an IGDB re-sync leaves alone.
+
+ALTER TABLE games ADD COLUMN IF NOT EXISTS release_date DATE;
+ALTER TABLE games ADD COLUMN IF NOT EXISTS screenshots TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE games ADD COLUMN IF NOT EXISTS edited_fields TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE games ADD COLUMN IF NOT EXISTS metadata_synced_at TIMESTAMPTZ;
+
+CREATE TABLE IF NOT EXISTS genres (
+    id   UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS game_genres (
+    game_id  UUID NOT NULL REFERENCES games(id) ON DELETE CASCADE,
+    genre_id UUID NOT NULL REFERENCES genres(id) ON DELETE CASCADE,
+    PRIMARY KEY (game_id, genre_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_game_genres_genre ON game_genres(genre_id);
+
+CREATE TABLE IF NOT EXISTS companies (
+    id   UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL UNIQUE
+);
+
+-- role: 'developer' or 'publisher'. A company can hold both roles on a game.
+CREATE TABLE IF NOT EXISTS game_companies (
+    game_id    UUID NOT NULL REFERENCES games(id) ON DELETE CASCADE,
+    company_id UUID NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    role       TEXT NOT NULL CHECK (role IN ('developer', 'publisher')),
+    PRIMARY KEY (game_id, company_id, role)
+);
+
+CREATE INDEX IF NOT EXISTS idx_game_companies_company ON game_companies(company_id, role);
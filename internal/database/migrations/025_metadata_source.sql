@@ -0,0 +1,10 @@
+-- Migration 025: Where each game's metadata came from.
+-- metadata_source is 'igdb', 'local' (offline catalog) or '' for games
+-- entered by hand; metadata_id is the game's ID in that source, used to
+-- re-sync it. Games stocked before this migration came from IGDB.
+
+ALTER TABLE games ADD COLUMN IF NOT EXISTS metadata_source TEXT NOT NULL DEFAULT '';
+ALTER TABLE games ADD COLUMN IF NOT EXISTS metadata_id TEXT NOT NULL DEFAULT '';
+
+UPDATE games SET metadata_source = 'igdb', metadata_id = igdb_id
+WHERE metadata_source = '' AND COALESCE(igdb_id, '') <> '';
@@ -0,0 +1,8 @@
+-- Migration 026: Covers served from the shop's own disk.
+-- cover_url keeps pointing at the detail-size image; cover_thumb_url is the
+-- small image for shelf cards and lists (empty until the cover is mirrored)
+-- and cover_source_url records the remote URL a mirrored cover came from.
+-- Existing covers are mirrored with `server -mirror-covers`.
+
+ALTER TABLE games ADD COLUMN IF NOT EXISTS cover_thumb_url TEXT NOT NULL DEFAULT '';
+ALTER TABLE games ADD COLUMN IF NOT EXISTS cover_source_url TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,71 @@
+-- Migration 027: Canonical platform registry.
+-- games.platform keeps the canonical name; aliases are the other names
+-- magazines, lists and providers use for the same console (Genesis for
+-- Mega Drive, Famicom for NES) and igdb_ids the matching IGDB platform IDs.
+-- on_shelf platforms get a card on the shelf even with no games.
+
+CREATE TABLE IF NOT EXISTS platforms (
+    slug           TEXT PRIMARY KEY,
+    name           TEXT NOT NULL UNIQUE,
+    aliases        TEXT[] NOT NULL DEFAULT '{}',
+    igdb_ids       INTEGER[] NOT NULL DEFAULT '{}',
+    manufacturer   TEXT NOT NULL DEFAULT '',
+    br_distributor TEXT NOT NULL DEFAULT '',
+    release_year   INTEGER,
+    logo_url       TEXT NOT NULL DEFAULT '',
+    on_shelf       BOOLEAN NOT NULL DEFAULT FALSE,
+    sort_order     INTEGER NOT NULL DEFAULT 0
+);
+
+INSERT INTO platforms (slug, name, aliases, igdb_ids, manufacturer, br_distributor, release_year, logo_url, on_shelf, sort_order) VALUES
+    ('mega-drive', 'Mega Drive',
+        '{"Genesis","Sega Genesis","Sega Mega Drive","Sega Mega Drive/Genesis","Genesis/MegaDrive","MD"}',
+        '{29}', 'Sega', 'TecToy', 1988, '/static/img/logos/mega-drive.svg', TRUE, 1),
+    ('super-nintendo', 'Super Nintendo',
+        '{"SNES","Super NES","Super Famicom","SFC","Super Nintendo Entertainment System"}',
+        '{19,58}', 'Nintendo', 'Playtronic', 1990, '/static/img/logos/snes.svg', TRUE, 2),
+    ('nes', 'NES',
+        '{"Famicom","Nintendinho","Nintendo Entertainment System","Family Computer"}',
+        '{18,99}', 'Nintendo', 'Playtronic', 1983, '/static/img/logos/nes.svg', TRUE, 3),
+    ('master-system', 'Master System',
+        '{"SMS","Sega Master System","Sega Mark III","Mark III","Sega Master System/Mark III"}',
+        '{64}', 'Sega', 'TecToy', 1985, '/static/img/logos/master-system.svg', TRUE, 4),
+    ('atari-2600', 'Atari 2600',
+        '{"Atari","VCS","Atari VCS"}',
+        '{59}', 'Atari', 'Polyvox', 1977, '/static/img/logos/atari-2600.svg', TRUE, 5),
+    ('game-boy', 'Game Boy',
+        '{"GB"}',
+        '{33}', 'Nintendo', 'Playtronic', 1989, '', FALSE, 10),
+    ('game-gear', 'Game Gear',
+        '{"GG","Sega Game Gear"}',
+        '{35}', 'Sega', 'TecToy', 1990, '', FALSE, 11),
+    ('mega-cd', 'Mega CD',
+        '{"Sega CD","Sega Mega-CD","Mega-CD"}',
+        '{78}', 'Sega', 'TecToy', 1991, '', FALSE, 12),
+    ('saturn', 'Saturn',
+        '{"Sega Saturn"}',
+        '{32}', 'Sega', 'TecToy', 1994, '', FALSE, 13),
+    ('nintendo-64', 'Nintendo 64',
+        '{"N64"}',
+        '{4}', 'Nintendo', 'Gradiente', 1996, '', FALSE, 14),
+    ('playstation', 'PlayStation',
+        '{"PS1","PSX","PS One","Sony PlayStation"}',
+        '{7}', 'Sony', '', 1994, '', FALSE, 15)
+ON CONFLICT (slug) DO NOTHING;
+
+-- Normalize existing games. Names are compared like the application does:
+-- lower case with everything but letters and digits dropped. Games stocked
+-- from IGDB hold a list such as "SNES, Genesis/MegaDrive"; the first entry
+-- the registry knows wins. Unknown values are left for staff to fix.
+UPDATE games g SET platform = m.name
+FROM (
+    SELECT DISTINCT ON (g2.id) g2.id, p.name
+    FROM games g2
+    CROSS JOIN LATERAL unnest(string_to_array(g2.platform, ',')) WITH ORDINALITY AS part(value, n)
+    JOIN platforms p
+      ON regexp_replace(lower(part.value), '[^a-z0-9]', '', 'g') IN (
+             SELECT regexp_replace(lower(a), '[^a-z0-9]', '', 'g')
+             FROM unnest(p.aliases || p.name || p.slug) AS a)
+    ORDER BY g2.id, part.n
+) m
+WHERE g.id = m.id AND g.platform <> m.name;
@@ -0,0 +1,14 @@
+-- Migration 028: First finisher recorded on the challenge.
+-- The member who completes a challenge first is stored when the completion
+-- is saved, so the club page and the challenge_first_finish feed event name
+-- the same member. Existing challenges take the earliest completion.
+
+ALTER TABLE club_challenges ADD COLUMN IF NOT EXISTS first_finisher_id UUID REFERENCES members(id) ON DELETE SET NULL;
+
+UPDATE club_challenges ch SET first_finisher_id = (
+    SELECT p.member_id
+    FROM club_challenge_participants p
+    WHERE p.challenge_id = ch.id AND p.status = 'completed'
+    ORDER BY p.finished_at, p.joined_at
+    LIMIT 1)
+WHERE ch.first_finisher_id IS NULL;
@@ -0,0 +1,34 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cmellojr/modo-locadora/internal/models"
+)
+
+// ListPlatformRegistry returns the platform registry in shelf order.
+func (s *PostgresStore) ListPlatformRegistry(ctx context.Context) (models.Platforms, error) {
+	query := `
+		SELECT slug, name, aliases, igdb_ids, manufacturer, br_distributor,
+			COALESCE(release_year, 0), logo_url, on_shelf, sort_order
+		FROM platforms
+		ORDER BY sort_order ASC, name ASC`
+
+	rows, err := s.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query platform registry: %w", err)
+	}
+	defer rows.Close()
+
+	var result models.Platforms
+	for rows.Next() {
+		var p models.Platform
+		if err := rows.Scan(&p.Slug, &p.Name, &p.Aliases, &p.IgdbIDs, &p.Manufacturer, &p.BrDistributor,
+			&p.ReleaseYear, &p.LogoURL, &p.OnShelf, &p.SortOrder); err != nil {
+			return nil, fmt.Errorf("failed to scan platform: %w", err)
+		}
+		result = append(result, p)
+	}
+	return result, nil
+}
@@ -2,7 +2,9 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/cmellojr/modo-locadora/internal/models"
@@ -11,6 +13,12 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// Rental errors the handlers map to user-facing messages.
+var (
+	ErrNoCopiesAvailable = errors.New("no available copies for this game")
+	ErrRentalNotFound    = errors.New("rental not found or does not belong to this member")
+)
+
 // PostgresStore implements the Store interface using PostgreSQL.
 type PostgresStore struct {
 	pool *pgxpool.Pool
@@ -41,17 +49,46 @@ func (s *PostgresStore) Close() {
 	s.pool.Close()
 }
 
+// lastUse names the column recording when a row was last used. Sessions, API
+// tokens and calendar feeds are read on every request, so the column is only
+// written once per interval rather than turning each read into an UPDATE.
+type lastUse struct {
+	table, column, key string
+	interval           time.Duration
+}
+
+// touch records a use of the row whose key is id, unless prev falls within
+// the interval. The UPDATE checks the interval too, so concurrent requests
+// write the column once. Returns the time to report as the last use.
+func (s *PostgresStore) touch(ctx context.Context, u lastUse, id any, prev *time.Time) (*time.Time, error) {
+	if prev != nil && time.Since(*prev) <= u.interval {
+		return prev, nil
+	}
+	_, err := s.pool.Exec(ctx,
+		`UPDATE `+u.table+` SET `+u.column+` = NOW()
+		 WHERE `+u.key+` = $1 AND (`+u.column+` IS NULL OR `+u.column+` < NOW() - $2::interval)`,
+		id, u.interval)
+	if err != nil {
+		return nil, fmt.Errorf("failed to touch %s: %w", u.table, err)
+	}
+	now := time.Now()
+	return &now, nil
+}
+
 // memberColumns is the shared column list for member queries.
 const memberColumns = `id, profile_name, email, password_hash, favorite_console,
 	COALESCE(membership_number, ''), COALESCE(address, ''), COALESCE(phone, ''),
 	COALESCE(password_notes, ''), COALESCE(status, 'active'), COALESCE(late_count, 0),
-	joined_at`
+	primary_club_id, email_verified_at, failed_logins, locked_until, pending_approval, deleted_at,
+	totp_secret, totp_enabled_at, joined_at`
 
 func scanMember(row pgx.Row) (*models.Member, error) {
 	var m models.Member
 	err := row.Scan(&m.ID, &m.ProfileName, &m.Email, &m.PasswordHash,
 		&m.FavoriteConsole, &m.MembershipNumber, &m.Address, &m.Phone,
-		&m.PasswordNotes, &m.Status, &m.LateCount, &m.JoinedAt)
+		&m.PasswordNotes, &m.Status, &m.LateCount, &m.PrimaryClubID, &m.EmailVerifiedAt,
+		&m.FailedLogins, &m.LockedUntil, &m.PendingApproval, &m.DeletedAt,
+		&m.TOTPSecret, &m.TOTPEnabledAt, &m.JoinedAt)
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, nil
@@ -61,15 +98,10 @@ func scanMember(row pgx.Row) (*models.Member, error) {
 	return &m, nil
 }
 
-// CreateMember persists a new member in the database.
+// CreateMember persists a new member in the database. Returns ErrProfileNameTaken
+// or ErrEmailTaken when another member already uses the name or e-mail.
 func (s *PostgresStore) CreateMember(ctx context.Context, m *models.Member) error {
-	query := `
-		INSERT INTO members (id, profile_name, email, password_hash, favorite_console, membership_number, address, phone, password_notes, joined_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
-
-	_, err := s.pool.Exec(ctx, query, m.ID, m.ProfileName, m.Email, m.PasswordHash,
-		m.FavoriteConsole, m.MembershipNumber, m.Address, m.Phone, m.PasswordNotes, m.JoinedAt)
-	if err != nil {
+	if err := insertMember(ctx, s.pool, m); err != nil {
 		return fmt.Errorf("failed to create member: %w", err)
 	}
 	return nil
@@ -85,9 +117,9 @@ func (s *PostgresStore) GetMemberByID(ctx context.Context, id uuid.UUID) (*model
 	return m, nil
 }
 
-// GetMemberByProfileName retrieves a member by their profile name.
+// GetMemberByProfileName retrieves a member by their profile name (case-insensitive).
 func (s *PostgresStore) GetMemberByProfileName(ctx context.Context, name string) (*models.Member, error) {
-	query := `SELECT ` + memberColumns + ` FROM members WHERE profile_name = $1`
+	query := `SELECT ` + memberColumns + ` FROM members WHERE LOWER(profile_name) = LOWER($1)`
 	m, err := scanMember(s.pool.QueryRow(ctx, query, name))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get member by profile name: %w", err)
@@ -107,16 +139,22 @@ func (s *PostgresStore) NextMembershipNumber(ctx context.Context) (string, error
 
 // GetGameByID retrieves a game by its ID.
 func (s *PostgresStore) GetGameByID(ctx context.Context, id uuid.UUID) (*models.Game, error) {
-	query := `SELECT id, title, igdb_id, platform, summary, cover_url, source_magazine, COALESCE(cover_display, 'cover'), acquired_at FROM games WHERE id = $1`
+	query := `SELECT id, title, igdb_id, platform, summary, cover_url, source_magazine, COALESCE(cover_display, 'cover'), acquired_at,
+		release_date, screenshots, edited_fields, metadata_synced_at, metadata_source, metadata_id, cover_thumb_url, cover_source_url
+		FROM games WHERE id = $1`
 
 	var g models.Game
-	err := s.pool.QueryRow(ctx, query, id).Scan(&g.ID, &g.Title, &g.IgdbID, &g.Platform, &g.Summary, &g.CoverURL, &g.SourceMagazine, &g.CoverDisplay, &g.AcquiredAt)
+	err := s.pool.QueryRow(ctx, query, id).Scan(&g.ID, &g.Title, &g.IgdbID, &g.Platform, &g.Summary, &g.CoverURL, &g.SourceMagazine, &g.CoverDisplay, &g.AcquiredAt,
+		&g.ReleaseDate, &g.Screenshots, &g.EditedFields, &g.MetadataSyncedAt, &g.MetadataSource, &g.MetadataID, &g.CoverThumbURL, &g.CoverSourceURL)
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, nil
 		}
 		return nil, fmt.Errorf("failed to get game: %w", err)
 	}
+	if err := s.loadGameTaxonomy(ctx, &g); err != nil {
+		return nil, err
+	}
 	return &g, nil
 }
 
@@ -128,43 +166,100 @@ func (s *PostgresStore) AddGame(ctx context.Context, g *models.Game) error {
 	}
 	defer tx.Rollback(ctx)
 
+	if err := addGameTx(ctx, tx, g, 1); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// addGameTx inserts a game with its genres, companies and copies available
+// copies.
+func addGameTx(ctx context.Context, tx pgx.Tx, g *models.Game, copies int) error {
 	gameQuery := `
-		INSERT INTO games (id, title, igdb_id, platform, summary, cover_url, source_magazine, acquired_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
-	_, err = tx.Exec(ctx, gameQuery, g.ID, g.Title, g.IgdbID, g.Platform, g.Summary, g.CoverURL, g.SourceMagazine, g.AcquiredAt)
+		INSERT INTO games (id, title, igdb_id, platform, summary, cover_url, source_magazine, acquired_at,
+			release_date, screenshots, edited_fields, metadata_synced_at, metadata_source, metadata_id, cover_thumb_url, cover_source_url)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)`
+	_, err := tx.Exec(ctx, gameQuery, g.ID, g.Title, g.IgdbID, g.Platform, g.Summary, g.CoverURL, g.SourceMagazine, g.AcquiredAt,
+		g.ReleaseDate, nonNil(g.Screenshots), nonNil(g.EditedFields), g.MetadataSyncedAt, g.MetadataSource, g.MetadataID,
+		g.CoverThumbURL, g.CoverSourceURL)
 	if err != nil {
-		return fmt.Errorf("failed to add game: %w", err)
+		return fmt.Errorf("failed to add game %q: %w", g.Title, err)
+	}
+
+	if err := saveGameTaxonomyTx(ctx, tx, g); err != nil {
+		return err
 	}
 
 	copyQuery := `INSERT INTO game_copies (id, game_id, status) VALUES ($1, $2, 'available')`
-	_, err = tx.Exec(ctx, copyQuery, uuid.New(), g.ID)
+	for range copies {
+		if _, err := tx.Exec(ctx, copyQuery, uuid.New(), g.ID); err != nil {
+			return fmt.Errorf("failed to create game copy: %w", err)
+		}
+	}
+	return nil
+}
+
+// StockGames adds a batch of games with their copies and one new_game
+// feed event summarizing them, all in one transaction: either the whole
+// batch reaches the shelves or none of it does.
+func (s *PostgresStore) StockGames(ctx context.Context, items []StockItem, summary string) error {
+	tx, err := s.pool.Begin(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to create game copy: %w", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	for _, it := range items {
+		if err := addGameTx(ctx, tx, it.Game, max(1, it.Copies)); err != nil {
+			return err
+		}
+	}
+	if err := insertActivity(ctx, tx, "new_game", "", summary); err != nil {
+		return err
 	}
 
 	return tx.Commit(ctx)
 }
 
-// UpdateGame updates the editable fields of an existing game.
+// UpdateGame updates the editable fields of an existing game, including its
+// genres and companies.
 func (s *PostgresStore) UpdateGame(ctx context.Context, g *models.Game) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
 	query := `
 		UPDATE games
-		SET title = $2, platform = $3, summary = $4, cover_url = $5, source_magazine = $6, cover_display = $7
+		SET title = $2, platform = $3, summary = $4, cover_url = $5, source_magazine = $6, cover_display = $7,
+			release_date = $8, screenshots = $9, edited_fields = $10, metadata_synced_at = $11, igdb_id = $12,
+			cover_thumb_url = $13, cover_source_url = $14
 		WHERE id = $1`
 
-	tag, err := s.pool.Exec(ctx, query, g.ID, g.Title, g.Platform, g.Summary, g.CoverURL, g.SourceMagazine, g.CoverDisplay)
+	tag, err := tx.Exec(ctx, query, g.ID, g.Title, g.Platform, g.Summary, g.CoverURL, g.SourceMagazine, g.CoverDisplay,
+		g.ReleaseDate, nonNil(g.Screenshots), nonNil(g.EditedFields), g.MetadataSyncedAt, g.IgdbID,
+		g.CoverThumbURL, g.CoverSourceURL)
 	if err != nil {
 		return fmt.Errorf("failed to update game: %w", err)
 	}
 	if tag.RowsAffected() == 0 {
 		return fmt.Errorf("game not found: %s", g.ID)
 	}
-	return nil
+
+	if err := saveGameTaxonomyTx(ctx, tx, g); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
 }
 
 // ListGames retrieves all games from the database.
 func (s *PostgresStore) ListGames(ctx context.Context) ([]models.Game, error) {
-	query := `SELECT id, title, igdb_id, platform, summary, cover_url, source_magazine, COALESCE(cover_display, 'cover'), acquired_at FROM games ORDER BY acquired_at DESC`
+	query := `SELECT id, title, igdb_id, platform, summary, cover_url, source_magazine, COALESCE(cover_display, 'cover'), acquired_at,
+		cover_thumb_url, cover_source_url
+		FROM games ORDER BY acquired_at DESC`
 
 	rows, err := s.pool.Query(ctx, query)
 	if err != nil {
@@ -175,7 +270,8 @@ func (s *PostgresStore) ListGames(ctx context.Context) ([]models.Game, error) {
 	var games []models.Game
 	for rows.Next() {
 		var g models.Game
-		if err := rows.Scan(&g.ID, &g.Title, &g.IgdbID, &g.Platform, &g.Summary, &g.CoverURL, &g.SourceMagazine, &g.CoverDisplay, &g.AcquiredAt); err != nil {
+		if err := rows.Scan(&g.ID, &g.Title, &g.IgdbID, &g.Platform, &g.Summary, &g.CoverURL, &g.SourceMagazine, &g.CoverDisplay, &g.AcquiredAt,
+			&g.CoverThumbURL, &g.CoverSourceURL); err != nil {
 			return nil, fmt.Errorf("failed to scan game: %w", err)
 		}
 		games = append(games, g)
@@ -183,10 +279,15 @@ func (s *PostgresStore) ListGames(ctx context.Context) ([]models.Game, error) {
 	return games, nil
 }
 
-// ListGamesWithAvailability returns games with copy counts and rental status, optionally filtered by platform.
-func (s *PostgresStore) ListGamesWithAvailability(ctx context.Context, platform string) ([]GameAvailability, error) {
+// ListGamesWithAvailability returns games with copy counts and rental status, narrowed by the filter.
+func (s *PostgresStore) ListGamesWithAvailability(ctx context.Context, f GameFilter) ([]GameAvailability, error) {
 	query := `
 		SELECT g.id, g.title, g.igdb_id, g.platform, g.summary, g.cover_url, g.source_magazine, COALESCE(g.cover_display, 'cover'), g.acquired_at,
+			g.release_date, g.cover_thumb_url,
+			ARRAY(SELECT ge.name FROM game_genres gg JOIN genres ge ON ge.id = gg.genre_id
+			      WHERE gg.game_id = g.id ORDER BY ge.name) AS genres,
+			ARRAY(SELECT co.name FROM game_companies gco JOIN companies co ON co.id = gco.company_id
+			      WHERE gco.game_id = g.id AND gco.role = 'developer' ORDER BY co.name) AS developers,
 			COUNT(gc.id) AS total_copies,
 			COUNT(gc.id) FILTER (WHERE gc.status = 'available') AS available_copies,
 			COALESCE(
@@ -198,10 +299,31 @@ func (s *PostgresStore) ListGamesWithAvailability(ctx context.Context, platform
 		FROM games g
 		LEFT JOIN game_copies gc ON gc.game_id = g.id`
 
+	var conds []string
 	var args []interface{}
-	if platform != "" {
-		query += ` WHERE g.platform = $1`
-		args = append(args, platform)
+	if f.Platform != "" {
+		args = append(args, f.Platform)
+		conds = append(conds, fmt.Sprintf(`g.platform = $%d`, len(args)))
+	}
+	if f.Genre != "" {
+		args = append(args, f.Genre)
+		conds = append(conds, fmt.Sprintf(`EXISTS (
+			SELECT 1 FROM game_genres gg JOIN genres ge ON ge.id = gg.genre_id
+			WHERE gg.game_id = g.id AND ge.name = $%d)`, len(args)))
+	}
+	if f.Developer != "" {
+		args = append(args, f.Developer)
+		conds = append(conds, fmt.Sprintf(`EXISTS (
+			SELECT 1 FROM game_companies gco JOIN companies co ON co.id = gco.company_id
+			WHERE gco.game_id = g.id AND gco.role = 'developer' AND co.name = $%d)`, len(args)))
+	}
+	if f.Year != 0 {
+		args = append(args, f.Year)
+		conds = append(conds, fmt.Sprintf(`EXTRACT(YEAR FROM g.release_date) = $%d`, len(args)))
+	}
+	if len(conds) > 0 {
+		query += `
+		WHERE ` + strings.Join(conds, " AND ")
 	}
 
 	query += `
@@ -220,6 +342,7 @@ func (s *PostgresStore) ListGamesWithAvailability(ctx context.Context, platform
 		if err := rows.Scan(
 			&ga.Game.ID, &ga.Game.Title, &ga.Game.IgdbID, &ga.Game.Platform,
 			&ga.Game.Summary, &ga.Game.CoverURL, &ga.Game.SourceMagazine, &ga.Game.CoverDisplay, &ga.Game.AcquiredAt,
+			&ga.Game.ReleaseDate, &ga.Game.CoverThumbURL, &ga.Game.Genres, &ga.Game.Developers,
 			&ga.TotalCopies, &ga.AvailableCopies, &ga.RenterName,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan game availability: %w", err)
@@ -259,6 +382,7 @@ func (s *PostgresStore) GetGameDetail(ctx context.Context, gameID uuid.UUID) (*G
 	// Base game + copy counts.
 	query := `
 		SELECT g.id, g.title, g.igdb_id, g.platform, g.summary, g.cover_url, g.source_magazine, COALESCE(g.cover_display, 'cover'), g.acquired_at,
+			g.release_date, g.screenshots, g.edited_fields, g.metadata_synced_at, g.metadata_source, g.metadata_id,
 			COUNT(gc.id) AS total_copies,
 			COUNT(gc.id) FILTER (WHERE gc.status = 'available') AS available_copies
 		FROM games g
@@ -270,6 +394,8 @@ func (s *PostgresStore) GetGameDetail(ctx context.Context, gameID uuid.UUID) (*G
 	err := s.pool.QueryRow(ctx, query, gameID).Scan(
 		&gd.Game.ID, &gd.Game.Title, &gd.Game.IgdbID, &gd.Game.Platform,
 		&gd.Game.Summary, &gd.Game.CoverURL, &gd.Game.SourceMagazine, &gd.Game.CoverDisplay, &gd.Game.AcquiredAt,
+		&gd.Game.ReleaseDate, &gd.Game.Screenshots, &gd.Game.EditedFields, &gd.Game.MetadataSyncedAt,
+		&gd.Game.MetadataSource, &gd.Game.MetadataID,
 		&gd.TotalCopies, &gd.AvailableCopies,
 	)
 	if err != nil {
@@ -278,6 +404,9 @@ func (s *PostgresStore) GetGameDetail(ctx context.Context, gameID uuid.UUID) (*G
 		}
 		return nil, fmt.Errorf("failed to get game detail: %w", err)
 	}
+	if err := s.loadGameTaxonomy(ctx, &gd.Game); err != nil {
+		return nil, err
+	}
 
 	// Total rental count for this game.
 	s.pool.QueryRow(ctx, `
@@ -322,7 +451,7 @@ func (s *PostgresStore) RentGame(ctx context.Context, gameID, memberID uuid.UUID
 		gameID).Scan(&copyID)
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return fmt.Errorf("no available copies for this game")
+			return ErrNoCopiesAvailable
 		}
 		return fmt.Errorf("failed to find available copy: %w", err)
 	}
@@ -384,7 +513,7 @@ func (s *PostgresStore) ReturnGame(ctx context.Context, rentalID uuid.UUID) erro
 // ListActiveRentals returns all currently active (unreturned) rentals.
 func (s *PostgresStore) ListActiveRentals(ctx context.Context) ([]ActiveRental, error) {
 	query := `
-		SELECT r.id, g.title, g.cover_url, m.profile_name, r.rented_at
+		SELECT r.id, g.title, COALESCE(NULLIF(g.cover_thumb_url, ''), g.cover_url), m.profile_name, r.rented_at
 		FROM rentals r
 		JOIN game_copies gc ON gc.id = r.copy_id
 		JOIN games g ON g.id = gc.game_id
@@ -529,7 +658,7 @@ func (s *PostgresStore) GetTopShameEntries(ctx context.Context, limit int) ([]Sh
 	query := `
 		SELECT profile_name, late_count
 		FROM members
-		WHERE late_count > 0
+		WHERE late_count > 0 AND deleted_at IS NULL
 		ORDER BY late_count DESC, profile_name ASC
 		LIMIT $1`
 
@@ -580,26 +709,12 @@ func (s *PostgresStore) GetMemberStatus(ctx context.Context, memberID uuid.UUID)
 
 // insertActivityTx records an activity event within an existing transaction.
 func (s *PostgresStore) insertActivityTx(ctx context.Context, tx pgx.Tx, eventType, memberName, gameTitle string) error {
-	_, err := tx.Exec(ctx,
-		`INSERT INTO activities (id, event_type, member_name, game_title, created_at)
-		 VALUES ($1, $2, $3, $4, NOW())`,
-		uuid.New(), eventType, memberName, gameTitle)
-	if err != nil {
-		return fmt.Errorf("failed to insert activity: %w", err)
-	}
-	return nil
+	return insertActivity(ctx, tx, eventType, memberName, gameTitle)
 }
 
 // InsertActivity records an activity event using the connection pool.
 func (s *PostgresStore) InsertActivity(ctx context.Context, eventType, memberName, gameTitle string) error {
-	_, err := s.pool.Exec(ctx,
-		`INSERT INTO activities (id, event_type, member_name, game_title, created_at)
-		 VALUES ($1, $2, $3, $4, NOW())`,
-		uuid.New(), eventType, memberName, gameTitle)
-	if err != nil {
-		return fmt.Errorf("failed to insert activity: %w", err)
-	}
-	return nil
+	return insertActivity(ctx, s.pool, eventType, memberName, gameTitle)
 }
 
 // ListRecentActivities returns the N most recent activity events.
@@ -630,7 +745,7 @@ func (s *PostgresStore) ListRecentActivities(ctx context.Context, limit int) ([]
 // ListMemberActiveRentals returns active rentals for a specific member.
 func (s *PostgresStore) ListMemberActiveRentals(ctx context.Context, memberID uuid.UUID) ([]MemberRental, error) {
 	query := `
-		SELECT r.id, g.title, g.cover_url, g.platform, r.rented_at, r.due_at,
+		SELECT r.id, g.title, COALESCE(NULLIF(g.cover_thumb_url, ''), g.cover_url), g.platform, r.rented_at, r.due_at,
 		       (r.due_at < NOW()) AS is_overdue
 		FROM rentals r
 		JOIN game_copies gc ON gc.id = r.copy_id
@@ -688,7 +803,7 @@ func (s *PostgresStore) ReturnGameByMember(ctx context.Context, rentalID, member
 		rentalID, memberID).Scan(&copyID)
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return fmt.Errorf("rental not found or does not belong to this member")
+			return ErrRentalNotFound
 		}
 		return fmt.Errorf("failed to find rental: %w", err)
 	}
@@ -920,7 +1035,7 @@ func (s *PostgresStore) LeaveClub(ctx context.Context, clubID, memberID uuid.UUI
 	if err != nil {
 		return fmt.Errorf("failed to leave club: %w", err)
 	}
-	return nil
+	return s.clearPrimaryClub(ctx, clubID, memberID)
 }
 
 // GetClubMemberRole returns the role of a member in a club, or "" if not a member.
@@ -963,7 +1078,7 @@ func (s *PostgresStore) RemoveClubMember(ctx context.Context, clubID, memberID u
 	if tag.RowsAffected() == 0 {
 		return fmt.Errorf("member not found in club")
 	}
-	return nil
+	return s.clearPrimaryClub(ctx, clubID, memberID)
 }
 
 // ListMemberClubs returns the clubs a member belongs to.
@@ -1012,8 +1127,8 @@ func computeGamePopularity(totalRentals, completedCount, gaveUpCount, notForMeCo
 // ListGamesWithPopularity returns all games with computed popularity for the admin inventory.
 func (s *PostgresStore) ListGamesWithPopularity(ctx context.Context) ([]GameInventoryItem, error) {
 	query := `
-		SELECT g.id, g.title, g.igdb_id, g.platform, g.summary, g.cover_url,
-		       g.source_magazine, COALESCE(g.cover_display, 'cover'), g.acquired_at,
+		SELECT g.id, g.title, g.igdb_id, g.platform, g.summary, g.cover_url, g.cover_thumb_url,
+		       g.source_magazine, COALESCE(g.cover_display, 'cover'), g.acquired_at, g.metadata_source,
 		       COUNT(r.id) AS total_rentals,
 		       COUNT(r.id) FILTER (WHERE r.returned_at IS NOT NULL) AS total_returned,
 		       COUNT(r.id) FILTER (WHERE r.public_legacy = 'completed') AS completed_count,
@@ -1048,8 +1163,8 @@ func (s *PostgresStore) ListGamesWithPopularity(ctx context.Context) ([]GameInve
 		var rentedDays30 float64
 		if err := rows.Scan(
 			&item.Game.ID, &item.Game.Title, &item.Game.IgdbID, &item.Game.Platform,
-			&item.Game.Summary, &item.Game.CoverURL, &item.Game.SourceMagazine,
-			&item.Game.CoverDisplay, &item.Game.AcquiredAt,
+			&item.Game.Summary, &item.Game.CoverURL, &item.Game.CoverThumbURL, &item.Game.SourceMagazine,
+			&item.Game.CoverDisplay, &item.Game.AcquiredAt, &item.Game.MetadataSource,
 			&totalRentals, &totalReturned, &completedCount, &gaveUpCount, &notForMeCount,
 			&copyCount, &rentedDays30, &rentalsLast30,
 		); err != nil {
@@ -0,0 +1,99 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cmellojr/modo-locadora/internal/models"
+	"github.com/google/uuid"
+)
+
+// ── Login protection methods ────────────────────────────────────────────────
+
+// RecordLoginFailure increments the member's consecutive failed logins and
+// returns the new count.
+func (s *PostgresStore) RecordLoginFailure(ctx context.Context, memberID uuid.UUID) (int, error) {
+	var count int
+	err := s.pool.QueryRow(ctx,
+		`UPDATE members SET failed_logins = failed_logins + 1 WHERE id = $1 RETURNING failed_logins`,
+		memberID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record login failure: %w", err)
+	}
+	return count, nil
+}
+
+// LockMember refuses logins for the member until the given time.
+func (s *PostgresStore) LockMember(ctx context.Context, memberID uuid.UUID, until time.Time) error {
+	_, err := s.pool.Exec(ctx, `UPDATE members SET locked_until = $2 WHERE id = $1`, memberID, until)
+	if err != nil {
+		return fmt.Errorf("failed to lock member: %w", err)
+	}
+	return nil
+}
+
+// ResetLoginFailures clears the member's failed login count and any lockout.
+func (s *PostgresStore) ResetLoginFailures(ctx context.Context, memberID uuid.UUID) error {
+	_, err := s.pool.Exec(ctx,
+		`UPDATE members SET failed_logins = 0, locked_until = NULL
+		 WHERE id = $1 AND (failed_logins <> 0 OR locked_until IS NOT NULL)`, memberID)
+	if err != nil {
+		return fmt.Errorf("failed to reset login failures: %w", err)
+	}
+	return nil
+}
+
+// ListLockedMembers returns members whose lockout has not expired yet,
+// longest lockout first.
+func (s *PostgresStore) ListLockedMembers(ctx context.Context) ([]models.Member, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT `+memberColumns+` FROM members WHERE locked_until > NOW() ORDER BY locked_until DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query locked members: %w", err)
+	}
+	defer rows.Close()
+
+	var members []models.Member
+	for rows.Next() {
+		m, err := scanMember(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan locked member: %w", err)
+		}
+		members = append(members, *m)
+	}
+	return members, nil
+}
+
+// RecordSecurityEvent persists a security event.
+func (s *PostgresStore) RecordSecurityEvent(ctx context.Context, e *models.SecurityEvent) error {
+	_, err := s.pool.Exec(ctx,
+		`INSERT INTO security_events (id, kind, member_id, profile_name, ip, detail, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		e.ID, e.Kind, e.MemberID, e.ProfileName, e.IP, e.Detail, e.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record security event: %w", err)
+	}
+	return nil
+}
+
+// ListSecurityEvents returns the most recent security events, newest first.
+func (s *PostgresStore) ListSecurityEvents(ctx context.Context, limit int) ([]models.SecurityEvent, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT id, kind, member_id, profile_name, ip, detail, created_at
+		 FROM security_events ORDER BY created_at DESC LIMIT $1`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query security events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []models.SecurityEvent
+	for rows.Next() {
+		var e models.SecurityEvent
+		if err := rows.Scan(&e.ID, &e.Kind, &e.MemberID, &e.ProfileName, &e.IP, &e.Detail, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan security event: %w", err)
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
@@ -0,0 +1,151 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cmellojr/modo-locadora/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ── Session methods ─────────────────────────────────────────────────────────
+
+// sessionUse is written at most once a minute per session.
+var sessionUse = lastUse{table: "sessions", column: "last_seen_at", key: "id", interval: time.Minute}
+
+const sessionColumns = `id, member_id, token_hash, user_agent, ip_address,
+	created_at, last_seen_at, expires_at, revoked_at`
+
+func scanSession(row pgx.Row) (*models.Session, error) {
+	var ss models.Session
+	err := row.Scan(&ss.ID, &ss.MemberID, &ss.TokenHash, &ss.UserAgent, &ss.IPAddress,
+		&ss.CreatedAt, &ss.LastSeenAt, &ss.ExpiresAt, &ss.RevokedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &ss, nil
+}
+
+// CreateSession persists a new login session.
+func (s *PostgresStore) CreateSession(ctx context.Context, session *models.Session) error {
+	_, err := s.pool.Exec(ctx,
+		`INSERT INTO sessions (id, member_id, token_hash, user_agent, ip_address, created_at, last_seen_at, expires_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		session.ID, session.MemberID, session.TokenHash, session.UserAgent, session.IPAddress,
+		session.CreatedAt, session.LastSeenAt, session.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+	return nil
+}
+
+// GetActiveSession returns the live session for a token hash and refreshes its
+// last-seen time. Returns nil, nil for unknown, revoked, expired or idle sessions.
+func (s *PostgresStore) GetActiveSession(ctx context.Context, tokenHash string, idleTimeout time.Duration) (*models.Session, error) {
+	ss, err := scanSession(s.pool.QueryRow(ctx,
+		`SELECT `+sessionColumns+` FROM sessions
+		 WHERE token_hash = $1 AND revoked_at IS NULL
+		   AND expires_at > NOW() AND last_seen_at > NOW() - $2::interval`,
+		tokenHash, idleTimeout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	if ss == nil {
+		return nil, nil
+	}
+
+	seen, err := s.touch(ctx, sessionUse, ss.ID, &ss.LastSeenAt)
+	if err != nil {
+		return nil, err
+	}
+	ss.LastSeenAt = *seen
+	return ss, nil
+}
+
+// ListMemberSessions returns a member's active sessions, most recently used first.
+func (s *PostgresStore) ListMemberSessions(ctx context.Context, memberID uuid.UUID, idleTimeout time.Duration) ([]models.Session, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT `+sessionColumns+` FROM sessions
+		 WHERE member_id = $1 AND revoked_at IS NULL
+		   AND expires_at > NOW() AND last_seen_at > NOW() - $2::interval
+		 ORDER BY last_seen_at DESC`, memberID, idleTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var result []models.Session
+	for rows.Next() {
+		ss, err := scanSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		result = append(result, *ss)
+	}
+	return result, nil
+}
+
+// RevokeSession revokes the session with the given token hash.
+func (s *PostgresStore) RevokeSession(ctx context.Context, tokenHash string) error {
+	_, err := s.pool.Exec(ctx,
+		`UPDATE sessions SET revoked_at = NOW() WHERE token_hash = $1 AND revoked_at IS NULL`, tokenHash)
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	return nil
+}
+
+// RevokeMemberSessions revokes every active session of a member.
+func (s *PostgresStore) RevokeMemberSessions(ctx context.Context, memberID uuid.UUID) (int64, error) {
+	tag, err := s.pool.Exec(ctx,
+		`UPDATE sessions SET revoked_at = NOW() WHERE member_id = $1 AND revoked_at IS NULL`, memberID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke member sessions: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// DeleteExpiredSessions removes sessions that can no longer be used.
+func (s *PostgresStore) DeleteExpiredSessions(ctx context.Context, idleTimeout time.Duration) (int64, error) {
+	tag, err := s.pool.Exec(ctx,
+		`DELETE FROM sessions
+		 WHERE revoked_at IS NOT NULL OR expires_at <= NOW() OR last_seen_at <= NOW() - $1::interval`,
+		idleTimeout)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired sessions: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// ListMembersForAdmin returns every member with their active session count.
+func (s *PostgresStore) ListMembersForAdmin(ctx context.Context, idleTimeout time.Duration) ([]AdminMemberView, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT m.id, m.profile_name, m.email, COALESCE(m.membership_number, ''),
+		        COALESCE(m.status, 'active'), m.pending_approval, m.totp_enabled_at IS NOT NULL, m.joined_at,
+		        (SELECT COUNT(*) FROM sessions ss
+		         WHERE ss.member_id = m.id AND ss.revoked_at IS NULL
+		           AND ss.expires_at > NOW() AND ss.last_seen_at > NOW() - $1::interval)
+		 FROM members m
+		 WHERE m.deleted_at IS NULL
+		 ORDER BY m.profile_name ASC`, idleTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query members: %w", err)
+	}
+	defer rows.Close()
+
+	var result []AdminMemberView
+	for rows.Next() {
+		var v AdminMemberView
+		if err := rows.Scan(&v.ID, &v.ProfileName, &v.Email, &v.MembershipNumber,
+			&v.Status, &v.PendingApproval, &v.TwoFactor, &v.JoinedAt, &v.ActiveSessions); err != nil {
+			return nil, fmt.Errorf("failed to scan member: %w", err)
+		}
+		result = append(result, v)
+	}
+	return result, nil
+}
@@ -0,0 +1,148 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cmellojr/modo-locadora/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ── Sign-up methods ─────────────────────────────────────────────────────────
+
+// Sign-up errors.
+var (
+	ErrProfileNameTaken = errors.New("profile name already taken")
+	ErrEmailTaken       = errors.New("e-mail already registered")
+	ErrInvalidInvite    = errors.New("invite code is invalid, used up, expired or revoked")
+)
+
+// execer is the subset of pgxpool.Pool and pgx.Tx used by write helpers that
+// must run either standalone or inside a transaction.
+type execer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+// insertMember inserts a member, translating unique violations on the profile
+// name and e-mail into ErrProfileNameTaken and ErrEmailTaken.
+func insertMember(ctx context.Context, q execer, m *models.Member) error {
+	_, err := q.Exec(ctx,
+		`INSERT INTO members (id, profile_name, email, password_hash, favorite_console, membership_number,
+		                      address, phone, password_notes, pending_approval, joined_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		m.ID, m.ProfileName, m.Email, m.PasswordHash, m.FavoriteConsole, m.MembershipNumber,
+		m.Address, m.Phone, m.PasswordNotes, m.PendingApproval, m.JoinedAt)
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		switch pgErr.ConstraintName {
+		case "idx_members_profile_name_lower":
+			return ErrProfileNameTaken
+		case "members_email_key":
+			return ErrEmailTaken
+		}
+	}
+	return err
+}
+
+// CreateInvitedMember redeems an invite code and creates the member in one
+// transaction. Returns ErrInvalidInvite when the code cannot be used.
+func (s *PostgresStore) CreateInvitedMember(ctx context.Context, m *models.Member, code string) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var inviteID uuid.UUID
+	err = tx.QueryRow(ctx,
+		`UPDATE invite_codes SET uses = uses + 1
+		 WHERE UPPER(code) = UPPER($1) AND revoked_at IS NULL AND uses < max_uses
+		   AND (expires_at IS NULL OR expires_at > NOW())
+		 RETURNING id`, code).Scan(&inviteID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return ErrInvalidInvite
+		}
+		return fmt.Errorf("failed to redeem invite code: %w", err)
+	}
+
+	if err := insertMember(ctx, tx, m); err != nil {
+		return fmt.Errorf("failed to create member: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// ApproveMember lets a member who signed up in approval mode log in.
+func (s *PostgresStore) ApproveMember(ctx context.Context, memberID uuid.UUID) error {
+	_, err := s.pool.Exec(ctx,
+		`UPDATE members SET pending_approval = FALSE WHERE id = $1 AND pending_approval`, memberID)
+	if err != nil {
+		return fmt.Errorf("failed to approve member: %w", err)
+	}
+	return nil
+}
+
+// RejectMember deletes a member that is still waiting for approval.
+func (s *PostgresStore) RejectMember(ctx context.Context, memberID uuid.UUID) error {
+	_, err := s.pool.Exec(ctx, `DELETE FROM members WHERE id = $1 AND pending_approval`, memberID)
+	if err != nil {
+		return fmt.Errorf("failed to reject member: %w", err)
+	}
+	return nil
+}
+
+// ListInviteCodes returns every invite code, newest first.
+func (s *PostgresStore) ListInviteCodes(ctx context.Context) ([]models.InviteCode, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT ic.id, ic.code, ic.max_uses, ic.uses, ic.created_by, COALESCE(m.profile_name, ''),
+		        ic.created_at, ic.expires_at, ic.revoked_at
+		 FROM invite_codes ic
+		 LEFT JOIN members m ON m.id = ic.created_by
+		 ORDER BY ic.created_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query invite codes: %w", err)
+	}
+	defer rows.Close()
+
+	var codes []models.InviteCode
+	for rows.Next() {
+		var c models.InviteCode
+		if err := rows.Scan(&c.ID, &c.Code, &c.MaxUses, &c.Uses, &c.CreatedBy, &c.CreatedByName,
+			&c.CreatedAt, &c.ExpiresAt, &c.RevokedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan invite code: %w", err)
+		}
+		codes = append(codes, c)
+	}
+	return codes, nil
+}
+
+// CreateInviteCode persists a new invite code.
+func (s *PostgresStore) CreateInviteCode(ctx context.Context, c *models.InviteCode) error {
+	_, err := s.pool.Exec(ctx,
+		`INSERT INTO invite_codes (id, code, max_uses, created_by, created_at, expires_at)
+		 VALUES ($1, $2, $3, $4, $5, $6)`,
+		c.ID, c.Code, c.MaxUses, c.CreatedBy, c.CreatedAt, c.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to create invite code: %w", err)
+	}
+	return nil
+}
+
+// RevokeInviteCode stops an invite code from being redeemed.
+func (s *PostgresStore) RevokeInviteCode(ctx context.Context, id uuid.UUID) error {
+	_, err := s.pool.Exec(ctx,
+		`UPDATE invite_codes SET revoked_at = $2 WHERE id = $1 AND revoked_at IS NULL`, id, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to revoke invite code: %w", err)
+	}
+	return nil
+}
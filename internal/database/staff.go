@@ -0,0 +1,133 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/cmellojr/modo-locadora/internal/models"
+	"github.com/google/uuid"
+)
+
+// ── Staff methods ───────────────────────────────────────────────────────────
+
+// ErrLastOwner is returned when revoking the role of the only remaining owner.
+var ErrLastOwner = errors.New("the store must keep at least one owner")
+
+// ListMemberRoles returns the staff roles held by a member.
+func (s *PostgresStore) ListMemberRoles(ctx context.Context, memberID uuid.UUID) ([]string, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT role FROM staff_roles WHERE member_id = $1 ORDER BY role`, memberID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query member roles: %w", err)
+	}
+	defer rows.Close()
+
+	var roles []string
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			return nil, fmt.Errorf("failed to scan member role: %w", err)
+		}
+		roles = append(roles, role)
+	}
+	return roles, nil
+}
+
+// ListStaff returns every member holding at least one staff role, by profile name.
+func (s *PostgresStore) ListStaff(ctx context.Context) ([]StaffView, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT m.id, m.profile_name, m.email, array_agg(sr.role ORDER BY sr.role)
+		 FROM staff_roles sr
+		 JOIN members m ON m.id = sr.member_id
+		 GROUP BY m.id, m.profile_name, m.email
+		 ORDER BY m.profile_name ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query staff: %w", err)
+	}
+	defer rows.Close()
+
+	var result []StaffView
+	for rows.Next() {
+		var v StaffView
+		if err := rows.Scan(&v.MemberID, &v.ProfileName, &v.Email, &v.Roles); err != nil {
+			return nil, fmt.Errorf("failed to scan staff member: %w", err)
+		}
+		result = append(result, v)
+	}
+	return result, nil
+}
+
+// GrantStaffRole gives a member a staff role.
+func (s *PostgresStore) GrantStaffRole(ctx context.Context, memberID uuid.UUID, role string, grantedBy uuid.UUID) error {
+	if !models.IsStaffRole(role) {
+		return fmt.Errorf("invalid staff role: %s", role)
+	}
+	_, err := s.pool.Exec(ctx,
+		`INSERT INTO staff_roles (member_id, role, granted_by, granted_at)
+		 VALUES ($1, $2, $3, NOW())
+		 ON CONFLICT (member_id, role) DO NOTHING`,
+		memberID, role, grantedBy)
+	if err != nil {
+		return fmt.Errorf("failed to grant staff role: %w", err)
+	}
+	return nil
+}
+
+// RevokeStaffRole removes a staff role from a member, refusing to remove the last owner.
+func (s *PostgresStore) RevokeStaffRole(ctx context.Context, memberID uuid.UUID, role string) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if role == models.RoleOwner {
+		// Lock the owner rows so two concurrent revocations can't both pass the check.
+		var owners int
+		if err := tx.QueryRow(ctx,
+			`SELECT COUNT(*) FROM (SELECT 1 FROM staff_roles WHERE role = 'owner' FOR UPDATE) o`).Scan(&owners); err != nil {
+			return fmt.Errorf("failed to count owners: %w", err)
+		}
+		if owners <= 1 {
+			return ErrLastOwner
+		}
+	}
+
+	if _, err := tx.Exec(ctx,
+		`DELETE FROM staff_roles WHERE member_id = $1 AND role = $2`, memberID, role); err != nil {
+		return fmt.Errorf("failed to revoke staff role: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit staff role revocation: %w", err)
+	}
+	return nil
+}
+
+// BootstrapOwner grants the owner role to the member with the given email,
+// compared case-insensitively, while no owner exists yet. The member must
+// have verified the address, so signing up with it is not enough.
+func (s *PostgresStore) BootstrapOwner(ctx context.Context, email string) (bool, error) {
+	tag, err := s.pool.Exec(ctx,
+		`INSERT INTO staff_roles (member_id, role, granted_at)
+		 SELECT id, 'owner', NOW() FROM members
+		 WHERE lower(email) = lower($1)
+		   AND email_verified_at IS NOT NULL
+		   AND deleted_at IS NULL
+		   AND NOT EXISTS (SELECT 1 FROM staff_roles WHERE role = 'owner')
+		 ON CONFLICT (member_id, role) DO NOTHING`, email)
+	if err != nil {
+		return false, fmt.Errorf("failed to bootstrap owner: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// DeleteActivity removes an event from the activities feed.
+func (s *PostgresStore) DeleteActivity(ctx context.Context, id uuid.UUID) error {
+	_, err := s.pool.Exec(ctx, `DELETE FROM activities WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete activity: %w", err)
+	}
+	return nil
+}
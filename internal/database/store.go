@@ -16,6 +16,22 @@ type GameAvailability struct {
 	RenterName      string // Non-empty when all copies are rented.
 }
 
+// GameFilter narrows the shelf returned by ListGamesWithAvailability. Empty
+// fields do not filter.
+type GameFilter struct {
+	Platform  string
+	Genre     string
+	Developer string
+	Year      int // Release year.
+}
+
+// GameFacets lists the values the shelf can be filtered by.
+type GameFacets struct {
+	Genres     []string
+	Developers []string
+	Years      []int
+}
+
 // ActiveRental holds rental info joined with game and member data for the admin returns page.
 type ActiveRental struct {
 	RentalID   uuid.UUID
@@ -37,6 +53,12 @@ type PlatformSummary struct {
 	GameCount int
 }
 
+// StockItem is one game of a batch added by StockGames.
+type StockItem struct {
+	Game   *models.Game
+	Copies int // Physical copies to create; at least one is.
+}
+
 // ActivityEntry holds data for the "Aconteceu na Locadora" feed.
 type ActivityEntry struct {
 	ID         uuid.UUID
@@ -46,6 +68,29 @@ type ActivityEntry struct {
 	CreatedAt  time.Time
 }
 
+// ActivityFilter narrows the feed events returned by ListActivities. Empty
+// fields do not filter.
+type ActivityFilter struct {
+	EventTypes []string
+	Platform   string     // Events about games of this platform.
+	MemberName string     // Events of this member.
+	ClubID     *uuid.UUID // Events naming the club or of its members.
+}
+
+// WebhookDeliveryView is a delivery with the feed event it carries.
+type WebhookDeliveryView struct {
+	Delivery models.WebhookDelivery
+	Activity ActivityEntry
+}
+
+// WebhookJob is a claimed delivery with what the dispatcher needs to send it.
+type WebhookJob struct {
+	WebhookDeliveryView
+	URL    string
+	Secret string
+	ClubID *uuid.UUID // Nil for staff endpoints.
+}
+
 // MemberRental holds a member's active rental for the membership self-return.
 type MemberRental struct {
 	RentalID  uuid.UUID
@@ -57,6 +102,40 @@ type MemberRental struct {
 	IsOverdue bool
 }
 
+// CalendarRental is an active rental on a member's calendar feed.
+type CalendarRental struct {
+	RentalID  uuid.UUID
+	GameID    uuid.UUID
+	GameTitle string
+	Platform  string
+	RentedAt  time.Time
+	DueAt     time.Time
+}
+
+// CalendarChallenge is a club challenge on a member's calendar feed.
+type CalendarChallenge struct {
+	ChallengeID uuid.UUID
+	ClubID      uuid.UUID
+	ClubName    string
+	GameTitle   string
+	Platform    string
+	StartsAt    time.Time
+	EndsAt      time.Time
+}
+
+// MemberRentalRecord holds one rental of a member for the data export and the API.
+type MemberRentalRecord struct {
+	RentalID     uuid.UUID
+	GameID       uuid.UUID
+	GameTitle    string
+	Platform     string
+	RentedAt     time.Time
+	DueAt        time.Time
+	ReturnedAt   *time.Time
+	PersonalNote string
+	Verdict      string
+}
+
 // GameDetail holds detailed info for a single game page.
 type GameDetail struct {
 	Game            models.Game
@@ -119,15 +198,80 @@ type MemberClubView struct {
 	Role     string
 }
 
+// ClubChallengeView holds a club challenge with its game, copy availability
+// and participants for the club detail page.
+type ClubChallengeView struct {
+	Challenge       models.ClubChallenge
+	GameTitle       string
+	CoverURL        string
+	Platform        string
+	TotalCopies     int
+	AvailableCopies int
+	FirstFinisher   string
+	Participants    []ChallengeParticipantView
+}
+
+// ChallengeParticipantView holds one participant's progress in a club challenge.
+type ChallengeParticipantView struct {
+	MemberID      uuid.UUID
+	ProfileName   string
+	Status        string // "joined", "rented", "completed", "gave_up"
+	JoinedAt      time.Time
+	FinishedAt    *time.Time
+	QueuePosition int // 1-based place in line for a copy; 0 once the member has rented.
+}
+
+// ChallengeFinish reports a participant reaching a final status in a challenge.
+type ChallengeFinish struct {
+	ChallengeID uuid.UUID
+	GameTitle   string
+	IsFirst     bool // True when this is the first "completed" of the challenge.
+}
+
+// LeagueStanding holds one club's position in a league season.
+type LeagueStanding struct {
+	Rank           int
+	ClubID         *uuid.UUID // Nil when an archived club has since been deleted.
+	ClubName       string
+	BadgeURL       string
+	Points         int
+	CompletedCount int
+	OnTimeCount    int
+	ChallengeCount int
+	PenaltyCount   int
+}
+
+// AdminMemberView holds a member row for the admin member list.
+type AdminMemberView struct {
+	ID               uuid.UUID
+	ProfileName      string
+	Email            string
+	MembershipNumber string
+	Status           string
+	PendingApproval  bool
+	TwoFactor        bool
+	JoinedAt         time.Time
+	ActiveSessions   int
+}
+
+// StaffView holds a staff member and their roles for the staff admin page.
+type StaffView struct {
+	MemberID    uuid.UUID
+	ProfileName string
+	Email       string
+	Roles       []string
+}
+
 // Store defines the set of operations for the database layer.
 type Store interface {
-	// CreateMember persists a new member in the database.
+	// CreateMember persists a new member in the database. Returns ErrProfileNameTaken
+	// or ErrEmailTaken when the name or e-mail is already in use.
 	CreateMember(ctx context.Context, member *models.Member) error
 
 	// GetMemberByID retrieves a member by their UUID.
 	GetMemberByID(ctx context.Context, id uuid.UUID) (*models.Member, error)
 
-	// GetMemberByProfileName retrieves a member by their profile name.
+	// GetMemberByProfileName retrieves a member by their profile name (case-insensitive).
 	GetMemberByProfileName(ctx context.Context, name string) (*models.Member, error)
 
 	// NextMembershipNumber generates the next sequential membership number (1991-XXX).
@@ -139,14 +283,15 @@ type Store interface {
 	// AddGame persists a new game and creates one physical copy for it.
 	AddGame(ctx context.Context, game *models.Game) error
 
-	// UpdateGame updates the editable fields of an existing game.
+	// UpdateGame updates the editable fields of an existing game, including
+	// its genres and companies.
 	UpdateGame(ctx context.Context, game *models.Game) error
 
 	// ListGames retrieves all games from the database.
 	ListGames(ctx context.Context) ([]models.Game, error)
 
-	// ListGamesWithAvailability returns games with their rental status, optionally filtered by platform.
-	ListGamesWithAvailability(ctx context.Context, platform string) ([]GameAvailability, error)
+	// ListGamesWithAvailability returns games with their rental status, narrowed by the filter.
+	ListGamesWithAvailability(ctx context.Context, f GameFilter) ([]GameAvailability, error)
 
 	// ListPlatforms returns a summary of each platform in the catalog.
 	ListPlatforms(ctx context.Context) ([]PlatformSummary, error)
@@ -155,6 +300,7 @@ type Store interface {
 	GetGameDetail(ctx context.Context, gameID uuid.UUID) (*GameDetail, error)
 
 	// RentGame creates a rental for the given game to the given member.
+	// Returns ErrNoCopiesAvailable when every copy is out.
 	RentGame(ctx context.Context, gameID, memberID uuid.UUID) error
 
 	// ReturnGame marks an active rental as returned.
@@ -198,6 +344,7 @@ type Store interface {
 
 	// ReturnGameByMember returns a game for a specific member (validates ownership).
 	// verdict stores the member's play status ("completed", "enjoyed", "quick_play", "not_for_me", "gave_up").
+	// Returns ErrRentalNotFound when the rental is not an open rental of the member.
 	ReturnGameByMember(ctx context.Context, rentalID, memberID uuid.UUID, verdict string) error
 
 	// GetRentalGameTitle returns the game title for a rental (used for activity logging).
@@ -250,4 +397,298 @@ type Store interface {
 
 	// ListMemberClubs returns the clubs a member belongs to.
 	ListMemberClubs(ctx context.Context, memberID uuid.UUID) ([]MemberClubView, error)
+
+	// CreateClubChallenge persists a new challenge for a club.
+	CreateClubChallenge(ctx context.Context, challenge *models.ClubChallenge) error
+
+	// GetClubChallenge retrieves a challenge by its UUID.
+	GetClubChallenge(ctx context.Context, id uuid.UUID) (*models.ClubChallenge, error)
+
+	// ListClubChallenges returns a club's challenges with availability and participants, newest first.
+	ListClubChallenges(ctx context.Context, clubID uuid.UUID) ([]ClubChallengeView, error)
+
+	// JoinClubChallenge enrolls a member in a challenge that has not ended yet.
+	JoinClubChallenge(ctx context.Context, challengeID, memberID uuid.UUID) error
+
+	// GiveUpClubChallenge marks a participant as having given up on a challenge.
+	GiveUpClubChallenge(ctx context.Context, challengeID, memberID uuid.UUID) error
+
+	// UpdateChallengeProgress moves a member's open challenges for a game to the given status.
+	// Returns the challenges that reached a final status ("completed" or "gave_up").
+	UpdateChallengeProgress(ctx context.Context, memberID, gameID uuid.UUID, status string) ([]ChallengeFinish, error)
+
+	// GetRentalGameID returns the game ID associated with a rental.
+	GetRentalGameID(ctx context.Context, rentalID uuid.UUID) (uuid.UUID, error)
+
+	// CreateLeagueSeason starts a new league season. Fails if another season is active.
+	CreateLeagueSeason(ctx context.Context, season *models.LeagueSeason) error
+
+	// GetLeagueSeason retrieves a league season by its UUID.
+	GetLeagueSeason(ctx context.Context, id uuid.UUID) (*models.LeagueSeason, error)
+
+	// GetActiveLeagueSeason returns the running season, or nil if there is none.
+	GetActiveLeagueSeason(ctx context.Context) (*models.LeagueSeason, error)
+
+	// ListLeagueSeasons returns all seasons, newest first.
+	ListLeagueSeasons(ctx context.Context) ([]models.LeagueSeason, error)
+
+	// GetLeagueStandings returns live standings for an active season or the frozen results of an archived one.
+	GetLeagueStandings(ctx context.Context, season *models.LeagueSeason) ([]LeagueStanding, error)
+
+	// ArchiveLeagueSeason freezes the season's standings and marks it as archived.
+	ArchiveLeagueSeason(ctx context.Context, seasonID uuid.UUID) error
+
+	// ListScoringRules returns the league scoring rules.
+	ListScoringRules(ctx context.Context) ([]models.ScoringRule, error)
+
+	// UpdateScoringRule sets the points awarded for a scoring rule.
+	UpdateScoringRule(ctx context.Context, key string, points int) error
+
+	// SetPrimaryClub sets the club that receives a member's league points (nil = every club).
+	SetPrimaryClub(ctx context.Context, memberID uuid.UUID, clubID *uuid.UUID) error
+
+	// CreateSession persists a new login session.
+	CreateSession(ctx context.Context, session *models.Session) error
+
+	// GetActiveSession returns the unrevoked, unexpired session for a token hash,
+	// or nil if there is none or it has been idle longer than idleTimeout.
+	// Refreshes the session's last-seen time.
+	GetActiveSession(ctx context.Context, tokenHash string, idleTimeout time.Duration) (*models.Session, error)
+
+	// ListMemberSessions returns a member's active sessions, most recently used first.
+	ListMemberSessions(ctx context.Context, memberID uuid.UUID, idleTimeout time.Duration) ([]models.Session, error)
+
+	// RevokeSession revokes the session with the given token hash.
+	RevokeSession(ctx context.Context, tokenHash string) error
+
+	// RevokeMemberSessions revokes every active session of a member and returns how many were revoked.
+	RevokeMemberSessions(ctx context.Context, memberID uuid.UUID) (int64, error)
+
+	// DeleteExpiredSessions removes revoked, expired and idle sessions. Returns the number deleted.
+	DeleteExpiredSessions(ctx context.Context, idleTimeout time.Duration) (int64, error)
+
+	// ListMembersForAdmin returns every member with their active session count.
+	ListMembersForAdmin(ctx context.Context, idleTimeout time.Duration) ([]AdminMemberView, error)
+
+	// ListMemberRoles returns the staff roles held by a member (empty for regular members).
+	ListMemberRoles(ctx context.Context, memberID uuid.UUID) ([]string, error)
+
+	// ListStaff returns every member holding at least one staff role.
+	ListStaff(ctx context.Context) ([]StaffView, error)
+
+	// GrantStaffRole gives a member a staff role. Granting a role twice is a no-op.
+	GrantStaffRole(ctx context.Context, memberID uuid.UUID, role string, grantedBy uuid.UUID) error
+
+	// RevokeStaffRole removes a staff role from a member. Fails when removing the last owner.
+	RevokeStaffRole(ctx context.Context, memberID uuid.UUID, role string) error
+
+	// BootstrapOwner makes the verified member with the given email the owner,
+	// but only while no owner exists yet. Returns true if the role was granted.
+	BootstrapOwner(ctx context.Context, email string) (bool, error)
+
+	// DeleteActivity removes an event from the activities feed.
+	DeleteActivity(ctx context.Context, id uuid.UUID) error
+
+	// GetMemberByEmail retrieves a member by e-mail (case-insensitive).
+	GetMemberByEmail(ctx context.Context, email string) (*models.Member, error)
+
+	// CreateMemberToken persists a new e-mail token, invalidating the member's
+	// unused tokens for the same purpose.
+	CreateMemberToken(ctx context.Context, token *models.MemberToken) error
+
+	// GetMemberToken returns an unused, unexpired token without using it up.
+	// Returns nil if the token is unknown, expired, already used or for another purpose.
+	GetMemberToken(ctx context.Context, tokenHash, purpose string) (*models.MemberToken, error)
+
+	// ConsumeMemberToken marks an unused, unexpired token as used and returns it.
+	// Returns nil if the token is unknown, expired, already used or for another purpose.
+	ConsumeMemberToken(ctx context.Context, tokenHash, purpose string) (*models.MemberToken, error)
+
+	// MarkEmailVerified records that the member confirmed their e-mail address.
+	MarkEmailVerified(ctx context.Context, memberID uuid.UUID) error
+
+	// UpdateMemberPassword replaces the member's password hash.
+	UpdateMemberPassword(ctx context.Context, memberID uuid.UUID, passwordHash string) error
+
+	// RecordLoginFailure increments the member's consecutive failed logins and returns the new count.
+	RecordLoginFailure(ctx context.Context, memberID uuid.UUID) (int, error)
+
+	// LockMember refuses logins for the member until the given time.
+	LockMember(ctx context.Context, memberID uuid.UUID, until time.Time) error
+
+	// ResetLoginFailures clears the member's failed login count and any lockout.
+	ResetLoginFailures(ctx context.Context, memberID uuid.UUID) error
+
+	// ListLockedMembers returns members whose lockout has not expired yet.
+	ListLockedMembers(ctx context.Context) ([]models.Member, error)
+
+	// RecordSecurityEvent persists a security event.
+	RecordSecurityEvent(ctx context.Context, e *models.SecurityEvent) error
+
+	// ListSecurityEvents returns the most recent security events, newest first.
+	ListSecurityEvents(ctx context.Context, limit int) ([]models.SecurityEvent, error)
+
+	// CreateInvitedMember redeems an invite code and creates the member atomically.
+	// Returns ErrInvalidInvite when the code cannot be used.
+	CreateInvitedMember(ctx context.Context, member *models.Member, code string) error
+
+	// ApproveMember lets a member who signed up in approval mode log in.
+	ApproveMember(ctx context.Context, memberID uuid.UUID) error
+
+	// RejectMember deletes a member that is still waiting for approval.
+	RejectMember(ctx context.Context, memberID uuid.UUID) error
+
+	// ListInviteCodes returns every invite code, newest first.
+	ListInviteCodes(ctx context.Context) ([]models.InviteCode, error)
+
+	// CreateInviteCode persists a new invite code.
+	CreateInviteCode(ctx context.Context, code *models.InviteCode) error
+
+	// RevokeInviteCode stops an invite code from being redeemed.
+	RevokeInviteCode(ctx context.Context, id uuid.UUID) error
+
+	// UpdateMemberProfile updates the member's address, phone and favorite console.
+	UpdateMemberProfile(ctx context.Context, memberID uuid.UUID, address, phone, favoriteConsole string) error
+
+	// ListMemberRentalRecords returns every rental of a member, newest first.
+	ListMemberRentalRecords(ctx context.Context, memberID uuid.UUID) ([]MemberRentalRecord, error)
+
+	// ListMemberActivities returns the feed events about a member, newest first.
+	ListMemberActivities(ctx context.Context, profileName string) ([]ActivityEntry, error)
+
+	// DeleteMemberAccount anonymizes the member and deletes their personal data,
+	// keeping rentals and activities for the store's history.
+	// Returns ErrOpenRentals or ErrLastOwner when the account cannot be deleted yet.
+	DeleteMemberAccount(ctx context.Context, memberID uuid.UUID) error
+
+	// StartTOTPEnrollment stores a new TOTP secret for a member who has not
+	// enabled two-factor authentication yet.
+	StartTOTPEnrollment(ctx context.Context, memberID uuid.UUID, secret string) error
+
+	// EnableTOTP completes enrollment after the first valid code and stores the
+	// member's recovery codes (as hashes), replacing any old ones.
+	EnableTOTP(ctx context.Context, memberID uuid.UUID, step int64, codeHashes []string) error
+
+	// UseTOTPStep records the time step of an accepted code. Returns false when
+	// that step was already used.
+	UseTOTPStep(ctx context.Context, memberID uuid.UUID, step int64) (bool, error)
+
+	// ReplaceRecoveryCodes swaps the member's recovery codes for a new set.
+	ReplaceRecoveryCodes(ctx context.Context, memberID uuid.UUID, codeHashes []string) error
+
+	// UseRecoveryCode marks an unused recovery code as used. Returns false when
+	// the member has no unused code with that hash.
+	UseRecoveryCode(ctx context.Context, memberID uuid.UUID, codeHash string) (bool, error)
+
+	// CountRecoveryCodes returns how many unused recovery codes the member has left.
+	CountRecoveryCodes(ctx context.Context, memberID uuid.UUID) (int, error)
+
+	// DisableTOTP turns two-factor authentication off and deletes the secret
+	// and the recovery codes.
+	DisableTOTP(ctx context.Context, memberID uuid.UUID) error
+
+	// CreateAPIToken persists a new personal API token.
+	CreateAPIToken(ctx context.Context, token *models.APIToken) error
+
+	// GetActiveAPIToken returns the unrevoked, unexpired token for a hash and
+	// records its use. Returns nil, nil when there is no such token.
+	GetActiveAPIToken(ctx context.Context, tokenHash string) (*models.APIToken, error)
+
+	// ListMemberAPITokens returns a member's unrevoked tokens, newest first.
+	ListMemberAPITokens(ctx context.Context, memberID uuid.UUID) ([]models.APIToken, error)
+
+	// RevokeAPIToken revokes one of the member's tokens. Returns false when the
+	// member has no such active token.
+	RevokeAPIToken(ctx context.Context, tokenID, memberID uuid.UUID) (bool, error)
+
+	// CreateWebhookEndpoint persists a new webhook endpoint.
+	CreateWebhookEndpoint(ctx context.Context, e *models.WebhookEndpoint) error
+
+	// GetWebhookEndpoint returns an endpoint by ID, or nil if not found.
+	GetWebhookEndpoint(ctx context.Context, id uuid.UUID) (*models.WebhookEndpoint, error)
+
+	// ListWebhookEndpoints returns the endpoints of a club, or the staff
+	// endpoints when clubID is nil.
+	ListWebhookEndpoints(ctx context.Context, clubID *uuid.UUID) ([]models.WebhookEndpoint, error)
+
+	// UpdateWebhookEndpoint changes an endpoint's URL, description and events.
+	UpdateWebhookEndpoint(ctx context.Context, e *models.WebhookEndpoint) error
+
+	// SetWebhookEndpointEnabled switches an endpoint on or off. Switching it on
+	// clears its failure count.
+	SetWebhookEndpointEnabled(ctx context.Context, id uuid.UUID, enabled bool) error
+
+	// RotateWebhookSecret replaces an endpoint's signing secret.
+	RotateWebhookSecret(ctx context.Context, id uuid.UUID, secret string) error
+
+	// DeleteWebhookEndpoint removes an endpoint and its delivery log.
+	DeleteWebhookEndpoint(ctx context.Context, id uuid.UUID) error
+
+	// ListWebhookDeliveries returns an endpoint's most recent deliveries, newest first.
+	ListWebhookDeliveries(ctx context.Context, endpointID uuid.UUID, limit int) ([]WebhookDeliveryView, error)
+
+	// ClaimWebhookDeliveries returns up to limit due deliveries of enabled
+	// endpoints and postpones them by lease while they are being sent.
+	ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]WebhookJob, error)
+
+	// CompleteWebhookDelivery marks a delivery as delivered and resets the
+	// endpoint's failure count.
+	CompleteWebhookDelivery(ctx context.Context, deliveryID, endpointID uuid.UUID, statusCode int) error
+
+	// FailWebhookDelivery records a failed attempt, to be retried at retryAt
+	// (nil gives up), and switches the endpoint off after maxFailures failures
+	// in a row. Returns true when this attempt switched it off.
+	FailWebhookDelivery(ctx context.Context, deliveryID, endpointID uuid.UUID, statusCode int, reason string, retryAt *time.Time, maxFailures int) (bool, error)
+
+	// RetryWebhookDelivery queues a failed delivery of the endpoint again.
+	// Returns false when the endpoint has no such failed delivery.
+	RetryWebhookDelivery(ctx context.Context, deliveryID, endpointID uuid.UUID) (bool, error)
+
+	// DeleteOldWebhookDeliveries removes finished deliveries created before the cutoff.
+	DeleteOldWebhookDeliveries(ctx context.Context, before time.Time) (int64, error)
+
+	// ListActivities returns the N most recent feed events matching the filter.
+	ListActivities(ctx context.Context, f ActivityFilter, limit int) ([]ActivityEntry, error)
+
+	// SaveCalendarFeed creates the member's calendar feed or replaces its
+	// token and settings, invalidating the previous URL.
+	SaveCalendarFeed(ctx context.Context, f *models.CalendarFeed) error
+
+	// GetCalendarFeed returns the member's calendar feed, or nil if it has none.
+	GetCalendarFeed(ctx context.Context, memberID uuid.UUID) (*models.CalendarFeed, error)
+
+	// GetCalendarFeedByToken returns the feed for a token hash and records
+	// its use. Returns nil for unknown tokens and deleted members.
+	GetCalendarFeedByToken(ctx context.Context, tokenHash string) (*models.CalendarFeed, error)
+
+	// SetCalendarFeedChallenges switches the club challenges of the feed on or off.
+	SetCalendarFeedChallenges(ctx context.Context, memberID uuid.UUID, include bool) error
+
+	// DeleteCalendarFeed removes the member's calendar feed.
+	DeleteCalendarFeed(ctx context.Context, memberID uuid.UUID) error
+
+	// ListCalendarRentals returns the member's active rentals, soonest due first.
+	ListCalendarRentals(ctx context.Context, memberID uuid.UUID) ([]CalendarRental, error)
+
+	// ListCalendarChallenges returns current and recent challenges of the member's clubs.
+	ListCalendarChallenges(ctx context.Context, memberID uuid.UUID) ([]CalendarChallenge, error)
+
+	// ListGameFacets returns the genres, developers and release years of a
+	// platform's games, for the shelf filters. An empty platform covers all games.
+	ListGameFacets(ctx context.Context, platform string) (*GameFacets, error)
+
+	// SetGameCover replaces a game's cover, thumbnail and cover source URLs.
+	SetGameCover(ctx context.Context, gameID uuid.UUID, coverURL, thumbURL, sourceURL string) error
+
+	// ReplaceMediaURLPrefix rewrites stored image URLs that start with
+	// oldPrefix to start with newPrefix, returning how many changed.
+	ReplaceMediaURLPrefix(ctx context.Context, oldPrefix, newPrefix string) (int64, error)
+
+	// StockGames adds a batch of games with their copies and one new_game
+	// feed event titled summary, in a single transaction.
+	StockGames(ctx context.Context, items []StockItem, summary string) error
+
+	// ListPlatformRegistry returns the canonical platforms, with their
+	// aliases and IGDB IDs, in shelf order.
+	ListPlatformRegistry(ctx context.Context) (models.Platforms, error)
 }
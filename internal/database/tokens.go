@@ -0,0 +1,105 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cmellojr/modo-locadora/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ── Account token methods ───────────────────────────────────────────────────
+
+// GetMemberByEmail retrieves a member by e-mail (case-insensitive).
+func (s *PostgresStore) GetMemberByEmail(ctx context.Context, email string) (*models.Member, error) {
+	query := `SELECT ` + memberColumns + ` FROM members WHERE LOWER(email) = LOWER($1)`
+	m, err := scanMember(s.pool.QueryRow(ctx, query, email))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get member by email: %w", err)
+	}
+	return m, nil
+}
+
+// CreateMemberToken persists a new token and invalidates older unused ones
+// for the same member and purpose, so only the latest e-mail link works.
+func (s *PostgresStore) CreateMemberToken(ctx context.Context, t *models.MemberToken) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE member_tokens SET used_at = NOW()
+		 WHERE member_id = $1 AND purpose = $2 AND used_at IS NULL`,
+		t.MemberID, t.Purpose); err != nil {
+		return fmt.Errorf("failed to invalidate old tokens: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx,
+		`INSERT INTO member_tokens (id, member_id, purpose, token_hash, created_at, expires_at)
+		 VALUES ($1, $2, $3, $4, $5, $6)`,
+		t.ID, t.MemberID, t.Purpose, t.TokenHash, t.CreatedAt, t.ExpiresAt); err != nil {
+		return fmt.Errorf("failed to create member token: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit member token: %w", err)
+	}
+	return nil
+}
+
+// GetMemberToken returns an unused, unexpired token without using it up.
+func (s *PostgresStore) GetMemberToken(ctx context.Context, tokenHash, purpose string) (*models.MemberToken, error) {
+	var t models.MemberToken
+	err := s.pool.QueryRow(ctx,
+		`SELECT id, member_id, purpose, token_hash, created_at, expires_at, used_at
+		 FROM member_tokens
+		 WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > NOW()`,
+		tokenHash, purpose).Scan(&t.ID, &t.MemberID, &t.Purpose, &t.TokenHash, &t.CreatedAt, &t.ExpiresAt, &t.UsedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get member token: %w", err)
+	}
+	return &t, nil
+}
+
+// ConsumeMemberToken atomically marks a valid token as used and returns it.
+func (s *PostgresStore) ConsumeMemberToken(ctx context.Context, tokenHash, purpose string) (*models.MemberToken, error) {
+	var t models.MemberToken
+	err := s.pool.QueryRow(ctx,
+		`UPDATE member_tokens SET used_at = NOW()
+		 WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > NOW()
+		 RETURNING id, member_id, purpose, token_hash, created_at, expires_at, used_at`,
+		tokenHash, purpose).Scan(&t.ID, &t.MemberID, &t.Purpose, &t.TokenHash, &t.CreatedAt, &t.ExpiresAt, &t.UsedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to consume member token: %w", err)
+	}
+	return &t, nil
+}
+
+// MarkEmailVerified records that the member confirmed their e-mail address.
+func (s *PostgresStore) MarkEmailVerified(ctx context.Context, memberID uuid.UUID) error {
+	_, err := s.pool.Exec(ctx,
+		`UPDATE members SET email_verified_at = COALESCE(email_verified_at, NOW()) WHERE id = $1`, memberID)
+	if err != nil {
+		return fmt.Errorf("failed to mark email verified: %w", err)
+	}
+	return nil
+}
+
+// UpdateMemberPassword replaces the member's password hash.
+func (s *PostgresStore) UpdateMemberPassword(ctx context.Context, memberID uuid.UUID, passwordHash string) error {
+	_, err := s.pool.Exec(ctx,
+		`UPDATE members SET password_hash = $2 WHERE id = $1`, memberID, passwordHash)
+	if err != nil {
+		return fmt.Errorf("failed to update member password: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// ── Two-factor methods ──────────────────────────────────────────────────────
+
+// StartTOTPEnrollment stores a new TOTP secret for a member who has not
+// enabled two-factor authentication yet.
+func (s *PostgresStore) StartTOTPEnrollment(ctx context.Context, memberID uuid.UUID, secret string) error {
+	_, err := s.pool.Exec(ctx,
+		`UPDATE members SET totp_secret = $2, totp_last_step = 0
+		 WHERE id = $1 AND totp_enabled_at IS NULL`, memberID, secret)
+	if err != nil {
+		return fmt.Errorf("failed to start TOTP enrollment: %w", err)
+	}
+	return nil
+}
+
+// EnableTOTP completes enrollment after the first valid code and stores the
+// member's recovery codes, replacing any old ones, in one transaction.
+func (s *PostgresStore) EnableTOTP(ctx context.Context, memberID uuid.UUID, step int64, codeHashes []string) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx,
+		`UPDATE members SET totp_enabled_at = NOW(), totp_last_step = $2
+		 WHERE id = $1 AND totp_enabled_at IS NULL AND totp_secret <> ''`, memberID, step)
+	if err != nil {
+		return fmt.Errorf("failed to enable TOTP: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("failed to enable TOTP: enrollment not started or already enabled")
+	}
+
+	if err := replaceRecoveryCodes(ctx, tx, memberID, codeHashes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// UseTOTPStep records the time step of an accepted code. Returns false when a
+// code from that step or a later one was already used, which blocks replays.
+func (s *PostgresStore) UseTOTPStep(ctx context.Context, memberID uuid.UUID, step int64) (bool, error) {
+	tag, err := s.pool.Exec(ctx,
+		`UPDATE members SET totp_last_step = $2 WHERE id = $1 AND totp_last_step < $2`, memberID, step)
+	if err != nil {
+		return false, fmt.Errorf("failed to record TOTP step: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// ReplaceRecoveryCodes swaps the member's recovery codes for a new set.
+func (s *PostgresStore) ReplaceRecoveryCodes(ctx context.Context, memberID uuid.UUID, codeHashes []string) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := replaceRecoveryCodes(ctx, tx, memberID, codeHashes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func replaceRecoveryCodes(ctx context.Context, q execer, memberID uuid.UUID, codeHashes []string) error {
+	if _, err := q.Exec(ctx, `DELETE FROM recovery_codes WHERE member_id = $1`, memberID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	for _, h := range codeHashes {
+		if _, err := q.Exec(ctx,
+			`INSERT INTO recovery_codes (id, member_id, code_hash) VALUES ($1, $2, $3)`,
+			uuid.New(), memberID, h); err != nil {
+			return fmt.Errorf("failed to create recovery code: %w", err)
+		}
+	}
+	return nil
+}
+
+// UseRecoveryCode marks an unused recovery code as used. Returns false when
+// the member has no unused code with that hash.
+func (s *PostgresStore) UseRecoveryCode(ctx context.Context, memberID uuid.UUID, codeHash string) (bool, error) {
+	tag, err := s.pool.Exec(ctx,
+		`UPDATE recovery_codes SET used_at = NOW()
+		 WHERE member_id = $1 AND code_hash = $2 AND used_at IS NULL`, memberID, codeHash)
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// CountRecoveryCodes returns how many unused recovery codes the member has left.
+func (s *PostgresStore) CountRecoveryCodes(ctx context.Context, memberID uuid.UUID) (int, error) {
+	var n int
+	err := s.pool.QueryRow(ctx,
+		`SELECT COUNT(*) FROM recovery_codes WHERE member_id = $1 AND used_at IS NULL`, memberID).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count recovery codes: %w", err)
+	}
+	return n, nil
+}
+
+// DisableTOTP turns two-factor authentication off and deletes the secret and
+// the recovery codes.
+func (s *PostgresStore) DisableTOTP(ctx context.Context, memberID uuid.UUID) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE members SET totp_secret = '', totp_enabled_at = NULL, totp_last_step = 0 WHERE id = $1`,
+		memberID); err != nil {
+		return fmt.Errorf("failed to disable TOTP: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM recovery_codes WHERE member_id = $1`, memberID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
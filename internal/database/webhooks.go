@@ -0,0 +1,305 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cmellojr/modo-locadora/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ── Webhook methods ─────────────────────────────────────────────────────────
+
+// insertActivitySQL records a feed event and, in the same statement, queues
+// a delivery for every enabled endpoint subscribed to its type. Club
+// endpoints only get catalog-wide events (no member involved), events that
+// name the club and events of the club's members.
+const insertActivitySQL = `
+	WITH a AS (
+		INSERT INTO activities (id, event_type, member_name, game_title, created_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		RETURNING id, event_type, member_name, game_title
+	)
+	INSERT INTO webhook_deliveries (id, endpoint_id, activity_id, event_type, next_attempt_at, created_at)
+	SELECT gen_random_uuid(), e.id, a.id, a.event_type, NOW(), NOW()
+	FROM a
+	JOIN webhook_endpoints e ON e.enabled AND a.event_type = ANY(e.event_types)
+	WHERE e.club_id IS NULL
+	   OR a.event_type IN ('new_game', 'relic', 'league_champion')
+	   OR EXISTS (SELECT 1 FROM clubs c WHERE c.id = e.club_id AND (
+	          (a.event_type IN ('club_created', 'club_joined') AND c.name = a.game_title)
+	       OR (a.event_type = 'challenge_created' AND c.name = a.member_name)))
+	   OR EXISTS (SELECT 1 FROM club_members cm JOIN members m ON m.id = cm.member_id
+	              WHERE cm.club_id = e.club_id AND m.profile_name = a.member_name)`
+
+// insertActivity records a feed event and queues its webhook deliveries.
+func insertActivity(ctx context.Context, q execer, eventType, memberName, gameTitle string) error {
+	if _, err := q.Exec(ctx, insertActivitySQL, uuid.New(), eventType, memberName, gameTitle); err != nil {
+		return fmt.Errorf("failed to insert activity: %w", err)
+	}
+	return nil
+}
+
+const webhookEndpointColumns = `id, club_id, url, description, secret, event_types, enabled,
+	consecutive_failures, disabled_at, created_by, created_at`
+
+func scanWebhookEndpoint(row pgx.Row) (*models.WebhookEndpoint, error) {
+	var e models.WebhookEndpoint
+	err := row.Scan(&e.ID, &e.ClubID, &e.URL, &e.Description, &e.Secret, &e.EventTypes, &e.Enabled,
+		&e.ConsecutiveFailures, &e.DisabledAt, &e.CreatedBy, &e.CreatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &e, nil
+}
+
+// CreateWebhookEndpoint persists a new webhook endpoint.
+func (s *PostgresStore) CreateWebhookEndpoint(ctx context.Context, e *models.WebhookEndpoint) error {
+	_, err := s.pool.Exec(ctx,
+		`INSERT INTO webhook_endpoints (id, club_id, url, description, secret, event_types, enabled, created_by, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		e.ID, e.ClubID, e.URL, e.Description, e.Secret, e.EventTypes, e.Enabled, e.CreatedBy, e.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook endpoint: %w", err)
+	}
+	return nil
+}
+
+// GetWebhookEndpoint returns an endpoint by ID, or nil if not found.
+func (s *PostgresStore) GetWebhookEndpoint(ctx context.Context, id uuid.UUID) (*models.WebhookEndpoint, error) {
+	e, err := scanWebhookEndpoint(s.pool.QueryRow(ctx,
+		`SELECT `+webhookEndpointColumns+` FROM webhook_endpoints WHERE id = $1`, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook endpoint: %w", err)
+	}
+	return e, nil
+}
+
+// ListWebhookEndpoints returns the endpoints of a club, or the staff
+// endpoints when clubID is nil, oldest first.
+func (s *PostgresStore) ListWebhookEndpoints(ctx context.Context, clubID *uuid.UUID) ([]models.WebhookEndpoint, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT `+webhookEndpointColumns+` FROM webhook_endpoints
+		 WHERE club_id IS NOT DISTINCT FROM $1
+		 ORDER BY created_at`, clubID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook endpoints: %w", err)
+	}
+	defer rows.Close()
+
+	var result []models.WebhookEndpoint
+	for rows.Next() {
+		e, err := scanWebhookEndpoint(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook endpoint: %w", err)
+		}
+		result = append(result, *e)
+	}
+	return result, nil
+}
+
+// UpdateWebhookEndpoint changes an endpoint's URL, description and events.
+func (s *PostgresStore) UpdateWebhookEndpoint(ctx context.Context, e *models.WebhookEndpoint) error {
+	_, err := s.pool.Exec(ctx,
+		`UPDATE webhook_endpoints SET url = $2, description = $3, event_types = $4 WHERE id = $1`,
+		e.ID, e.URL, e.Description, e.EventTypes)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook endpoint: %w", err)
+	}
+	return nil
+}
+
+// SetWebhookEndpointEnabled switches an endpoint on or off. Switching it on
+// clears its failure count; its pending deliveries resume.
+func (s *PostgresStore) SetWebhookEndpointEnabled(ctx context.Context, id uuid.UUID, enabled bool) error {
+	_, err := s.pool.Exec(ctx,
+		`UPDATE webhook_endpoints
+		 SET enabled = $2,
+		     consecutive_failures = CASE WHEN $2 THEN 0 ELSE consecutive_failures END,
+		     disabled_at = CASE WHEN $2 THEN NULL ELSE NOW() END
+		 WHERE id = $1`, id, enabled)
+	if err != nil {
+		return fmt.Errorf("failed to switch webhook endpoint: %w", err)
+	}
+	return nil
+}
+
+// RotateWebhookSecret replaces an endpoint's signing secret.
+func (s *PostgresStore) RotateWebhookSecret(ctx context.Context, id uuid.UUID, secret string) error {
+	_, err := s.pool.Exec(ctx, `UPDATE webhook_endpoints SET secret = $2 WHERE id = $1`, id, secret)
+	if err != nil {
+		return fmt.Errorf("failed to rotate webhook secret: %w", err)
+	}
+	return nil
+}
+
+// DeleteWebhookEndpoint removes an endpoint and its delivery log.
+func (s *PostgresStore) DeleteWebhookEndpoint(ctx context.Context, id uuid.UUID) error {
+	_, err := s.pool.Exec(ctx, `DELETE FROM webhook_endpoints WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook endpoint: %w", err)
+	}
+	return nil
+}
+
+// ListWebhookDeliveries returns an endpoint's most recent deliveries, newest first.
+func (s *PostgresStore) ListWebhookDeliveries(ctx context.Context, endpointID uuid.UUID, limit int) ([]WebhookDeliveryView, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT d.id, d.endpoint_id, d.activity_id, d.event_type, d.status, d.attempts,
+		        d.last_status_code, d.last_error, d.next_attempt_at, d.delivered_at, d.created_at,
+		        a.member_name, a.game_title, a.created_at
+		 FROM webhook_deliveries d
+		 JOIN activities a ON a.id = d.activity_id
+		 WHERE d.endpoint_id = $1
+		 ORDER BY d.created_at DESC
+		 LIMIT $2`, endpointID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var result []WebhookDeliveryView
+	for rows.Next() {
+		var v WebhookDeliveryView
+		d := &v.Delivery
+		if err := rows.Scan(&d.ID, &d.EndpointID, &d.ActivityID, &d.EventType, &d.Status, &d.Attempts,
+			&d.LastStatusCode, &d.LastError, &d.NextAttemptAt, &d.DeliveredAt, &d.CreatedAt,
+			&v.Activity.MemberName, &v.Activity.GameTitle, &v.Activity.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		v.Activity.ID = d.ActivityID
+		v.Activity.EventType = d.EventType
+		result = append(result, v)
+	}
+	return result, nil
+}
+
+// ClaimWebhookDeliveries returns up to limit pending deliveries that are due,
+// skipping disabled endpoints. Claimed deliveries are pushed lease into the
+// future, so a crash mid-send retries them later and concurrent workers do
+// not send them twice.
+func (s *PostgresStore) ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]WebhookJob, error) {
+	rows, err := s.pool.Query(ctx,
+		`WITH due AS (
+		     SELECT d.id FROM webhook_deliveries d
+		     JOIN webhook_endpoints e ON e.id = d.endpoint_id
+		     WHERE d.status = 'pending' AND d.next_attempt_at <= NOW() AND e.enabled
+		     ORDER BY d.next_attempt_at
+		     LIMIT $1
+		     FOR UPDATE OF d SKIP LOCKED
+		 )
+		 UPDATE webhook_deliveries d
+		 SET next_attempt_at = NOW() + make_interval(secs => $2)
+		 FROM due, webhook_endpoints e, activities a
+		 WHERE d.id = due.id AND e.id = d.endpoint_id AND a.id = d.activity_id
+		 RETURNING d.id, d.endpoint_id, d.activity_id, d.event_type, d.status, d.attempts,
+		           d.last_status_code, d.last_error, d.next_attempt_at, d.delivered_at, d.created_at,
+		           e.url, e.secret, e.club_id, a.member_name, a.game_title, a.created_at`,
+		limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var result []WebhookJob
+	for rows.Next() {
+		var j WebhookJob
+		d := &j.Delivery
+		if err := rows.Scan(&d.ID, &d.EndpointID, &d.ActivityID, &d.EventType, &d.Status, &d.Attempts,
+			&d.LastStatusCode, &d.LastError, &d.NextAttemptAt, &d.DeliveredAt, &d.CreatedAt,
+			&j.URL, &j.Secret, &j.ClubID, &j.Activity.MemberName, &j.Activity.GameTitle, &j.Activity.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook job: %w", err)
+		}
+		j.Activity.ID = d.ActivityID
+		j.Activity.EventType = d.EventType
+		result = append(result, j)
+	}
+	return result, nil
+}
+
+// CompleteWebhookDelivery marks a delivery as delivered and resets the
+// endpoint's failure count.
+func (s *PostgresStore) CompleteWebhookDelivery(ctx context.Context, deliveryID, endpointID uuid.UUID, statusCode int) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE webhook_deliveries
+		 SET status = 'delivered', attempts = attempts + 1, last_status_code = $2,
+		     last_error = '', delivered_at = NOW()
+		 WHERE id = $1`, deliveryID, statusCode); err != nil {
+		return fmt.Errorf("failed to complete webhook delivery: %w", err)
+	}
+	if _, err := tx.Exec(ctx,
+		`UPDATE webhook_endpoints SET consecutive_failures = 0 WHERE id = $1`, endpointID); err != nil {
+		return fmt.Errorf("failed to reset webhook failures: %w", err)
+	}
+	return tx.Commit(ctx)
+}
+
+// FailWebhookDelivery records a failed attempt. The delivery is retried at
+// retryAt, or fails for good when retryAt is nil. The endpoint is switched
+// off once it reaches maxFailures failed attempts in a row; the returned
+// bool reports whether this attempt switched it off.
+func (s *PostgresStore) FailWebhookDelivery(ctx context.Context, deliveryID, endpointID uuid.UUID, statusCode int, reason string, retryAt *time.Time, maxFailures int) (bool, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE webhook_deliveries
+		 SET status = CASE WHEN $4::timestamptz IS NULL THEN 'failed' ELSE 'pending' END,
+		     attempts = attempts + 1, last_status_code = $2, last_error = $3,
+		     next_attempt_at = COALESCE($4, next_attempt_at)
+		 WHERE id = $1`, deliveryID, statusCode, reason, retryAt); err != nil {
+		return false, fmt.Errorf("failed to record webhook failure: %w", err)
+	}
+
+	var disabled bool
+	err = tx.QueryRow(ctx,
+		`UPDATE webhook_endpoints
+		 SET consecutive_failures = consecutive_failures + 1,
+		     enabled = enabled AND consecutive_failures + 1 < $2,
+		     disabled_at = CASE WHEN enabled AND consecutive_failures + 1 >= $2 THEN NOW() ELSE disabled_at END
+		 WHERE id = $1
+		 RETURNING NOT enabled AND disabled_at = NOW()`,
+		endpointID, maxFailures).Scan(&disabled)
+	if err != nil && err != pgx.ErrNoRows {
+		return false, fmt.Errorf("failed to count webhook failure: %w", err)
+	}
+	return disabled, tx.Commit(ctx)
+}
+
+// RetryWebhookDelivery queues a failed delivery of the endpoint again.
+// Returns false when the endpoint has no such failed delivery.
+func (s *PostgresStore) RetryWebhookDelivery(ctx context.Context, deliveryID, endpointID uuid.UUID) (bool, error) {
+	tag, err := s.pool.Exec(ctx,
+		`UPDATE webhook_deliveries
+		 SET status = 'pending', attempts = 0, next_attempt_at = NOW()
+		 WHERE id = $1 AND endpoint_id = $2 AND status = 'failed'`, deliveryID, endpointID)
+	if err != nil {
+		return false, fmt.Errorf("failed to retry webhook delivery: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// DeleteOldWebhookDeliveries removes finished deliveries created before the
+// cutoff and returns how many were deleted.
+func (s *PostgresStore) DeleteOldWebhookDeliveries(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := s.pool.Exec(ctx,
+		`DELETE FROM webhook_deliveries WHERE status <> 'pending' AND created_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete old webhook deliveries: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,189 @@
+// Package feed renders syndication feeds in Atom (RFC 4287) and RSS 2.0 from
+// the same description, so every feed of the site is offered in both.
+package feed
+
+import (
+	"bytes"
+	"encoding/xml"
+	"time"
+)
+
+// Content types of the two formats.
+const (
+	AtomContentType = "application/atom+xml; charset=utf-8"
+	RSSContentType  = "application/rss+xml; charset=utf-8"
+)
+
+// Feed describes a feed. Links must be absolute URLs.
+type Feed struct {
+	ID          string // Permanent identifier, such as the feed's own URL.
+	Title       string
+	Description string
+	Link        string // HTML page the feed mirrors.
+	Self        string // URL of the feed document itself.
+	Language    string
+	Updated     time.Time // Newest entry; the zero time for an empty feed.
+	Entries     []Entry
+}
+
+// Entry is one item of a feed.
+type Entry struct {
+	ID      string // Stable globally unique ID, such as "urn:uuid:…".
+	Title   string
+	Link    string
+	Author  string
+	Content string // Plain text.
+	Updated time.Time
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Lang    string      `xml:"xml:lang,attr,omitempty"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Sub     string      `xml:"subtitle,omitempty"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Author  atomPerson  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Link    *atomLink   `xml:"link,omitempty"`
+	Author  *atomPerson `xml:"author,omitempty"`
+	Content atomText    `xml:"content"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+// Atom renders f as an Atom 1.0 document.
+func Atom(f Feed) ([]byte, error) {
+	doc := atomFeed{
+		Lang:    f.Language,
+		ID:      f.ID,
+		Title:   f.Title,
+		Sub:     f.Description,
+		Updated: atomTime(f.Updated),
+		Links: []atomLink{
+			{Rel: "alternate", Type: "text/html", Href: f.Link},
+			{Rel: "self", Type: "application/atom+xml", Href: f.Self},
+		},
+		// Atom requires an author on the feed or on every entry.
+		Author: atomPerson{Name: f.Title},
+	}
+	for _, e := range f.Entries {
+		entry := atomEntry{
+			ID:      e.ID,
+			Title:   e.Title,
+			Updated: atomTime(e.Updated),
+			Content: atomText{Type: "text", Body: e.Content},
+		}
+		if e.Link != "" {
+			entry.Link = &atomLink{Rel: "alternate", Type: "text/html", Href: e.Link}
+		}
+		if e.Author != "" {
+			entry.Author = &atomPerson{Name: e.Author}
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+	return encode(doc)
+}
+
+func atomTime(t time.Time) string {
+	if t.IsZero() {
+		t = time.Unix(0, 0)
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+type rssDoc struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	AtomNS  string     `xml:"xmlns:atom,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title       string    `xml:"title"`
+	Link        string    `xml:"link"`
+	Description string    `xml:"description"`
+	Language    string    `xml:"language,omitempty"`
+	LastBuild   string    `xml:"lastBuildDate,omitempty"`
+	Self        rssSelf   `xml:"atom:link"`
+	Items       []rssItem `xml:"item"`
+}
+
+// rssSelf is the atom:link element feed validators expect in RSS.
+type rssSelf struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link,omitempty"`
+	Description string  `xml:"description"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// RSS renders f as an RSS 2.0 document.
+func RSS(f Feed) ([]byte, error) {
+	ch := rssChannel{
+		Title:       f.Title,
+		Link:        f.Link,
+		Description: f.Description,
+		Language:    f.Language,
+		Self:        rssSelf{Href: f.Self, Rel: "self", Type: "application/rss+xml"},
+	}
+	if ch.Description == "" {
+		ch.Description = f.Title
+	}
+	if !f.Updated.IsZero() {
+		ch.LastBuild = f.Updated.UTC().Format(time.RFC1123Z)
+	}
+	for _, e := range f.Entries {
+		ch.Items = append(ch.Items, rssItem{
+			Title:       e.Title,
+			Link:        e.Link,
+			Description: e.Content,
+			GUID:        rssGUID{Value: e.ID},
+			PubDate:     e.Updated.UTC().Format(time.RFC1123Z),
+		})
+	}
+	return encode(rssDoc{Version: "2.0", AtomNS: "http://www.w3.org/2005/Atom", Channel: ch})
+}
+
+func encode(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,330 @@
+package handlers
+
+import (
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cmellojr/modo-locadora/internal/auth"
+	"github.com/cmellojr/modo-locadora/internal/mailer"
+	"github.com/cmellojr/modo-locadora/internal/models"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ── Account recovery and e-mail verification handlers ──────────────────────
+
+const (
+	verifyEmailTTL   = 48 * time.Hour
+	passwordResetTTL = time.Hour
+
+	// minPasswordLength is the shortest password accepted when setting one.
+	minPasswordLength = 8
+)
+
+// issueMemberToken creates a single-use token for the member and returns it
+// signed with the cookie secret, ready to go into an e-mail link.
+func (h *Handler) issueMemberToken(r *http.Request, memberID uuid.UUID, purpose string, ttl time.Duration) (string, error) {
+	raw, err := auth.NewToken()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	t := &models.MemberToken{
+		ID:        uuid.New(),
+		MemberID:  memberID,
+		Purpose:   purpose,
+		TokenHash: auth.HashToken(raw),
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+	if err := h.store.CreateMemberToken(r.Context(), t); err != nil {
+		return "", err
+	}
+	return h.keys.Sign(raw), nil
+}
+
+// findMemberToken verifies a signed token from an e-mail link without using
+// it up. Returns nil in the same cases as consumeMemberToken.
+func (h *Handler) findMemberToken(r *http.Request, signed, purpose string) *models.MemberToken {
+	raw, err := h.keys.Verify(signed)
+	if err != nil || raw == "" {
+		return nil
+	}
+	t, err := h.store.GetMemberToken(r.Context(), auth.HashToken(raw), purpose)
+	if err != nil {
+		return nil
+	}
+	return t
+}
+
+// consumeMemberToken verifies a signed token from an e-mail link and marks it
+// used. Returns nil if the token is forged, unknown, expired or already used.
+func (h *Handler) consumeMemberToken(r *http.Request, signed, purpose string) *models.MemberToken {
+	raw, err := h.keys.Verify(signed)
+	if err != nil || raw == "" {
+		return nil
+	}
+	t, err := h.store.ConsumeMemberToken(r.Context(), auth.HashToken(raw), purpose)
+	if err != nil {
+		return nil
+	}
+	return t
+}
+
+// absoluteURL builds a link for e-mails from BASE_URL, or from the request host.
+func (h *Handler) absoluteURL(r *http.Request, path string) string {
+	if h.baseURL != "" {
+		return h.baseURL + path
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + path
+}
+
+// sendVerificationEmail e-mails the member a link to confirm their address.
+func (h *Handler) sendVerificationEmail(r *http.Request, member *models.Member) error {
+	if h.mailer == nil {
+		return fmt.Errorf("mailer not configured")
+	}
+
+	token, err := h.issueMemberToken(r, member.ID, models.TokenPurposeVerifyEmail, verifyEmailTTL)
+	if err != nil {
+		return err
+	}
+
+	link := h.absoluteURL(r, "/verify-email?token="+token)
+	return h.mailer.Send(r.Context(), mailer.Message{
+		To:      member.Email,
+		Subject: "Confirme seu e-mail no Modo Locadora",
+		Body: fmt.Sprintf(`Fala, %s!
+
+Sua carteirinha %s está quase pronta. Confirme seu e-mail para liberar o aluguel de fitas:
+
+%s
+
+O link vale por 48 horas. Se você não fez essa carteirinha, ignore esta mensagem.
+
+-- O Tio da Locadora`, member.ProfileName, member.MembershipNumber, link),
+	})
+}
+
+// ForgotPasswordPage handles GET /password/forgot.
+func (h *Handler) ForgotPasswordPage(w http.ResponseWriter, r *http.Request, tmpl *template.Template) {
+	ld := h.buildLayoutData(r, "Esqueci minha senha")
+
+	data := struct {
+		LayoutData
+		Sent bool
+	}{
+		LayoutData: ld,
+		Sent:       r.URL.Query().Get("sent") == "1",
+	}
+
+	if err := tmpl.Execute(w, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// RequestPasswordReset handles POST /password/forgot. The response is the
+// same whether or not the e-mail belongs to a member, so it can't be used to
+// discover accounts.
+func (h *Handler) RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	if h.store == nil || h.mailer == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	email := strings.TrimSpace(r.FormValue("email"))
+	if email == "" {
+		http.Error(w, "Email is required", http.StatusBadRequest)
+		return
+	}
+
+	member, err := h.store.GetMemberByEmail(r.Context(), email)
+	if err != nil {
+		http.Error(w, "Failed to look up member", http.StatusInternalServerError)
+		return
+	}
+
+	if member != nil {
+		token, err := h.issueMemberToken(r, member.ID, models.TokenPurposePasswordReset, passwordResetTTL)
+		if err != nil {
+			http.Error(w, "Failed to create reset token: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		link := h.absoluteURL(r, "/password/reset?token="+token)
+		err = h.mailer.Send(r.Context(), mailer.Message{
+			To:      member.Email,
+			Subject: "Trocar a senha do Modo Locadora",
+			Body: fmt.Sprintf(`Fala, %s!
+
+Alguém pediu para trocar a senha da sua carteirinha. Para escolher uma nova, use o link abaixo:
+
+%s
+
+O link vale por 1 hora e só pode ser usado uma vez. Se não foi você, ignore esta mensagem: sua senha continua a mesma.
+
+-- O Tio da Locadora`, member.ProfileName, link),
+		})
+		if err != nil {
+			log.Printf("[mailer] Failed to send password reset to %s: %v", member.Email, err)
+		}
+	}
+
+	http.Redirect(w, r, "/password/forgot?sent=1", http.StatusSeeOther)
+}
+
+// ResetPasswordPage handles GET /password/reset?token=...
+func (h *Handler) ResetPasswordPage(w http.ResponseWriter, r *http.Request, tmpl *template.Template) {
+	q := r.URL.Query()
+	h.renderResetPassword(w, r, tmpl, q.Get("token"), q.Get("error"), "", http.StatusOK)
+}
+
+// renderResetPassword renders the new password form. errCode comes from a
+// redirect; message explains why the password just sent was refused.
+func (h *Handler) renderResetPassword(w http.ResponseWriter, r *http.Request, tmpl *template.Template, token, errCode, message string, status int) {
+	ld := h.buildLayoutData(r, "Nova senha")
+
+	data := struct {
+		LayoutData
+		Token     string
+		Error     string
+		Message   string
+		MinLength int
+	}{
+		LayoutData: ld,
+		Token:      token,
+		Error:      errCode,
+		Message:    message,
+		MinLength:  minPasswordLength,
+	}
+
+	w.WriteHeader(status)
+	if err := tmpl.Execute(w, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// ResetPassword handles POST /password/reset. A refused password re-renders
+// the form with the reason. A successful reset signs the member out of every
+// device.
+func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request, tmpl *template.Template) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	token := r.FormValue("token")
+	password := r.FormValue("password")
+	invalid := "/password/reset?error=invalid_token"
+
+	// The link is single-use, so the password is checked before using it up.
+	pending := h.findMemberToken(r, token, models.TokenPurposePasswordReset)
+	if pending == nil {
+		http.Redirect(w, r, invalid, http.StatusSeeOther)
+		return
+	}
+	member, err := h.store.GetMemberByID(r.Context(), pending.MemberID)
+	if err != nil {
+		http.Error(w, "Failed to load member: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	msg := passwordProblem(password, member.ProfileName, member.Email)
+	if msg == "" && password != r.FormValue("password_confirm") {
+		msg = "As duas senhas não batem."
+	}
+	if msg != "" {
+		h.renderResetPassword(w, r, tmpl, token, "", msg, http.StatusUnprocessableEntity)
+		return
+	}
+
+	t := h.consumeMemberToken(r, token, models.TokenPurposePasswordReset)
+	if t == nil {
+		http.Redirect(w, r, invalid, http.StatusSeeOther)
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		http.Error(w, "Failed to process password", http.StatusInternalServerError)
+		return
+	}
+	if err := h.store.UpdateMemberPassword(r.Context(), t.MemberID, string(hash)); err != nil {
+		http.Error(w, "Failed to update password: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// The link proved control of the mailbox, so the address is verified too.
+	_ = h.store.MarkEmailVerified(r.Context(), t.MemberID)
+	_, _ = h.store.RevokeMemberSessions(r.Context(), t.MemberID)
+	auth.ClearSessionCookie(w)
+
+	http.Redirect(w, r, "/?success=password_reset", http.StatusSeeOther)
+}
+
+// VerifyEmail handles GET /verify-email?token=... from the verification e-mail.
+func (h *Handler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	t := h.consumeMemberToken(r, r.URL.Query().Get("token"), models.TokenPurposeVerifyEmail)
+	if t == nil {
+		http.Redirect(w, r, "/?success=verify_failed", http.StatusSeeOther)
+		return
+	}
+
+	if err := h.store.MarkEmailVerified(r.Context(), t.MemberID); err != nil {
+		http.Error(w, "Failed to verify email: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if member, err := h.store.GetMemberByID(r.Context(), t.MemberID); err == nil && member != nil {
+		h.bootstrapOwner(r.Context(), member)
+	}
+
+	if id, ok := h.getSessionMemberID(r); ok && id == t.MemberID {
+		http.Redirect(w, r, "/membership?success=email_verified", http.StatusSeeOther)
+		return
+	}
+	http.Redirect(w, r, "/?success=email_verified", http.StatusSeeOther)
+}
+
+// ResendVerification handles POST /membership/verify-email.
+func (h *Handler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	memberID, ok := h.getSessionMemberID(r)
+	if !ok {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+
+	member, err := h.store.GetMemberByID(r.Context(), memberID)
+	if err != nil || member == nil {
+		http.Error(w, "Failed to load member", http.StatusInternalServerError)
+		return
+	}
+	if member.IsEmailVerified() {
+		http.Redirect(w, r, "/membership", http.StatusSeeOther)
+		return
+	}
+
+	if err := h.sendVerificationEmail(r, member); err != nil {
+		http.Error(w, "Failed to send verification e-mail: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/membership?success=verification_sent", http.StatusSeeOther)
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"context"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/cmellojr/modo-locadora/internal/auth"
+	"github.com/cmellojr/modo-locadora/internal/database"
+	"github.com/cmellojr/modo-locadora/internal/models"
+	"github.com/google/uuid"
+)
+
+// tokenStore holds one password reset token for the stub member.
+type tokenStore struct {
+	*stubStore
+	hash     string
+	used     bool
+	password string
+}
+
+func (s *tokenStore) GetMemberToken(ctx context.Context, tokenHash, purpose string) (*models.MemberToken, error) {
+	if tokenHash != s.hash || s.used {
+		return nil, nil
+	}
+	return &models.MemberToken{MemberID: s.member.ID, Purpose: purpose, TokenHash: tokenHash}, nil
+}
+
+func (s *tokenStore) ConsumeMemberToken(ctx context.Context, tokenHash, purpose string) (*models.MemberToken, error) {
+	t, err := s.GetMemberToken(ctx, tokenHash, purpose)
+	s.used = t != nil
+	return t, err
+}
+
+func (s *tokenStore) UpdateMemberPassword(ctx context.Context, id uuid.UUID, hash string) error {
+	s.password = hash
+	return nil
+}
+
+func (s *tokenStore) MarkEmailVerified(ctx context.Context, memberID uuid.UUID) error {
+	return nil
+}
+
+func (s *tokenStore) RevokeMemberSessions(ctx context.Context, memberID uuid.UUID) (int64, error) {
+	return 0, nil
+}
+
+func (s *tokenStore) GetTopShameEntries(ctx context.Context, limit int) ([]database.ShameEntry, error) {
+	return nil, nil
+}
+
+func TestResetPasswordKeepsTokenOnRefusal(t *testing.T) {
+	store := &tokenStore{stubStore: newStubStore(), hash: auth.HashToken("raw")}
+	h := newTestHandler(t, store)
+	tmpl := template.Must(template.New("reset").Parse(`{{.Message}}`))
+	signed := h.keys.Sign("raw")
+
+	reset := func(password string) *httptest.ResponseRecorder {
+		form := url.Values{"token": {signed}, "password": {password}, "password_confirm": {password}}
+		req := httptest.NewRequest("POST", "/password/reset", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		h.ResetPassword(rec, req, tmpl)
+		return rec
+	}
+
+	// Past bcrypt's 72 bytes, and containing the profile name.
+	for _, password := range []string{strings.Repeat("senha123", 10), "tester2024x"} {
+		rec := reset(password)
+		if rec.Code != http.StatusUnprocessableEntity || rec.Body.Len() == 0 {
+			t.Errorf("reset with %q: status = %d, body = %q; want 422 with a reason", password, rec.Code, rec.Body)
+		}
+		if store.used {
+			t.Fatalf("reset with %q used up the token", password)
+		}
+	}
+
+	if rec := reset("fita-azul-1991"); rec.Code != http.StatusSeeOther || !store.used || store.password == "" {
+		t.Errorf("status = %d, used = %v; want the password saved and the token used", rec.Code, store.used)
+	}
+}
@@ -0,0 +1,910 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cmellojr/modo-locadora/internal/database"
+	"github.com/cmellojr/modo-locadora/internal/middleware"
+	"github.com/cmellojr/modo-locadora/internal/models"
+	"github.com/google/uuid"
+)
+
+// ── API handlers ────────────────────────────────────────────────────────────
+
+// Pagination limits for list endpoints.
+const (
+	apiDefaultPerPage = 20
+	apiMaxPerPage     = 100
+	apiFeedLimit      = 200 // Most recent feed events the API pages through.
+	maxAPIBodyBytes   = 64 << 10
+	apiMaxPage        = math.MaxInt / apiMaxPerPage // Keeps page offsets from overflowing.
+)
+
+// apiError is the body of every non-2xx API response.
+type apiError struct {
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Fields  FieldErrors `json:"fields,omitempty"`
+}
+
+// apiPagination describes the page returned by a list endpoint.
+type apiPagination struct {
+	Page       int `json:"page"`
+	PerPage    int `json:"per_page"`
+	Total      int `json:"total"`
+	TotalPages int `json:"total_pages"`
+}
+
+// apiList is the envelope of list responses.
+type apiList[T any] struct {
+	Data       []T           `json:"data"`
+	Pagination apiPagination `json:"pagination"`
+}
+
+// apiItem is the envelope of single-object responses.
+type apiItem[T any] struct {
+	Data T `json:"data"`
+}
+
+// apiErrorBody is the envelope of error responses.
+type apiErrorBody struct {
+	Error apiError `json:"error"`
+}
+
+// apiPlatform is a shelf platform. Registry fields are empty, and
+// release_year null, for a platform the registry does not know.
+type apiPlatform struct {
+	Name          string   `json:"name"`
+	Slug          string   `json:"slug"`
+	Aliases       []string `json:"aliases"`
+	Manufacturer  string   `json:"manufacturer"`
+	BrDistributor string   `json:"br_distributor"`
+	ReleaseYear   *int     `json:"release_year"`
+	LogoURL       string   `json:"logo_url"`
+	GameCount     int      `json:"game_count"`
+}
+
+type apiGame struct {
+	ID              uuid.UUID `json:"id"`
+	Title           string    `json:"title"`
+	Platform        string    `json:"platform"`
+	Summary         string    `json:"summary"`
+	CoverURL        string    `json:"cover_url"`
+	SourceMagazine  string    `json:"source_magazine"`
+	AcquiredAt      time.Time `json:"acquired_at"`
+	ReleaseDate     *string   `json:"release_date"` // YYYY-MM-DD.
+	Genres          []string  `json:"genres"`
+	Developers      []string  `json:"developers"`
+	TotalCopies     int       `json:"total_copies"`
+	AvailableCopies int       `json:"available_copies"`
+	Available       bool      `json:"available"`
+}
+
+type apiGameDetail struct {
+	apiGame
+	Publishers    []string     `json:"publishers"`
+	Screenshots   []string     `json:"screenshots"`
+	TotalRentals  int          `json:"total_rentals"`
+	TopRenter     *apiRenterOf `json:"top_renter"`
+	CurrentRenter string       `json:"current_renter,omitempty"`
+}
+
+type apiRenterOf struct {
+	ProfileName string `json:"profile_name"`
+	Rentals     int    `json:"rentals"`
+}
+
+type apiRental struct {
+	ID           uuid.UUID  `json:"id"`
+	GameID       uuid.UUID  `json:"game_id"`
+	GameTitle    string     `json:"game_title"`
+	Platform     string     `json:"platform"`
+	RentedAt     time.Time  `json:"rented_at"`
+	DueAt        time.Time  `json:"due_at"`
+	ReturnedAt   *time.Time `json:"returned_at"`
+	Overdue      bool       `json:"overdue"`
+	Verdict      string     `json:"verdict,omitempty"`
+	PersonalNote string     `json:"personal_note,omitempty"`
+}
+
+type apiClub struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	BadgeURL    string    `json:"badge_url"`
+	WebsiteURL  string    `json:"website_url"`
+	CreatedAt   time.Time `json:"created_at"`
+	MemberCount int       `json:"member_count"`
+	IsMember    bool      `json:"is_member"`
+}
+
+type apiClubDetail struct {
+	apiClub
+	Members []apiClubMember `json:"members"`
+}
+
+type apiClubMember struct {
+	ProfileName string    `json:"profile_name"`
+	Role        string    `json:"role"`
+	JoinedAt    time.Time `json:"joined_at"`
+}
+
+type apiChallenge struct {
+	ID        uuid.UUID `json:"id"`
+	ClubID    uuid.UUID `json:"club_id"`
+	GameID    uuid.UUID `json:"game_id"`
+	GameTitle string    `json:"game_title"`
+	StartsAt  time.Time `json:"starts_at"`
+	EndsAt    time.Time `json:"ends_at"`
+}
+
+type apiActivity struct {
+	ID         uuid.UUID `json:"id"`
+	Type       string    `json:"type"`
+	MemberName string    `json:"member_name,omitempty"`
+	GameTitle  string    `json:"game_title,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type apiMe struct {
+	ID               uuid.UUID `json:"id"`
+	ProfileName      string    `json:"profile_name"`
+	MembershipNumber string    `json:"membership_number"`
+	Status           string    `json:"status"`
+	EmailVerified    bool      `json:"email_verified"`
+	TwoFactor        bool      `json:"two_factor"`
+	CSRFToken        string    `json:"csrf_token,omitempty"`
+	Scopes           []string  `json:"scopes,omitempty"`
+}
+
+// apiRentRequest is the body of POST /api/v1/rentals.
+type apiRentRequest struct {
+	GameID string `json:"game_id"`
+}
+
+// apiReturnRequest is the optional body of POST /api/v1/rentals/{id}/return.
+type apiReturnRequest struct {
+	Verdict string `json:"verdict,omitempty"`
+}
+
+// apiChallengeRequest is the body of POST /api/v1/clubs/{id}/challenges;
+// dates are YYYY-MM-DD.
+type apiChallengeRequest struct {
+	GameID   string `json:"game_id"`
+	StartsAt string `json:"starts_at"`
+	EndsAt   string `json:"ends_at"`
+}
+
+// writeJSON responds with v encoded as JSON.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeAPIError responds with the standard API error body.
+func writeAPIError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, apiErrorBody{Error: apiError{Code: code, Message: message}})
+}
+
+// writeAPIFieldErrors responds 422 with the invalid fields of the request.
+func writeAPIFieldErrors(w http.ResponseWriter, errs FieldErrors) {
+	writeJSON(w, http.StatusUnprocessableEntity, apiErrorBody{Error: apiError{
+		Code:    "validation_failed",
+		Message: "The request has invalid fields.",
+		Fields:  errs,
+	}})
+}
+
+// writeAPIInternalError logs err and responds 500 without leaking details.
+func writeAPIInternalError(w http.ResponseWriter, r *http.Request, err error) {
+	log.Printf("[api] %s %s: %v", r.Method, r.URL.Path, err)
+	writeAPIError(w, http.StatusInternalServerError, "internal_error", "Something went wrong.")
+}
+
+// isAPIRequest reports whether the request targets the JSON API.
+func isAPIRequest(r *http.Request) bool {
+	return strings.HasPrefix(r.URL.Path, "/api/")
+}
+
+// apiReady responds 503 and returns false when the database is missing.
+func (h *Handler) apiReady(w http.ResponseWriter) bool {
+	if h.store == nil {
+		writeAPIError(w, http.StatusServiceUnavailable, "unavailable", "Database not configured.")
+		return false
+	}
+	return true
+}
+
+// apiMember returns the authenticated member, or responds and returns nil.
+// Requests authenticated by a personal API token must hold scope (an empty
+// scope accepts any token); cookie sessions may do everything.
+func (h *Handler) apiMember(w http.ResponseWriter, r *http.Request, scope string) *models.Member {
+	id, ok := h.apiViewer(r)
+	if !ok {
+		writeAPIError(w, http.StatusUnauthorized, "unauthorized", "Authentication required.")
+		return nil
+	}
+	if !apiAllow(w, r, scope) {
+		return nil
+	}
+	member, err := h.store.GetMemberByID(r.Context(), id)
+	if err != nil {
+		writeAPIInternalError(w, r, err)
+		return nil
+	}
+	if member == nil || member.IsDeleted() {
+		writeAPIError(w, http.StatusUnauthorized, "unauthorized", "Authentication required.")
+		return nil
+	}
+	return member
+}
+
+// apiViewer returns the member behind the request's API token or session.
+func (h *Handler) apiViewer(r *http.Request) (uuid.UUID, bool) {
+	if t := middleware.CurrentAPIToken(r); t != nil {
+		return t.MemberID, true
+	}
+	return h.getSessionMemberID(r)
+}
+
+// apiAllow responds 403 and returns false when the request's API token lacks
+// scope. Anonymous and cookie requests are always allowed.
+func apiAllow(w http.ResponseWriter, r *http.Request, scope string) bool {
+	t := middleware.CurrentAPIToken(r)
+	if t == nil || scope == "" || t.HasScope(scope) {
+		return true
+	}
+	writeAPIError(w, http.StatusForbidden, "insufficient_scope", "This token lacks the "+scope+" scope.")
+	return false
+}
+
+// APIInvalidToken responds to requests whose bearer token is unknown,
+// revoked or expired.
+func (h *Handler) APIInvalidToken(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+	writeAPIError(w, http.StatusUnauthorized, "invalid_token", "The API token is invalid, expired or revoked.")
+}
+
+// decodeAPIBody decodes a JSON request body into v, or responds 400 and
+// returns false. An empty body leaves v untouched.
+func decodeAPIBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAPIBodyBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil && !errors.Is(err, io.EOF) {
+		writeAPIError(w, http.StatusBadRequest, "invalid_body", "Request body must be a JSON object: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// apiPathID parses the {name} path value as a UUID, or responds 404.
+func apiPathID(w http.ResponseWriter, r *http.Request, name string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(r.PathValue(name))
+	if err != nil {
+		writeAPIError(w, http.StatusNotFound, "not_found", "Resource not found.")
+		return uuid.UUID{}, false
+	}
+	return id, true
+}
+
+// parsePage reads ?page and ?per_page, or responds 400 and returns false.
+func parsePage(w http.ResponseWriter, r *http.Request) (apiPagination, bool) {
+	p := apiPagination{Page: 1, PerPage: apiDefaultPerPage}
+	q := r.URL.Query()
+	if v := q.Get("page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > apiMaxPage {
+			writeAPIError(w, http.StatusBadRequest, "invalid_page",
+				"page must be between 1 and "+strconv.Itoa(apiMaxPage)+".")
+			return p, false
+		}
+		p.Page = n
+	}
+	if v := q.Get("per_page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > apiMaxPerPage {
+			writeAPIError(w, http.StatusBadRequest, "invalid_per_page",
+				"per_page must be between 1 and "+strconv.Itoa(apiMaxPerPage)+".")
+			return p, false
+		}
+		p.PerPage = n
+	}
+	return p, true
+}
+
+// writePage slices items to the requested page and writes the list envelope.
+func writePage[T any](w http.ResponseWriter, p apiPagination, items []T) {
+	p.Total = len(items)
+	p.TotalPages = (p.Total + p.PerPage - 1) / p.PerPage
+	start := min((p.Page-1)*p.PerPage, p.Total)
+	end := min(start+p.PerPage, p.Total)
+	page := items[start:end]
+	if page == nil {
+		page = []T{}
+	}
+	writeJSON(w, http.StatusOK, apiList[T]{Data: page, Pagination: p})
+}
+
+func toAPIGame(g models.Game, total, available int) apiGame {
+	ag := apiGame{
+		ID:              g.ID,
+		Title:           g.Title,
+		Platform:        g.Platform,
+		Summary:         g.Summary,
+		CoverURL:        g.CoverURL,
+		SourceMagazine:  g.SourceMagazine,
+		AcquiredAt:      g.AcquiredAt,
+		Genres:          append([]string{}, g.Genres...),
+		Developers:      append([]string{}, g.Developers...),
+		TotalCopies:     total,
+		AvailableCopies: available,
+		Available:       available > 0,
+	}
+	if g.ReleaseDate != nil {
+		d := g.ReleaseDate.Format("2006-01-02")
+		ag.ReleaseDate = &d
+	}
+	return ag
+}
+
+func toAPIRental(rec database.MemberRentalRecord, now time.Time) apiRental {
+	return apiRental{
+		ID:           rec.RentalID,
+		GameID:       rec.GameID,
+		GameTitle:    rec.GameTitle,
+		Platform:     rec.Platform,
+		RentedAt:     rec.RentedAt,
+		DueAt:        rec.DueAt,
+		ReturnedAt:   rec.ReturnedAt,
+		Overdue:      rec.ReturnedAt == nil && now.After(rec.DueAt),
+		Verdict:      rec.Verdict,
+		PersonalNote: rec.PersonalNote,
+	}
+}
+
+func toAPIClub(c models.Club, memberCount int, isMember bool) apiClub {
+	return apiClub{
+		ID:          c.ID,
+		Name:        c.Name,
+		Description: c.Description,
+		BadgeURL:    c.BadgeURL,
+		WebsiteURL:  c.WebsiteURL,
+		CreatedAt:   c.CreatedAt,
+		MemberCount: memberCount,
+		IsMember:    isMember,
+	}
+}
+
+// findMemberRental returns the member's rental with the given ID.
+func (h *Handler) findMemberRental(r *http.Request, memberID, rentalID uuid.UUID) (*database.MemberRentalRecord, error) {
+	records, err := h.store.ListMemberRentalRecords(r.Context(), memberID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range records {
+		if records[i].RentalID == rentalID {
+			return &records[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// APINotFound handles every unknown path under /api/.
+func (h *Handler) APINotFound(w http.ResponseWriter, r *http.Request) {
+	writeAPIError(w, http.StatusNotFound, "not_found", "Resource not found.")
+}
+
+// APIPlatforms handles GET /api/v1/platforms.
+func (h *Handler) APIPlatforms(w http.ResponseWriter, r *http.Request) {
+	if !h.apiReady(w) {
+		return
+	}
+	if !apiAllow(w, r, models.ScopeCatalogRead) {
+		return
+	}
+	p, ok := parsePage(w, r)
+	if !ok {
+		return
+	}
+
+	platforms, err := h.store.ListPlatforms(r.Context())
+	if err != nil {
+		writeAPIInternalError(w, r, err)
+		return
+	}
+	registry, err := h.platformRegistry(r.Context())
+	if err != nil {
+		writeAPIInternalError(w, r, err)
+		return
+	}
+	counts := make(map[string]int, len(platforms))
+	for _, pl := range platforms {
+		counts[pl.Platform] = pl.GameCount
+	}
+
+	// Registry platforms in shelf order, then any other platform with games.
+	items := make([]apiPlatform, 0, len(platforms))
+	for _, pl := range registry {
+		n, ok := counts[pl.Name]
+		if !ok && !pl.OnShelf {
+			continue
+		}
+		delete(counts, pl.Name)
+		item := apiPlatform{
+			Name:          pl.Name,
+			Slug:          pl.Slug,
+			Aliases:       append([]string{}, pl.Aliases...),
+			Manufacturer:  pl.Manufacturer,
+			BrDistributor: pl.BrDistributor,
+			LogoURL:       pl.LogoURL,
+			GameCount:     n,
+		}
+		if pl.ReleaseYear != 0 {
+			item.ReleaseYear = &pl.ReleaseYear
+		}
+		items = append(items, item)
+	}
+	for _, pl := range platforms {
+		if n, ok := counts[pl.Platform]; ok {
+			items = append(items, apiPlatform{Name: pl.Platform, Aliases: []string{}, GameCount: n})
+		}
+	}
+	writePage(w, p, items)
+}
+
+// APIGames handles GET /api/v1/games with optional ?platform= (a name or
+// alias), ?genre=, ?developer=, ?year= and ?available=true filters.
+func (h *Handler) APIGames(w http.ResponseWriter, r *http.Request) {
+	if !h.apiReady(w) {
+		return
+	}
+	if !apiAllow(w, r, models.ScopeCatalogRead) {
+		return
+	}
+	p, ok := parsePage(w, r)
+	if !ok {
+		return
+	}
+	onlyAvailable := false
+	if v := r.URL.Query().Get("available"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, "invalid_available", "available must be true or false.")
+			return
+		}
+		onlyAvailable = b
+	}
+	registry, err := h.platformRegistry(r.Context())
+	if err != nil {
+		writeAPIInternalError(w, r, err)
+		return
+	}
+	q := r.URL.Query()
+	filter := database.GameFilter{
+		Platform:  registry.Canonical(q.Get("platform")),
+		Genre:     q.Get("genre"),
+		Developer: q.Get("developer"),
+	}
+	if v := q.Get("year"); v != "" {
+		year, err := strconv.Atoi(v)
+		if err != nil || year <= 0 {
+			writeAPIError(w, http.StatusBadRequest, "invalid_year", "year must be a positive integer.")
+			return
+		}
+		filter.Year = year
+	}
+
+	games, err := h.store.ListGamesWithAvailability(r.Context(), filter)
+	if err != nil {
+		writeAPIInternalError(w, r, err)
+		return
+	}
+	items := make([]apiGame, 0, len(games))
+	for _, g := range games {
+		if onlyAvailable && g.AvailableCopies == 0 {
+			continue
+		}
+		items = append(items, toAPIGame(g.Game, g.TotalCopies, g.AvailableCopies))
+	}
+	writePage(w, p, items)
+}
+
+// APIGame handles GET /api/v1/games/{id}.
+func (h *Handler) APIGame(w http.ResponseWriter, r *http.Request) {
+	if !h.apiReady(w) {
+		return
+	}
+	if !apiAllow(w, r, models.ScopeCatalogRead) {
+		return
+	}
+	gameID, ok := apiPathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	gd, err := h.store.GetGameDetail(r.Context(), gameID)
+	if err != nil {
+		writeAPIInternalError(w, r, err)
+		return
+	}
+	if gd == nil {
+		writeAPIError(w, http.StatusNotFound, "not_found", "Game not found.")
+		return
+	}
+
+	detail := apiGameDetail{
+		apiGame:       toAPIGame(gd.Game, gd.TotalCopies, gd.AvailableCopies),
+		Publishers:    append([]string{}, gd.Game.Publishers...),
+		Screenshots:   append([]string{}, gd.Game.Screenshots...),
+		TotalRentals:  gd.TotalRentals,
+		CurrentRenter: gd.CurrentRenter,
+	}
+	if gd.TopRenterName != "" {
+		detail.TopRenter = &apiRenterOf{ProfileName: gd.TopRenterName, Rentals: gd.TopRenterCount}
+	}
+	writeJSON(w, http.StatusOK, apiItem[apiGameDetail]{Data: detail})
+}
+
+// APIMe handles GET /api/v1/me. Browser clients read the CSRF token for
+// their writes from here; token clients see the scopes of their token.
+func (h *Handler) APIMe(w http.ResponseWriter, r *http.Request) {
+	if !h.apiReady(w) {
+		return
+	}
+	member := h.apiMember(w, r, "")
+	if member == nil {
+		return
+	}
+	me := apiMe{
+		ID:               member.ID,
+		ProfileName:      member.ProfileName,
+		MembershipNumber: member.MembershipNumber,
+		Status:           member.Status,
+		EmailVerified:    member.IsEmailVerified(),
+		TwoFactor:        member.HasTwoFactor(),
+	}
+	if t := middleware.CurrentAPIToken(r); t != nil {
+		me.Scopes = t.Scopes
+	} else {
+		me.CSRFToken = middleware.CSRFToken(r, h.keys)
+	}
+	writeJSON(w, http.StatusOK, apiItem[apiMe]{Data: me})
+}
+
+// APIMyRentals handles GET /api/v1/me/rentals. ?status= is "active" (the
+// default), "returned" or "all".
+func (h *Handler) APIMyRentals(w http.ResponseWriter, r *http.Request) {
+	if !h.apiReady(w) {
+		return
+	}
+	member := h.apiMember(w, r, models.ScopeRentalsRead)
+	if member == nil {
+		return
+	}
+	p, ok := parsePage(w, r)
+	if !ok {
+		return
+	}
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "":
+		status = "active"
+	case "active", "returned", "all":
+	default:
+		writeAPIError(w, http.StatusBadRequest, "invalid_status", "status must be active, returned or all.")
+		return
+	}
+
+	records, err := h.store.ListMemberRentalRecords(r.Context(), member.ID)
+	if err != nil {
+		writeAPIInternalError(w, r, err)
+		return
+	}
+	now := time.Now()
+	items := make([]apiRental, 0, len(records))
+	for _, rec := range records {
+		open := rec.ReturnedAt == nil
+		if (status == "active" && !open) || (status == "returned" && open) {
+			continue
+		}
+		items = append(items, toAPIRental(rec, now))
+	}
+	writePage(w, p, items)
+}
+
+// APIRent handles POST /api/v1/rentals with {"game_id": "..."}.
+func (h *Handler) APIRent(w http.ResponseWriter, r *http.Request) {
+	if !h.apiReady(w) {
+		return
+	}
+	member := h.apiMember(w, r, models.ScopeRentalsWrite)
+	if member == nil {
+		return
+	}
+
+	var body apiRentRequest
+	if !decodeAPIBody(w, r, &body) {
+		return
+	}
+	gameID, err := uuid.Parse(body.GameID)
+	if err != nil {
+		writeAPIFieldErrors(w, FieldErrors{"game_id": "must be a game ID"})
+		return
+	}
+	gd, err := h.store.GetGameDetail(r.Context(), gameID)
+	if err != nil {
+		writeAPIInternalError(w, r, err)
+		return
+	}
+	if gd == nil {
+		writeAPIError(w, http.StatusNotFound, "not_found", "Game not found.")
+		return
+	}
+
+	switch err := h.rentForMember(r, member.ID, gameID); {
+	case errors.Is(err, errMemberInDebt):
+		writeAPIError(w, http.StatusForbidden, "in_debt", "Settle your debt at the counter before renting.")
+		return
+	case errors.Is(err, errEmailUnverified):
+		writeAPIError(w, http.StatusForbidden, "email_unverified", "Confirm your e-mail before renting.")
+		return
+	case errors.Is(err, database.ErrNoCopiesAvailable):
+		writeAPIError(w, http.StatusConflict, "unavailable", "Every copy of this game is rented.")
+		return
+	case err != nil:
+		writeAPIInternalError(w, r, err)
+		return
+	}
+
+	// The newest open rental of this game is the one just created.
+	records, err := h.store.ListMemberRentalRecords(r.Context(), member.ID)
+	if err != nil {
+		writeAPIInternalError(w, r, err)
+		return
+	}
+	for _, rec := range records {
+		if rec.GameID == gameID && rec.ReturnedAt == nil {
+			w.Header().Set("Location", "/api/v1/me/rentals")
+			writeJSON(w, http.StatusCreated, apiItem[apiRental]{Data: toAPIRental(rec, time.Now())})
+			return
+		}
+	}
+	writeAPIInternalError(w, r, errors.New("rental created but not found"))
+}
+
+// APIReturn handles POST /api/v1/rentals/{id}/return with an optional
+// {"verdict": "..."}.
+func (h *Handler) APIReturn(w http.ResponseWriter, r *http.Request) {
+	if !h.apiReady(w) {
+		return
+	}
+	member := h.apiMember(w, r, models.ScopeRentalsWrite)
+	if member == nil {
+		return
+	}
+	rentalID, ok := apiPathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var body apiReturnRequest
+	if !decodeAPIBody(w, r, &body) {
+		return
+	}
+	if body.Verdict != "" && !rentalVerdicts[body.Verdict] {
+		writeAPIFieldErrors(w, FieldErrors{"verdict": "must be completed, enjoyed, quick_play, not_for_me or gave_up"})
+		return
+	}
+
+	if err := h.returnForMember(r, member.ID, rentalID, body.Verdict); err != nil {
+		if errors.Is(err, database.ErrRentalNotFound) {
+			writeAPIError(w, http.StatusNotFound, "not_found", "No open rental with this ID.")
+			return
+		}
+		writeAPIInternalError(w, r, err)
+		return
+	}
+
+	rec, err := h.findMemberRental(r, member.ID, rentalID)
+	if err != nil || rec == nil {
+		writeAPIInternalError(w, r, errors.New("returned rental not found"))
+		return
+	}
+	writeJSON(w, http.StatusOK, apiItem[apiRental]{Data: toAPIRental(*rec, time.Now())})
+}
+
+// APIClubs handles GET /api/v1/clubs.
+func (h *Handler) APIClubs(w http.ResponseWriter, r *http.Request) {
+	if !h.apiReady(w) {
+		return
+	}
+	if !apiAllow(w, r, models.ScopeCatalogRead) {
+		return
+	}
+	p, ok := parsePage(w, r)
+	if !ok {
+		return
+	}
+
+	var viewerID *uuid.UUID
+	if id, ok := h.apiViewer(r); ok {
+		viewerID = &id
+	}
+	clubs, err := h.store.ListClubs(r.Context(), viewerID)
+	if err != nil {
+		writeAPIInternalError(w, r, err)
+		return
+	}
+	items := make([]apiClub, 0, len(clubs))
+	for _, c := range clubs {
+		items = append(items, toAPIClub(c.Club, c.MemberCount, c.IsMember))
+	}
+	writePage(w, p, items)
+}
+
+// APIClub handles GET /api/v1/clubs/{id}.
+func (h *Handler) APIClub(w http.ResponseWriter, r *http.Request) {
+	if !h.apiReady(w) {
+		return
+	}
+	if !apiAllow(w, r, models.ScopeCatalogRead) {
+		return
+	}
+	clubID, ok := apiPathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	cd, err := h.store.GetClubDetail(r.Context(), clubID)
+	if err != nil {
+		writeAPIInternalError(w, r, err)
+		return
+	}
+	if cd == nil {
+		writeAPIError(w, http.StatusNotFound, "not_found", "Club not found.")
+		return
+	}
+
+	isMember := false
+	members := make([]apiClubMember, 0, len(cd.Members))
+	viewerID, signedIn := h.apiViewer(r)
+	for _, m := range cd.Members {
+		if signedIn && m.MemberID == viewerID {
+			isMember = true
+		}
+		members = append(members, apiClubMember{ProfileName: m.ProfileName, Role: m.Role, JoinedAt: m.JoinedAt})
+	}
+	writeJSON(w, http.StatusOK, apiItem[apiClubDetail]{Data: apiClubDetail{
+		apiClub: toAPIClub(cd.Club, cd.MemberCount, isMember),
+		Members: members,
+	}})
+}
+
+// APICreateClubChallenge handles POST /api/v1/clubs/{id}/challenges with
+// {"game_id", "starts_at", "ends_at"}; dates are YYYY-MM-DD and the end date
+// is inclusive. Only admins of the club may call it.
+func (h *Handler) APICreateClubChallenge(w http.ResponseWriter, r *http.Request) {
+	if !h.apiReady(w) {
+		return
+	}
+	member := h.apiMember(w, r, models.ScopeClubsAdmin)
+	if member == nil {
+		return
+	}
+	clubID, ok := apiPathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	club, err := h.store.GetClubByID(r.Context(), clubID)
+	if err != nil {
+		writeAPIInternalError(w, r, err)
+		return
+	}
+	if club == nil {
+		writeAPIError(w, http.StatusNotFound, "not_found", "Club not found.")
+		return
+	}
+	role, err := h.store.GetClubMemberRole(r.Context(), clubID, member.ID)
+	if err != nil {
+		writeAPIInternalError(w, r, err)
+		return
+	}
+	if role != models.ClubRoleAdmin {
+		writeAPIError(w, http.StatusForbidden, "not_club_admin", "Only admins of this club can do this.")
+		return
+	}
+
+	var body apiChallengeRequest
+	if !decodeAPIBody(w, r, &body) {
+		return
+	}
+
+	errs := FieldErrors{}
+	gameID, err := uuid.Parse(body.GameID)
+	if err != nil {
+		errs["game_id"] = "must be a game ID"
+	}
+	startsAt, err := time.ParseInLocation(challengeDateLayout, body.StartsAt, time.Local)
+	if err != nil {
+		errs["starts_at"] = "must be a date as YYYY-MM-DD"
+	}
+	endsAt, err := time.ParseInLocation(challengeDateLayout, body.EndsAt, time.Local)
+	if err != nil {
+		errs["ends_at"] = "must be a date as YYYY-MM-DD"
+	}
+	// The end date is inclusive: the challenge runs until the end of that day.
+	endsAt = endsAt.AddDate(0, 0, 1)
+	if len(errs) == 0 && !endsAt.After(startsAt) {
+		errs["ends_at"] = "must not be before starts_at"
+	}
+	if len(errs) > 0 {
+		writeAPIFieldErrors(w, errs)
+		return
+	}
+
+	game, err := h.store.GetGameByID(r.Context(), gameID)
+	if err != nil {
+		writeAPIInternalError(w, r, err)
+		return
+	}
+	if game == nil {
+		writeAPIFieldErrors(w, FieldErrors{"game_id": "game not found"})
+		return
+	}
+
+	challenge, err := h.createClubChallenge(r, member.ID, clubID, game, startsAt, endsAt)
+	if err != nil {
+		writeAPIInternalError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, apiItem[apiChallenge]{Data: apiChallenge{
+		ID:        challenge.ID,
+		ClubID:    challenge.ClubID,
+		GameID:    challenge.GameID,
+		GameTitle: game.Title,
+		StartsAt:  challenge.StartsAt,
+		EndsAt:    challenge.EndsAt,
+	}})
+}
+
+// APIActivities handles GET /api/v1/activities, the "Aconteceu na Locadora"
+// feed, newest first.
+func (h *Handler) APIActivities(w http.ResponseWriter, r *http.Request) {
+	if !h.apiReady(w) {
+		return
+	}
+	if !apiAllow(w, r, models.ScopeCatalogRead) {
+		return
+	}
+	p, ok := parsePage(w, r)
+	if !ok {
+		return
+	}
+
+	entries, err := h.store.ListRecentActivities(r.Context(), apiFeedLimit)
+	if err != nil {
+		writeAPIInternalError(w, r, err)
+		return
+	}
+	items := make([]apiActivity, 0, len(entries))
+	for _, e := range entries {
+		items = append(items, apiActivity{
+			ID:         e.ID,
+			Type:       e.EventType,
+			MemberName: e.MemberName,
+			GameTitle:  e.GameTitle,
+			CreatedAt:  e.CreatedAt,
+		})
+	}
+	writePage(w, p, items)
+}
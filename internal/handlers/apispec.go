@@ -0,0 +1,368 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/cmellojr/modo-locadora/internal/auth"
+	"github.com/cmellojr/modo-locadora/internal/igdb"
+	"github.com/cmellojr/modo-locadora/internal/middleware"
+	"github.com/cmellojr/modo-locadora/internal/models"
+	"github.com/cmellojr/modo-locadora/internal/openapi"
+)
+
+// ── OpenAPI handlers ────────────────────────────────────────────────────────
+
+// APIRoute is a JSON endpoint together with its OpenAPI description. The
+// server registers exactly these routes, so an endpoint cannot be added
+// without a spec entry.
+type APIRoute struct {
+	Method      string
+	Path        string
+	OperationID string
+	Summary     string
+	Description string
+	Tag         string
+	Auth        apiAuth
+	Scope       string              // Token scope required; empty accepts any token.
+	Query       []openapi.Parameter // Path parameters are derived from Path.
+	Body        any                 // Zero value of the JSON request body, or nil.
+	Responses   []APIResponse
+	Handler     http.HandlerFunc
+}
+
+// APIResponse documents one response status of an APIRoute.
+type APIResponse struct {
+	Status      int
+	Description string
+	Body        any // Zero value of the JSON body; nil for plain text or no body.
+	Text        bool
+}
+
+// apiAuth is how an APIRoute authenticates its caller.
+type apiAuth int
+
+const (
+	apiAuthNone     apiAuth = iota // Anonymous; cookie-less.
+	apiAuthOptional                // Anonymous, session or token.
+	apiAuthMember                  // Session or token.
+	apiAuthSession                 // Session cookie only.
+)
+
+// apiErrorDescriptions describe the error statuses of the JSON API.
+var apiErrorDescriptions = map[int]string{
+	http.StatusBadRequest:          "Malformed query string or request body.",
+	http.StatusUnauthorized:        "Authentication required, or the API token is invalid, expired or revoked.",
+	http.StatusForbidden:           "The token lacks the scope, the CSRF token is missing, or the member may not do this.",
+	http.StatusNotFound:            "Resource not found.",
+	http.StatusConflict:            "The request conflicts with the current state.",
+	http.StatusUnprocessableEntity: "Validation failed; error.fields maps each field to a message.",
+	http.StatusInternalServerError: "Internal error.",
+	http.StatusServiceUnavailable:  "Database not configured.",
+}
+
+// apiErrors returns the error responses of a /api/v1 route: the given
+// statuses plus those every route may answer.
+func apiErrors(statuses ...int) []APIResponse {
+	statuses = append(statuses, http.StatusUnauthorized, http.StatusForbidden,
+		http.StatusInternalServerError, http.StatusServiceUnavailable)
+	resps := make([]APIResponse, 0, len(statuses))
+	for _, s := range statuses {
+		resps = append(resps, APIResponse{Status: s, Description: apiErrorDescriptions[s], Body: apiErrorBody{}})
+	}
+	return resps
+}
+
+// apiOK prepends the success response to the error responses.
+func apiOK(status int, description string, body any, errs []APIResponse) []APIResponse {
+	return append([]APIResponse{{Status: status, Description: description, Body: body}}, errs...)
+}
+
+// textError documents a plain-text error of the endpoints outside /api/v1.
+func textError(status int, description string) APIResponse {
+	return APIResponse{Status: status, Description: description, Text: true}
+}
+
+// pageParams are the query parameters of every list endpoint.
+func pageParams(extra ...openapi.Parameter) []openapi.Parameter {
+	one, maxPer := 1.0, float64(apiMaxPerPage)
+	return append(extra,
+		openapi.Parameter{Name: "page", In: "query", Description: "Page number, from 1.",
+			Schema: &openapi.Schema{Type: "integer", Minimum: &one}},
+		openapi.Parameter{Name: "per_page", In: "query",
+			Description: "Items per page (default " + strconv.Itoa(apiDefaultPerPage) + ").",
+			Schema:      &openapi.Schema{Type: "integer", Minimum: &one, Maximum: &maxPer}},
+	)
+}
+
+// APIRoutes returns every JSON endpoint of the server.
+func (h *Handler) APIRoutes() []APIRoute {
+	return []APIRoute{
+		{
+			Method: "GET", Path: "/api/v1/platforms", OperationID: "listPlatforms", Tag: "Catalog",
+			Summary: "List platforms with their game counts",
+			Auth:    apiAuthOptional, Scope: models.ScopeCatalogRead,
+			Query:     pageParams(),
+			Responses: apiOK(http.StatusOK, "Platforms.", apiList[apiPlatform]{}, apiErrors(http.StatusBadRequest)),
+			Handler:   h.APIPlatforms,
+		},
+		{
+			Method: "GET", Path: "/api/v1/games", OperationID: "listGames", Tag: "Catalog",
+			Summary: "List games with their availability",
+			Auth:    apiAuthOptional, Scope: models.ScopeCatalogRead,
+			Query: pageParams(
+				openapi.Parameter{Name: "platform", In: "query", Description: "Only games of this platform.",
+					Schema: &openapi.Schema{Type: "string"}},
+				openapi.Parameter{Name: "genre", In: "query", Description: "Only games of this genre.",
+					Schema: &openapi.Schema{Type: "string"}},
+				openapi.Parameter{Name: "developer", In: "query", Description: "Only games made by this developer.",
+					Schema: &openapi.Schema{Type: "string"}},
+				openapi.Parameter{Name: "year", In: "query", Description: "Only games released in this year.",
+					Schema: &openapi.Schema{Type: "integer"}},
+				openapi.Parameter{Name: "available", In: "query", Description: "Only games with a copy on the shelf.",
+					Schema: &openapi.Schema{Type: "boolean"}},
+			),
+			Responses: apiOK(http.StatusOK, "Games.", apiList[apiGame]{}, apiErrors(http.StatusBadRequest)),
+			Handler:   h.APIGames,
+		},
+		{
+			Method: "GET", Path: "/api/v1/games/{id}", OperationID: "getGame", Tag: "Catalog",
+			Summary: "Get a game with its rental statistics",
+			Auth:    apiAuthOptional, Scope: models.ScopeCatalogRead,
+			Responses: apiOK(http.StatusOK, "The game.", apiItem[apiGameDetail]{}, apiErrors(http.StatusNotFound)),
+			Handler:   h.APIGame,
+		},
+		{
+			Method: "GET", Path: "/api/v1/clubs", OperationID: "listClubs", Tag: "Clubs",
+			Summary: "List clubs",
+			Auth:    apiAuthOptional, Scope: models.ScopeCatalogRead,
+			Query:     pageParams(),
+			Responses: apiOK(http.StatusOK, "Clubs.", apiList[apiClub]{}, apiErrors(http.StatusBadRequest)),
+			Handler:   h.APIClubs,
+		},
+		{
+			Method: "GET", Path: "/api/v1/clubs/{id}", OperationID: "getClub", Tag: "Clubs",
+			Summary: "Get a club with its members",
+			Auth:    apiAuthOptional, Scope: models.ScopeCatalogRead,
+			Responses: apiOK(http.StatusOK, "The club.", apiItem[apiClubDetail]{}, apiErrors(http.StatusNotFound)),
+			Handler:   h.APIClub,
+		},
+		{
+			Method: "POST", Path: "/api/v1/clubs/{id}/challenges", OperationID: "createClubChallenge", Tag: "Clubs",
+			Summary:     "Start a club challenge",
+			Description: "Only admins of the club may call it. Dates are YYYY-MM-DD and the end date is inclusive.",
+			Auth:        apiAuthMember, Scope: models.ScopeClubsAdmin,
+			Body: apiChallengeRequest{},
+			Responses: apiOK(http.StatusCreated, "The new challenge.", apiItem[apiChallenge]{},
+				apiErrors(http.StatusBadRequest, http.StatusNotFound, http.StatusUnprocessableEntity)),
+			Handler: h.APICreateClubChallenge,
+		},
+		{
+			Method: "GET", Path: "/api/v1/activities", OperationID: "listActivities", Tag: "Activity",
+			Summary: "List the \"Aconteceu na Locadora\" feed, newest first",
+			Auth:    apiAuthOptional, Scope: models.ScopeCatalogRead,
+			Query:     pageParams(),
+			Responses: apiOK(http.StatusOK, "Feed events.", apiList[apiActivity]{}, apiErrors(http.StatusBadRequest)),
+			Handler:   h.APIActivities,
+		},
+		{
+			Method: "GET", Path: "/api/v1/me", OperationID: "getMe", Tag: "Member",
+			Summary:     "Get the authenticated member",
+			Description: "Session requests get the CSRF token for their writes; token requests get the token's scopes.",
+			Auth:        apiAuthMember,
+			Responses:   apiOK(http.StatusOK, "The member.", apiItem[apiMe]{}, apiErrors()),
+			Handler:     h.APIMe,
+		},
+		{
+			Method: "GET", Path: "/api/v1/me/rentals", OperationID: "listMyRentals", Tag: "Rentals",
+			Summary: "List the member's rentals",
+			Auth:    apiAuthMember, Scope: models.ScopeRentalsRead,
+			Query: pageParams(
+				openapi.Parameter{Name: "status", In: "query", Description: "Which rentals to list (default active).",
+					Schema: &openapi.Schema{Type: "string", Enum: []string{"active", "returned", "all"}}},
+			),
+			Responses: apiOK(http.StatusOK, "Rentals.", apiList[apiRental]{}, apiErrors(http.StatusBadRequest)),
+			Handler:   h.APIMyRentals,
+		},
+		{
+			Method: "POST", Path: "/api/v1/rentals", OperationID: "rentGame", Tag: "Rentals",
+			Summary:     "Rent a copy of a game",
+			Description: "Answers 403 in_debt or email_unverified when the member may not rent, and 409 unavailable when every copy is out.",
+			Auth:        apiAuthMember, Scope: models.ScopeRentalsWrite,
+			Body: apiRentRequest{},
+			Responses: apiOK(http.StatusCreated, "The new rental.", apiItem[apiRental]{},
+				apiErrors(http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity)),
+			Handler: h.APIRent,
+		},
+		{
+			Method: "POST", Path: "/api/v1/rentals/{id}/return", OperationID: "returnRental", Tag: "Rentals",
+			Summary:     "Return a rental",
+			Description: "The optional verdict is one of completed, enjoyed, quick_play, not_for_me or gave_up.",
+			Auth:        apiAuthMember, Scope: models.ScopeRentalsWrite,
+			Body: apiReturnRequest{},
+			Responses: apiOK(http.StatusOK, "The returned rental.", apiItem[apiRental]{},
+				apiErrors(http.StatusBadRequest, http.StatusNotFound, http.StatusUnprocessableEntity)),
+			Handler: h.APIReturn,
+		},
+		{
+			Method: "GET", Path: "/api/openapi.json", OperationID: "getOpenAPI", Tag: "Meta",
+			Summary: "Get this OpenAPI document",
+			Auth:    apiAuthNone,
+			Responses: []APIResponse{
+				{Status: http.StatusOK, Description: "The OpenAPI 3 document.", Body: map[string]any{}},
+				textError(http.StatusInternalServerError, "The document could not be built."),
+			},
+			Handler: h.OpenAPISpec,
+		},
+		{
+			Method: "POST", Path: "/members", OperationID: "createMember", Tag: "Member",
+			Summary:     "Sign up",
+			Description: "Cookie-less JSON sign-up, following the server's sign-up mode.",
+			Auth:        apiAuthNone,
+			Body:        SignupForm{},
+			Responses: []APIResponse{
+				{Status: http.StatusCreated, Description: "The new member, without the password hash.", Body: models.Member{}},
+				{Status: http.StatusUnprocessableEntity, Description: "Validation failed.", Body: fieldErrorsBody{}},
+				textError(http.StatusBadRequest, "Malformed JSON."),
+				textError(http.StatusTooManyRequests, "Too many sign-ups from this address; see Retry-After."),
+				textError(http.StatusInternalServerError, "Internal error."),
+				textError(http.StatusServiceUnavailable, "Database not configured."),
+			},
+			Handler: h.CreateMember,
+		},
+		{
+			Method: "GET", Path: "/search", OperationID: "searchIGDB", Tag: "Catalog",
+			Summary: "Search games on IGDB",
+			Auth:    apiAuthNone,
+			Query: []openapi.Parameter{{Name: "q", In: "query", Required: true, Description: "Search terms.",
+				Schema: &openapi.Schema{Type: "string"}}},
+			Responses: []APIResponse{
+				{Status: http.StatusOK, Description: "Raw IGDB results.", Body: []igdb.GameData{}},
+				textError(http.StatusBadRequest, "q is missing."),
+				textError(http.StatusTooManyRequests, "IGDB rate limit reached; try again shortly."),
+				textError(http.StatusBadGateway, "IGDB failed."),
+				textError(http.StatusServiceUnavailable, "IGDB credentials not configured."),
+			},
+			Handler: h.SearchGame,
+		},
+		{
+			Method: "GET", Path: "/membership/export", OperationID: "exportMyData", Tag: "Member",
+			Summary:     "Download the member's data",
+			Description: "The \"baixar meus dados\" archive, served as an attachment.",
+			Auth:        apiAuthSession,
+			Responses: []APIResponse{
+				{Status: http.StatusOK, Description: "The archive.", Body: memberExport{}},
+				{Status: http.StatusSeeOther, Description: "Not signed in; redirects to the home page."},
+				textError(http.StatusInternalServerError, "Internal error."),
+				textError(http.StatusServiceUnavailable, "Database not configured."),
+			},
+			Handler: middleware.RequireAuth(h.keys, h.store, h.ExportData),
+		},
+	}
+}
+
+// BuildOpenAPI builds the OpenAPI document of routes and checks that every
+// operation is complete.
+func BuildOpenAPI(routes []APIRoute) (*openapi.Document, error) {
+	doc := openapi.New("Modo Locadora API", "1.0.0",
+		"JSON API of the locadora. Errors of /api/v1 share the {\"error\": {...}} envelope.")
+	doc.Components.SecuritySchemes["bearerAuth"] = &openapi.SecurityScheme{
+		Type: "http", Scheme: "bearer",
+		Description: "Personal API token (" + auth.APITokenPrefix + "...) created at /membership/tokens.",
+	}
+	doc.Components.SecuritySchemes["cookieAuth"] = &openapi.SecurityScheme{
+		Type: "apiKey", In: "cookie", Name: auth.SessionCookieName,
+		Description: "Browser session; writes also need the X-CSRF-Token header from GET /api/v1/me.",
+	}
+
+	for _, rt := range routes {
+		if rt.Handler == nil {
+			return nil, fmt.Errorf("%s %s has no handler", rt.Method, rt.Path)
+		}
+		op := &openapi.Operation{
+			OperationID: rt.OperationID,
+			Summary:     rt.Summary,
+			Description: rt.Description,
+			Responses:   map[string]*openapi.Response{},
+		}
+		if rt.Tag != "" {
+			op.Tags = []string{rt.Tag}
+		}
+
+		switch rt.Auth {
+		case apiAuthOptional:
+			op.Security = []map[string][]string{{}, {"bearerAuth": {}}, {"cookieAuth": {}}}
+		case apiAuthMember:
+			op.Security = []map[string][]string{{"bearerAuth": {}}, {"cookieAuth": {}}}
+		case apiAuthSession:
+			op.Security = []map[string][]string{{"cookieAuth": {}}}
+		}
+		if rt.Scope != "" {
+			op.Scopes = []string{rt.Scope}
+		}
+
+		for _, seg := range strings.Split(rt.Path, "/") {
+			if name, ok := strings.CutPrefix(seg, "{"); ok {
+				op.Parameters = append(op.Parameters, openapi.Parameter{
+					Name: strings.TrimSuffix(name, "}"), In: "path", Required: true,
+					Schema: &openapi.Schema{Type: "string", Format: "uuid"},
+				})
+			}
+		}
+		op.Parameters = append(op.Parameters, rt.Query...)
+
+		if rt.Body != nil {
+			op.RequestBody = &openapi.RequestBody{
+				Required: true,
+				Content:  map[string]openapi.MediaType{"application/json": {Schema: doc.SchemaOf(rt.Body)}},
+			}
+		}
+		for _, resp := range rt.Responses {
+			r := &openapi.Response{Description: resp.Description}
+			switch {
+			case resp.Body != nil:
+				r.Content = map[string]openapi.MediaType{"application/json": {Schema: doc.SchemaOf(resp.Body)}}
+			case resp.Text:
+				r.Content = map[string]openapi.MediaType{"text/plain": {Schema: &openapi.Schema{Type: "string"}}}
+			}
+			op.Responses[strconv.Itoa(resp.Status)] = r
+		}
+
+		if err := doc.Add(rt.Method, rt.Path, op); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := doc.Check(); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// openAPISpec caches the document built by Handler.OpenAPI.
+type openAPISpec struct {
+	once sync.Once
+	doc  *openapi.Document
+	err  error
+}
+
+// OpenAPI returns the document of APIRoutes, building it on first use.
+func (h *Handler) OpenAPI() (*openapi.Document, error) {
+	h.spec.once.Do(func() {
+		h.spec.doc, h.spec.err = BuildOpenAPI(h.APIRoutes())
+	})
+	return h.spec.doc, h.spec.err
+}
+
+// OpenAPISpec handles GET /api/openapi.json.
+func (h *Handler) OpenAPISpec(w http.ResponseWriter, r *http.Request) {
+	doc, err := h.OpenAPI()
+	if err != nil {
+		http.Error(w, "Failed to build the OpenAPI document: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, doc)
+}
@@ -0,0 +1,424 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cmellojr/modo-locadora/internal/auth"
+	"github.com/cmellojr/modo-locadora/internal/database"
+	"github.com/cmellojr/modo-locadora/internal/middleware"
+	"github.com/cmellojr/modo-locadora/internal/models"
+	"github.com/cmellojr/modo-locadora/internal/openapi"
+)
+
+// Bearer tokens and the session token known to stubStore.
+const (
+	stubFullToken    = "ml_full"
+	stubCatalogToken = "ml_catalog"
+	stubSession      = "session"
+)
+
+// stubStore serves one member, game, club and rental to the API routes.
+// Methods the routes do not call fall through to the nil Store and panic.
+type stubStore struct {
+	database.Store
+	member models.Member
+	game   models.Game
+	club   models.Club
+	rental database.MemberRentalRecord
+}
+
+func newStubStore() *stubStore {
+	now := time.Now()
+	release := time.Date(1991, 6, 23, 0, 0, 0, 0, time.UTC)
+	s := &stubStore{
+		member: models.Member{
+			ID: uuid.New(), ProfileName: "tester", Email: "tester@example.com",
+			MembershipNumber: "0001", Status: models.MemberStatusActive,
+			EmailVerifiedAt: &now, JoinedAt: now,
+		},
+		game: models.Game{
+			ID: uuid.New(), Title: "Sonic the Hedgehog", Platform: "Mega Drive",
+			ReleaseDate: &release, Genres: []string{"Platform"}, Developers: []string{"Sonic Team"},
+		},
+		club: models.Club{ID: uuid.New(), Name: "Clube do Sonic", CreatedAt: now},
+	}
+	s.rental = database.MemberRentalRecord{
+		RentalID: uuid.New(), GameID: s.game.ID, GameTitle: s.game.Title, Platform: s.game.Platform,
+		RentedAt: now, DueAt: now.Add(72 * time.Hour),
+	}
+	return s
+}
+
+func (s *stubStore) GetActiveAPIToken(ctx context.Context, tokenHash string) (*models.APIToken, error) {
+	t := &models.APIToken{ID: uuid.New(), MemberID: s.member.ID, Name: "test"}
+	switch tokenHash {
+	case auth.HashToken(stubFullToken):
+		t.Scopes = models.APITokenScopes
+	case auth.HashToken(stubCatalogToken):
+		t.Scopes = []string{models.ScopeCatalogRead}
+	default:
+		return nil, nil
+	}
+	return t, nil
+}
+
+func (s *stubStore) GetActiveSession(ctx context.Context, tokenHash string, idleTimeout time.Duration) (*models.Session, error) {
+	if tokenHash != auth.HashToken(stubSession) {
+		return nil, nil
+	}
+	return &models.Session{ID: uuid.New(), MemberID: s.member.ID, TokenHash: tokenHash}, nil
+}
+
+func (s *stubStore) GetMemberByID(ctx context.Context, id uuid.UUID) (*models.Member, error) {
+	if id != s.member.ID {
+		return nil, nil
+	}
+	m := s.member
+	return &m, nil
+}
+
+func (s *stubStore) GetMemberStatus(ctx context.Context, id uuid.UUID) (string, error) {
+	return s.member.Status, nil
+}
+
+func (s *stubStore) NextMembershipNumber(ctx context.Context) (string, error) {
+	return "0002", nil
+}
+
+func (s *stubStore) CreateMember(ctx context.Context, m *models.Member) error {
+	return nil
+}
+
+func (s *stubStore) ListPlatformRegistry(ctx context.Context) (models.Platforms, error) {
+	return models.Platforms{{Slug: "megadrive", Name: "Mega Drive", Aliases: []string{"Genesis"},
+		Manufacturer: "Sega", BrDistributor: "TecToy", ReleaseYear: 1988, OnShelf: true}}, nil
+}
+
+func (s *stubStore) ListPlatforms(ctx context.Context) ([]database.PlatformSummary, error) {
+	return []database.PlatformSummary{{Platform: "Mega Drive", GameCount: 1}, {Platform: "Atari", GameCount: 2}}, nil
+}
+
+func (s *stubStore) ListGamesWithAvailability(ctx context.Context, filter database.GameFilter) ([]database.GameAvailability, error) {
+	return []database.GameAvailability{{Game: s.game, TotalCopies: 2, AvailableCopies: 1}}, nil
+}
+
+func (s *stubStore) GetGameByID(ctx context.Context, id uuid.UUID) (*models.Game, error) {
+	if id != s.game.ID {
+		return nil, nil
+	}
+	g := s.game
+	return &g, nil
+}
+
+func (s *stubStore) GetGameDetail(ctx context.Context, id uuid.UUID) (*database.GameDetail, error) {
+	if id != s.game.ID {
+		return nil, nil
+	}
+	return &database.GameDetail{Game: s.game, TotalCopies: 2, AvailableCopies: 1, TotalRentals: 5,
+		TopRenterName: "tester", TopRenterCount: 3, CurrentRenter: "tester"}, nil
+}
+
+func (s *stubStore) ListClubs(ctx context.Context, viewerID *uuid.UUID) ([]database.ClubListItem, error) {
+	return []database.ClubListItem{{Club: s.club, MemberCount: 1, IsMember: viewerID != nil}}, nil
+}
+
+func (s *stubStore) GetClubByID(ctx context.Context, id uuid.UUID) (*models.Club, error) {
+	if id != s.club.ID {
+		return nil, nil
+	}
+	c := s.club
+	return &c, nil
+}
+
+func (s *stubStore) GetClubDetail(ctx context.Context, id uuid.UUID) (*database.ClubDetail, error) {
+	if id != s.club.ID {
+		return nil, nil
+	}
+	return &database.ClubDetail{Club: s.club, MemberCount: 1, Members: []database.ClubMemberView{
+		{MemberID: s.member.ID, ProfileName: s.member.ProfileName, Role: models.ClubRoleAdmin, JoinedAt: s.club.CreatedAt},
+	}}, nil
+}
+
+func (s *stubStore) GetClubMemberRole(ctx context.Context, clubID, memberID uuid.UUID) (string, error) {
+	return models.ClubRoleAdmin, nil
+}
+
+func (s *stubStore) CreateClubChallenge(ctx context.Context, c *models.ClubChallenge) error {
+	return nil
+}
+
+func (s *stubStore) ListRecentActivities(ctx context.Context, limit int) ([]database.ActivityEntry, error) {
+	return []database.ActivityEntry{{ID: uuid.New(), EventType: "new_game", GameTitle: s.game.Title, CreatedAt: time.Now()}}, nil
+}
+
+func (s *stubStore) InsertActivity(ctx context.Context, eventType, memberName, gameTitle string) error {
+	return nil
+}
+
+func (s *stubStore) ListMemberRentalRecords(ctx context.Context, memberID uuid.UUID) ([]database.MemberRentalRecord, error) {
+	return []database.MemberRentalRecord{s.rental}, nil
+}
+
+func (s *stubStore) RentGame(ctx context.Context, gameID, memberID uuid.UUID) error {
+	return nil
+}
+
+func (s *stubStore) UpdateChallengeProgress(ctx context.Context, memberID, gameID uuid.UUID, status string) ([]database.ChallengeFinish, error) {
+	return nil, nil
+}
+
+func (s *stubStore) GetRentalGameTitle(ctx context.Context, rentalID uuid.UUID) (string, error) {
+	return s.rental.GameTitle, nil
+}
+
+func (s *stubStore) GetRentalGameID(ctx context.Context, rentalID uuid.UUID) (uuid.UUID, error) {
+	return s.rental.GameID, nil
+}
+
+func (s *stubStore) ReturnGameByMember(ctx context.Context, rentalID, memberID uuid.UUID, verdict string) error {
+	if rentalID != s.rental.RentalID {
+		return database.ErrRentalNotFound
+	}
+	return nil
+}
+
+func (s *stubStore) CountGameCompletions(ctx context.Context, rentalID uuid.UUID) (int, error) {
+	return 1, nil
+}
+
+func (s *stubStore) CountOnTimeReturns(ctx context.Context, memberID uuid.UUID) (int, error) {
+	return 1, nil
+}
+
+func (s *stubStore) ListMemberClubs(ctx context.Context, memberID uuid.UUID) ([]database.MemberClubView, error) {
+	return []database.MemberClubView{{ClubID: s.club.ID, Name: s.club.Name, Role: models.ClubRoleAdmin}}, nil
+}
+
+func (s *stubStore) ListMemberActivities(ctx context.Context, profileName string) ([]database.ActivityEntry, error) {
+	return nil, nil
+}
+
+// newTestHandler returns a handler backed by store, without mailer, IGDB or
+// media storage.
+func newTestHandler(t *testing.T, store database.Store) *Handler {
+	t.Helper()
+	keys, err := auth.NewKeyring(auth.Key{ID: "k1", Secret: "test-secret-that-is-long-enough-for-hmac"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewHandler(store, nil, nil, nil, nil, nil, keys, "", "", models.SignupModeOpen)
+}
+
+// TestAPIRoutesRegistered checks that the server registers the JSON routes
+// from APIRoutes and no /api pattern of its own, so every /api route on the
+// mux has a spec entry.
+func TestAPIRoutesRegistered(t *testing.T) {
+	h := newTestHandler(t, nil)
+	documented := map[string]bool{}
+	for _, rt := range h.APIRoutes() {
+		documented[rt.Method+" "+rt.Path] = true
+	}
+
+	file, err := parser.ParseFile(token.NewFileSet(), "../../cmd/server/main.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rangesRoutes := false
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.RangeStmt:
+			if call, ok := n.X.(*ast.CallExpr); ok {
+				if sel, ok := call.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "APIRoutes" {
+					rangesRoutes = true
+				}
+			}
+		case *ast.CallExpr:
+			sel, ok := n.Fun.(*ast.SelectorExpr)
+			if !ok || (sel.Sel.Name != "HandleFunc" && sel.Sel.Name != "Handle") || len(n.Args) == 0 {
+				return true
+			}
+			lit, ok := n.Args[0].(*ast.BasicLit)
+			if !ok || lit.Kind != token.STRING {
+				return true
+			}
+			pattern, err := strconv.Unquote(lit.Value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, path, found := strings.Cut(pattern, " ")
+			if !found {
+				path = pattern
+			}
+			if path == "/api/" || !strings.HasPrefix(path, "/api/") {
+				return true
+			}
+			if documented[pattern] {
+				t.Errorf("%s is in APIRoutes but main.go registers it again", pattern)
+			} else {
+				t.Errorf("%s is registered in main.go but has no APIRoutes entry", pattern)
+			}
+		}
+		return true
+	})
+	if !rangesRoutes {
+		t.Error("main.go does not register the routes of h.APIRoutes()")
+	}
+}
+
+// TestAPIResponsesMatchSpec serves every APIRoute, on success and on its
+// errors, and fails on each response the OpenAPI document does not describe.
+func TestAPIResponsesMatchSpec(t *testing.T) {
+	store := newStubStore()
+	h := newTestHandler(t, store)
+	spec, err := h.OpenAPI()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mismatches []error
+	report := func(r *http.Request, err error) {
+		mismatches = append(mismatches, err)
+	}
+	served := map[string]bool{}
+	mux := http.NewServeMux()
+	for _, rt := range h.APIRoutes() {
+		pattern := rt.Method + " " + rt.Path
+		validated := spec.ValidateResponses(rt.Method, rt.Path, rt.Handler, report)
+		mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+			served[pattern] = true
+			validated(w, r)
+		})
+	}
+	mux.HandleFunc("/api/", h.APINotFound)
+	srv := middleware.APITokens(store, h.APIInvalidToken, spec.ValidateRoutes(mux, report))
+
+	rec := httptest.NewRecorder()
+	auth.SetSessionCookie(rec, stubSession, h.keys)
+	sessionCookies := rec.Result().Cookies()
+
+	game, club, rental, unknown := store.game.ID.String(), store.club.ID.String(), store.rental.RentalID.String(), uuid.NewString()
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		as     string // stubFullToken, stubCatalogToken, stubSession or "" for anonymous.
+		want   int
+	}{
+		{"platforms", "GET", "/api/v1/platforms", "", "", http.StatusOK},
+		{"platforms bad page", "GET", "/api/v1/platforms?page=0", "", "", http.StatusBadRequest},
+		{"platforms page overflow", "GET", "/api/v1/platforms?page=9223372036854775807&per_page=20", "", "", http.StatusBadRequest},
+		{"games", "GET", "/api/v1/games?platform=Genesis&available=true", "", stubCatalogToken, http.StatusOK},
+		{"games bad year", "GET", "/api/v1/games?year=soon", "", "", http.StatusBadRequest},
+		{"game", "GET", "/api/v1/games/" + game, "", "", http.StatusOK},
+		{"game missing", "GET", "/api/v1/games/" + unknown, "", "", http.StatusNotFound},
+		{"clubs", "GET", "/api/v1/clubs", "", stubSession, http.StatusOK},
+		{"club", "GET", "/api/v1/clubs/" + club, "", stubFullToken, http.StatusOK},
+		{"club missing", "GET", "/api/v1/clubs/" + unknown, "", "", http.StatusNotFound},
+		{"create challenge", "POST", "/api/v1/clubs/" + club + "/challenges",
+			`{"game_id":"` + game + `","starts_at":"2026-01-01","ends_at":"2026-01-31"}`, stubFullToken, http.StatusCreated},
+		{"create challenge bad dates", "POST", "/api/v1/clubs/" + club + "/challenges",
+			`{"game_id":"` + game + `","starts_at":"2026-01-31","ends_at":"2026-01-01"}`, stubFullToken, http.StatusUnprocessableEntity},
+		{"create challenge anonymous", "POST", "/api/v1/clubs/" + club + "/challenges", `{}`, "", http.StatusUnauthorized},
+		{"create challenge scope", "POST", "/api/v1/clubs/" + club + "/challenges", `{}`, stubCatalogToken, http.StatusForbidden},
+		{"activities", "GET", "/api/v1/activities?per_page=10", "", "", http.StatusOK},
+		{"me token", "GET", "/api/v1/me", "", stubFullToken, http.StatusOK},
+		{"me session", "GET", "/api/v1/me", "", stubSession, http.StatusOK},
+		{"me anonymous", "GET", "/api/v1/me", "", "", http.StatusUnauthorized},
+		{"my rentals", "GET", "/api/v1/me/rentals?status=all", "", stubFullToken, http.StatusOK},
+		{"my rentals bad status", "GET", "/api/v1/me/rentals?status=late", "", stubFullToken, http.StatusBadRequest},
+		{"rent", "POST", "/api/v1/rentals", `{"game_id":"` + game + `"}`, stubFullToken, http.StatusCreated},
+		{"rent bad body", "POST", "/api/v1/rentals", `[`, stubFullToken, http.StatusBadRequest},
+		{"rent bad game", "POST", "/api/v1/rentals", `{"game_id":"sonic"}`, stubFullToken, http.StatusUnprocessableEntity},
+		{"rent missing game", "POST", "/api/v1/rentals", `{"game_id":"` + unknown + `"}`, stubFullToken, http.StatusNotFound},
+		{"return", "POST", "/api/v1/rentals/" + rental + "/return", `{"verdict":"completed"}`, stubFullToken, http.StatusOK},
+		{"return bad verdict", "POST", "/api/v1/rentals/" + rental + "/return", `{"verdict":"meh"}`, stubFullToken, http.StatusUnprocessableEntity},
+		{"return missing", "POST", "/api/v1/rentals/" + unknown + "/return", "", stubFullToken, http.StatusNotFound},
+		{"openapi", "GET", "/api/openapi.json", "", "", http.StatusOK},
+		{"sign up", "POST", "/members",
+			`{"profile_name":"newbie","email":"newbie@example.com","password":"blast9processing"}`, "", http.StatusCreated},
+		{"sign up invalid", "POST", "/members", `{"profile_name":"x"}`, "", http.StatusUnprocessableEntity},
+		{"sign up malformed", "POST", "/members", `{`, "", http.StatusBadRequest},
+		{"search without q", "GET", "/search", "", "", http.StatusBadRequest},
+		{"search without igdb", "GET", "/search?q=sonic", "", "", http.StatusServiceUnavailable},
+		{"export", "GET", "/membership/export", "", stubSession, http.StatusOK},
+		{"export anonymous", "GET", "/membership/export", "", "", http.StatusSeeOther},
+		{"unknown api path", "GET", "/api/v1/nothing", "", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mismatches = nil
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			switch tt.as {
+			case "":
+			case stubSession:
+				for _, c := range sessionCookies {
+					req.AddCookie(c)
+				}
+			default:
+				req.Header.Set("Authorization", "Bearer "+tt.as)
+			}
+			rec := httptest.NewRecorder()
+			srv.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d; body: %s", rec.Code, tt.want, rec.Body)
+			}
+			for _, err := range mismatches {
+				t.Errorf("response does not match the spec: %v", err)
+			}
+		})
+	}
+
+	for _, rt := range h.APIRoutes() {
+		if pattern := rt.Method + " " + rt.Path; !served[pattern] {
+			t.Errorf("%s has no test case", pattern)
+		}
+	}
+}
+
+// TestValidateReportsMismatches checks that the validators used above do
+// fail, on a body that breaks the spec and on an undocumented JSON route.
+func TestValidateReportsMismatches(t *testing.T) {
+	h := newTestHandler(t, newStubStore())
+	spec, err := h.OpenAPI()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mismatches []error
+	report := func(r *http.Request, err error) {
+		mismatches = append(mismatches, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/me", spec.ValidateResponses("GET", "/api/v1/me", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]int{"data": 1})
+	}, report))
+	mux.HandleFunc("GET /api/v1/secret", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]int{"data": 1})
+	})
+	srv := spec.ValidateRoutes(mux, report)
+
+	srv.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/v1/me", nil))
+	if len(mismatches) != 1 {
+		t.Errorf("wrong body: got %d mismatches, want 1", len(mismatches))
+	}
+
+	mismatches = nil
+	srv.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/v1/secret", nil))
+	if len(mismatches) != 1 || !errors.Is(mismatches[0], openapi.ErrUndocumented) {
+		t.Errorf("undocumented route: got %v, want ErrUndocumented", mismatches)
+	}
+}
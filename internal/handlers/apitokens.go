@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"html/template"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/cmellojr/modo-locadora/internal/auth"
+	"github.com/cmellojr/modo-locadora/internal/models"
+	"github.com/google/uuid"
+)
+
+// ── API token handlers ──────────────────────────────────────────────────────
+
+// Personal API token limits.
+const (
+	maxAPITokens       = 20 // Active tokens per member.
+	maxAPITokenNameLen = 40
+)
+
+// apiTokenExpiryDays are the expiry choices on the token form; 0 means never.
+var apiTokenExpiryDays = []int{30, 90, 365, 0}
+
+// APITokenView represents a personal API token for display.
+type APITokenView struct {
+	ID        uuid.UUID
+	Name      string
+	Scopes    []string // Portuguese labels.
+	CreatedAt time.Time
+	ExpiresAt *time.Time
+	Expired   bool
+	LastUsed  string
+}
+
+// APIScopeOption is a scope checkbox on the token form.
+type APIScopeOption struct {
+	Value string
+	Label string
+}
+
+// APITokensPage handles GET /membership/tokens.
+func (h *Handler) APITokensPage(w http.ResponseWriter, r *http.Request, tmpl *template.Template) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	member := h.currentMember(w, r)
+	if member == nil {
+		return
+	}
+
+	h.renderAPITokens(w, r, tmpl, member, nil, "", http.StatusOK)
+}
+
+// renderAPITokens renders the token page. newToken is only passed right
+// after creation: it is never stored and cannot be shown again.
+func (h *Handler) renderAPITokens(w http.ResponseWriter, r *http.Request, tmpl *template.Template, member *models.Member, errs FieldErrors, newToken string, status int) {
+	ld := h.buildLayoutData(r, "Tokens de API")
+
+	tokens, err := h.store.ListMemberAPITokens(r.Context(), member.ID)
+	if err != nil {
+		http.Error(w, "Failed to list tokens: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	views := make([]APITokenView, 0, len(tokens))
+	for _, t := range tokens {
+		labels := make([]string, 0, len(t.Scopes))
+		for _, s := range t.Scopes {
+			labels = append(labels, models.ScopeLabel(s))
+		}
+		lastUsed := "nunca"
+		if t.LastUsedAt != nil {
+			lastUsed = formatTimeAgo(*t.LastUsedAt)
+		}
+		views = append(views, APITokenView{
+			ID:        t.ID,
+			Name:      t.Name,
+			Scopes:    labels,
+			CreatedAt: t.CreatedAt,
+			ExpiresAt: t.ExpiresAt,
+			Expired:   t.IsExpired(now),
+			LastUsed:  lastUsed,
+		})
+	}
+
+	scopes := make([]APIScopeOption, 0, len(models.APITokenScopes))
+	for _, s := range models.APITokenScopes {
+		scopes = append(scopes, APIScopeOption{Value: s, Label: models.ScopeLabel(s)})
+	}
+
+	form := r.PostForm
+	checked := map[string]bool{}
+	for _, s := range form["scope"] {
+		checked[s] = true
+	}
+
+	data := struct {
+		LayoutData
+		Tokens      []APITokenView
+		NewToken    string
+		Scopes      []APIScopeOption
+		ExpiryDays  []int
+		FormName    string
+		FormScopes  map[string]bool
+		FormExpires string
+		Errors      FieldErrors
+		Success     string
+	}{
+		LayoutData:  ld,
+		Tokens:      views,
+		NewToken:    newToken,
+		Scopes:      scopes,
+		ExpiryDays:  apiTokenExpiryDays,
+		FormName:    form.Get("name"),
+		FormScopes:  checked,
+		FormExpires: form.Get("expires"),
+		Errors:      errs,
+		Success:     r.URL.Query().Get("success"),
+	}
+
+	w.WriteHeader(status)
+	if err := tmpl.Execute(w, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// CreateAPIToken handles POST /membership/tokens. Fields: name, scope
+// (repeated), expires (days, or 0 for never) and password.
+func (h *Handler) CreateAPIToken(w http.ResponseWriter, r *http.Request, tmpl *template.Template) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	member := h.currentMember(w, r)
+	if member == nil {
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+
+	errs := FieldErrors{}
+	name := strings.TrimSpace(r.PostForm.Get("name"))
+	switch {
+	case name == "":
+		errs["name"] = "Dê um nome para lembrar onde o token é usado."
+	case utf8.RuneCountInString(name) > maxAPITokenNameLen:
+		errs["name"] = "Use no máximo " + strconv.Itoa(maxAPITokenNameLen) + " caracteres."
+	}
+
+	var scopes []string
+	for _, s := range models.APITokenScopes {
+		if slices.Contains(r.PostForm["scope"], s) {
+			scopes = append(scopes, s)
+		}
+	}
+	if len(scopes) == 0 {
+		errs["scope"] = "Marque pelo menos uma permissão."
+	}
+
+	days, err := strconv.Atoi(r.PostForm.Get("expires"))
+	if err != nil || !slices.Contains(apiTokenExpiryDays, days) {
+		errs["expires"] = "Escolha uma validade."
+	}
+
+	if len(errs) == 0 {
+		if msg := h.checkCurrentPassword(member, r.PostForm.Get("password")); msg != "" {
+			errs["password"] = msg
+		}
+	}
+	if len(errs) == 0 {
+		existing, err := h.store.ListMemberAPITokens(r.Context(), member.ID)
+		if err != nil {
+			http.Error(w, "Failed to list tokens: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if len(existing) >= maxAPITokens {
+			errs["name"] = "Limite de " + strconv.Itoa(maxAPITokens) + " tokens. Revogue algum antes de criar outro."
+		}
+	}
+	if len(errs) > 0 {
+		h.renderAPITokens(w, r, tmpl, member, errs, "", http.StatusUnprocessableEntity)
+		return
+	}
+
+	raw, err := auth.NewAPIToken()
+	if err != nil {
+		http.Error(w, "Failed to generate token", http.StatusInternalServerError)
+		return
+	}
+	now := time.Now()
+	token := &models.APIToken{
+		ID:        uuid.New(),
+		MemberID:  member.ID,
+		Name:      name,
+		TokenHash: auth.HashToken(raw),
+		Scopes:    scopes,
+		CreatedAt: now,
+	}
+	if days > 0 {
+		expires := now.AddDate(0, 0, days)
+		token.ExpiresAt = &expires
+	}
+	if err := h.store.CreateAPIToken(r.Context(), token); err != nil {
+		http.Error(w, "Failed to create token: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.recordSecurityEvent(r, models.SecurityEventAPITokenCreated, &member.ID, member.ProfileName,
+		name+" ("+strings.Join(scopes, " ")+")")
+
+	// Clear the form so the page does not offer the same token again.
+	r.PostForm = nil
+	h.renderAPITokens(w, r, tmpl, member, nil, raw, http.StatusOK)
+}
+
+// RevokeAPIToken handles POST /membership/tokens/{id}/revoke.
+func (h *Handler) RevokeAPIToken(w http.ResponseWriter, r *http.Request) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	member := h.currentMember(w, r)
+	if member == nil {
+		return
+	}
+
+	tokenID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid token ID", http.StatusBadRequest)
+		return
+	}
+
+	revoked, err := h.store.RevokeAPIToken(r.Context(), tokenID, member.ID)
+	if err != nil {
+		http.Error(w, "Failed to revoke token: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !revoked {
+		http.Error(w, "Token not found", http.StatusNotFound)
+		return
+	}
+	h.recordSecurityEvent(r, models.SecurityEventAPITokenRevoked, &member.ID, member.ProfileName, tokenID.String())
+
+	http.Redirect(w, r, "/membership/tokens?success=revoked", http.StatusSeeOther)
+}
@@ -0,0 +1,481 @@
+package handlers
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cmellojr/modo-locadora/internal/database"
+	"github.com/cmellojr/modo-locadora/internal/igdb"
+	"github.com/cmellojr/modo-locadora/internal/metadata"
+	"github.com/cmellojr/modo-locadora/internal/models"
+	"github.com/google/uuid"
+)
+
+// ── Bulk stocking handlers ──────────────────────────────────────────────────
+
+const (
+	maxStockList    = 1 << 20 // Bytes of an uploaded list.
+	maxStockTitles  = 100     // Titles per list.
+	maxStockCopies  = 99      // Copies of one title.
+	stockCandidates = 5       // Matches offered per title.
+	stockWorkers    = 4       // Titles searched at once; IGDB allows 4 requests per second.
+)
+
+// Choices on the review table besides a candidate's ID.
+const (
+	stockChoiceManual = "manual" // Stock with the listed title and no metadata.
+	stockChoiceSkip   = "skip"
+)
+
+// stockRow is one title on the bulk stocking review table.
+type stockRow struct {
+	Index      int
+	Query      string // Title as listed, searched in the provider.
+	Platform   string
+	Copies     int
+	Candidates []metadata.Candidate
+	Choice     string // A candidate ID, stockChoiceManual or stockChoiceSkip.
+	Error      string // Why the search failed.
+}
+
+// Number returns the row's position counting from 1.
+func (r stockRow) Number() int {
+	return r.Index + 1
+}
+
+// Best returns the highest scored candidate, or nil.
+func (r stockRow) Best() *metadata.Candidate {
+	if len(r.Candidates) == 0 {
+		return nil
+	}
+	return &r.Candidates[0]
+}
+
+// listMarker matches bullets and numbering in front of a listed title.
+var listMarker = regexp.MustCompile(`^(?:[-*•]|\d{1,3}[.)-])\s+`)
+
+// titlePlatform matches "Title (Platform)".
+var titlePlatform = regexp.MustCompile(`^(.+?)\s*\(([^()]+)\)$`)
+
+// parseStockList reads a list of titles, one per line, as
+// "Title; Platform; Copies" (also tab-separated) or "Title (Platform)".
+// A line ending in a colon, such as "Mega Drive:", sets the platform of the
+// lines after it. Blank lines and lines starting with # are skipped, and
+// bullets or numbering in front of titles are dropped. Errors are shown to
+// staff as they are.
+func parseStockList(text string) ([]stockRow, error) {
+	var rows []stockRow
+	section := ""
+	sc := bufio.NewScanner(strings.NewReader(strings.TrimPrefix(text, "\ufeff")))
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasSuffix(line, ":") && !strings.ContainsAny(line, ";\t") {
+			section = strings.TrimSpace(strings.TrimSuffix(line, ":"))
+			continue
+		}
+
+		row := stockRow{Platform: section, Copies: 1}
+		fields := strings.Split(strings.ReplaceAll(line, "\t", ";"), ";")
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		switch {
+		case len(fields) > 3:
+			return nil, fmt.Errorf("linha %d: use no máximo três campos, título; plataforma; cópias", n)
+		case len(fields) > 1:
+			row.Query = fields[0]
+			if fields[1] != "" {
+				row.Platform = fields[1]
+			}
+			if len(fields) == 3 && fields[2] != "" {
+				copies, err := strconv.Atoi(fields[2])
+				if err != nil || copies < 1 || copies > maxStockCopies {
+					return nil, fmt.Errorf("linha %d: a quantidade de cópias precisa ser de 1 a %d", n, maxStockCopies)
+				}
+				row.Copies = copies
+			}
+		case len(fields) == 1:
+			row.Query = fields[0]
+			if m := titlePlatform.FindStringSubmatch(row.Query); m != nil {
+				row.Query, row.Platform = m[1], strings.TrimSpace(m[2])
+			}
+		}
+		row.Query = strings.TrimSpace(listMarker.ReplaceAllString(row.Query, ""))
+		if row.Query == "" {
+			return nil, fmt.Errorf("linha %d: falta o título", n)
+		}
+		if len([]rune(row.Query)) > 200 {
+			return nil, fmt.Errorf("linha %d: título longo demais", n)
+		}
+
+		if len(rows) == maxStockTitles {
+			return nil, fmt.Errorf("a lista passa de %d títulos; divida em mais de um lote", maxStockTitles)
+		}
+		row.Index = len(rows)
+		rows = append(rows, row)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("não deu para ler a lista: %v", err)
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("a lista está vazia")
+	}
+	return rows, nil
+}
+
+// stockRowsFromForm reads the rows of a submitted review table.
+func stockRowsFromForm(r *http.Request) ([]stockRow, error) {
+	n, err := strconv.Atoi(r.PostForm.Get("rows"))
+	if err != nil || n < 1 || n > maxStockTitles {
+		return nil, errors.New("invalid review table")
+	}
+	rows := make([]stockRow, n)
+	for i := range rows {
+		field := func(name string) string {
+			return strings.TrimSpace(r.PostForm.Get(name + "_" + strconv.Itoa(i)))
+		}
+		copies, err := strconv.Atoi(field("copies"))
+		if err != nil || copies < 1 {
+			copies = 1
+		}
+		rows[i] = stockRow{
+			Index:    i,
+			Query:    field("query"),
+			Platform: field("platform"),
+			Copies:   min(copies, maxStockCopies),
+			Choice:   field("choice"),
+		}
+	}
+	return rows, nil
+}
+
+// matchStockRows searches provider for every row, a few at a time. Row and
+// candidate platforms are shown under their registry names. A row keeps its
+// choice while that candidate is still found; otherwise the best candidate
+// is chosen unless its confidence is low.
+func matchStockRows(r *http.Request, provider metadata.Provider, registry models.Platforms, rows []stockRow) {
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(stockWorkers, len(rows)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				row := &rows[i]
+				row.Platform = registry.Canonical(row.Platform)
+				if row.Query == "" {
+					row.Choice = stockChoiceSkip
+					continue
+				}
+				candidates, err := metadata.Match(r.Context(), provider, row.Query, row.Platform, registry.Canonical, stockCandidates)
+				if err != nil {
+					log.Printf("[%s] Match %q failed: %v", provider.Source(), row.Query, err)
+					row.Error = "A busca falhou."
+					if errors.Is(err, igdb.ErrRateLimited) {
+						row.Error = "O IGDB pediu calma."
+					}
+				}
+				for j := range candidates {
+					canonicalPlatforms(registry, &candidates[j].Game)
+				}
+				row.Candidates = candidates
+				row.Choice = defaultStockChoice(*row)
+			}
+		}()
+	}
+	for i := range rows {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// defaultStockChoice returns the row's choice if it is still valid, or the
+// choice to suggest.
+func defaultStockChoice(row stockRow) string {
+	switch row.Choice {
+	case stockChoiceManual, stockChoiceSkip:
+		return row.Choice
+	}
+	for _, c := range row.Candidates {
+		if c.ID == row.Choice {
+			return row.Choice
+		}
+	}
+	if best := row.Best(); best != nil && best.Confidence() != metadata.ConfidenceLow {
+		return best.ID
+	}
+	return stockChoiceManual
+}
+
+// stockSummary titles the feed event of a batch, such as "Sonic, Golden
+// Axe, Ecco e mais 9 (Ação Games #12)".
+func stockSummary(titles []string, magazine string) string {
+	var s string
+	switch n := len(titles); {
+	case n == 1:
+		s = titles[0]
+	case n <= 3:
+		s = strings.Join(titles[:n-1], ", ") + " e " + titles[n-1]
+	default:
+		s = fmt.Sprintf("%s e mais %d", strings.Join(titles[:3], ", "), n-3)
+	}
+	if magazine != "" {
+		s += " (" + magazine + ")"
+	}
+	return s
+}
+
+// BulkStockPage handles GET /admin/stock/bulk: the form for a list of titles
+// from a magazine issue.
+func (h *Handler) BulkStockPage(w http.ResponseWriter, r *http.Request, tmpl *template.Template) {
+	h.renderBulkStock(w, r, tmpl, r.URL.Query().Get("magazine"), r.URL.Query().Get("source"), "", nil, "", http.StatusOK)
+}
+
+// renderBulkStock renders the bulk stocking page: the list form when rows
+// is empty, the review table otherwise. errMsg explains why the last step
+// was refused.
+func (h *Handler) renderBulkStock(w http.ResponseWriter, r *http.Request, tmpl *template.Template,
+	magazine, source, list string, rows []stockRow, errMsg string, status int) {
+	registry, err := h.platformRegistry(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load platforms: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	providers := h.providers()
+	if source == "" && len(providers) > 0 {
+		source = providers[0].Source()
+	}
+
+	matched := 0
+	for _, row := range rows {
+		if row.Choice != stockChoiceSkip {
+			matched++
+		}
+	}
+
+	data := struct {
+		LayoutData
+		Magazine  string
+		Source    string
+		Providers []metadata.Provider
+		List      string
+		Rows      []stockRow
+		Platforms models.Platforms
+		ToStock   int
+		Error     string
+		Stocked   string
+	}{
+		LayoutData: h.buildLayoutData(r, "Abastecer por Revista"),
+		Magazine:   magazine,
+		Source:     source,
+		Providers:  providers,
+		List:       list,
+		Rows:       rows,
+		Platforms:  registry,
+		ToStock:    matched,
+		Error:      errMsg,
+		Stocked:    r.URL.Query().Get("stocked"),
+	}
+
+	w.WriteHeader(status)
+	if err := tmpl.Execute(w, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// BulkStockReview handles POST /admin/stock/bulk/review. Fields: magazine,
+// source, and either list (pasted text) or list_file (a .txt or .csv
+// upload) for a new list, or the rows of the review table to search again
+// after corrections. Renders the review table with the matches found.
+func (h *Handler) BulkStockReview(w http.ResponseWriter, r *http.Request, tmpl *template.Template) {
+	// The review table is posted urlencoded; the cap matches the one
+	// net/http puts on those bodies.
+	if err := parseUploadForm(w, r, maxFormMemory); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		http.Error(w, "Failed to process form", http.StatusBadRequest)
+		return
+	}
+	defer removeUploads(r)
+	magazine := strings.TrimSpace(r.FormValue("magazine"))
+	source := r.FormValue("source")
+	list := r.FormValue("list")
+
+	var rows []stockRow
+	if r.PostForm.Has("rows") {
+		var err error
+		if rows, err = stockRowsFromForm(r); err != nil {
+			http.Error(w, "Invalid review table", http.StatusBadRequest)
+			return
+		}
+	} else {
+		if file, header, err := r.FormFile("list_file"); err == nil {
+			defer file.Close()
+			if header.Size > maxStockList {
+				h.renderBulkStock(w, r, tmpl, magazine, source, list, nil, "Arquivo grande demais: o limite é de 1 MB.", http.StatusUnprocessableEntity)
+				return
+			}
+			data, err := io.ReadAll(io.LimitReader(file, maxStockList))
+			if err != nil {
+				http.Error(w, "Failed to read list: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			list = string(data)
+		}
+		var err error
+		if rows, err = parseStockList(list); err != nil {
+			h.renderBulkStock(w, r, tmpl, magazine, source, list, nil, "Lista recusada: "+err.Error()+".", http.StatusUnprocessableEntity)
+			return
+		}
+	}
+
+	provider := h.provider(source)
+	if provider == nil {
+		h.renderBulkStock(w, r, tmpl, magazine, source, list, nil,
+			"Essa fonte não está disponível. Para o IGDB, defina TWITCH_CLIENT_ID e TWITCH_CLIENT_SECRET; sem internet, use o catálogo local.",
+			http.StatusUnprocessableEntity)
+		return
+	}
+	registry, err := h.platformRegistry(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load platforms: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	matchStockRows(r, provider, registry, rows)
+
+	h.renderBulkStock(w, r, tmpl, magazine, source, list, rows, "", http.StatusOK)
+}
+
+// BulkStock handles POST /admin/stock/bulk. Fields: magazine, source, rows,
+// and query_N, platform_N, copies_N and choice_N for each row of the review
+// table. Creates every game that was not skipped, with its copies, and one
+// summarized new_game feed event, all in one transaction.
+func (h *Handler) BulkStock(w http.ResponseWriter, r *http.Request, tmpl *template.Template) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+	magazine := strings.TrimSpace(r.PostForm.Get("magazine"))
+	source := r.PostForm.Get("source")
+	rows, err := stockRowsFromForm(r)
+	if err != nil {
+		http.Error(w, "Invalid review table", http.StatusBadRequest)
+		return
+	}
+	provider := h.provider(source)
+	registry, err := h.platformRegistry(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load platforms: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// refuse shows the table again, searched anew, with the reason.
+	refuse := func(msg string) {
+		if provider != nil {
+			matchStockRows(r, provider, registry, rows)
+		}
+		h.renderBulkStock(w, r, tmpl, magazine, source, "", rows, msg, http.StatusUnprocessableEntity)
+	}
+
+	now := time.Now()
+	var items []database.StockItem
+	var titles []string
+	for _, row := range rows {
+		if row.Choice == stockChoiceSkip || row.Choice == "" {
+			continue
+		}
+		if row.Query == "" {
+			refuse(fmt.Sprintf("A linha %d está sem título. Preencha ou marque para pular.", row.Index+1))
+			return
+		}
+
+		// Platforms must be in the registry. A row without one takes the
+		// first known platform of its game.
+		game := &models.Game{
+			ID:             uuid.New(),
+			Title:          row.Query,
+			SourceMagazine: magazine,
+			AcquiredAt:     now,
+		}
+		if row.Platform != "" {
+			p := registry.Resolve(row.Platform)
+			if p == nil {
+				refuse(fmt.Sprintf("A plataforma %q da linha %d não está no cadastro de plataformas.", row.Platform, row.Index+1))
+				return
+			}
+			game.Platform = p.Name
+		}
+		if row.Choice != stockChoiceManual {
+			if provider == nil {
+				refuse("Essa fonte não está mais disponível. Tente de novo ou escolha outra fonte.")
+				return
+			}
+			data, err := provider.GetGame(r.Context(), row.Choice)
+			if err != nil {
+				log.Printf("[%s] Game %s failed: %v", source, row.Choice, err)
+				refuse(fmt.Sprintf("Não deu para carregar a ficha de %q. Tente de novo.", row.Query))
+				return
+			}
+			if data == nil {
+				refuse(fmt.Sprintf("A ficha escolhida para %q não existe mais. Escolha outra.", row.Query))
+				return
+			}
+			game.MetadataSource, game.MetadataID = provider.Source(), data.ID
+			applyMetadata(game, data)
+			if game.Platform == "" {
+				canonicalPlatforms(registry, data)
+				game.Platform = knownPlatform(registry, data)
+			}
+		}
+		if game.Platform == "" {
+			refuse(fmt.Sprintf("Escolha a plataforma da linha %d: a ficha não traz nenhuma do cadastro.", row.Index+1))
+			return
+		}
+
+		items = append(items, database.StockItem{Game: game, Copies: row.Copies})
+		titles = append(titles, game.Title)
+	}
+	if len(items) == 0 {
+		refuse("Nenhuma fita para abastecer: todas as linhas estão marcadas para pular.")
+		return
+	}
+
+	// Covers download a few at a time, like the searches.
+	sem := make(chan struct{}, stockWorkers)
+	var wg sync.WaitGroup
+	for _, it := range items {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			h.mirrorCover(r.Context(), it.Game)
+			<-sem
+		}()
+	}
+	wg.Wait()
+	if err := h.store.StockGames(r.Context(), items, stockSummary(titles, magazine)); err != nil {
+		for _, it := range items {
+			removeReplaced(r.Context(), h.covers, []string{it.Game.CoverURL, it.Game.CoverThumbURL}, nil)
+		}
+		http.Error(w, "Failed to stock games: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/admin/stock/bulk?stocked="+strconv.Itoa(len(items)), http.StatusSeeOther)
+}
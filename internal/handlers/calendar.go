@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cmellojr/modo-locadora/internal/auth"
+	"github.com/cmellojr/modo-locadora/internal/ical"
+	"github.com/cmellojr/modo-locadora/internal/models"
+)
+
+// ── Calendar feed handlers ──────────────────────────────────────────────────
+
+// Calendar feed settings.
+const (
+	calendarRefresh     = time.Hour
+	calendarDueLead     = 30 * time.Minute // Length of the due-date event, ending at the deadline.
+	calendarDueReminder = 24 * time.Hour
+	calendarLastCall    = 2 * time.Hour
+)
+
+// CalendarPage handles GET /membership/calendar.
+func (h *Handler) CalendarPage(w http.ResponseWriter, r *http.Request, tmpl *template.Template) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	member := h.currentMember(w, r)
+	if member == nil {
+		return
+	}
+
+	h.renderCalendar(w, r, tmpl, member, "")
+}
+
+// renderCalendar renders the calendar page. newToken is only passed right
+// after the link is created: it is never stored and cannot be shown again.
+func (h *Handler) renderCalendar(w http.ResponseWriter, r *http.Request, tmpl *template.Template, member *models.Member, newToken string) {
+	ld := h.buildLayoutData(r, "Agenda")
+
+	f, err := h.store.GetCalendarFeed(r.Context(), member.ID)
+	if err != nil {
+		http.Error(w, "Failed to load calendar feed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var feedURL, webcalURL, lastUsed string
+	if newToken != "" {
+		feedURL = h.absoluteURL(r, "/calendar/"+newToken+".ics")
+		webcalURL = "webcal://" + feedURL[strings.Index(feedURL, "://")+3:]
+	}
+	if f != nil {
+		lastUsed = "nunca"
+		if f.LastUsedAt != nil {
+			lastUsed = formatTimeAgo(*f.LastUsedAt)
+		}
+	}
+
+	data := struct {
+		LayoutData
+		Feed      *models.CalendarFeed
+		FeedURL   string
+		WebcalURL string
+		LastUsed  string
+		Success   string
+	}{
+		LayoutData: ld,
+		Feed:       f,
+		FeedURL:    feedURL,
+		WebcalURL:  webcalURL,
+		LastUsed:   lastUsed,
+		Success:    r.URL.Query().Get("success"),
+	}
+
+	if err := tmpl.Execute(w, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// CreateCalendarFeed handles POST /membership/calendar. Field:
+// include_challenges. Creates the member's private calendar link, replacing
+// any previous one, and shows it once.
+func (h *Handler) CreateCalendarFeed(w http.ResponseWriter, r *http.Request, tmpl *template.Template) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	member := h.currentMember(w, r)
+	if member == nil {
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+
+	token, err := auth.NewToken()
+	if err != nil {
+		http.Error(w, "Failed to generate token", http.StatusInternalServerError)
+		return
+	}
+	f := &models.CalendarFeed{
+		MemberID:          member.ID,
+		TokenHash:         auth.HashToken(token),
+		IncludeChallenges: r.PostForm.Get("include_challenges") == "on",
+		CreatedAt:         time.Now(),
+	}
+	if err := h.store.SaveCalendarFeed(r.Context(), f); err != nil {
+		http.Error(w, "Failed to save calendar feed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.renderCalendar(w, r, tmpl, member, token)
+}
+
+// UpdateCalendarFeed handles POST /membership/calendar/settings. Field:
+// include_challenges.
+func (h *Handler) UpdateCalendarFeed(w http.ResponseWriter, r *http.Request) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	member := h.currentMember(w, r)
+	if member == nil {
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+
+	include := r.PostForm.Get("include_challenges") == "on"
+	if err := h.store.SetCalendarFeedChallenges(r.Context(), member.ID, include); err != nil {
+		http.Error(w, "Failed to update calendar feed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/membership/calendar?success=updated", http.StatusSeeOther)
+}
+
+// DeleteCalendarFeed handles POST /membership/calendar/delete. The link
+// stops working; subscribed calendars keep their last copy.
+func (h *Handler) DeleteCalendarFeed(w http.ResponseWriter, r *http.Request) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	member := h.currentMember(w, r)
+	if member == nil {
+		return
+	}
+
+	if err := h.store.DeleteCalendarFeed(r.Context(), member.ID); err != nil {
+		http.Error(w, "Failed to delete calendar feed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/membership/calendar?success=deleted", http.StatusSeeOther)
+}
+
+// CalendarFeed handles GET /calendar/{file}, where file is "<token>.ics":
+// the member's due dates, and optionally their club challenges, as an
+// iCalendar document. The token is the only credential.
+func (h *Handler) CalendarFeed(w http.ResponseWriter, r *http.Request) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	token, ok := strings.CutSuffix(r.PathValue("file"), ".ics")
+	if !ok || token == "" {
+		http.NotFound(w, r)
+		return
+	}
+	f, err := h.store.GetCalendarFeedByToken(r.Context(), auth.HashToken(token))
+	if err != nil {
+		http.Error(w, "Failed to load calendar feed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if f == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	rentals, err := h.store.ListCalendarRentals(r.Context(), f.MemberID)
+	if err != nil {
+		http.Error(w, "Failed to load rentals: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	cal := ical.Calendar{
+		ProdID:      "-//Modo Locadora//Agenda//PT",
+		Name:        "Modo Locadora",
+		Description: "Prazos de devolução das suas fitas.",
+		Refresh:     calendarRefresh,
+	}
+	membershipURL := h.absoluteURL(r, "/membership")
+	for _, rental := range rentals {
+		cal.Events = append(cal.Events, ical.Event{
+			UID:     "rental-" + rental.RentalID.String() + "@modo-locadora",
+			Summary: "Devolver " + rental.GameTitle + " (" + rental.Platform + ")",
+			Description: "Prazo da fita alugada em " + rental.RentedAt.Format("02/01/2006") +
+				". Depois disso o Fiscal Automático devolve a fita e seu nome vai para o Painel da Vergonha.",
+			URL:    membershipURL,
+			Start:  rental.DueAt.Add(-calendarDueLead),
+			End:    rental.DueAt,
+			Alarms: []time.Duration{calendarDueReminder, calendarLastCall},
+		})
+	}
+
+	if f.IncludeChallenges {
+		challenges, err := h.store.ListCalendarChallenges(r.Context(), f.MemberID)
+		if err != nil {
+			http.Error(w, "Failed to load challenges: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		for _, c := range challenges {
+			cal.Events = append(cal.Events, ical.Event{
+				UID:         "challenge-" + c.ChallengeID.String() + "@modo-locadora",
+				Summary:     "Desafio da turma " + c.ClubName + ": " + c.GameTitle,
+				Description: "Zere " + c.GameTitle + " (" + c.Platform + ") antes do fim do desafio.",
+				URL:         h.absoluteURL(r, "/clubs/"+c.ClubID.String()),
+				Start:       c.StartsAt,
+				End:         c.EndsAt,
+				Alarms:      []time.Duration{calendarDueReminder},
+			})
+		}
+	}
+
+	w.Header().Set("Content-Type", ical.ContentType)
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.Write(ical.Encode(cal, time.Now()))
+}
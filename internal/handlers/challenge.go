@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/cmellojr/modo-locadora/internal/models"
+	"github.com/google/uuid"
+)
+
+// ── Club challenge handlers ─────────────────────────────────────────────────
+
+// challengeDateLayout is the format of the HTML date inputs on the challenge form.
+const challengeDateLayout = "2006-01-02"
+
+// CreateClubChallenge handles POST /clubs/{id}/challenges.
+func (h *Handler) CreateClubChallenge(w http.ResponseWriter, r *http.Request) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	memberID, clubID, ok := h.requireClubAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	gameID, err := uuid.Parse(r.FormValue("game_id"))
+	if err != nil {
+		http.Error(w, "Invalid game ID", http.StatusBadRequest)
+		return
+	}
+
+	startsAt, err := time.ParseInLocation(challengeDateLayout, r.FormValue("starts_at"), time.Local)
+	if err != nil {
+		http.Error(w, "Invalid start date", http.StatusBadRequest)
+		return
+	}
+	endsAt, err := time.ParseInLocation(challengeDateLayout, r.FormValue("ends_at"), time.Local)
+	if err != nil {
+		http.Error(w, "Invalid end date", http.StatusBadRequest)
+		return
+	}
+	// The end date is inclusive: the challenge runs until the end of that day.
+	endsAt = endsAt.AddDate(0, 0, 1)
+	if !endsAt.After(startsAt) {
+		http.Error(w, "End date must be after start date", http.StatusBadRequest)
+		return
+	}
+
+	game, err := h.store.GetGameByID(r.Context(), gameID)
+	if err != nil || game == nil {
+		http.Error(w, "Game not found", http.StatusNotFound)
+		return
+	}
+
+	if _, err := h.createClubChallenge(r, memberID, clubID, game, startsAt, endsAt); err != nil {
+		http.Error(w, "Failed to create challenge: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/clubs/"+clubID.String()+"?success=challenge_created", http.StatusSeeOther)
+}
+
+// createClubChallenge launches a challenge and announces it in the feed.
+// The HTML form and the API share it.
+func (h *Handler) createClubChallenge(r *http.Request, memberID, clubID uuid.UUID, game *models.Game, startsAt, endsAt time.Time) (*models.ClubChallenge, error) {
+	challenge := &models.ClubChallenge{
+		ID:        uuid.New(),
+		ClubID:    clubID,
+		GameID:    game.ID,
+		StartsAt:  startsAt,
+		EndsAt:    endsAt,
+		CreatedBy: memberID,
+		CreatedAt: time.Now(),
+	}
+
+	if err := h.store.CreateClubChallenge(r.Context(), challenge); err != nil {
+		return nil, err
+	}
+
+	club, _ := h.store.GetClubByID(r.Context(), clubID)
+	if club != nil {
+		_ = h.store.InsertActivity(r.Context(), "challenge_created", club.Name, game.Title)
+	}
+	return challenge, nil
+}
+
+// clubChallengeFromPath parses {id} and {challengeID} and checks that the challenge belongs to the club.
+func (h *Handler) clubChallengeFromPath(w http.ResponseWriter, r *http.Request) (*models.ClubChallenge, bool) {
+	clubID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid club ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	challengeID, err := uuid.Parse(r.PathValue("challengeID"))
+	if err != nil {
+		http.Error(w, "Invalid challenge ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	challenge, err := h.store.GetClubChallenge(r.Context(), challengeID)
+	if err != nil || challenge == nil || challenge.ClubID != clubID {
+		http.Error(w, "Challenge not found", http.StatusNotFound)
+		return nil, false
+	}
+
+	return challenge, true
+}
+
+// JoinClubChallenge handles POST /clubs/{id}/challenges/{challengeID}/join.
+func (h *Handler) JoinClubChallenge(w http.ResponseWriter, r *http.Request) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	memberID, ok := h.getSessionMemberID(r)
+	if !ok {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+
+	challenge, ok := h.clubChallengeFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	role, err := h.store.GetClubMemberRole(r.Context(), challenge.ClubID, memberID)
+	if err != nil || role == "" {
+		http.Error(w, "Only club members can join the challenge", http.StatusForbidden)
+		return
+	}
+
+	if err := h.store.JoinClubChallenge(r.Context(), challenge.ID, memberID); err != nil {
+		http.Error(w, "Failed to join challenge: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	http.Redirect(w, r, "/clubs/"+challenge.ClubID.String()+"?success=challenge_joined", http.StatusSeeOther)
+}
+
+// GiveUpClubChallenge handles POST /clubs/{id}/challenges/{challengeID}/give-up.
+func (h *Handler) GiveUpClubChallenge(w http.ResponseWriter, r *http.Request) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	memberID, ok := h.getSessionMemberID(r)
+	if !ok {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+
+	challenge, ok := h.clubChallengeFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.store.GiveUpClubChallenge(r.Context(), challenge.ID, memberID); err != nil {
+		http.Error(w, "Failed to give up challenge: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	http.Redirect(w, r, "/clubs/"+challenge.ClubID.String()+"?success=challenge_gave_up", http.StatusSeeOther)
+}
+
+// recordChallengeProgress advances the member's open challenges for a game and
+// celebrates the first finisher of each challenge in the activity feed.
+func (h *Handler) recordChallengeProgress(r *http.Request, memberID, gameID uuid.UUID, status string) {
+	finishes, err := h.store.UpdateChallengeProgress(r.Context(), memberID, gameID, status)
+	if err != nil {
+		return
+	}
+	for _, f := range finishes {
+		if !f.IsFirst {
+			continue
+		}
+		member, _ := h.store.GetMemberByID(r.Context(), memberID)
+		if member != nil {
+			_ = h.store.InsertActivity(r.Context(), "challenge_first_finish", member.ProfileName, f.GameTitle)
+		}
+	}
+}
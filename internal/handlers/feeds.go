@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"slices"
+
+	"github.com/cmellojr/modo-locadora/internal/database"
+	"github.com/cmellojr/modo-locadora/internal/feed"
+	"github.com/cmellojr/modo-locadora/internal/models"
+	"github.com/google/uuid"
+)
+
+// ── Feed handlers ───────────────────────────────────────────────────────────
+
+// feedSize is the number of events in each Atom/RSS feed.
+const feedSize = 50
+
+// ActivityFeed handles GET /feeds/activity/{format}: every feed event.
+func (h *Handler) ActivityFeed(w http.ResponseWriter, r *http.Request) {
+	h.serveFeed(w, r, feed.Feed{
+		Title:       "Aconteceu na Locadora",
+		Description: "Tudo o que acontece no balcão do Modo Locadora.",
+		Link:        h.absoluteURL(r, "/"),
+	}, database.ActivityFilter{})
+}
+
+// NewArrivalsFeed handles GET /feeds/new-arrivals/{format}: tapes added to
+// the catalog.
+func (h *Handler) NewArrivalsFeed(w http.ResponseWriter, r *http.Request) {
+	h.serveFeed(w, r, feed.Feed{
+		Title:       "Fitas novas no Modo Locadora",
+		Description: "Cartuchos que acabaram de chegar na prateleira.",
+		Link:        h.absoluteURL(r, "/games"),
+	}, database.ActivityFilter{EventTypes: []string{models.EventNewGame}})
+}
+
+// PlatformFeed handles GET /feeds/platforms/{platform}/{format}: events
+// about games of one platform, named by its canonical name or an alias.
+func (h *Handler) PlatformFeed(w http.ResponseWriter, r *http.Request) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	platforms, err := h.store.ListPlatforms(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load platforms: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	registry, err := h.platformRegistry(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load platforms: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	platform := registry.Canonical(r.PathValue("platform"))
+	if !slices.ContainsFunc(platforms, func(p database.PlatformSummary) bool { return p.Platform == platform }) {
+		http.NotFound(w, r)
+		return
+	}
+
+	h.serveFeed(w, r, feed.Feed{
+		Title:       platform + " no Modo Locadora",
+		Description: "Fitas novas, vereditos e relíquias de " + platform + ".",
+		Link:        h.absoluteURL(r, "/games?platform="+url.QueryEscape(platform)),
+	}, database.ActivityFilter{Platform: platform})
+}
+
+// MemberFeed handles GET /feeds/members/{name}/{format}: the public feed
+// events of one member.
+func (h *Handler) MemberFeed(w http.ResponseWriter, r *http.Request) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	member, err := h.store.GetMemberByProfileName(r.Context(), r.PathValue("name"))
+	if err != nil {
+		http.Error(w, "Failed to load member: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if member == nil || member.IsDeleted() {
+		http.NotFound(w, r)
+		return
+	}
+
+	h.serveFeed(w, r, feed.Feed{
+		Title:       member.ProfileName + " no Modo Locadora",
+		Description: "O que o sócio " + member.ProfileName + " anda jogando.",
+		Link:        h.absoluteURL(r, "/"),
+	}, database.ActivityFilter{MemberName: member.ProfileName})
+}
+
+// ClubFeed handles GET /feeds/clubs/{id}/{format}: events naming the club
+// and events of its members.
+func (h *Handler) ClubFeed(w http.ResponseWriter, r *http.Request) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	club, err := h.store.GetClubByID(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Failed to load club: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if club == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	h.serveFeed(w, r, feed.Feed{
+		Title:       "Turma " + club.Name,
+		Description: "Desafios, entradas e vereditos da turma " + club.Name + ".",
+		Link:        h.absoluteURL(r, "/clubs/"+club.ID.String()),
+	}, database.ActivityFilter{ClubID: &club.ID})
+}
+
+// serveFeed fills f with the matching events and writes it in the {format}
+// of the path, atom or rss. The ETag is a hash of the document and
+// Last-Modified the newest event, so unchanged feeds answer 304.
+func (h *Handler) serveFeed(w http.ResponseWriter, r *http.Request, f feed.Feed, filter database.ActivityFilter) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	format := r.PathValue("format")
+	if format != "atom" && format != "rss" {
+		http.NotFound(w, r)
+		return
+	}
+
+	activities, err := h.store.ListActivities(r.Context(), filter, feedSize)
+	if err != nil {
+		http.Error(w, "Failed to load feed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	f.Self = h.absoluteURL(r, r.URL.EscapedPath())
+	f.ID = f.Self
+	f.Language = "pt-BR"
+	for _, a := range activities {
+		msg := FormatActivityMessage(a)
+		f.Entries = append(f.Entries, feed.Entry{
+			ID:      "urn:uuid:" + a.ID.String(),
+			Title:   msg,
+			Link:    f.Link,
+			Author:  a.MemberName,
+			Content: msg,
+			Updated: a.CreatedAt,
+		})
+	}
+	if len(activities) > 0 {
+		f.Updated = activities[0].CreatedAt
+	}
+
+	var body []byte
+	if format == "atom" {
+		w.Header().Set("Content-Type", feed.AtomContentType)
+		body, err = feed.Atom(f)
+	} else {
+		w.Header().Set("Content-Type", feed.RSSContentType)
+		body, err = feed.RSS(f)
+	}
+	if err != nil {
+		http.Error(w, "Failed to render feed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	http.ServeContent(w, r, "", f.Updated, bytes.NewReader(body))
+}
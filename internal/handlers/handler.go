@@ -2,19 +2,24 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
-	"io"
+	"log"
 	"net/http"
-	"os"
-	"path/filepath"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/cmellojr/modo-locadora/internal/almanac"
 	"github.com/cmellojr/modo-locadora/internal/auth"
 	"github.com/cmellojr/modo-locadora/internal/database"
 	"github.com/cmellojr/modo-locadora/internal/igdb"
+	"github.com/cmellojr/modo-locadora/internal/mailer"
+	"github.com/cmellojr/modo-locadora/internal/media"
+	"github.com/cmellojr/modo-locadora/internal/metadata"
+	"github.com/cmellojr/modo-locadora/internal/middleware"
 	"github.com/cmellojr/modo-locadora/internal/models"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
@@ -22,30 +27,57 @@ import (
 
 // Handler handles HTTP requests for the system.
 type Handler struct {
-	store        database.Store
-	cookieSecret string
-	adminEmail   string
+	store      database.Store
+	mailer     mailer.Mailer
+	igdb       *igdb.Client      // Nil when IGDB credentials are not configured.
+	catalog    *metadata.Catalog // Offline metadata provider; nil disables it.
+	covers     *media.Images     // Game covers, with thumbnails.
+	badges     *media.Images     // Club badges.
+	keys       *auth.Keyring     // Signs session cookies and e-mail links.
+	adminEmail string
+	baseURL    string // Public URL used in e-mail links; derived from the request when empty.
+	signupMode string // One of models.SignupMode*.
+	limits     limiters
+	spec       openAPISpec   // Built from APIRoutes on first use.
+	platforms  platformCache // Platform registry read from the store.
+	hasOwner   atomic.Bool   // Set once the store is known to have an owner.
 }
 
-// NewHandler creates a new Handler with the provided store, cookie secret and admin email.
-func NewHandler(store database.Store, cookieSecret, adminEmail string) *Handler {
-	return &Handler{store: store, cookieSecret: cookieSecret, adminEmail: adminEmail}
+// NewHandler creates a new Handler with the provided store, mailer, metadata
+// providers (the IGDB client and the local catalog, either of which may be
+// nil), cover and badge image storage, signing keyring, admin email, public
+// base URL and sign-up mode.
+func NewHandler(store database.Store, mail mailer.Mailer, games *igdb.Client, catalog *metadata.Catalog, covers, badges *media.Images, keys *auth.Keyring, adminEmail, baseURL, signupMode string) *Handler {
+	return &Handler{
+		store:      store,
+		mailer:     mail,
+		igdb:       games,
+		catalog:    catalog,
+		covers:     covers,
+		badges:     badges,
+		keys:       keys,
+		adminEmail: adminEmail,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		signupMode: signupMode,
+		limits:     newLimiters(),
+	}
 }
 
 
-// Logout handles POST /logout by clearing the session cookie.
+// Logout handles POST /logout by revoking the current session and clearing the cookie.
 func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
-	http.SetCookie(w, &http.Cookie{
-		Name:   "session_member",
-		Value:  "",
-		Path:   "/",
-		MaxAge: -1,
-	})
+	if h.store != nil {
+		if token := auth.GetSessionToken(r, h.keys); token != "" {
+			_ = h.store.RevokeSession(r.Context(), auth.HashToken(token))
+		}
+	}
+	auth.ClearSessionCookie(w)
 	http.Redirect(w, r, "/", http.StatusSeeOther)
 }
 
 // ActivityView represents a formatted activity event for template display.
 type ActivityView struct {
+	ID         uuid.UUID
 	EventType  string
 	MemberName string
 	GameTitle  string
@@ -89,8 +121,8 @@ type MemberMiniView struct {
 	ActiveRentals    int
 }
 
-// formatActivityMessage returns a human-readable message for an activity event.
-func formatActivityMessage(a database.ActivityEntry) string {
+// FormatActivityMessage returns a human-readable message for an activity event.
+func FormatActivityMessage(a database.ActivityEntry) string {
 	switch a.EventType {
 	case "penalty":
 		return fmt.Sprintf("%s foi penalizado(a) por atrasar %s!", a.MemberName, a.GameTitle)
@@ -116,6 +148,12 @@ func formatActivityMessage(a database.ActivityEntry) string {
 		return fmt.Sprintf("%s formou a turma %s! Quem vai entrar?", a.MemberName, a.GameTitle)
 	case "club_joined":
 		return fmt.Sprintf("%s entrou na turma %s!", a.MemberName, a.GameTitle)
+	case "challenge_created":
+		return fmt.Sprintf("Desafio da turma %s: todo mundo jogando %s!", a.MemberName, a.GameTitle)
+	case "challenge_first_finish":
+		return fmt.Sprintf("%s foi o(a) primeiro(a) a zerar %s no desafio da turma!", a.MemberName, a.GameTitle)
+	case "league_champion":
+		return fmt.Sprintf("A turma %s venceu a Gincana %s!", a.MemberName, a.GameTitle)
 	default:
 		return ""
 	}
@@ -126,24 +164,32 @@ func formatActivityMessage(a database.ActivityEntry) string {
 type LayoutData struct {
 	PageTitle    string
 	IsLoggedIn   bool
-	IsAdmin      bool
+	IsAdmin      bool     // True for any staff member; use Can for specific permissions.
+	Roles        []string // Staff roles of the logged-in member.
 	MemberName   string
 	MemberMini   *MemberMiniView
 	ShameEntries []database.ShameEntry
 	Activities   []ActivityView
 	AlmanacEntry string
+	CSRFToken    string // Must be sent as the "csrf_token" field of every POST form.
+}
+
+// Can reports whether the logged-in member's staff roles grant perm.
+// Templates call it as {{if .Can "catalog"}}.
+func (ld LayoutData) Can(perm string) bool {
+	return models.HasPermission(ld.Roles, perm)
 }
 
 // buildLayoutData loads shared layout data (auth state, sidebar content) for every page.
 func (h *Handler) buildLayoutData(r *http.Request, pageTitle string) LayoutData {
-	ld := LayoutData{PageTitle: pageTitle}
+	ld := LayoutData{PageTitle: pageTitle, CSRFToken: middleware.CSRFToken(r, h.keys)}
 
 	if h.store == nil {
 		return ld
 	}
 
 	// Authenticate
-	rawID := auth.GetSessionMemberID(r, h.cookieSecret)
+	rawID := h.sessionMemberID(r)
 	if rawID != "" {
 		id, err := uuid.Parse(rawID)
 		if err == nil {
@@ -151,7 +197,8 @@ func (h *Handler) buildLayoutData(r *http.Request, pageTitle string) LayoutData
 			if err == nil && member != nil {
 				ld.IsLoggedIn = true
 				ld.MemberName = member.ProfileName
-				ld.IsAdmin = h.adminEmail != "" && member.Email == h.adminEmail
+				ld.Roles, _ = h.store.ListMemberRoles(r.Context(), id)
+				ld.IsAdmin = len(ld.Roles) > 0
 
 				// Left sidebar: member mini-card
 				activeCount, overdueCount, _ := h.store.GetMemberRentalStats(r.Context(), id)
@@ -179,10 +226,11 @@ func (h *Handler) buildLayoutData(r *http.Request, pageTitle string) LayoutData
 	activities, _ := h.store.ListRecentActivities(r.Context(), 5)
 	for _, a := range activities {
 		ld.Activities = append(ld.Activities, ActivityView{
+			ID:         a.ID,
 			EventType:  a.EventType,
 			MemberName: a.MemberName,
 			GameTitle:  a.GameTitle,
-			Message:    formatActivityMessage(a),
+			Message:    FormatActivityMessage(a),
 			TimeAgo:    formatTimeAgo(a.CreatedAt),
 		})
 	}
@@ -192,15 +240,8 @@ func (h *Handler) buildLayoutData(r *http.Request, pageTitle string) LayoutData
 	return ld
 }
 
-// CreateMemberRequest defines the input for member registration.
-type CreateMemberRequest struct {
-	ProfileName     string `json:"profile_name"`
-	Email           string `json:"email"`
-	Password        string `json:"password"`
-	FavoriteConsole string `json:"favorite_console"`
-}
-
-// CreateMember handles POST /members.
+// CreateMember handles POST /members, the JSON sign-up. It shares validation
+// and the sign-up mode with the HTML form at /signup.
 func (h *Handler) CreateMember(w http.ResponseWriter, r *http.Request) {
 	if h.store == nil {
 		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
@@ -212,41 +253,26 @@ func (h *Handler) CreateMember(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var req CreateMemberRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request payload", http.StatusBadRequest)
-		return
-	}
-
-	if req.Password == "" {
-		http.Error(w, "Password is required", http.StatusBadRequest)
+	if ok, wait := h.limits.signupIP.Allow(clientIP(r)); !ok {
+		log.Printf("[security] Sign-up rate limited for ip=%s", clientIP(r))
+		w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())))
+		http.Error(w, "Too many sign-ups, try again later", http.StatusTooManyRequests)
 		return
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
-	if err != nil {
-		http.Error(w, "Failed to process password", http.StatusInternalServerError)
+	var req SignupForm
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
 		return
 	}
 
-	membershipNumber, err := h.store.NextMembershipNumber(r.Context())
+	member, errs, err := h.registerMember(r, &req, false)
 	if err != nil {
-		http.Error(w, "Failed to generate membership number", http.StatusInternalServerError)
+		http.Error(w, "Failed to create member", http.StatusInternalServerError)
 		return
 	}
-
-	member := &models.Member{
-		ID:               uuid.New(),
-		ProfileName:      req.ProfileName,
-		Email:            req.Email,
-		PasswordHash:     string(hashedPassword),
-		FavoriteConsole:   req.FavoriteConsole,
-		MembershipNumber: membershipNumber,
-		JoinedAt:         time.Now(),
-	}
-
-	if err := h.store.CreateMember(r.Context(), member); err != nil {
-		http.Error(w, "Failed to create member", http.StatusInternalServerError)
+	if len(errs) > 0 {
+		writeFieldErrors(w, errs)
 		return
 	}
 
@@ -278,43 +304,79 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if ok, wait := h.allowLogin(r, profileName); !ok {
+		redirectLoginWait(w, r, "slow_down", wait)
+		return
+	}
+
 	member, err := h.store.GetMemberByProfileName(r.Context(), profileName)
 	if err != nil {
 		http.Error(w, "Failed to authenticate", http.StatusInternalServerError)
 		return
 	}
 
-	if member == nil {
-		http.Error(w, "Invalid name or password", http.StatusUnauthorized)
+	if member == nil || member.IsDeleted() {
+		http.Redirect(w, r, "/?error=invalid_login", http.StatusSeeOther)
+		return
+	}
+
+	// A locked account is refused before the password is even checked.
+	if now := time.Now(); member.IsLocked(now) {
+		redirectLoginWait(w, r, "locked", member.LockedUntil.Sub(now))
 		return
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(member.PasswordHash), []byte(password)); err != nil {
-		http.Error(w, "Invalid name or password", http.StatusUnauthorized)
+		if lock := h.registerLoginFailure(r, member); lock > 0 {
+			redirectLoginWait(w, r, "locked", lock)
+			return
+		}
+		http.Redirect(w, r, "/?error=invalid_login", http.StatusSeeOther)
+		return
+	}
+
+	if member.PendingApproval {
+		http.Redirect(w, r, "/?error=pending_approval", http.StatusSeeOther)
+		return
+	}
+
+	// With two-factor on, the password only unlocks the code step.
+	if member.HasTwoFactor() {
+		auth.SetPendingLoginCookie(w, member.ID.String(), h.keys)
+		http.Redirect(w, r, "/login/2fa", http.StatusSeeOther)
 		return
 	}
 
-	auth.SetSessionCookie(w, member.ID.String(), h.cookieSecret)
+	if err := h.finishLogin(w, r, member); err != nil {
+		http.Error(w, "Failed to start session: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
 	http.Redirect(w, r, "/games", http.StatusSeeOther)
 }
 
-// PlatformView represents a platform for display in the console selection grid.
-type PlatformView struct {
-	Platform  string
-	GameCount int
-	LogoURL   string
+// finishLogin clears the failed login count, bootstraps the owner and starts
+// the session once every login step has passed.
+func (h *Handler) finishLogin(w http.ResponseWriter, r *http.Request, member *models.Member) error {
+	if member.FailedLogins > 0 || member.LockedUntil != nil {
+		if err := h.store.ResetLoginFailures(r.Context(), member.ID); err != nil {
+			log.Printf("[security] Failed to reset login failures for %s: %v", member.ProfileName, err)
+		}
+	}
+	h.limits.loginName.Reset(strings.ToLower(member.ProfileName))
+
+	h.bootstrapOwner(r.Context(), member)
+
+	return h.startSession(w, r, member.ID)
 }
 
-// platformLogoFile returns the logo filename for a platform name.
-func platformLogoFile(platform string) string {
-	aliases := map[string]string{
-		"Super Nintendo": "snes",
-	}
-	if alias, ok := aliases[platform]; ok {
-		return "/static/img/logos/" + alias + ".svg"
-	}
-	name := strings.ToLower(strings.ReplaceAll(platform, " ", "-"))
-	return "/static/img/logos/" + name + ".svg"
+// PlatformView represents a platform for display in the console selection grid.
+type PlatformView struct {
+	Platform      string
+	GameCount     int
+	LogoURL       string // Empty when the registry has no logo.
+	Manufacturer  string
+	BrDistributor string
+	ReleaseYear   int // 0 when unknown.
 }
 
 // GameView represents a game for display in the shelf.
@@ -326,21 +388,29 @@ type GameView struct {
 	CoverDisplay    string
 	Summary         string
 	SourceMagazine  string
+	ReleaseYear     int // 0 when unknown.
+	Genres          []string
 	TotalCopies     int
 	AvailableCopies int
 	RenterName      string
 }
 
 // ListGames handles GET /games. Without ?platform= it shows the platform selection page.
-// With ?platform=X it shows the filtered games shelf for that platform.
+// With ?platform=X it shows the games shelf for that platform, optionally
+// narrowed by ?genre=, ?developer= and ?year=.
 func (h *Handler) ListGames(w http.ResponseWriter, r *http.Request, platformsTmpl, gamesTmpl *template.Template) {
 	platform := r.URL.Query().Get("platform")
+	registry, err := h.platformRegistry(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load platforms: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
 
 	// No platform filter → show platform selection page.
 	if platform == "" {
 		ld := h.buildLayoutData(r, "Acervo de Cartuchos")
 
-		fixedPlatforms := []string{"Mega Drive", "Super Nintendo", "NES", "Master System", "Atari 2600"}
+		// Registry platforms on the shelf, plus any other that has games.
 		platformCounts := make(map[string]int)
 		if h.store != nil {
 			platforms, _ := h.store.ListPlatforms(r.Context())
@@ -349,11 +419,17 @@ func (h *Handler) ListGames(w http.ResponseWriter, r *http.Request, platformsTmp
 			}
 		}
 		var platformViews []PlatformView
-		for _, p := range fixedPlatforms {
+		for _, p := range registry {
+			if !p.OnShelf && platformCounts[p.Name] == 0 {
+				continue
+			}
 			platformViews = append(platformViews, PlatformView{
-				Platform:  p,
-				GameCount: platformCounts[p],
-				LogoURL:   platformLogoFile(p),
+				Platform:      p.Name,
+				GameCount:     platformCounts[p.Name],
+				LogoURL:       p.LogoURL,
+				Manufacturer:  p.Manufacturer,
+				BrDistributor: p.BrDistributor,
+				ReleaseYear:   p.ReleaseYear,
 			})
 		}
 
@@ -371,33 +447,52 @@ func (h *Handler) ListGames(w http.ResponseWriter, r *http.Request, platformsTmp
 		return
 	}
 
-	// Platform filter present → show games for that platform.
+	// Platform filter present → show games for that platform. An alias such
+	// as ?platform=Genesis shows the canonical platform's shelf.
+	if p := registry.Resolve(platform); p != nil {
+		platform = p.Name
+	}
 	ld := h.buildLayoutData(r, platform)
 
+	q := r.URL.Query()
+	filter := database.GameFilter{
+		Platform:  platform,
+		Genre:     q.Get("genre"),
+		Developer: q.Get("developer"),
+	}
+	filter.Year, _ = strconv.Atoi(q.Get("year"))
+
 	var games []GameView
+	var facets *database.GameFacets
 	if h.store != nil {
-		gamesAvail, err := h.store.ListGamesWithAvailability(r.Context(), platform)
+		gamesAvail, err := h.store.ListGamesWithAvailability(r.Context(), filter)
 		if err == nil {
 			for _, ga := range gamesAvail {
 				games = append(games, GameView{
 					ID:              ga.Game.ID.String(),
 					Title:           ga.Game.Title,
 					Platform:        ga.Game.Platform,
-					CoverURL:        ga.Game.CoverURL,
+					CoverURL:        ga.Game.Thumbnail(),
 					CoverDisplay:    ga.Game.CoverDisplay,
+					ReleaseYear:     ga.Game.ReleaseYear(),
+					Genres:          ga.Game.Genres,
 					TotalCopies:     ga.TotalCopies,
 					AvailableCopies: ga.AvailableCopies,
 					RenterName:      ga.RenterName,
 				})
 			}
 		}
+		facets, _ = h.store.ListGameFacets(r.Context(), platform)
+	}
+	if facets == nil {
+		facets = &database.GameFacets{}
 	}
 
 	debtError := r.URL.Query().Get("error") == "in_debt"
 
 	completedGames := make(map[string]bool)
 	if ld.IsLoggedIn && h.store != nil {
-		rawID := auth.GetSessionMemberID(r, h.cookieSecret)
+		rawID := h.sessionMemberID(r)
 		memberUUID, err := uuid.Parse(rawID)
 		if err == nil {
 			completedIDs, _ := h.store.ListCompletedGameIDs(r.Context(), memberUUID)
@@ -411,12 +506,16 @@ func (h *Handler) ListGames(w http.ResponseWriter, r *http.Request, platformsTmp
 		LayoutData
 		Games          []GameView
 		Platform       string
+		Filter         database.GameFilter
+		Facets         *database.GameFacets
 		DebtError      bool
 		CompletedGames map[string]bool
 	}{
 		LayoutData:     ld,
 		Games:          games,
 		Platform:       platform,
+		Filter:         filter,
+		Facets:         facets,
 		DebtError:      debtError,
 		CompletedGames: completedGames,
 	}
@@ -454,12 +553,14 @@ func (h *Handler) GameDetailPage(w http.ResponseWriter, r *http.Request, tmpl *t
 
 	data := struct {
 		LayoutData
-		Detail    *database.GameDetail
-		DebtError bool
+		Detail          *database.GameDetail
+		DebtError       bool
+		UnverifiedError bool
 	}{
-		LayoutData: ld,
-		Detail:     detail,
-		DebtError:  r.URL.Query().Get("error") == "in_debt",
+		LayoutData:      ld,
+		Detail:          detail,
+		DebtError:       r.URL.Query().Get("error") == "in_debt",
+		UnverifiedError: r.URL.Query().Get("error") == "unverified",
 	}
 
 	if err := tmpl.Execute(w, data); err != nil {
@@ -474,7 +575,7 @@ func (h *Handler) Membership(w http.ResponseWriter, r *http.Request, tmpl *templ
 		return
 	}
 
-	rawMemberID := auth.GetSessionMemberID(r, h.cookieSecret)
+	rawMemberID := h.sessionMemberID(r)
 	if rawMemberID == "" {
 		http.Redirect(w, r, "/", http.StatusSeeOther)
 		return
@@ -513,6 +614,13 @@ func (h *Handler) Membership(w http.ResponseWriter, r *http.Request, tmpl *templ
 	memberTitle := models.ComputeMemberTitle(len(completedGameIDs), onTimeCount)
 	memberClubs, _ := h.store.ListMemberClubs(r.Context(), id)
 
+	var primaryClubID string
+	if member.PrimaryClubID != nil {
+		primaryClubID = member.PrimaryClubID.String()
+	}
+
+	sessions := h.listSessionViews(r, id)
+
 	data := struct {
 		LayoutData
 		Member        *models.Member
@@ -526,6 +634,8 @@ func (h *Handler) Membership(w http.ResponseWriter, r *http.Request, tmpl *templ
 		Rentals       []database.MemberRental
 		Title         models.MemberTitle
 		Clubs         []database.MemberClubView
+		PrimaryClubID string
+		Sessions      []SessionView
 	}{
 		LayoutData:    ld,
 		Member:        member,
@@ -539,6 +649,8 @@ func (h *Handler) Membership(w http.ResponseWriter, r *http.Request, tmpl *templ
 		Rentals:       memberRentals,
 		Title:         memberTitle,
 		Clubs:         memberClubs,
+		PrimaryClubID: primaryClubID,
+		Sessions:      sessions,
 	}
 
 	if err := tmpl.Execute(w, data); err != nil {
@@ -553,7 +665,7 @@ func (h *Handler) SavePasswordNotes(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	rawMemberID := auth.GetSessionMemberID(r, h.cookieSecret)
+	rawMemberID := h.sessionMemberID(r)
 	if rawMemberID == "" {
 		http.Redirect(w, r, "/", http.StatusSeeOther)
 		return
@@ -574,6 +686,12 @@ func (h *Handler) SavePasswordNotes(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/membership?success=1", http.StatusSeeOther)
 }
 
+// Errors returned by rentForMember when the member may not rent.
+var (
+	errMemberInDebt    = errors.New("member is in debt")
+	errEmailUnverified = errors.New("member has not confirmed their e-mail")
+)
+
 // RentGame handles POST /rent.
 func (h *Handler) RentGame(w http.ResponseWriter, r *http.Request) {
 	if h.store == nil {
@@ -581,7 +699,7 @@ func (h *Handler) RentGame(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	rawMemberID := auth.GetSessionMemberID(r, h.cookieSecret)
+	rawMemberID := h.sessionMemberID(r)
 	if rawMemberID == "" {
 		http.Redirect(w, r, "/", http.StatusSeeOther)
 		return
@@ -593,31 +711,60 @@ func (h *Handler) RentGame(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Block rental if member is in debt.
-	status, err := h.store.GetMemberStatus(r.Context(), memberID)
+	gameIDStr := r.FormValue("game_id")
+	gameID, err := uuid.Parse(gameIDStr)
 	if err != nil {
-		http.Error(w, "Failed to check status: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, "Invalid game ID", http.StatusBadRequest)
 		return
 	}
-	gameIDStr := r.FormValue("game_id")
 
-	if status == models.MemberStatusInDebt {
+	switch err := h.rentForMember(r, memberID, gameID); {
+	case errors.Is(err, errMemberInDebt):
 		http.Redirect(w, r, "/games/"+gameIDStr+"?error=in_debt", http.StatusSeeOther)
 		return
+	case errors.Is(err, errEmailUnverified):
+		http.Redirect(w, r, "/games/"+gameIDStr+"?error=unverified", http.StatusSeeOther)
+		return
+	case err != nil:
+		http.Error(w, "Failed to rent: "+err.Error(), http.StatusInternalServerError)
+		return
 	}
 
-	gameID, err := uuid.Parse(gameIDStr)
+	http.Redirect(w, r, "/games/"+gameID.String(), http.StatusSeeOther)
+}
+
+// rentForMember rents a copy of the game to the member and advances their
+// club challenges. The HTML form and the API share it. Returns
+// errMemberInDebt, errEmailUnverified or database.ErrNoCopiesAvailable when
+// the rental is refused.
+func (h *Handler) rentForMember(r *http.Request, memberID, gameID uuid.UUID) error {
+	// Block rental if member is in debt.
+	status, err := h.store.GetMemberStatus(r.Context(), memberID)
 	if err != nil {
-		http.Error(w, "Invalid game ID", http.StatusBadRequest)
-		return
+		return fmt.Errorf("failed to check status: %w", err)
+	}
+	if status == models.MemberStatusInDebt {
+		return errMemberInDebt
+	}
+
+	// Block rental until the member confirms their e-mail.
+	renter, err := h.store.GetMemberByID(r.Context(), memberID)
+	if err != nil {
+		return fmt.Errorf("failed to load member: %w", err)
+	}
+	if renter == nil {
+		return fmt.Errorf("member not found")
+	}
+	if !renter.IsEmailVerified() {
+		return errEmailUnverified
 	}
 
 	if err := h.store.RentGame(r.Context(), gameID, memberID); err != nil {
-		http.Error(w, "Failed to rent: "+err.Error(), http.StatusInternalServerError)
-		return
+		return err
 	}
 
-	http.Redirect(w, r, "/games/"+gameID.String(), http.StatusSeeOther)
+	h.recordChallengeProgress(r, memberID, gameID, models.ChallengeStatusRented)
+	return nil
 }
 
 // AdminReturns handles GET /admin/returns and renders the active rentals for check-in.
@@ -671,31 +818,50 @@ func (h *Handler) ReturnGame(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/admin/returns?success=Game+returned", http.StatusSeeOther)
 }
 
-// AdminStock handles GET /admin/stock and renders the IGDB search page.
+// AdminStock handles GET /admin/stock and renders the catalog search page.
+// Parameters: q, magazine, source (a metadata provider) and selected (a
+// game ID in that provider).
 func (h *Handler) AdminStock(w http.ResponseWriter, r *http.Request, tmpl *template.Template) {
+	h.renderStock(w, r, tmpl, "", http.StatusOK)
+}
+
+// renderStock renders the stock page. catalogErr explains why a catalog
+// upload was refused.
+func (h *Handler) renderStock(w http.ResponseWriter, r *http.Request, tmpl *template.Template, catalogErr string, status int) {
 	ld := h.buildLayoutData(r, "Abastecer Prateleiras")
 
-	query := r.URL.Query().Get("q")
-	magazine := r.URL.Query().Get("magazine")
-	selectedIDStr := r.URL.Query().Get("selected")
+	q := r.URL.Query()
+	query := q.Get("q")
+	magazine := q.Get("magazine")
+	selectedID := q.Get("selected")
 
-	var results []igdb.GameData
-	var selected *igdb.GameData
+	providers := h.providers()
+	source := q.Get("source")
+	if source == "" && len(providers) > 0 {
+		source = providers[0].Source()
+	}
+	provider := h.provider(source)
 
-	if query != "" {
-		clientID := os.Getenv("TWITCH_CLIENT_ID")
-		clientSecret := os.Getenv("TWITCH_CLIENT_SECRET")
+	var results []metadata.Game
+	var selected *metadata.Game
+	var searchErr string
 
-		if clientID != "" && clientSecret != "" {
-			token, err := igdb.GetAccessToken(clientID, clientSecret)
-			if err == nil {
-				results, _ = igdb.SearchGame(clientID, token.AccessToken, query)
+	if query != "" {
+		if provider == nil {
+			searchErr = "unavailable"
+		} else {
+			var err error
+			results, err = provider.SearchGames(r.Context(), query)
+			if err != nil {
+				log.Printf("[%s] Search %q failed: %v", source, query, err)
+				searchErr = "failed"
+				if errors.Is(err, igdb.ErrRateLimited) {
+					searchErr = "rate_limited"
+				}
 			}
 		}
 
-		if selectedIDStr != "" {
-			selectedID := 0
-			fmt.Sscanf(selectedIDStr, "%d", &selectedID)
+		if selectedID != "" {
 			for i := range results {
 				if results[i].ID == selectedID {
 					selected = &results[i]
@@ -705,38 +871,83 @@ func (h *Handler) AdminStock(w http.ResponseWriter, r *http.Request, tmpl *templ
 		}
 	}
 
+	// Show provider platforms under their registry names, and preselect the
+	// selected game's first known platform.
+	registry, err := h.platformRegistry(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load platforms: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for i := range results {
+		canonicalPlatforms(registry, &results[i])
+	}
+	var selectedPlatform string
+	if selected != nil {
+		selectedPlatform = knownPlatform(registry, selected)
+	}
+
+	var catalogFiles []metadata.CatalogFile
+	if h.catalog != nil {
+		catalogFiles = h.catalog.Files()
+	}
+
 	data := struct {
 		LayoutData
-		Query    string
-		Magazine string
-		Results  []igdb.GameData
-		Selected *igdb.GameData
-		Success  string
+		Query        string
+		Magazine     string
+		Source       string
+		Providers    []metadata.Provider
+		Results      []metadata.Game
+		Selected     *metadata.Game
+		Platforms    models.Platforms
+		Platform     string
+		SearchError  string
+		CatalogFiles []metadata.CatalogFile
+		CatalogError string
+		Success      string
+		Catalog      string
 	}{
-		LayoutData: ld,
-		Query:      query,
-		Magazine:   magazine,
-		Results:    results,
-		Selected:   selected,
-		Success:    r.URL.Query().Get("success"),
-	}
-
+		LayoutData:   ld,
+		Query:        query,
+		Magazine:     magazine,
+		Source:       source,
+		Providers:    providers,
+		Results:      results,
+		Selected:     selected,
+		Platforms:    registry,
+		Platform:     selectedPlatform,
+		SearchError:  searchErr,
+		CatalogFiles: catalogFiles,
+		CatalogError: catalogErr,
+		Success:      q.Get("success"),
+		Catalog:      q.Get("catalog"),
+	}
+
+	w.WriteHeader(status)
 	if err := tmpl.Execute(w, data); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
 
-// PurchaseGame handles POST /admin/purchase.
+// PurchaseGame handles POST /admin/purchase. The platform must be in the
+// platform registry, by name or alias; it is stored under its canonical name.
 func (h *Handler) PurchaseGame(w http.ResponseWriter, r *http.Request) {
 	if h.store == nil {
 		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
 		return
 	}
 
-	platform := r.FormValue("platform")
-	if platform == "" {
-		platform = "N/A"
+	registry, err := h.platformRegistry(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load platforms: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	p := resolvePlatform(registry, r.FormValue("platform"))
+	if p == nil {
+		http.Error(w, "Unknown platform", http.StatusBadRequest)
+		return
 	}
+	platform := p.Name
 
 	coverURL := r.FormValue("cover_url")
 	if strings.Contains(coverURL, "t_thumb") {
@@ -752,8 +963,31 @@ func (h *Handler) PurchaseGame(w http.ResponseWriter, r *http.Request) {
 		CoverURL:       coverURL,
 		SourceMagazine: r.FormValue("magazine"),
 		AcquiredAt:     time.Now(),
+		MetadataSource: r.FormValue("source"),
+		MetadataID:     r.FormValue("source_id"),
+	}
+	if game.MetadataSource == "" && game.IgdbID != "" {
+		game.MetadataSource, game.MetadataID = metadata.SourceIGDB, game.IgdbID
 	}
 
+	// Fill in genres, companies, release date and screenshots. A title or
+	// summary changed on the confirmation form counts as a manual edit.
+	if p := h.provider(game.MetadataSource); p != nil && game.MetadataID != "" {
+		data, err := p.GetGame(r.Context(), game.MetadataID)
+		if err != nil {
+			log.Printf("[%s] Game %s failed: %v", game.MetadataSource, game.MetadataID, err)
+		} else if data != nil {
+			if game.Title != data.Name {
+				game.MarkEdited(models.GameFieldTitle)
+			}
+			if game.Summary != data.Summary {
+				game.MarkEdited(models.GameFieldSummary)
+			}
+			applyMetadata(game, data)
+		}
+	}
+	h.mirrorCover(r.Context(), game)
+
 	if err := h.store.AddGame(r.Context(), game); err != nil {
 		http.Error(w, "Failed to purchase game: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -818,36 +1052,75 @@ func (h *Handler) EditGame(w http.ResponseWriter, r *http.Request, tmpl *templat
 		return
 	}
 
+	h.renderEditGame(w, r, tmpl, game, "", http.StatusOK)
+}
+
+// renderEditGame renders the edit form for game, with the reason a cover
+// upload was refused, if any.
+func (h *Handler) renderEditGame(w http.ResponseWriter, r *http.Request, tmpl *template.Template, game *models.Game, coverErr string, status int) {
 	ld := h.buildLayoutData(r, "Edit "+game.Title)
 
-	rentalHistory, _ := h.store.ListGameRentalHistory(r.Context(), id, 5)
+	rentalHistory, _ := h.store.ListGameRentalHistory(r.Context(), game.ID, 5)
+	registry, err := h.platformRegistry(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load platforms: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	p := registry.Resolve(game.Platform)
 
 	data := struct {
 		LayoutData
 		Game          *models.Game
+		ReleaseDate   string
+		Genres        string
+		Developers    string
+		Publishers    string
+		CanSync       bool
+		Source        string
 		RentalHistory []database.GameRentalHistoryEntry
+		Platforms     models.Platforms
+		KnownPlatform bool
+		CoverKind     media.Kind
+		CoverError    string
+		Success       string
+		Error         string
 	}{
 		LayoutData:    ld,
 		Game:          game,
+		ReleaseDate:   formatDate(game.ReleaseDate),
+		Genres:        strings.Join(game.Genres, ", "),
+		Developers:    strings.Join(game.Developers, ", "),
+		Publishers:    strings.Join(game.Publishers, ", "),
+		CanSync:       h.provider(game.MetadataSource) != nil && game.MetadataID != "",
+		Source:        metadata.SourceLabel(game.MetadataSource),
 		RentalHistory: rentalHistory,
+		Platforms:     registry,
+		KnownPlatform: p != nil && p.Name == game.Platform,
+		CoverKind:     h.covers.Kind(),
+		CoverError:    coverErr,
+		Success:       r.URL.Query().Get("success"),
+		Error:         r.URL.Query().Get("error"),
 	}
 
+	w.WriteHeader(status)
 	if err := tmpl.Execute(w, data); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
 
-// UpdateGame handles POST /admin/update-game and updates game fields in the database.
-func (h *Handler) UpdateGame(w http.ResponseWriter, r *http.Request) {
+// UpdateGame handles POST /admin/update-game and updates game fields in the
+// database. A refused cover upload re-renders the form with the reason.
+func (h *Handler) UpdateGame(w http.ResponseWriter, r *http.Request, tmpl *template.Template) {
 	if h.store == nil {
 		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
 		return
 	}
 
-	if err := r.ParseMultipartForm(10 << 20); err != nil {
+	if err := parseUploadForm(w, r, maxImageForm); err != nil {
 		http.Error(w, "Failed to process form", http.StatusBadRequest)
 		return
 	}
+	defer removeUploads(r)
 
 	idStr := r.FormValue("id")
 	id, err := uuid.Parse(idStr)
@@ -862,40 +1135,58 @@ func (h *Handler) UpdateGame(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	registry, err := h.platformRegistry(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load platforms: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	before := *game
+
+	// The platform must be in the registry; a game stocked before the
+	// registry may keep the unknown platform it already has.
 	game.Title = r.FormValue("title")
-	game.Platform = r.FormValue("platform")
+	if p := registry.Resolve(r.FormValue("platform")); p != nil {
+		game.Platform = p.Name
+	} else if r.FormValue("platform") != before.Platform {
+		http.Error(w, "Unknown platform", http.StatusBadRequest)
+		return
+	}
 	game.Summary = r.FormValue("summary")
+	game.Genres = splitList(r.FormValue("genres"))
+	game.Developers = splitList(r.FormValue("developers"))
+	game.Publishers = splitList(r.FormValue("publishers"))
+	game.ReleaseDate = nil
+	if v := r.FormValue("release_date"); v != "" {
+		d, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			http.Error(w, "Invalid release date", http.StatusBadRequest)
+			return
+		}
+		game.ReleaseDate = &d
+	}
 	game.SourceMagazine = r.FormValue("magazine")
 	game.CoverDisplay = r.FormValue("cover_display")
 	if game.CoverDisplay == "" {
 		game.CoverDisplay = "cover"
 	}
 
-	// Handle cover file upload.
-	file, header, err := r.FormFile("cover_file")
+	// Handle cover file upload: checked, stripped of metadata and saved in
+	// the detail and thumbnail sizes.
+	file, _, err := r.FormFile("cover_file")
 	if err == nil {
 		defer file.Close()
 
-		ext := filepath.Ext(header.Filename)
-		if ext == "" {
-			ext = ".jpg"
-		}
-		filename := id.String() + ext
-		savePath := filepath.Join("web", "static", "covers", filename)
-
-		dst, err := os.Create(savePath)
+		cover, err := h.covers.Upload(r.Context(), id.String(), file)
 		if err != nil {
+			if msg, ok := uploadErrorMessage(err, h.covers.Kind()); ok {
+				h.renderEditGame(w, r, tmpl, game, msg, http.StatusUnprocessableEntity)
+				return
+			}
 			http.Error(w, "Failed to save cover: "+err.Error(), http.StatusInternalServerError)
 			return
 		}
-		defer dst.Close()
 
-		if _, err := io.Copy(dst, file); err != nil {
-			http.Error(w, "Failed to write cover: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-
-		game.CoverURL = "/static/covers/" + filename
+		game.CoverURL, game.CoverThumbURL, game.CoverSourceURL = cover.URL, cover.ThumbURL, cover.SourceURL
 	} else {
 		// No upload — preserve existing cover_url from hidden field.
 		coverURL := r.FormValue("cover_url")
@@ -906,11 +1197,13 @@ func (h *Handler) UpdateGame(w http.ResponseWriter, r *http.Request) {
 			game.CoverURL = coverURL
 		}
 	}
+	markEditedFields(&before, game)
 
 	if err := h.store.UpdateGame(r.Context(), game); err != nil {
 		http.Error(w, "Failed to update game: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
+	removeReplaced(r.Context(), h.covers, []string{before.CoverURL, before.CoverThumbURL}, []string{game.CoverURL, game.CoverThumbURL})
 
 	redirectURL := fmt.Sprintf("/admin/inventory?success=%s",
 		template.URLQueryEscaper(game.Title))
@@ -925,23 +1218,18 @@ func (h *Handler) SearchGame(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	clientID := os.Getenv("TWITCH_CLIENT_ID")
-	clientSecret := os.Getenv("TWITCH_CLIENT_SECRET")
-
-	if clientID == "" || clientSecret == "" {
+	if h.igdb == nil {
 		http.Error(w, "IGDB credentials not configured", http.StatusServiceUnavailable)
 		return
 	}
 
-	token, err := igdb.GetAccessToken(clientID, clientSecret)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to get IGDB token: %v", err), http.StatusInternalServerError)
-		return
-	}
-
-	games, err := igdb.SearchGame(clientID, token.AccessToken, query)
+	games, err := h.igdb.Search(r.Context(), query)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to search IGDB: %v", err), http.StatusInternalServerError)
+		status := http.StatusBadGateway
+		if errors.Is(err, igdb.ErrRateLimited) {
+			status = http.StatusTooManyRequests
+		}
+		http.Error(w, fmt.Sprintf("Failed to search IGDB: %v", err), status)
 		return
 	}
 
@@ -953,10 +1241,22 @@ func (h *Handler) SearchGame(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) HandleIndex(w http.ResponseWriter, r *http.Request, tmpl *template.Template) {
 	ld := h.buildLayoutData(r, "Welcome")
 
+	q := r.URL.Query()
+	var wait string
+	if secs, err := strconv.Atoi(q.Get("wait")); err == nil && secs > 0 {
+		wait = formatWait(time.Duration(secs) * time.Second)
+	}
+
 	data := struct {
 		LayoutData
+		Success string
+		Error   string
+		Wait    string
 	}{
 		LayoutData: ld,
+		Success:    q.Get("success"),
+		Error:      q.Get("error"),
+		Wait:       wait,
 	}
 
 	if err := tmpl.Execute(w, data); err != nil {
@@ -971,7 +1271,7 @@ func (h *Handler) HandleRedeem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	rawMemberID := auth.GetSessionMemberID(r, h.cookieSecret)
+	rawMemberID := h.sessionMemberID(r)
 	if rawMemberID == "" {
 		http.Redirect(w, r, "/", http.StatusSeeOther)
 		return
@@ -996,6 +1296,12 @@ func (h *Handler) HandleRedeem(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/membership?success=redeemed", http.StatusSeeOther)
 }
 
+// rentalVerdicts are the play statuses a member can leave when returning a game.
+var rentalVerdicts = map[string]bool{
+	"completed": true, "enjoyed": true, "quick_play": true,
+	"not_for_me": true, "gave_up": true,
+}
+
 // HandleMemberReturn handles POST /membership/return, allowing a member to self-return a game.
 func (h *Handler) HandleMemberReturn(w http.ResponseWriter, r *http.Request) {
 	if h.store == nil {
@@ -1003,7 +1309,7 @@ func (h *Handler) HandleMemberReturn(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	rawMemberID := auth.GetSessionMemberID(r, h.cookieSecret)
+	rawMemberID := h.sessionMemberID(r)
 	if rawMemberID == "" {
 		http.Redirect(w, r, "/", http.StatusSeeOther)
 		return
@@ -1022,20 +1328,29 @@ func (h *Handler) HandleMemberReturn(w http.ResponseWriter, r *http.Request) {
 	}
 
 	verdict := r.FormValue("verdict")
-	validVerdicts := map[string]bool{
-		"completed": true, "enjoyed": true, "quick_play": true,
-		"not_for_me": true, "gave_up": true,
-	}
-	if !validVerdicts[verdict] {
+	if !rentalVerdicts[verdict] {
 		verdict = ""
 	}
 
-	// Get game title before the return (for activity logging).
+	if err := h.returnForMember(r, memberID, rentalID, verdict); err != nil {
+		http.Error(w, "Failed to return: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/membership?success=returned", http.StatusSeeOther)
+}
+
+// returnForMember closes one of the member's rentals with an optional
+// verdict and fires the feed events and challenge progress that follow.
+// The HTML form and the API share it. Returns database.ErrRentalNotFound
+// when the rental is not open or belongs to someone else.
+func (h *Handler) returnForMember(r *http.Request, memberID, rentalID uuid.UUID, verdict string) error {
+	// Get game title and ID before the return (for activity logging and challenges).
 	gameTitle, _ := h.store.GetRentalGameTitle(r.Context(), rentalID)
+	gameID, _ := h.store.GetRentalGameID(r.Context(), rentalID)
 
 	if err := h.store.ReturnGameByMember(r.Context(), rentalID, memberID, verdict); err != nil {
-		http.Error(w, "Failed to return: "+err.Error(), http.StatusInternalServerError)
-		return
+		return err
 	}
 
 	// Fire verdict activity event.
@@ -1054,6 +1369,14 @@ func (h *Handler) HandleMemberReturn(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Advance club challenges for this game.
+	switch verdict {
+	case "completed":
+		h.recordChallengeProgress(r, memberID, gameID, models.ChallengeStatusCompleted)
+	case "gave_up":
+		h.recordChallengeProgress(r, memberID, gameID, models.ChallengeStatusGaveUp)
+	}
+
 	// Check for prestige milestone (every 10th on-time return).
 	onTimeCount, err := h.store.CountOnTimeReturns(r.Context(), memberID)
 	if err == nil && onTimeCount > 0 && onTimeCount%10 == 0 {
@@ -1062,15 +1385,24 @@ func (h *Handler) HandleMemberReturn(w http.ResponseWriter, r *http.Request) {
 			_ = h.store.InsertActivity(r.Context(), "prestige", member.ProfileName, "")
 		}
 	}
-
-	http.Redirect(w, r, "/membership?success=returned", http.StatusSeeOther)
+	return nil
 }
 
 // ── Club handlers ───────────────────────────────────────────────────────────
 
-// getSessionMemberID extracts and parses the member UUID from the session cookie.
+// sessionMemberID returns the member ID of the request's active session as a
+// string, or "" if the request is not authenticated.
+func (h *Handler) sessionMemberID(r *http.Request) string {
+	ss := middleware.CurrentSession(r, h.keys, h.store)
+	if ss == nil {
+		return ""
+	}
+	return ss.MemberID.String()
+}
+
+// getSessionMemberID returns the member UUID of the request's active session.
 func (h *Handler) getSessionMemberID(r *http.Request) (uuid.UUID, bool) {
-	raw := auth.GetSessionMemberID(r, h.cookieSecret)
+	raw := h.sessionMemberID(r)
 	if raw == "" {
 		return uuid.UUID{}, false
 	}
@@ -1166,21 +1498,37 @@ func (h *Handler) ClubDetail(w http.ResponseWriter, r *http.Request, tmpl *templ
 		viewerRole, _ = h.store.GetClubMemberRole(r.Context(), clubID, id)
 	}
 
+	challenges, _ := h.store.ListClubChallenges(r.Context(), clubID)
+
+	// Admins pick the challenge cartridge from the whole catalog.
+	var games []models.Game
+	if viewerRole == models.ClubRoleAdmin {
+		games, _ = h.store.ListGames(r.Context())
+	}
+
 	data := struct {
 		LayoutData
 		Detail      *database.ClubDetail
 		ViewerRole  string
+		ViewerID    uuid.UUID
 		IsMember    bool
 		IsClubAdmin bool
 		IsCreator   bool
+		Challenges  []database.ClubChallengeView
+		Games       []models.Game
+		Now         time.Time
 		Success     string
 	}{
 		LayoutData:  ld,
 		Detail:      detail,
 		ViewerRole:  viewerRole,
+		ViewerID:    viewerID,
 		IsMember:    viewerRole != "",
 		IsClubAdmin: viewerRole == models.ClubRoleAdmin,
 		IsCreator:   viewerID == detail.Club.CreatedBy,
+		Challenges:  challenges,
+		Games:       games,
+		Now:         time.Now(),
 		Success:     r.URL.Query().Get("success"),
 	}
 
@@ -1205,6 +1553,12 @@ func (h *Handler) ClubFormPage(w http.ResponseWriter, r *http.Request, tmpl *tem
 		}
 	}
 
+	h.renderClubForm(w, r, tmpl, club, isEdit, "", http.StatusOK)
+}
+
+// renderClubForm renders the club form, filled in from club when it is not
+// nil, with the reason a badge upload was refused, if any.
+func (h *Handler) renderClubForm(w http.ResponseWriter, r *http.Request, tmpl *template.Template, club *models.Club, isEdit bool, badgeErr string, status int) {
 	title := "Create Club"
 	if isEdit && club != nil {
 		title = "Edit " + club.Name
@@ -1213,21 +1567,27 @@ func (h *Handler) ClubFormPage(w http.ResponseWriter, r *http.Request, tmpl *tem
 
 	data := struct {
 		LayoutData
-		Club   *models.Club
-		IsEdit bool
+		Club       *models.Club
+		IsEdit     bool
+		BadgeKind  media.Kind
+		BadgeError string
 	}{
 		LayoutData: ld,
 		Club:       club,
 		IsEdit:     isEdit,
+		BadgeKind:  h.badges.Kind(),
+		BadgeError: badgeErr,
 	}
 
+	w.WriteHeader(status)
 	if err := tmpl.Execute(w, data); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
 
-// CreateClub handles POST /clubs.
-func (h *Handler) CreateClub(w http.ResponseWriter, r *http.Request) {
+// CreateClub handles POST /clubs. A refused badge upload re-renders the form
+// with the reason.
+func (h *Handler) CreateClub(w http.ResponseWriter, r *http.Request, tmpl *template.Template) {
 	if h.store == nil {
 		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
 		return
@@ -1239,10 +1599,11 @@ func (h *Handler) CreateClub(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := r.ParseMultipartForm(10 << 20); err != nil {
+	if err := parseUploadForm(w, r, maxImageForm); err != nil {
 		http.Error(w, "Failed to process form", http.StatusBadRequest)
 		return
 	}
+	defer removeUploads(r)
 
 	name := strings.TrimSpace(r.FormValue("name"))
 	if name == "" {
@@ -1262,29 +1623,23 @@ func (h *Handler) CreateClub(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Handle badge file upload.
-	file, header, err := r.FormFile("badge_file")
+	file, _, err := r.FormFile("badge_file")
 	if err == nil {
 		defer file.Close()
-		ext := filepath.Ext(header.Filename)
-		if ext == "" {
-			ext = ".png"
-		}
-		filename := club.ID.String() + ext
-		savePath := filepath.Join("web", "static", "clubs", filename)
-		dst, err := os.Create(savePath)
+		badge, err := h.badges.Upload(r.Context(), club.ID.String(), file)
 		if err != nil {
+			if msg, ok := uploadErrorMessage(err, h.badges.Kind()); ok {
+				h.renderClubForm(w, r, tmpl, club, false, msg, http.StatusUnprocessableEntity)
+				return
+			}
 			http.Error(w, "Failed to save badge: "+err.Error(), http.StatusInternalServerError)
 			return
 		}
-		defer dst.Close()
-		if _, err := io.Copy(dst, file); err != nil {
-			http.Error(w, "Failed to write badge: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-		club.BadgeURL = "/static/clubs/" + filename
+		club.BadgeURL = badge.URL
 	}
 
 	if err := h.store.CreateClub(r.Context(), club); err != nil {
+		removeReplaced(r.Context(), h.badges, []string{club.BadgeURL}, nil)
 		if strings.Contains(err.Error(), "unique") || strings.Contains(err.Error(), "duplicate") {
 			http.Error(w, "A club with this name already exists", http.StatusConflict)
 			return
@@ -1301,8 +1656,9 @@ func (h *Handler) CreateClub(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/clubs/"+club.ID.String()+"?success=created", http.StatusSeeOther)
 }
 
-// UpdateClub handles POST /clubs/{id}/edit.
-func (h *Handler) UpdateClub(w http.ResponseWriter, r *http.Request) {
+// UpdateClub handles POST /clubs/{id}/edit. A refused badge upload
+// re-renders the form with the reason, and a replaced badge is deleted.
+func (h *Handler) UpdateClub(w http.ResponseWriter, r *http.Request, tmpl *template.Template) {
 	if h.store == nil {
 		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
 		return
@@ -1313,10 +1669,11 @@ func (h *Handler) UpdateClub(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := r.ParseMultipartForm(10 << 20); err != nil {
+	if err := parseUploadForm(w, r, maxImageForm); err != nil {
 		http.Error(w, "Failed to process form", http.StatusBadRequest)
 		return
 	}
+	defer removeUploads(r)
 
 	club, err := h.store.GetClubByID(r.Context(), clubID)
 	if err != nil || club == nil {
@@ -1330,37 +1687,33 @@ func (h *Handler) UpdateClub(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	oldBadge := club.BadgeURL
 	club.Name = name
 	club.Description = r.FormValue("description")
 	club.WebsiteURL = r.FormValue("website_url")
 
 	// Handle badge file upload.
-	file, header, err := r.FormFile("badge_file")
+	file, _, err := r.FormFile("badge_file")
 	if err == nil {
 		defer file.Close()
-		ext := filepath.Ext(header.Filename)
-		if ext == "" {
-			ext = ".png"
-		}
-		filename := club.ID.String() + ext
-		savePath := filepath.Join("web", "static", "clubs", filename)
-		dst, err := os.Create(savePath)
+		badge, err := h.badges.Upload(r.Context(), club.ID.String(), file)
 		if err != nil {
+			if msg, ok := uploadErrorMessage(err, h.badges.Kind()); ok {
+				h.renderClubForm(w, r, tmpl, club, true, msg, http.StatusUnprocessableEntity)
+				return
+			}
 			http.Error(w, "Failed to save badge: "+err.Error(), http.StatusInternalServerError)
 			return
 		}
-		defer dst.Close()
-		if _, err := io.Copy(dst, file); err != nil {
-			http.Error(w, "Failed to write badge: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-		club.BadgeURL = "/static/clubs/" + filename
+		club.BadgeURL = badge.URL
 	}
 
 	if err := h.store.UpdateClub(r.Context(), club); err != nil {
+		removeReplaced(r.Context(), h.badges, []string{club.BadgeURL}, []string{oldBadge})
 		http.Error(w, "Failed to update club: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
+	removeReplaced(r.Context(), h.badges, []string{oldBadge}, []string{club.BadgeURL})
 
 	http.Redirect(w, r, "/clubs/"+clubID.String()+"?success=updated", http.StatusSeeOther)
 }
@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cmellojr/modo-locadora/internal/database"
+	"github.com/cmellojr/modo-locadora/internal/models"
+	"github.com/google/uuid"
+)
+
+// ── League handlers (Gincana das Turmas) ────────────────────────────────────
+
+// LeaguePage handles GET /league and GET /league/{id}. Without an ID it shows
+// the running season; archived seasons show their frozen final standings.
+func (h *Handler) LeaguePage(w http.ResponseWriter, r *http.Request, tmpl *template.Template) {
+	ld := h.buildLayoutData(r, "Gincana das Turmas")
+
+	var season *models.LeagueSeason
+	var seasons []models.LeagueSeason
+	var standings []database.LeagueStanding
+	var rules []models.ScoringRule
+
+	if h.store != nil {
+		var err error
+		if idStr := r.PathValue("id"); idStr != "" {
+			id, perr := uuid.Parse(idStr)
+			if perr != nil {
+				http.Error(w, "Invalid season ID", http.StatusBadRequest)
+				return
+			}
+			season, err = h.store.GetLeagueSeason(r.Context(), id)
+			if err == nil && season == nil {
+				http.Error(w, "Season not found", http.StatusNotFound)
+				return
+			}
+		} else {
+			season, err = h.store.GetActiveLeagueSeason(r.Context())
+		}
+		if err != nil {
+			http.Error(w, "Failed to load season: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if season != nil {
+			standings, err = h.store.GetLeagueStandings(r.Context(), season)
+			if err != nil {
+				http.Error(w, "Failed to load standings: "+err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+		seasons, _ = h.store.ListLeagueSeasons(r.Context())
+		rules, _ = h.store.ListScoringRules(r.Context())
+	}
+
+	data := struct {
+		LayoutData
+		Season    *models.LeagueSeason
+		Standings []database.LeagueStanding
+		Seasons   []models.LeagueSeason
+		Rules     []models.ScoringRule
+	}{
+		LayoutData: ld,
+		Season:     season,
+		Standings:  standings,
+		Seasons:    seasons,
+		Rules:      rules,
+	}
+
+	if err := tmpl.Execute(w, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// AdminLeague handles GET /admin/league and renders the season and scoring rule controls.
+func (h *Handler) AdminLeague(w http.ResponseWriter, r *http.Request, tmpl *template.Template) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	ld := h.buildLayoutData(r, "Gincana das Turmas")
+
+	active, err := h.store.GetActiveLeagueSeason(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load season: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	seasons, _ := h.store.ListLeagueSeasons(r.Context())
+	rules, _ := h.store.ListScoringRules(r.Context())
+
+	data := struct {
+		LayoutData
+		Active  *models.LeagueSeason
+		Seasons []models.LeagueSeason
+		Rules   []models.ScoringRule
+		Success string
+	}{
+		LayoutData: ld,
+		Active:     active,
+		Seasons:    seasons,
+		Rules:      rules,
+		Success:    r.URL.Query().Get("success"),
+	}
+
+	if err := tmpl.Execute(w, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// CreateLeagueSeason handles POST /admin/league/seasons.
+func (h *Handler) CreateLeagueSeason(w http.ResponseWriter, r *http.Request) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	name := strings.TrimSpace(r.FormValue("name"))
+	if name == "" {
+		http.Error(w, "Season name is required", http.StatusBadRequest)
+		return
+	}
+
+	startsAt, err := time.ParseInLocation(challengeDateLayout, r.FormValue("starts_at"), time.Local)
+	if err != nil {
+		http.Error(w, "Invalid start date", http.StatusBadRequest)
+		return
+	}
+	endsAt, err := time.ParseInLocation(challengeDateLayout, r.FormValue("ends_at"), time.Local)
+	if err != nil {
+		http.Error(w, "Invalid end date", http.StatusBadRequest)
+		return
+	}
+	endsAt = endsAt.AddDate(0, 0, 1)
+	if !endsAt.After(startsAt) {
+		http.Error(w, "End date must be after start date", http.StatusBadRequest)
+		return
+	}
+
+	season := &models.LeagueSeason{
+		ID:        uuid.New(),
+		Name:      name,
+		StartsAt:  startsAt,
+		EndsAt:    endsAt,
+		Status:    models.SeasonStatusActive,
+		CreatedAt: time.Now(),
+	}
+
+	if err := h.store.CreateLeagueSeason(r.Context(), season); err != nil {
+		if strings.Contains(err.Error(), "unique") || strings.Contains(err.Error(), "duplicate") {
+			http.Error(w, "Archive the current season before starting a new one", http.StatusConflict)
+			return
+		}
+		http.Error(w, "Failed to create season: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/admin/league?success=season_created", http.StatusSeeOther)
+}
+
+// ArchiveLeagueSeason handles POST /admin/league/seasons/{id}/archive.
+func (h *Handler) ArchiveLeagueSeason(w http.ResponseWriter, r *http.Request) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	seasonID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid season ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.store.ArchiveLeagueSeason(r.Context(), seasonID); err != nil {
+		http.Error(w, "Failed to archive season: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	season, _ := h.store.GetLeagueSeason(r.Context(), seasonID)
+	if season != nil {
+		standings, _ := h.store.GetLeagueStandings(r.Context(), season)
+		if len(standings) > 0 && standings[0].Points > 0 {
+			_ = h.store.InsertActivity(r.Context(), "league_champion", standings[0].ClubName, season.Name)
+		}
+	}
+
+	http.Redirect(w, r, "/league/"+seasonID.String(), http.StatusSeeOther)
+}
+
+// UpdateScoringRules handles POST /admin/league/rules. Each rule is submitted
+// as a "points_<rule_key>" field.
+func (h *Handler) UpdateScoringRules(w http.ResponseWriter, r *http.Request) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	rules, err := h.store.ListScoringRules(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load scoring rules: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	for _, rule := range rules {
+		raw := r.FormValue("points_" + rule.Key)
+		if raw == "" {
+			continue
+		}
+		points, err := strconv.Atoi(raw)
+		if err != nil {
+			http.Error(w, "Invalid points for "+rule.Key, http.StatusBadRequest)
+			return
+		}
+		if points == rule.Points {
+			continue
+		}
+		if err := h.store.UpdateScoringRule(r.Context(), rule.Key, points); err != nil {
+			http.Error(w, "Failed to update scoring rule: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	http.Redirect(w, r, "/admin/league?success=rules_updated", http.StatusSeeOther)
+}
+
+// SetPrimaryClub handles POST /membership/primary-club. An empty club_id
+// credits the member's league points to every club they belong to.
+func (h *Handler) SetPrimaryClub(w http.ResponseWriter, r *http.Request) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	memberID, ok := h.getSessionMemberID(r)
+	if !ok {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+
+	var clubID *uuid.UUID
+	if raw := r.FormValue("club_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			http.Error(w, "Invalid club ID", http.StatusBadRequest)
+			return
+		}
+		clubID = &id
+	}
+
+	if err := h.store.SetPrimaryClub(r.Context(), memberID, clubID); err != nil {
+		http.Error(w, "Failed to set primary club: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	http.Redirect(w, r, "/membership?success=primary_club", http.StatusSeeOther)
+}
@@ -0,0 +1,287 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"html/template"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cmellojr/modo-locadora/internal/igdb"
+	"github.com/cmellojr/modo-locadora/internal/media"
+	"github.com/cmellojr/modo-locadora/internal/metadata"
+	"github.com/cmellojr/modo-locadora/internal/models"
+	"github.com/google/uuid"
+)
+
+// ── Game metadata handlers ──────────────────────────────────────────────────
+
+// maxCatalogUpload caps the size of an uploaded catalog file.
+const maxCatalogUpload = 5 << 20
+
+// providers returns the configured metadata providers, IGDB first.
+func (h *Handler) providers() []metadata.Provider {
+	var ps []metadata.Provider
+	if h.igdb != nil {
+		ps = append(ps, h.igdb)
+	}
+	if h.catalog != nil {
+		ps = append(ps, h.catalog)
+	}
+	return ps
+}
+
+// provider returns the configured provider for a source, or nil.
+func (h *Handler) provider(source string) metadata.Provider {
+	for _, p := range h.providers() {
+		if p.Source() == source {
+			return p
+		}
+	}
+	return nil
+}
+
+// SyncGameMetadata handles POST /admin/edit/{id}/sync. Field: force.
+// Reloads the game's metadata from the provider it was stocked from. Fields
+// staff edited by hand are kept unless force is set, which also forgets
+// those edits.
+func (h *Handler) SyncGameMetadata(w http.ResponseWriter, r *http.Request) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid game ID", http.StatusBadRequest)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+
+	game, err := h.store.GetGameByID(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Failed to retrieve game", http.StatusInternalServerError)
+		return
+	}
+	if game == nil {
+		http.Error(w, "Game not found", http.StatusNotFound)
+		return
+	}
+
+	editURL := "/admin/edit/" + game.ID.String()
+	if game.MetadataID == "" {
+		http.Redirect(w, r, editURL+"?error=no_source", http.StatusSeeOther)
+		return
+	}
+	p := h.provider(game.MetadataSource)
+	if p == nil {
+		http.Redirect(w, r, editURL+"?error=provider_unavailable", http.StatusSeeOther)
+		return
+	}
+
+	data, err := p.GetGame(r.Context(), game.MetadataID)
+	if err != nil {
+		log.Printf("[%s] Game %s failed: %v", game.MetadataSource, game.MetadataID, err)
+		slug := "provider_failed"
+		if errors.Is(err, igdb.ErrRateLimited) {
+			slug = "rate_limited"
+		}
+		http.Redirect(w, r, editURL+"?error="+slug, http.StatusSeeOther)
+		return
+	}
+	if data == nil {
+		http.Redirect(w, r, editURL+"?error=not_found", http.StatusSeeOther)
+		return
+	}
+
+	if r.PostForm.Get("force") == "on" {
+		game.EditedFields = nil
+	}
+	before := *game
+	applyMetadata(game, data)
+	h.mirrorCover(r.Context(), game)
+
+	if err := h.store.UpdateGame(r.Context(), game); err != nil {
+		http.Error(w, "Failed to update game: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	removeReplaced(r.Context(), h.covers, []string{before.CoverURL, before.CoverThumbURL}, []string{game.CoverURL, game.CoverThumbURL})
+
+	http.Redirect(w, r, editURL+"?success=synced", http.StatusSeeOther)
+}
+
+// UploadCatalog handles POST /admin/catalog. Field: catalog_file, a JSON or
+// CSV list of games added to the local catalog. Invalid files re-render the
+// stock page with the reason.
+func (h *Handler) UploadCatalog(w http.ResponseWriter, r *http.Request, tmpl *template.Template) {
+	if h.catalog == nil {
+		http.Error(w, "Local catalog not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	var tooLarge *http.MaxBytesError
+	err := parseUploadForm(w, r, 2*maxCatalogUpload)
+	defer removeUploads(r)
+	if errors.As(err, &tooLarge) {
+		h.renderStock(w, r, tmpl, "Arquivo grande demais: o limite é de 5 MB.", http.StatusUnprocessableEntity)
+		return
+	}
+	file, header, err := r.FormFile("catalog_file")
+	if err != nil {
+		h.renderStock(w, r, tmpl, "Escolha um arquivo .json ou .csv.", http.StatusUnprocessableEntity)
+		return
+	}
+	defer file.Close()
+	if header.Size > maxCatalogUpload {
+		h.renderStock(w, r, tmpl, "Arquivo grande demais: o limite é de 5 MB.", http.StatusUnprocessableEntity)
+		return
+	}
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		http.Error(w, "Failed to read catalog: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	n, err := h.catalog.Import(header.Filename, data)
+	if err != nil && n == 0 {
+		if errors.Is(err, metadata.ErrCatalogFile) {
+			h.renderStock(w, r, tmpl, "O catálogo precisa ser um arquivo .json ou .csv.", http.StatusUnprocessableEntity)
+			return
+		}
+		h.renderStock(w, r, tmpl, "Catálogo recusado: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		// Saved, but another catalog file failed to reload.
+		log.Printf("[catalog] Reload after import: %v", err)
+	}
+
+	http.Redirect(w, r, "/admin/stock?source="+metadata.SourceLocal+"&catalog=imported", http.StatusSeeOther)
+}
+
+// RemoveCatalog handles POST /admin/catalog/delete. Field: name, an
+// uploaded catalog file. The bundled catalog cannot be removed.
+func (h *Handler) RemoveCatalog(w http.ResponseWriter, r *http.Request) {
+	if h.catalog == nil {
+		http.Error(w, "Local catalog not configured", http.StatusServiceUnavailable)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.catalog.Remove(r.PostForm.Get("name")); err != nil {
+		if errors.Is(err, metadata.ErrCatalogFile) || errors.Is(err, os.ErrNotExist) {
+			http.Error(w, "Catalog file not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("[catalog] Remove: %v", err)
+	}
+
+	http.Redirect(w, r, "/admin/stock?source="+metadata.SourceLocal+"&catalog=removed", http.StatusSeeOther)
+}
+
+// applyMetadata copies the provider's metadata into the game, skipping the
+// fields staff edited and those the provider left empty.
+func applyMetadata(g *models.Game, data *metadata.Game) {
+	setString := func(field string, dst *string, v string) {
+		if v != "" && !g.IsEdited(field) {
+			*dst = v
+		}
+	}
+	setList := func(field string, dst *[]string, v []string) {
+		if len(v) > 0 && !g.IsEdited(field) {
+			*dst = v
+		}
+	}
+
+	setString(models.GameFieldTitle, &g.Title, data.Name)
+	setString(models.GameFieldSummary, &g.Summary, data.Summary)
+	// A mirrored cover is kept while the provider still offers the same image.
+	if data.CoverURL != "" && data.CoverURL != g.CoverSourceURL && !g.IsEdited(models.GameFieldCover) {
+		g.CoverURL, g.CoverThumbURL, g.CoverSourceURL = data.CoverURL, "", ""
+	}
+	if data.ReleaseDate != nil && !g.IsEdited(models.GameFieldReleaseDate) {
+		g.ReleaseDate = data.ReleaseDate
+	}
+	setList(models.GameFieldGenres, &g.Genres, data.Genres)
+	setList(models.GameFieldDevelopers, &g.Developers, data.Developers)
+	setList(models.GameFieldPublishers, &g.Publishers, data.Publishers)
+	setList(models.GameFieldScreenshots, &g.Screenshots, data.Screenshots)
+	if g.IgdbID == "" {
+		g.IgdbID = data.IgdbID
+	}
+
+	now := time.Now()
+	g.MetadataSyncedAt = &now
+}
+
+// mirrorCover copies a game's remote cover into media storage, with its
+// thumbnail. If the download fails the game keeps hotlinking the cover, and
+// the next sync or -mirror-covers run tries again.
+func (h *Handler) mirrorCover(ctx context.Context, g *models.Game) {
+	if !media.IsRemote(g.CoverURL) {
+		return
+	}
+	cover, err := h.covers.Mirror(ctx, g.ID.String(), g.CoverURL)
+	if err != nil {
+		log.Printf("[covers] Failed to mirror %s: %v", g.CoverURL, err)
+		return
+	}
+	g.CoverURL, g.CoverThumbURL, g.CoverSourceURL = cover.URL, cover.ThumbURL, cover.SourceURL
+}
+
+// markEditedFields records in after.EditedFields the metadata fields that
+// differ from before.
+func markEditedFields(before, after *models.Game) {
+	if before.Title != after.Title {
+		after.MarkEdited(models.GameFieldTitle)
+	}
+	if before.Summary != after.Summary {
+		after.MarkEdited(models.GameFieldSummary)
+	}
+	if before.CoverURL != after.CoverURL {
+		after.MarkEdited(models.GameFieldCover)
+	}
+	if formatDate(before.ReleaseDate) != formatDate(after.ReleaseDate) {
+		after.MarkEdited(models.GameFieldReleaseDate)
+	}
+	if !slices.Equal(before.Genres, after.Genres) {
+		after.MarkEdited(models.GameFieldGenres)
+	}
+	if !slices.Equal(before.Developers, after.Developers) {
+		after.MarkEdited(models.GameFieldDevelopers)
+	}
+	if !slices.Equal(before.Publishers, after.Publishers) {
+		after.MarkEdited(models.GameFieldPublishers)
+	}
+}
+
+// splitList parses a comma-separated form value, dropping blanks and repeats.
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part != "" && !slices.Contains(out, part) {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// formatDate formats a date for <input type="date">, or "" for nil.
+func formatDate(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
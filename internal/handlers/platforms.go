@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cmellojr/modo-locadora/internal/metadata"
+	"github.com/cmellojr/modo-locadora/internal/models"
+)
+
+// ── Platform registry helpers ───────────────────────────────────────────────
+
+// platformRegistryTTL is how long the platform registry is cached, so rows
+// added to the platforms table show up without a restart.
+const platformRegistryTTL = time.Minute
+
+// platformCache holds the platform registry between reads of the store.
+type platformCache struct {
+	mu       sync.Mutex
+	reg      models.Platforms
+	loadedAt time.Time // Zero until the first successful read.
+}
+
+// platformRegistry returns the platform registry, read from the store at
+// most once per platformRegistryTTL. A failed reload keeps the previous
+// registry; the error is returned only when there is none yet. It returns
+// nil when the database is not configured.
+func (h *Handler) platformRegistry(ctx context.Context) (models.Platforms, error) {
+	if h.store == nil {
+		return nil, nil
+	}
+	c := &h.platforms
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.loadedAt.IsZero() && time.Since(c.loadedAt) < platformRegistryTTL {
+		return c.reg, nil
+	}
+
+	reg, err := h.store.ListPlatformRegistry(ctx)
+	if err != nil {
+		if c.loadedAt.IsZero() {
+			return nil, err
+		}
+		log.Printf("[platforms] Keeping the cached registry: %v", err)
+		return c.reg, nil
+	}
+	c.reg, c.loadedAt = reg, time.Now()
+	return reg, nil
+}
+
+// resolvePlatform returns the registry platform for a form value, which may
+// be a comma-separated list such as "SNES, Genesis/MegaDrive": the first
+// entry the registry knows wins. It returns nil when none is known.
+func resolvePlatform(reg models.Platforms, value string) *models.Platform {
+	for _, name := range strings.Split(value, ",") {
+		if p := reg.Resolve(name); p != nil {
+			return p
+		}
+	}
+	return nil
+}
+
+// canonicalPlatforms rewrites a provider game's platforms to their registry
+// names, matching IGDB platforms by ID and the rest by name or alias.
+// Platforms the registry does not know keep the provider's name.
+func canonicalPlatforms(reg models.Platforms, g *metadata.Game) {
+	var names []string
+	var ids []int
+	for i, name := range g.Platforms {
+		var p *models.Platform
+		if i < len(g.IgdbPlatformIDs) {
+			p = reg.ByIGDBID(g.IgdbPlatformIDs[i])
+		}
+		if p == nil {
+			p = reg.Resolve(name)
+		}
+		if p != nil {
+			name = p.Name
+		}
+		if slices.Contains(names, name) {
+			continue
+		}
+		names = append(names, name)
+		if i < len(g.IgdbPlatformIDs) {
+			ids = append(ids, g.IgdbPlatformIDs[i])
+		}
+	}
+	g.Platforms, g.IgdbPlatformIDs = names, ids
+}
+
+// knownPlatform returns the first of a provider game's platforms that is in
+// the registry, or "". Call it after canonicalPlatforms.
+func knownPlatform(reg models.Platforms, g *metadata.Game) string {
+	for _, name := range g.Platforms {
+		if p := reg.Resolve(name); p != nil {
+			return p.Name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cmellojr/modo-locadora/internal/models"
+)
+
+// registryStore counts registry reads and fails them while err is set.
+type registryStore struct {
+	*stubStore
+	reads int
+	err   error
+}
+
+func (s *registryStore) ListPlatformRegistry(ctx context.Context) (models.Platforms, error) {
+	s.reads++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.stubStore.ListPlatformRegistry(ctx)
+}
+
+func TestPlatformRegistryCached(t *testing.T) {
+	store := &registryStore{stubStore: newStubStore()}
+	h := newTestHandler(t, store)
+	ctx := context.Background()
+
+	for range 3 {
+		if _, err := h.platformRegistry(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if store.reads != 1 {
+		t.Errorf("store reads = %d, want 1", store.reads)
+	}
+
+	// Once stale, a failed reload keeps the registry already read.
+	h.platforms.loadedAt = time.Now().Add(-2 * platformRegistryTTL)
+	store.err = errors.New("connection refused")
+	reg, err := h.platformRegistry(ctx)
+	if err != nil || reg.Resolve("Genesis") == nil {
+		t.Errorf("registry = %v, %v; want the cached one", reg, err)
+	}
+
+	store.err = nil
+	if _, err := h.platformRegistry(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if store.reads != 3 {
+		t.Errorf("store reads = %d, want 3", store.reads)
+	}
+}
+
+func TestPlatformRegistryLoadFailure(t *testing.T) {
+	store := &registryStore{stubStore: newStubStore(), err: errors.New("connection refused")}
+	h := newTestHandler(t, store)
+
+	form := url.Values{"title": {"Sonic"}, "platform": {"Mega Drive"}}
+	req := httptest.NewRequest("POST", "/admin/purchase", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	h.PurchaseGame(rec, req)
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("purchase status = %d, want 500 rather than an unknown platform", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest("GET", "/api/v1/games?platform=Genesis", nil)
+	h.APIGames(rec, req)
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("API status = %d, want 500", rec.Code)
+	}
+}
+
+func TestPlatformKeyFoldsAccents(t *testing.T) {
+	tests := []struct{ a, b string }{
+		{"Genesis/MegaDrive", "genesis megadrive"},
+		{"Mega Drive", "MEGA-DRIVE"},
+		{"Sega Gênesis", "Sega Genesis"},
+		{"Nintendo Família", "nintendo familia"},
+	}
+	for _, tt := range tests {
+		if models.PlatformKey(tt.a) != models.PlatformKey(tt.b) {
+			t.Errorf("PlatformKey(%q) = %q, PlatformKey(%q) = %q; want equal",
+				tt.a, models.PlatformKey(tt.a), tt.b, models.PlatformKey(tt.b))
+		}
+	}
+
+	reg := models.Platforms{{Name: "Mega Drive", Aliases: []string{"Genesis"}}}
+	if p := reg.Resolve("Gênesis"); p == nil || p.Name != "Mega Drive" {
+		t.Errorf("Resolve(Gênesis) = %v, want Mega Drive", p)
+	}
+}
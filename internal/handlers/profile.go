@@ -0,0 +1,387 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/cmellojr/modo-locadora/internal/auth"
+	"github.com/cmellojr/modo-locadora/internal/database"
+	"github.com/cmellojr/modo-locadora/internal/models"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ── Profile settings handlers ───────────────────────────────────────────────
+
+// Profile field limits.
+const (
+	maxAddressLength = 200
+	minPhoneDigits   = 8
+	maxPhoneLength   = 20
+)
+
+// deleteConfirmWord must be typed to confirm account deletion.
+const deleteConfirmWord = "APAGAR"
+
+// currentMember loads the logged-in member, redirecting to the counter when
+// there is none. Returns nil after redirecting.
+func (h *Handler) currentMember(w http.ResponseWriter, r *http.Request) *models.Member {
+	id, ok := h.getSessionMemberID(r)
+	if !ok {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return nil
+	}
+	member, err := h.store.GetMemberByID(r.Context(), id)
+	if err != nil || member == nil || member.IsDeleted() {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return nil
+	}
+	return member
+}
+
+// checkCurrentPassword re-authenticates the member for a sensitive action.
+// Attempts share the login bucket for the profile name, so a stolen session
+// cannot be used to guess the password. Returns a field error message or "".
+func (h *Handler) checkCurrentPassword(member *models.Member, password string) string {
+	if ok, wait := h.limits.loginName.Allow(strings.ToLower(member.ProfileName)); !ok {
+		return "Muitas tentativas. Tente de novo em " + formatWait(wait) + "."
+	}
+	if password == "" || bcrypt.CompareHashAndPassword([]byte(member.PasswordHash), []byte(password)) != nil {
+		return "Senha atual errada."
+	}
+	return ""
+}
+
+// phoneProblem describes what is wrong with a phone number, or returns "".
+func phoneProblem(phone string) string {
+	if phone == "" {
+		return ""
+	}
+	if len(phone) > maxPhoneLength {
+		return fmt.Sprintf("Use no máximo %d caracteres.", maxPhoneLength)
+	}
+	digits := 0
+	for _, r := range phone {
+		switch {
+		case r >= '0' && r <= '9':
+			digits++
+		case strings.ContainsRune(" +-()", r):
+		default:
+			return "Use só números, espaço, +, - e parênteses."
+		}
+	}
+	if digits < minPhoneDigits {
+		return "Esse telefone parece curto demais."
+	}
+	return ""
+}
+
+// ProfilePage handles GET /membership/profile.
+func (h *Handler) ProfilePage(w http.ResponseWriter, r *http.Request, tmpl *template.Template) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	member := h.currentMember(w, r)
+	if member == nil {
+		return
+	}
+	h.renderProfile(w, r, tmpl, member, nil, http.StatusOK)
+}
+
+// renderProfile renders the profile settings page with optional field errors.
+func (h *Handler) renderProfile(w http.ResponseWriter, r *http.Request, tmpl *template.Template, member *models.Member, errs FieldErrors, status int) {
+	ld := h.buildLayoutData(r, "Editar Carteirinha")
+
+	data := struct {
+		LayoutData
+		Member      *models.Member
+		Errors      FieldErrors
+		Success     string
+		MinLength   int
+		ConfirmWord string
+	}{
+		LayoutData:  ld,
+		Member:      member,
+		Errors:      errs,
+		Success:     r.URL.Query().Get("success"),
+		MinLength:   minPasswordLength,
+		ConfirmWord: deleteConfirmWord,
+	}
+
+	w.WriteHeader(status)
+	if err := tmpl.Execute(w, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// UpdateProfile handles POST /membership/profile. Fields: address, phone, favorite_console.
+func (h *Handler) UpdateProfile(w http.ResponseWriter, r *http.Request, tmpl *template.Template) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	member := h.currentMember(w, r)
+	if member == nil {
+		return
+	}
+
+	member.Address = strings.TrimSpace(r.FormValue("address"))
+	member.Phone = strings.TrimSpace(r.FormValue("phone"))
+	member.FavoriteConsole = strings.TrimSpace(r.FormValue("favorite_console"))
+
+	errs := FieldErrors{}
+	if utf8.RuneCountInString(member.Address) > maxAddressLength {
+		errs["address"] = fmt.Sprintf("Use no máximo %d caracteres.", maxAddressLength)
+	}
+	if msg := phoneProblem(member.Phone); msg != "" {
+		errs["phone"] = msg
+	}
+	if utf8.RuneCountInString(member.FavoriteConsole) > maxConsoleLength {
+		errs["favorite_console"] = fmt.Sprintf("Use no máximo %d caracteres.", maxConsoleLength)
+	}
+	if len(errs) > 0 {
+		h.renderProfile(w, r, tmpl, member, errs, http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err := h.store.UpdateMemberProfile(r.Context(), member.ID, member.Address, member.Phone, member.FavoriteConsole); err != nil {
+		http.Error(w, "Failed to update profile: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/membership/profile?success=profile_saved", http.StatusSeeOther)
+}
+
+// ChangePassword handles POST /membership/password. Fields: current_password,
+// new_password, new_password_confirm. Every other session is signed out.
+func (h *Handler) ChangePassword(w http.ResponseWriter, r *http.Request, tmpl *template.Template) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	member := h.currentMember(w, r)
+	if member == nil {
+		return
+	}
+
+	current := r.FormValue("current_password")
+	password := r.FormValue("new_password")
+
+	errs := FieldErrors{}
+	if msg := h.checkCurrentPassword(member, current); msg != "" {
+		errs["current_password"] = msg
+	} else if msg := passwordProblem(password, member.ProfileName, member.Email); msg != "" {
+		errs["new_password"] = msg
+	} else if password == current {
+		errs["new_password"] = "A senha nova precisa ser diferente da atual."
+	} else if r.FormValue("new_password_confirm") != password {
+		errs["new_password_confirm"] = "As duas senhas não batem."
+	}
+	if len(errs) > 0 {
+		h.renderProfile(w, r, tmpl, member, errs, http.StatusUnprocessableEntity)
+		return
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		http.Error(w, "Failed to process password", http.StatusInternalServerError)
+		return
+	}
+	if err := h.store.UpdateMemberPassword(r.Context(), member.ID, string(hashed)); err != nil {
+		http.Error(w, "Failed to update password: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Sign out everywhere, then start a fresh session on this device.
+	if _, err := h.store.RevokeMemberSessions(r.Context(), member.ID); err != nil {
+		log.Printf("Failed to revoke sessions after password change for %s: %v", member.ProfileName, err)
+	}
+	if err := h.startSession(w, r, member.ID); err != nil {
+		http.Error(w, "Failed to start session: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/membership/profile?success=password_changed", http.StatusSeeOther)
+}
+
+// memberExport is the "baixar meus dados" archive.
+type memberExport struct {
+	ExportedAt time.Time            `json:"exported_at"`
+	Member     memberExportProfile  `json:"member"`
+	Notes      string               `json:"password_notes"`
+	Rentals    []memberExportRental `json:"rentals"`
+	Clubs      []memberExportClub   `json:"clubs"`
+	Activities []memberExportEvent  `json:"activities"`
+}
+
+type memberExportProfile struct {
+	ProfileName      string     `json:"profile_name"`
+	Email            string     `json:"email"`
+	MembershipNumber string     `json:"membership_number"`
+	Address          string     `json:"address"`
+	Phone            string     `json:"phone"`
+	FavoriteConsole  string     `json:"favorite_console"`
+	Status           string     `json:"status"`
+	LateCount        int        `json:"late_count"`
+	EmailVerifiedAt  *time.Time `json:"email_verified_at"`
+	JoinedAt         time.Time  `json:"joined_at"`
+}
+
+type memberExportRental struct {
+	GameTitle    string     `json:"game_title"`
+	Platform     string     `json:"platform"`
+	RentedAt     time.Time  `json:"rented_at"`
+	DueAt        time.Time  `json:"due_at"`
+	ReturnedAt   *time.Time `json:"returned_at"`
+	PersonalNote string     `json:"personal_note"`
+	Verdict      string     `json:"verdict"`
+}
+
+type memberExportClub struct {
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
+
+type memberExportEvent struct {
+	EventType string    `json:"event_type"`
+	GameTitle string    `json:"game_title"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ExportData handles GET /membership/export, downloading everything the store
+// keeps about the member as a JSON file.
+func (h *Handler) ExportData(w http.ResponseWriter, r *http.Request) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	member := h.currentMember(w, r)
+	if member == nil {
+		return
+	}
+
+	rentals, err := h.store.ListMemberRentalRecords(r.Context(), member.ID)
+	if err != nil {
+		http.Error(w, "Failed to load rentals: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	clubs, err := h.store.ListMemberClubs(r.Context(), member.ID)
+	if err != nil {
+		http.Error(w, "Failed to load clubs: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	activities, err := h.store.ListMemberActivities(r.Context(), member.ProfileName)
+	if err != nil {
+		http.Error(w, "Failed to load activities: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	export := memberExport{
+		ExportedAt: time.Now().UTC(),
+		Member: memberExportProfile{
+			ProfileName:      member.ProfileName,
+			Email:            member.Email,
+			MembershipNumber: member.MembershipNumber,
+			Address:          member.Address,
+			Phone:            member.Phone,
+			FavoriteConsole:  member.FavoriteConsole,
+			Status:           member.Status,
+			LateCount:        member.LateCount,
+			EmailVerifiedAt:  member.EmailVerifiedAt,
+			JoinedAt:         member.JoinedAt,
+		},
+		Notes:      member.PasswordNotes,
+		Rentals:    make([]memberExportRental, 0, len(rentals)),
+		Clubs:      make([]memberExportClub, 0, len(clubs)),
+		Activities: make([]memberExportEvent, 0, len(activities)),
+	}
+	for _, rec := range rentals {
+		export.Rentals = append(export.Rentals, memberExportRental{
+			GameTitle:    rec.GameTitle,
+			Platform:     rec.Platform,
+			RentedAt:     rec.RentedAt,
+			DueAt:        rec.DueAt,
+			ReturnedAt:   rec.ReturnedAt,
+			PersonalNote: rec.PersonalNote,
+			Verdict:      rec.Verdict,
+		})
+	}
+	for _, c := range clubs {
+		export.Clubs = append(export.Clubs, memberExportClub{Name: c.Name, Role: c.Role})
+	}
+	for _, a := range activities {
+		export.Activities = append(export.Activities, memberExportEvent{
+			EventType: a.EventType,
+			GameTitle: a.GameTitle,
+			CreatedAt: a.CreatedAt,
+		})
+	}
+
+	filename := "modo-locadora-" + member.MembershipNumber + ".json"
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w.Header().Set("Cache-Control", "no-store")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(export); err != nil {
+		log.Printf("Failed to write data export for %s: %v", member.ProfileName, err)
+	}
+}
+
+// DeleteAccount handles POST /membership/delete. Fields: password and confirm,
+// which must be deleteConfirmWord.
+func (h *Handler) DeleteAccount(w http.ResponseWriter, r *http.Request, tmpl *template.Template) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	member := h.currentMember(w, r)
+	if member == nil {
+		return
+	}
+
+	errs := FieldErrors{}
+	if msg := h.checkCurrentPassword(member, r.FormValue("password")); msg != "" {
+		errs["delete_password"] = msg
+	}
+	if strings.ToUpper(strings.TrimSpace(r.FormValue("confirm"))) != deleteConfirmWord {
+		errs["delete_confirm"] = "Digite " + deleteConfirmWord + " para confirmar."
+	}
+	if len(errs) > 0 {
+		h.renderProfile(w, r, tmpl, member, errs, http.StatusUnprocessableEntity)
+		return
+	}
+
+	err := h.store.DeleteMemberAccount(r.Context(), member.ID)
+	switch {
+	case errors.Is(err, database.ErrOpenRentals):
+		h.renderProfile(w, r, tmpl, member, FieldErrors{
+			"delete": "Devolva todas as fitas antes de cancelar a carteirinha.",
+		}, http.StatusConflict)
+		return
+	case errors.Is(err, database.ErrLastOwner):
+		h.renderProfile(w, r, tmpl, member, FieldErrors{
+			"delete": "Você é o único Tio. Nomeie outro Tio em /admin/staff antes de sair.",
+		}, http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, "Failed to delete account: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Member %s deleted their account.", member.MembershipNumber)
+	auth.ClearSessionCookie(w)
+	http.Redirect(w, r, "/?success=account_deleted", http.StatusSeeOther)
+}
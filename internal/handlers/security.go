@@ -0,0 +1,289 @@
+package handlers
+
+import (
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cmellojr/modo-locadora/internal/models"
+	"github.com/cmellojr/modo-locadora/internal/ratelimit"
+	"github.com/google/uuid"
+)
+
+// ── Login protection handlers ───────────────────────────────────────────────
+
+// Lockout policy: after loginLockThreshold consecutive failures the member is
+// locked for loginLockBase, doubling with every further failure up to loginLockMax.
+const (
+	loginLockThreshold = 5
+	loginLockBase      = time.Minute
+	loginLockMax       = time.Hour
+)
+
+// limiters groups the token buckets guarding the unauthenticated endpoints.
+type limiters struct {
+	loginIP   *ratelimit.Limiter // POST /login by client IP.
+	loginName *ratelimit.Limiter // POST /login by profile name.
+	signupIP  *ratelimit.Limiter // POST /members by client IP.
+}
+
+func newLimiters() limiters {
+	return limiters{
+		loginIP:   ratelimit.New(10, 30*time.Second),
+		loginName: ratelimit.New(5, time.Minute),
+		signupIP:  ratelimit.New(3, 20*time.Minute),
+	}
+}
+
+// lockoutDuration returns how long to lock a member after the given number of
+// consecutive failed logins, or zero while under the threshold.
+func lockoutDuration(failures int) time.Duration {
+	if failures < loginLockThreshold {
+		return 0
+	}
+	d := loginLockBase
+	for i := loginLockThreshold; i < failures && d < loginLockMax; i++ {
+		d *= 2
+	}
+	if d > loginLockMax {
+		d = loginLockMax
+	}
+	return d
+}
+
+// formatWait renders a wait time in Portuguese, rounded up: in seconds under
+// a minute and in minutes after that ("45 segundos", "2 minutos").
+func formatWait(d time.Duration) string {
+	if d < time.Minute {
+		secs := int((d + time.Second - 1) / time.Second)
+		if secs <= 1 {
+			return "1 segundo"
+		}
+		return fmt.Sprintf("%d segundos", secs)
+	}
+	mins := int((d + time.Minute - 1) / time.Minute)
+	if mins == 1 {
+		return "1 minuto"
+	}
+	return fmt.Sprintf("%d minutos", mins)
+}
+
+// redirectLoginWait sends the visitor back to the counter with an error and
+// the number of seconds to wait.
+func redirectLoginWait(w http.ResponseWriter, r *http.Request, errSlug string, wait time.Duration) {
+	secs := int((wait + time.Second - 1) / time.Second)
+	http.Redirect(w, r, "/?error="+errSlug+"&wait="+strconv.Itoa(secs), http.StatusSeeOther)
+}
+
+// allowLogin applies the IP and profile name token buckets to a login attempt.
+// Returns false and the wait time when either bucket is empty.
+func (h *Handler) allowLogin(r *http.Request, profileName string) (bool, time.Duration) {
+	ip := clientIP(r)
+	if ok, wait := h.limits.loginIP.Allow(ip); !ok {
+		log.Printf("[security] Login rate limited for ip=%s", ip)
+		return false, wait
+	}
+	if ok, wait := h.limits.loginName.Allow(strings.ToLower(profileName)); !ok {
+		log.Printf("[security] Login rate limited for profile=%q ip=%s", profileName, ip)
+		return false, wait
+	}
+	return true, 0
+}
+
+// registerLoginFailure counts a failed login for the member and locks the
+// account once the threshold is reached. Returns the lockout length, or zero.
+func (h *Handler) registerLoginFailure(r *http.Request, member *models.Member) time.Duration {
+	failures, err := h.store.RecordLoginFailure(r.Context(), member.ID)
+	if err != nil {
+		log.Printf("[security] Failed to record login failure for %s: %v", member.ProfileName, err)
+		return 0
+	}
+
+	lock := lockoutDuration(failures)
+	if lock == 0 {
+		return 0
+	}
+
+	if err := h.store.LockMember(r.Context(), member.ID, time.Now().Add(lock)); err != nil {
+		log.Printf("[security] Failed to lock %s: %v", member.ProfileName, err)
+		return 0
+	}
+
+	h.recordSecurityEvent(r, models.SecurityEventLoginLockout, &member.ID, member.ProfileName,
+		fmt.Sprintf("%d failed logins, locked for %v", failures, lock))
+	return lock
+}
+
+// recordSecurityEvent logs a security event and persists it for the staff.
+func (h *Handler) recordSecurityEvent(r *http.Request, kind string, memberID *uuid.UUID, profileName, detail string) {
+	ip := clientIP(r)
+	log.Printf("[security] %s profile=%q ip=%s: %s", kind, profileName, ip, detail)
+
+	event := &models.SecurityEvent{
+		ID:          uuid.New(),
+		Kind:        kind,
+		MemberID:    memberID,
+		ProfileName: profileName,
+		IP:          ip,
+		Detail:      detail,
+		CreatedAt:   time.Now(),
+	}
+	if err := h.store.RecordSecurityEvent(r.Context(), event); err != nil {
+		log.Printf("[security] Failed to persist %s event: %v", kind, err)
+	}
+}
+
+// LockedMemberView is a locked account for the admin security page.
+type LockedMemberView struct {
+	ID           uuid.UUID
+	ProfileName  string
+	Email        string
+	FailedLogins int
+	LockedUntil  string
+	WaitLeft     string
+}
+
+// SecurityEventView is a security event formatted for display.
+type SecurityEventView struct {
+	Kind        string
+	Label       string
+	ProfileName string
+	IP          string
+	Detail      string
+	CreatedAt   string
+}
+
+// securityEventLabel returns the Portuguese label for a security event kind.
+func securityEventLabel(kind string) string {
+	switch kind {
+	case models.SecurityEventLoginLockout:
+		return "Carteirinha travada"
+	case models.SecurityEventAccountUnlocked:
+		return "Carteirinha destravada"
+	case models.SecurityEventTwoFactorOn:
+		return "Senha do controle ligada"
+	case models.SecurityEventTwoFactorOff:
+		return "Senha do controle desligada"
+	case models.SecurityEventRecoveryUsed:
+		return "Código de emergência usado"
+	case models.SecurityEventAPITokenCreated:
+		return "Token de API criado"
+	case models.SecurityEventAPITokenRevoked:
+		return "Token de API revogado"
+	case models.SecurityEventWebhookCreated:
+		return "Webhook criado"
+	case models.SecurityEventWebhookDeleted:
+		return "Webhook apagado"
+	default:
+		return kind
+	}
+}
+
+// AdminSecurity handles GET /admin/security, listing locked accounts and
+// recent security events.
+func (h *Handler) AdminSecurity(w http.ResponseWriter, r *http.Request, tmpl *template.Template) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	ld := h.buildLayoutData(r, "Seguranca do Balcao")
+
+	members, err := h.store.ListLockedMembers(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load locked members: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	events, err := h.store.ListSecurityEvents(r.Context(), 50)
+	if err != nil {
+		http.Error(w, "Failed to load security events: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	locked := make([]LockedMemberView, 0, len(members))
+	for _, m := range members {
+		locked = append(locked, LockedMemberView{
+			ID:           m.ID,
+			ProfileName:  m.ProfileName,
+			Email:        m.Email,
+			FailedLogins: m.FailedLogins,
+			LockedUntil:  m.LockedUntil.Format("02/01/2006 15:04"),
+			WaitLeft:     formatWait(m.LockedUntil.Sub(now)),
+		})
+	}
+
+	eventViews := make([]SecurityEventView, 0, len(events))
+	for _, e := range events {
+		eventViews = append(eventViews, SecurityEventView{
+			Kind:        e.Kind,
+			Label:       securityEventLabel(e.Kind),
+			ProfileName: e.ProfileName,
+			IP:          e.IP,
+			Detail:      e.Detail,
+			CreatedAt:   e.CreatedAt.Format("02/01/2006 15:04"),
+		})
+	}
+
+	data := struct {
+		LayoutData
+		Locked  []LockedMemberView
+		Events  []SecurityEventView
+		Success string
+	}{
+		LayoutData: ld,
+		Locked:     locked,
+		Events:     eventViews,
+		Success:    r.URL.Query().Get("success"),
+	}
+
+	if err := tmpl.Execute(w, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// UnlockMember handles POST /admin/security/{id}/unlock, clearing a lockout.
+func (h *Handler) UnlockMember(w http.ResponseWriter, r *http.Request) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	memberID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid member ID", http.StatusBadRequest)
+		return
+	}
+
+	member, err := h.store.GetMemberByID(r.Context(), memberID)
+	if err != nil {
+		http.Error(w, "Failed to load member: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if member == nil {
+		http.Error(w, "Member not found", http.StatusNotFound)
+		return
+	}
+
+	if err := h.store.ResetLoginFailures(r.Context(), member.ID); err != nil {
+		http.Error(w, "Failed to unlock member: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.limits.loginName.Reset(strings.ToLower(member.ProfileName))
+
+	unlockedBy := "staff"
+	if staffID, ok := h.getSessionMemberID(r); ok {
+		if staff, err := h.store.GetMemberByID(r.Context(), staffID); err == nil && staff != nil {
+			unlockedBy = staff.ProfileName
+		}
+	}
+	h.recordSecurityEvent(r, models.SecurityEventAccountUnlocked, &member.ID, member.ProfileName,
+		"unlocked by "+unlockedBy)
+
+	http.Redirect(w, r, "/admin/security?success=unlocked", http.StatusSeeOther)
+}
@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"html/template"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cmellojr/modo-locadora/internal/auth"
+	"github.com/cmellojr/modo-locadora/internal/database"
+	"github.com/cmellojr/modo-locadora/internal/middleware"
+	"github.com/cmellojr/modo-locadora/internal/models"
+	"github.com/google/uuid"
+)
+
+// ── Session handlers ────────────────────────────────────────────────────────
+
+// SessionView represents an active session for display on the membership page.
+type SessionView struct {
+	Device    string
+	IPAddress string
+	CreatedAt time.Time
+	LastSeen  string
+	IsCurrent bool
+}
+
+// startSession creates a server-side session for the member and sets the cookie.
+func (h *Handler) startSession(w http.ResponseWriter, r *http.Request, memberID uuid.UUID) error {
+	token, err := auth.NewToken()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	ss := &models.Session{
+		ID:         uuid.New(),
+		MemberID:   memberID,
+		TokenHash:  auth.HashToken(token),
+		UserAgent:  truncate(r.UserAgent(), 255),
+		IPAddress:  clientIP(r),
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(auth.SessionMaxAge),
+	}
+	if err := h.store.CreateSession(r.Context(), ss); err != nil {
+		return err
+	}
+
+	auth.SetSessionCookie(w, token, h.keys)
+	return nil
+}
+
+// listSessionViews returns the member's active sessions, flagging the one
+// making this request.
+func (h *Handler) listSessionViews(r *http.Request, memberID uuid.UUID) []SessionView {
+	sessions, err := h.store.ListMemberSessions(r.Context(), memberID, auth.SessionIdleTimeout)
+	if err != nil {
+		return nil
+	}
+
+	var currentID uuid.UUID
+	if ss := middleware.CurrentSession(r, h.keys, h.store); ss != nil {
+		currentID = ss.ID
+	}
+
+	views := make([]SessionView, 0, len(sessions))
+	for _, ss := range sessions {
+		views = append(views, SessionView{
+			Device:    describeUserAgent(ss.UserAgent),
+			IPAddress: ss.IPAddress,
+			CreatedAt: ss.CreatedAt,
+			LastSeen:  formatTimeAgo(ss.LastSeenAt),
+			IsCurrent: ss.ID == currentID,
+		})
+	}
+	return views
+}
+
+// RevokeAllSessions handles POST /membership/sessions/revoke-all, signing the
+// member out of every device including the current one.
+func (h *Handler) RevokeAllSessions(w http.ResponseWriter, r *http.Request) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	memberID, ok := h.getSessionMemberID(r)
+	if !ok {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+
+	if _, err := h.store.RevokeMemberSessions(r.Context(), memberID); err != nil {
+		http.Error(w, "Failed to revoke sessions: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	auth.ClearSessionCookie(w)
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+// AdminMembers handles GET /admin/members, listing members and their active sessions.
+func (h *Handler) AdminMembers(w http.ResponseWriter, r *http.Request, tmpl *template.Template) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	ld := h.buildLayoutData(r, "Socios")
+
+	members, err := h.store.ListMembersForAdmin(r.Context(), auth.SessionIdleTimeout)
+	if err != nil {
+		http.Error(w, "Failed to load members: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var active, pending []database.AdminMemberView
+	for _, m := range members {
+		if m.PendingApproval {
+			pending = append(pending, m)
+		} else {
+			active = append(active, m)
+		}
+	}
+
+	data := struct {
+		LayoutData
+		Members []database.AdminMemberView
+		Pending []database.AdminMemberView
+		Success string
+	}{
+		LayoutData: ld,
+		Members:    active,
+		Pending:    pending,
+		Success:    r.URL.Query().Get("success"),
+	}
+
+	if err := tmpl.Execute(w, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// AdminRevokeMemberSessions handles POST /admin/members/{id}/revoke-sessions.
+func (h *Handler) AdminRevokeMemberSessions(w http.ResponseWriter, r *http.Request) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	memberID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid member ID", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.store.RevokeMemberSessions(r.Context(), memberID); err != nil {
+		http.Error(w, "Failed to revoke sessions: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/admin/members?success=sessions_revoked", http.StatusSeeOther)
+}
+
+// clientIP returns the host part of the request's remote address.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// describeUserAgent turns a User-Agent header into a short "browser / system" label.
+func describeUserAgent(ua string) string {
+	if ua == "" {
+		return "Aparelho desconhecido"
+	}
+
+	browser := "Navegador"
+	switch {
+	case strings.Contains(ua, "Edg/"):
+		browser = "Edge"
+	case strings.Contains(ua, "OPR/"):
+		browser = "Opera"
+	case strings.Contains(ua, "Firefox/"):
+		browser = "Firefox"
+	case strings.Contains(ua, "Chrome/"):
+		browser = "Chrome"
+	case strings.Contains(ua, "Safari/"):
+		browser = "Safari"
+	case strings.Contains(ua, "curl/"):
+		browser = "curl"
+	}
+
+	system := ""
+	switch {
+	case strings.Contains(ua, "Android"):
+		system = "Android"
+	case strings.Contains(ua, "iPhone"), strings.Contains(ua, "iPad"):
+		system = "iOS"
+	case strings.Contains(ua, "Windows"):
+		system = "Windows"
+	case strings.Contains(ua, "Mac OS X"):
+		system = "macOS"
+	case strings.Contains(ua, "Linux"):
+		system = "Linux"
+	}
+
+	if system == "" {
+		return browser
+	}
+	return browser + " / " + system
+}
+
+// truncate shortens s to at most n bytes.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
+
+// CSRFFailure renders the friendly error page for a missing or stale CSRF token.
+func (h *Handler) CSRFFailure(w http.ResponseWriter, r *http.Request, tmpl *template.Template) {
+	if isAPIRequest(r) {
+		writeAPIError(w, http.StatusForbidden, "csrf_failed", "Missing or invalid X-CSRF-Token header.")
+		return
+	}
+
+	ld := h.buildLayoutData(r, "Ficha vencida")
+
+	back := "/"
+	if ref := r.Referer(); ref != "" && strings.HasPrefix(ref, "http") {
+		if u, err := url.Parse(ref); err == nil && u.Host == r.Host {
+			back = u.RequestURI()
+		}
+	}
+
+	data := struct {
+		LayoutData
+		BackURL string
+	}{
+		LayoutData: ld,
+		BackURL:    back,
+	}
+
+	w.WriteHeader(http.StatusForbidden)
+	if err := tmpl.Execute(w, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
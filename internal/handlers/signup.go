@@ -0,0 +1,546 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/cmellojr/modo-locadora/internal/database"
+	"github.com/cmellojr/modo-locadora/internal/mailer"
+	"github.com/cmellojr/modo-locadora/internal/models"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ── Sign-up handlers ────────────────────────────────────────────────────────
+
+// Sign-up limits.
+const (
+	minProfileNameLength = 3
+	maxProfileNameLength = 24
+	maxPasswordBytes     = 72 // bcrypt ignores everything past 72 bytes.
+	maxEmailLength       = 254
+	maxConsoleLength     = 40
+)
+
+// commonPasswords are refused outright, whatever their length.
+var commonPasswords = map[string]bool{
+	"12345678": true, "123456789": true, "1234567890": true, "87654321": true,
+	"password": true, "password1": true, "senha123": true, "senha1234": true,
+	"qwerty123": true, "abc12345": true, "11111111": true, "00000000": true,
+	"iloveyou1": true, "nintendo64": true, "playstation1": true, "supermario1": true,
+}
+
+// SignupForm holds the fields of a sign-up, from the HTML form or JSON.
+type SignupForm struct {
+	ProfileName     string `json:"profile_name"`
+	Email           string `json:"email"`
+	Password        string `json:"password"`
+	PasswordConfirm string `json:"-"`
+	FavoriteConsole string `json:"favorite_console"`
+	InviteCode      string `json:"invite_code"`
+}
+
+// FieldErrors maps a form field to a friendly error message in Portuguese.
+type FieldErrors map[string]string
+
+// normalize trims the fields and lower-cases the e-mail.
+func (f *SignupForm) normalize() {
+	f.ProfileName = strings.TrimSpace(f.ProfileName)
+	f.Email = strings.ToLower(strings.TrimSpace(f.Email))
+	f.FavoriteConsole = strings.TrimSpace(f.FavoriteConsole)
+	f.InviteCode = strings.ToUpper(strings.TrimSpace(f.InviteCode))
+}
+
+// validate checks the form and returns one message per invalid field.
+// The password confirmation is only checked when checkConfirm is set.
+func (f *SignupForm) validate(checkConfirm bool) FieldErrors {
+	errs := FieldErrors{}
+
+	if msg := profileNameProblem(f.ProfileName); msg != "" {
+		errs["profile_name"] = msg
+	}
+	if msg := emailProblem(f.Email); msg != "" {
+		errs["email"] = msg
+	}
+	if msg := passwordProblem(f.Password, f.ProfileName, f.Email); msg != "" {
+		errs["password"] = msg
+	} else if checkConfirm && f.PasswordConfirm != f.Password {
+		errs["password_confirm"] = "As duas senhas não batem."
+	}
+	if utf8.RuneCountInString(f.FavoriteConsole) > maxConsoleLength {
+		errs["favorite_console"] = fmt.Sprintf("Use no máximo %d caracteres.", maxConsoleLength)
+	}
+	return errs
+}
+
+// profileNameProblem describes what is wrong with a profile name, or returns "".
+func profileNameProblem(name string) string {
+	n := utf8.RuneCountInString(name)
+	switch {
+	case n == 0:
+		return "Escolha um nome de sócio."
+	case n < minProfileNameLength || n > maxProfileNameLength:
+		return fmt.Sprintf("O nome precisa ter de %d a %d caracteres.", minProfileNameLength, maxProfileNameLength)
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-' && r != '.' {
+			return "Use só letras, números, ponto, hífen e sublinhado."
+		}
+	}
+	return ""
+}
+
+// emailProblem describes what is wrong with an e-mail address, or returns "".
+func emailProblem(email string) string {
+	if email == "" {
+		return "Informe seu e-mail."
+	}
+	if len(email) > maxEmailLength {
+		return "Esse e-mail é comprido demais."
+	}
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || addr.Name != "" {
+		return "Esse e-mail não parece válido."
+	}
+	at := strings.LastIndex(email, "@")
+	if domain := email[at+1:]; !strings.Contains(domain, ".") || strings.HasSuffix(domain, ".") {
+		return "Esse e-mail não parece válido."
+	}
+	return ""
+}
+
+// passwordProblem describes why a password is too weak, or returns "".
+func passwordProblem(password, profileName, email string) string {
+	switch {
+	case len(password) < minPasswordLength:
+		return fmt.Sprintf("A senha precisa ter pelo menos %d caracteres.", minPasswordLength)
+	case len(password) > maxPasswordBytes:
+		return fmt.Sprintf("A senha pode ter no máximo %d caracteres.", maxPasswordBytes)
+	}
+
+	var hasLetter, hasDigit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+	if !hasLetter || !hasDigit {
+		return "Misture letras e números na senha."
+	}
+
+	lower := strings.ToLower(password)
+	if commonPasswords[lower] {
+		return "Essa senha é manjada demais. Escolha outra."
+	}
+	if profileName != "" && strings.Contains(lower, strings.ToLower(profileName)) {
+		return "A senha não pode conter seu nome de sócio."
+	}
+	if local, _, ok := strings.Cut(email, "@"); ok && len(local) >= minProfileNameLength && strings.Contains(lower, local) {
+		return "A senha não pode conter seu e-mail."
+	}
+	return ""
+}
+
+// registerMember validates a sign-up and creates the member according to the
+// sign-up mode. Field errors are returned for anything the visitor can fix;
+// err is set only for unexpected failures.
+func (h *Handler) registerMember(r *http.Request, f *SignupForm, checkConfirm bool) (*models.Member, FieldErrors, error) {
+	f.normalize()
+	errs := f.validate(checkConfirm)
+
+	// The ADMIN_EMAIL member skips invites and approval, so a closed store
+	// can still get its first owner.
+	mode := h.signupMode
+	if h.adminEmail != "" && strings.EqualFold(f.Email, h.adminEmail) {
+		mode = models.SignupModeOpen
+	}
+	if mode == models.SignupModeInvite && f.InviteCode == "" {
+		errs["invite_code"] = "A locadora só aceita sócios convidados. Informe o código do convite."
+	}
+	if len(errs) > 0 {
+		return nil, errs, nil
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(f.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to process password: %w", err)
+	}
+
+	membershipNumber, err := h.store.NextMembershipNumber(r.Context())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	member := &models.Member{
+		ID:               uuid.New(),
+		ProfileName:      f.ProfileName,
+		Email:            f.Email,
+		PasswordHash:     string(hashedPassword),
+		FavoriteConsole:  f.FavoriteConsole,
+		MembershipNumber: membershipNumber,
+		PendingApproval:  mode == models.SignupModeApproval,
+		JoinedAt:         time.Now(),
+	}
+
+	if mode == models.SignupModeInvite {
+		err = h.store.CreateInvitedMember(r.Context(), member, f.InviteCode)
+	} else {
+		err = h.store.CreateMember(r.Context(), member)
+	}
+	switch {
+	case errors.Is(err, database.ErrProfileNameTaken):
+		return nil, FieldErrors{"profile_name": "Esse nome já tem dono. Escolha outro."}, nil
+	case errors.Is(err, database.ErrEmailTaken):
+		return nil, FieldErrors{"email": "Já existe uma carteirinha com esse e-mail. Esqueceu a senha?"}, nil
+	case errors.Is(err, database.ErrInvalidInvite):
+		return nil, FieldErrors{"invite_code": "Convite inválido, vencido ou já usado."}, nil
+	case err != nil:
+		return nil, nil, err
+	}
+
+	if err := h.sendVerificationEmail(r, member); err != nil {
+		log.Printf("[mailer] Failed to send verification e-mail to %s: %v", member.Email, err)
+	}
+	return member, nil, nil
+}
+
+// SignupPage handles GET /signup, the "fazer a carteirinha" form.
+func (h *Handler) SignupPage(w http.ResponseWriter, r *http.Request, tmpl *template.Template) {
+	if h.sessionMemberID(r) != "" {
+		http.Redirect(w, r, "/membership", http.StatusSeeOther)
+		return
+	}
+	h.renderSignup(w, r, tmpl, &SignupForm{InviteCode: r.URL.Query().Get("invite")}, nil, http.StatusOK)
+}
+
+// renderSignup renders the sign-up form with the submitted values and errors.
+func (h *Handler) renderSignup(w http.ResponseWriter, r *http.Request, tmpl *template.Template, f *SignupForm, errs FieldErrors, status int) {
+	ld := h.buildLayoutData(r, "Fazer a Carteirinha")
+
+	data := struct {
+		LayoutData
+		Form       *SignupForm
+		Errors     FieldErrors
+		Mode       string
+		MinLength  int
+		MaxNameLen int
+	}{
+		LayoutData: ld,
+		Form:       f,
+		Errors:     errs,
+		Mode:       h.signupMode,
+		MinLength:  minPasswordLength,
+		MaxNameLen: maxProfileNameLength,
+	}
+
+	w.WriteHeader(status)
+	if err := tmpl.Execute(w, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Signup handles POST /signup. Fields: profile_name, email, password,
+// password_confirm, favorite_console and, in invite mode, invite_code.
+func (h *Handler) Signup(w http.ResponseWriter, r *http.Request, tmpl *template.Template) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	f := &SignupForm{
+		ProfileName:     r.FormValue("profile_name"),
+		Email:           r.FormValue("email"),
+		Password:        r.FormValue("password"),
+		PasswordConfirm: r.FormValue("password_confirm"),
+		FavoriteConsole: r.FormValue("favorite_console"),
+		InviteCode:      r.FormValue("invite_code"),
+	}
+
+	if ok, wait := h.limits.signupIP.Allow(clientIP(r)); !ok {
+		log.Printf("[security] Sign-up rate limited for ip=%s", clientIP(r))
+		h.renderSignup(w, r, tmpl, f, FieldErrors{
+			"form": "Muitas carteirinhas feitas daqui. Tente de novo em " + formatWait(wait) + ".",
+		}, http.StatusTooManyRequests)
+		return
+	}
+
+	member, errs, err := h.registerMember(r, f, true)
+	if err != nil {
+		http.Error(w, "Failed to create member: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(errs) > 0 {
+		f.Password, f.PasswordConfirm = "", ""
+		h.renderSignup(w, r, tmpl, f, errs, http.StatusUnprocessableEntity)
+		return
+	}
+
+	if member.PendingApproval {
+		http.Redirect(w, r, "/?success=pending_approval", http.StatusSeeOther)
+		return
+	}
+
+	if err := h.startSession(w, r, member.ID); err != nil {
+		http.Error(w, "Failed to start session: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/membership?success=welcome", http.StatusSeeOther)
+}
+
+// fieldErrorsBody is the 422 body of POST /members.
+type fieldErrorsBody struct {
+	Errors FieldErrors `json:"errors"`
+}
+
+// writeFieldErrors responds 422 with the field errors as JSON.
+func writeFieldErrors(w http.ResponseWriter, errs FieldErrors) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	json.NewEncoder(w).Encode(fieldErrorsBody{Errors: errs})
+}
+
+// ApproveMember handles POST /admin/members/{id}/approve.
+func (h *Handler) ApproveMember(w http.ResponseWriter, r *http.Request) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	memberID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid member ID", http.StatusBadRequest)
+		return
+	}
+
+	member, err := h.store.GetMemberByID(r.Context(), memberID)
+	if err != nil {
+		http.Error(w, "Failed to load member: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if member == nil || !member.PendingApproval {
+		http.Error(w, "Member not pending approval", http.StatusNotFound)
+		return
+	}
+
+	if err := h.store.ApproveMember(r.Context(), member.ID); err != nil {
+		http.Error(w, "Failed to approve member: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if h.mailer != nil {
+		err := h.mailer.Send(r.Context(), mailer.Message{
+			To:      member.Email,
+			Subject: "Sua carteirinha do Modo Locadora foi aprovada",
+			Body: fmt.Sprintf(`Fala, %s!
+
+O Tio aprovou sua carteirinha %s. Já pode entrar no balcão:
+
+%s
+
+-- O Tio da Locadora`, member.ProfileName, member.MembershipNumber, h.absoluteURL(r, "/")),
+		})
+		if err != nil {
+			log.Printf("[mailer] Failed to send approval e-mail to %s: %v", member.Email, err)
+		}
+	}
+
+	http.Redirect(w, r, "/admin/members?success=approved", http.StatusSeeOther)
+}
+
+// RejectMember handles POST /admin/members/{id}/reject, deleting a pending sign-up.
+func (h *Handler) RejectMember(w http.ResponseWriter, r *http.Request) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	memberID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid member ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.store.RejectMember(r.Context(), memberID); err != nil {
+		http.Error(w, "Failed to reject member: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/admin/members?success=rejected", http.StatusSeeOther)
+}
+
+// InviteCodeView is an invite code formatted for the admin page.
+type InviteCodeView struct {
+	ID            uuid.UUID
+	Code          string
+	Uses          int
+	MaxUses       int
+	CreatedByName string
+	CreatedAt     string
+	ExpiresAt     string
+	Status        string // "active", "used_up", "expired" or "revoked"
+}
+
+// inviteCodeStatus returns the display status of an invite code at now.
+func inviteCodeStatus(c *models.InviteCode, now time.Time) string {
+	switch {
+	case c.RevokedAt != nil:
+		return "revoked"
+	case c.Uses >= c.MaxUses:
+		return "used_up"
+	case c.ExpiresAt != nil && !now.Before(*c.ExpiresAt):
+		return "expired"
+	default:
+		return "active"
+	}
+}
+
+// newInviteCode returns a random code like "K7QX-P2MD", avoiding look-alike characters.
+func newInviteCode() (string, error) {
+	const alphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	for i := range b {
+		b[i] = alphabet[int(b[i])%len(alphabet)]
+	}
+	return string(b[:4]) + "-" + string(b[4:]), nil
+}
+
+// AdminInvites handles GET /admin/invites, listing invite codes.
+func (h *Handler) AdminInvites(w http.ResponseWriter, r *http.Request, tmpl *template.Template) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	ld := h.buildLayoutData(r, "Convites")
+
+	codes, err := h.store.ListInviteCodes(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load invite codes: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	views := make([]InviteCodeView, 0, len(codes))
+	for i := range codes {
+		c := &codes[i]
+		v := InviteCodeView{
+			ID:            c.ID,
+			Code:          c.Code,
+			Uses:          c.Uses,
+			MaxUses:       c.MaxUses,
+			CreatedByName: c.CreatedByName,
+			CreatedAt:     c.CreatedAt.Format("02/01/2006"),
+			Status:        inviteCodeStatus(c, now),
+		}
+		if c.ExpiresAt != nil {
+			v.ExpiresAt = c.ExpiresAt.Format("02/01/2006 15:04")
+		}
+		views = append(views, v)
+	}
+
+	data := struct {
+		LayoutData
+		Codes     []InviteCodeView
+		Mode      string
+		SignupURL string
+		Success   string
+		Error     string
+	}{
+		LayoutData: ld,
+		Codes:      views,
+		Mode:       h.signupMode,
+		SignupURL:  h.absoluteURL(r, "/signup"),
+		Success:    r.URL.Query().Get("success"),
+		Error:      r.URL.Query().Get("error"),
+	}
+
+	if err := tmpl.Execute(w, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// CreateInviteCode handles POST /admin/invites. Fields: max_uses (1-100)
+// and valid_days (0 for no expiry, up to 365).
+func (h *Handler) CreateInviteCode(w http.ResponseWriter, r *http.Request) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	maxUses, err := strconv.Atoi(r.FormValue("max_uses"))
+	if err != nil || maxUses < 1 || maxUses > 100 {
+		http.Redirect(w, r, "/admin/invites?error=invalid_uses", http.StatusSeeOther)
+		return
+	}
+	validDays, err := strconv.Atoi(r.FormValue("valid_days"))
+	if err != nil || validDays < 0 || validDays > 365 {
+		http.Redirect(w, r, "/admin/invites?error=invalid_days", http.StatusSeeOther)
+		return
+	}
+
+	code, err := newInviteCode()
+	if err != nil {
+		http.Error(w, "Failed to generate invite code: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	invite := &models.InviteCode{
+		ID:        uuid.New(),
+		Code:      code,
+		MaxUses:   maxUses,
+		CreatedAt: now,
+	}
+	if staffID, ok := h.getSessionMemberID(r); ok {
+		invite.CreatedBy = &staffID
+	}
+	if validDays > 0 {
+		expires := now.AddDate(0, 0, validDays)
+		invite.ExpiresAt = &expires
+	}
+
+	if err := h.store.CreateInviteCode(r.Context(), invite); err != nil {
+		http.Error(w, "Failed to create invite code: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/admin/invites?success=created", http.StatusSeeOther)
+}
+
+// RevokeInviteCode handles POST /admin/invites/{id}/revoke.
+func (h *Handler) RevokeInviteCode(w http.ResponseWriter, r *http.Request) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid invite code ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.store.RevokeInviteCode(r.Context(), id); err != nil {
+		http.Error(w, "Failed to revoke invite code: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/admin/invites?success=revoked", http.StatusSeeOther)
+}
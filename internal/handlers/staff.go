@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/cmellojr/modo-locadora/internal/database"
+	"github.com/cmellojr/modo-locadora/internal/models"
+	"github.com/google/uuid"
+)
+
+// ── Staff handlers ──────────────────────────────────────────────────────────
+
+// bootstrapOwner makes member the owner when they are the ADMIN_EMAIL member,
+// have verified that address and the store has no owner yet. Once an owner
+// is known to exist it does nothing.
+func (h *Handler) bootstrapOwner(ctx context.Context, member *models.Member) {
+	if h.adminEmail == "" || h.hasOwner.Load() ||
+		!member.IsEmailVerified() || !strings.EqualFold(member.Email, h.adminEmail) {
+		return
+	}
+	granted, err := h.store.BootstrapOwner(ctx, member.Email)
+	if err != nil {
+		log.Printf("[staff] Failed to bootstrap owner: %v", err)
+		return
+	}
+	if granted {
+		log.Printf("[staff] %s is now the store owner.", member.Email)
+	}
+	// Granted or not, a verified ADMIN_EMAIL member now means an owner exists.
+	h.hasOwner.Store(true)
+}
+
+// StaffRoleOption is a role choice for the staff admin page.
+type StaffRoleOption struct {
+	Key   string
+	Label string
+}
+
+// StaffMemberView is a staff member with display labels for their roles.
+type StaffMemberView struct {
+	database.StaffView
+	RoleOptions []StaffRoleOption
+}
+
+func staffRoleOptions(roles []string) []StaffRoleOption {
+	opts := make([]StaffRoleOption, 0, len(roles))
+	for _, role := range roles {
+		opts = append(opts, StaffRoleOption{Key: role, Label: models.RoleLabel(role)})
+	}
+	return opts
+}
+
+// AdminStaff handles GET /admin/staff, listing staff members and their roles.
+func (h *Handler) AdminStaff(w http.ResponseWriter, r *http.Request, tmpl *template.Template) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	ld := h.buildLayoutData(r, "Equipe da Locadora")
+
+	staff, err := h.store.ListStaff(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load staff: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	views := make([]StaffMemberView, 0, len(staff))
+	for _, s := range staff {
+		views = append(views, StaffMemberView{StaffView: s, RoleOptions: staffRoleOptions(s.Roles)})
+	}
+
+	data := struct {
+		LayoutData
+		Staff       []StaffMemberView
+		RoleChoices []StaffRoleOption
+		Success     string
+		Error       string
+	}{
+		LayoutData:  ld,
+		Staff:       views,
+		RoleChoices: staffRoleOptions(models.StaffRoles),
+		Success:     r.URL.Query().Get("success"),
+		Error:       r.URL.Query().Get("error"),
+	}
+
+	if err := tmpl.Execute(w, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// GrantStaffRole handles POST /admin/staff. Fields: profile_name, role.
+func (h *Handler) GrantStaffRole(w http.ResponseWriter, r *http.Request) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	grantedBy, ok := h.getSessionMemberID(r)
+	if !ok {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+
+	role := r.FormValue("role")
+	if !models.IsStaffRole(role) {
+		http.Error(w, "Invalid staff role", http.StatusBadRequest)
+		return
+	}
+
+	member, err := h.store.GetMemberByProfileName(r.Context(), strings.TrimSpace(r.FormValue("profile_name")))
+	if err != nil {
+		http.Error(w, "Failed to look up member: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if member == nil {
+		http.Redirect(w, r, "/admin/staff?error=member_not_found", http.StatusSeeOther)
+		return
+	}
+
+	if err := h.store.GrantStaffRole(r.Context(), member.ID, role, grantedBy); err != nil {
+		http.Error(w, "Failed to grant role: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/admin/staff?success=role_granted", http.StatusSeeOther)
+}
+
+// RevokeStaffRole handles POST /admin/staff/{id}/revoke. Field: role.
+func (h *Handler) RevokeStaffRole(w http.ResponseWriter, r *http.Request) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	memberID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid member ID", http.StatusBadRequest)
+		return
+	}
+
+	role := r.FormValue("role")
+	if !models.IsStaffRole(role) {
+		http.Error(w, "Invalid staff role", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.store.RevokeStaffRole(r.Context(), memberID, role); err != nil {
+		if errors.Is(err, database.ErrLastOwner) {
+			http.Redirect(w, r, "/admin/staff?error=last_owner", http.StatusSeeOther)
+			return
+		}
+		http.Error(w, "Failed to revoke role: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/admin/staff?success=role_revoked", http.StatusSeeOther)
+}
+
+// AdminFeed handles GET /admin/feed, listing recent feed events for moderation.
+func (h *Handler) AdminFeed(w http.ResponseWriter, r *http.Request, tmpl *template.Template) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	ld := h.buildLayoutData(r, "Moderar o Feed")
+
+	entries, err := h.store.ListRecentActivities(r.Context(), 100)
+	if err != nil {
+		http.Error(w, "Failed to load activities: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	events := make([]ActivityView, 0, len(entries))
+	for _, a := range entries {
+		events = append(events, ActivityView{
+			ID:         a.ID,
+			EventType:  a.EventType,
+			MemberName: a.MemberName,
+			GameTitle:  a.GameTitle,
+			Message:    FormatActivityMessage(a),
+			TimeAgo:    formatTimeAgo(a.CreatedAt),
+		})
+	}
+
+	data := struct {
+		LayoutData
+		Events  []ActivityView
+		Success string
+	}{
+		LayoutData: ld,
+		Events:     events,
+		Success:    r.URL.Query().Get("success"),
+	}
+
+	if err := tmpl.Execute(w, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// DeleteActivity handles POST /admin/feed/{id}/delete.
+func (h *Handler) DeleteActivity(w http.ResponseWriter, r *http.Request) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid activity ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.store.DeleteActivity(r.Context(), id); err != nil {
+		http.Error(w, "Failed to delete activity: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/admin/feed?success=deleted", http.StatusSeeOther)
+}
@@ -0,0 +1,481 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cmellojr/modo-locadora/internal/auth"
+	"github.com/cmellojr/modo-locadora/internal/models"
+	"github.com/cmellojr/modo-locadora/internal/qrcode"
+	"github.com/cmellojr/modo-locadora/internal/totp"
+	"github.com/google/uuid"
+)
+
+// ── Two-factor handlers ─────────────────────────────────────────────────────
+
+// totpIssuer names the store in authenticator apps.
+const totpIssuer = "Modo Locadora"
+
+// Recovery codes: recoveryCodeCount codes of two five-character groups.
+const (
+	recoveryCodeCount = 10
+	recoveryGroupLen  = 5
+)
+
+// recoveryAlphabet leaves out 0/o and 1/l so codes can be read off paper.
+const recoveryAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+// newRecoveryCodes returns fresh recovery codes and their hashes for storage.
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		raw, err := randomRecoveryChars(2 * recoveryGroupLen)
+		if err != nil {
+			return nil, nil, err
+		}
+		codes = append(codes, raw[:recoveryGroupLen]+"-"+raw[recoveryGroupLen:])
+		hashes = append(hashes, auth.HashToken(raw))
+	}
+	return codes, hashes, nil
+}
+
+// randomRecoveryChars returns n characters drawn uniformly from
+// recoveryAlphabet, rejecting bytes that would bias the modulo.
+func randomRecoveryChars(n int) (string, error) {
+	limit := 256 - 256%len(recoveryAlphabet)
+	out := make([]byte, 0, n)
+	buf := make([]byte, 1)
+	for len(out) < n {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		if int(buf[0]) < limit {
+			out = append(out, recoveryAlphabet[int(buf[0])%len(recoveryAlphabet)])
+		}
+	}
+	return string(out), nil
+}
+
+// normalizeRecoveryCode lowercases a typed code and drops spaces and dashes.
+func normalizeRecoveryCode(code string) string {
+	return strings.NewReplacer(" ", "", "-", "").Replace(strings.ToLower(strings.TrimSpace(code)))
+}
+
+// isTOTPCode reports whether a typed code looks like an app code rather than
+// a recovery code.
+func isTOTPCode(code string) bool {
+	code = normalizeRecoveryCode(code)
+	if len(code) != totp.Digits {
+		return false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// checkSecondFactor accepts an app code, once per time step, or an unused
+// recovery code. Returns whether it passed and whether a recovery code was used.
+func (h *Handler) checkSecondFactor(r *http.Request, member *models.Member, code string) (ok, recovery bool) {
+	if isTOTPCode(code) {
+		step, valid := totp.Validate(member.TOTPSecret, code, time.Now())
+		if !valid {
+			return false, false
+		}
+		fresh, err := h.store.UseTOTPStep(r.Context(), member.ID, step)
+		if err != nil {
+			log.Printf("[security] Failed to record TOTP step for %s: %v", member.ProfileName, err)
+			return false, false
+		}
+		return fresh, false
+	}
+
+	code = normalizeRecoveryCode(code)
+	if len(code) != 2*recoveryGroupLen {
+		return false, false
+	}
+	used, err := h.store.UseRecoveryCode(r.Context(), member.ID, auth.HashToken(code))
+	if err != nil {
+		log.Printf("[security] Failed to use recovery code for %s: %v", member.ProfileName, err)
+		return false, false
+	}
+	return used, used
+}
+
+// isStaff reports whether the member holds any staff role, which makes
+// two-factor authentication mandatory.
+func (h *Handler) isStaff(r *http.Request, memberID uuid.UUID) bool {
+	roles, err := h.store.ListMemberRoles(r.Context(), memberID)
+	return err == nil && len(roles) > 0
+}
+
+// TwoFactorPage handles GET /membership/2fa. Members without two-factor get a
+// secret and its QR code to scan; enrolled members see how many recovery
+// codes are left.
+func (h *Handler) TwoFactorPage(w http.ResponseWriter, r *http.Request, tmpl *template.Template) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	member := h.currentMember(w, r)
+	if member == nil {
+		return
+	}
+
+	if !member.HasTwoFactor() && member.TOTPSecret == "" {
+		secret, err := totp.NewSecret()
+		if err != nil {
+			http.Error(w, "Failed to generate secret", http.StatusInternalServerError)
+			return
+		}
+		if err := h.store.StartTOTPEnrollment(r.Context(), member.ID, secret); err != nil {
+			http.Error(w, "Failed to start enrollment: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		member.TOTPSecret = secret
+	}
+
+	h.renderTwoFactor(w, r, tmpl, member, nil, nil, http.StatusOK)
+}
+
+// renderTwoFactor renders the two-factor page. Recovery codes are only passed
+// right after they are generated; they are never shown again.
+func (h *Handler) renderTwoFactor(w http.ResponseWriter, r *http.Request, tmpl *template.Template, member *models.Member, errs FieldErrors, codes []string, status int) {
+	ld := h.buildLayoutData(r, "Senha do Controle")
+
+	var qr template.HTML
+	var secret string
+	if !member.HasTwoFactor() {
+		uri := totp.URI(totpIssuer, member.ProfileName, member.TOTPSecret)
+		code, err := qrcode.Encode([]byte(uri))
+		if err != nil {
+			http.Error(w, "Failed to draw QR code: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		// The SVG is built from module coordinates only, so it is safe markup.
+		qr = template.HTML(code.SVG())
+		secret = totp.FormatSecret(member.TOTPSecret)
+	}
+
+	left := 0
+	if member.HasTwoFactor() {
+		n, err := h.store.CountRecoveryCodes(r.Context(), member.ID)
+		if err != nil {
+			http.Error(w, "Failed to count recovery codes: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		left = n
+	}
+
+	data := struct {
+		LayoutData
+		Member        *models.Member
+		Enabled       bool
+		Required      bool
+		IsStaff       bool
+		QRCode        template.HTML
+		Secret        string
+		RecoveryCodes []string
+		RecoveryLeft  int
+		Errors        FieldErrors
+		Success       string
+	}{
+		LayoutData:    ld,
+		Member:        member,
+		Enabled:       member.HasTwoFactor(),
+		Required:      r.URL.Query().Get("required") != "",
+		IsStaff:       h.isStaff(r, member.ID),
+		QRCode:        qr,
+		Secret:        secret,
+		RecoveryCodes: codes,
+		RecoveryLeft:  left,
+		Errors:        errs,
+		Success:       r.URL.Query().Get("success"),
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := tmpl.Execute(w, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// EnableTwoFactor handles POST /membership/2fa/enable. Field: code. The first
+// valid code turns two-factor on, every other session is signed out and the
+// recovery codes are shown once.
+func (h *Handler) EnableTwoFactor(w http.ResponseWriter, r *http.Request, tmpl *template.Template) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	member := h.currentMember(w, r)
+	if member == nil {
+		return
+	}
+	if member.HasTwoFactor() || member.TOTPSecret == "" {
+		http.Redirect(w, r, "/membership/2fa", http.StatusSeeOther)
+		return
+	}
+
+	step, ok := totp.Validate(member.TOTPSecret, r.FormValue("code"), time.Now())
+	if !ok {
+		h.renderTwoFactor(w, r, tmpl, member, FieldErrors{
+			"code": "Código errado. Confira o relógio do celular e tente o código novo.",
+		}, nil, http.StatusUnprocessableEntity)
+		return
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		http.Error(w, "Failed to generate recovery codes", http.StatusInternalServerError)
+		return
+	}
+	if err := h.store.EnableTOTP(r.Context(), member.ID, step, hashes); err != nil {
+		http.Error(w, "Failed to enable two-factor: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.recordSecurityEvent(r, models.SecurityEventTwoFactorOn, &member.ID, member.ProfileName, "")
+
+	// Sessions opened with the password alone end here.
+	if _, err := h.store.RevokeMemberSessions(r.Context(), member.ID); err != nil {
+		log.Printf("Failed to revoke sessions after enabling two-factor for %s: %v", member.ProfileName, err)
+	}
+	if err := h.startSession(w, r, member.ID); err != nil {
+		http.Error(w, "Failed to start session: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	member.TOTPEnabledAt = &now
+	h.renderTwoFactor(w, r, tmpl, member, nil, codes, http.StatusOK)
+}
+
+// RegenerateRecoveryCodes handles POST /membership/2fa/recovery-codes.
+// Field: password. The old codes stop working.
+func (h *Handler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request, tmpl *template.Template) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	member := h.currentMember(w, r)
+	if member == nil {
+		return
+	}
+	if !member.HasTwoFactor() {
+		http.Redirect(w, r, "/membership/2fa", http.StatusSeeOther)
+		return
+	}
+
+	if msg := h.checkCurrentPassword(member, r.FormValue("password")); msg != "" {
+		h.renderTwoFactor(w, r, tmpl, member, FieldErrors{"recovery_password": msg}, nil, http.StatusUnprocessableEntity)
+		return
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		http.Error(w, "Failed to generate recovery codes", http.StatusInternalServerError)
+		return
+	}
+	if err := h.store.ReplaceRecoveryCodes(r.Context(), member.ID, hashes); err != nil {
+		http.Error(w, "Failed to replace recovery codes: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.renderTwoFactor(w, r, tmpl, member, nil, codes, http.StatusOK)
+}
+
+// DisableTwoFactor handles POST /membership/2fa/disable. Fields: password and
+// code (app or recovery code). Staff members cannot turn it off.
+func (h *Handler) DisableTwoFactor(w http.ResponseWriter, r *http.Request, tmpl *template.Template) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	member := h.currentMember(w, r)
+	if member == nil {
+		return
+	}
+	if !member.HasTwoFactor() {
+		http.Redirect(w, r, "/membership/2fa", http.StatusSeeOther)
+		return
+	}
+
+	if h.isStaff(r, member.ID) {
+		h.renderTwoFactor(w, r, tmpl, member, FieldErrors{
+			"disable": "Quem é da equipe não pode desligar a senha do controle.",
+		}, nil, http.StatusConflict)
+		return
+	}
+
+	errs := FieldErrors{}
+	if msg := h.checkCurrentPassword(member, r.FormValue("password")); msg != "" {
+		errs["disable_password"] = msg
+	} else if ok, _ := h.checkSecondFactor(r, member, r.FormValue("code")); !ok {
+		errs["disable_code"] = "Código errado ou já usado."
+	}
+	if len(errs) > 0 {
+		h.renderTwoFactor(w, r, tmpl, member, errs, nil, http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err := h.store.DisableTOTP(r.Context(), member.ID); err != nil {
+		http.Error(w, "Failed to disable two-factor: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.recordSecurityEvent(r, models.SecurityEventTwoFactorOff, &member.ID, member.ProfileName, "disabled by the member")
+
+	http.Redirect(w, r, "/membership/2fa?success=disabled", http.StatusSeeOther)
+}
+
+// pendingLoginMember returns the member waiting on the two-factor step, or nil
+// when the pending login cookie is missing, forged or expired.
+func (h *Handler) pendingLoginMember(r *http.Request) *models.Member {
+	id, err := uuid.Parse(auth.GetPendingLogin(r, h.keys))
+	if err != nil {
+		return nil
+	}
+	member, err := h.store.GetMemberByID(r.Context(), id)
+	if err != nil || member == nil || member.IsDeleted() || !member.HasTwoFactor() {
+		return nil
+	}
+	return member
+}
+
+// LoginTwoFactorPage handles GET /login/2fa, the code step after the password.
+func (h *Handler) LoginTwoFactorPage(w http.ResponseWriter, r *http.Request, tmpl *template.Template) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	member := h.pendingLoginMember(r)
+	if member == nil {
+		auth.ClearPendingLoginCookie(w)
+		http.Redirect(w, r, "/?error=login_expired", http.StatusSeeOther)
+		return
+	}
+
+	ld := h.buildLayoutData(r, "Senha do Controle")
+	data := struct {
+		LayoutData
+		ProfileName string
+		Error       string
+	}{
+		LayoutData:  ld,
+		ProfileName: member.ProfileName,
+		Error:       r.URL.Query().Get("error"),
+	}
+
+	if err := tmpl.Execute(w, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// LoginTwoFactor handles POST /login/2fa. Field: code (app or recovery code).
+// Wrong codes count as failed logins and share the password lockout.
+func (h *Handler) LoginTwoFactor(w http.ResponseWriter, r *http.Request) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	member := h.pendingLoginMember(r)
+	if member == nil {
+		auth.ClearPendingLoginCookie(w)
+		http.Redirect(w, r, "/?error=login_expired", http.StatusSeeOther)
+		return
+	}
+
+	if ok, wait := h.allowLogin(r, member.ProfileName); !ok {
+		redirectLoginWait(w, r, "slow_down", wait)
+		return
+	}
+	if now := time.Now(); member.IsLocked(now) {
+		auth.ClearPendingLoginCookie(w)
+		redirectLoginWait(w, r, "locked", member.LockedUntil.Sub(now))
+		return
+	}
+
+	ok, recovery := h.checkSecondFactor(r, member, r.FormValue("code"))
+	if !ok {
+		if lock := h.registerLoginFailure(r, member); lock > 0 {
+			auth.ClearPendingLoginCookie(w)
+			redirectLoginWait(w, r, "locked", lock)
+			return
+		}
+		http.Redirect(w, r, "/login/2fa?error=invalid_code", http.StatusSeeOther)
+		return
+	}
+
+	auth.ClearPendingLoginCookie(w)
+	if err := h.finishLogin(w, r, member); err != nil {
+		http.Error(w, "Failed to start session: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if recovery {
+		left, _ := h.store.CountRecoveryCodes(r.Context(), member.ID)
+		h.recordSecurityEvent(r, models.SecurityEventRecoveryUsed, &member.ID, member.ProfileName,
+			fmt.Sprintf("%d recovery code(s) left", left))
+		http.Redirect(w, r, "/membership/2fa?success=recovery_used", http.StatusSeeOther)
+		return
+	}
+	http.Redirect(w, r, "/games", http.StatusSeeOther)
+}
+
+// AdminResetTwoFactor handles POST /admin/members/{id}/reset-2fa, for a member
+// who lost both the phone and the recovery codes. Their sessions are revoked;
+// staff members must enroll again on their next visit to the admin pages.
+func (h *Handler) AdminResetTwoFactor(w http.ResponseWriter, r *http.Request) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	memberID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid member ID", http.StatusBadRequest)
+		return
+	}
+
+	member, err := h.store.GetMemberByID(r.Context(), memberID)
+	if err != nil {
+		http.Error(w, "Failed to load member: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if member == nil {
+		http.Error(w, "Member not found", http.StatusNotFound)
+		return
+	}
+
+	if err := h.store.DisableTOTP(r.Context(), member.ID); err != nil {
+		http.Error(w, "Failed to reset two-factor: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if _, err := h.store.RevokeMemberSessions(r.Context(), member.ID); err != nil {
+		log.Printf("Failed to revoke sessions after two-factor reset for %s: %v", member.ProfileName, err)
+	}
+
+	resetBy := "staff"
+	if staffID, ok := h.getSessionMemberID(r); ok {
+		if staff, err := h.store.GetMemberByID(r.Context(), staffID); err == nil && staff != nil {
+			resetBy = staff.ProfileName
+		}
+	}
+	h.recordSecurityEvent(r, models.SecurityEventTwoFactorOff, &member.ID, member.ProfileName,
+		"reset by "+resetBy)
+
+	http.Redirect(w, r, "/admin/members?success=two_factor_reset", http.StatusSeeOther)
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+
+	"github.com/cmellojr/modo-locadora/internal/media"
+)
+
+// ── Image upload helpers ────────────────────────────────────────────────────
+
+const (
+	// maxFormMemory is how much of a multipart form is held in memory; the
+	// rest of its files are spooled to temporary files.
+	maxFormMemory = 10 << 20
+
+	// maxImageForm caps the body of a form with an image upload. It leaves
+	// room over the largest media.Kind so an oversized image still gets the
+	// message from uploadErrorMessage.
+	maxImageForm = 16 << 20
+)
+
+// parseUploadForm parses a multipart form whose body is capped at limit
+// bytes. The request a handler sees is a copy made by the middleware, so
+// net/http won't delete the temporary files of this parse: callers must
+// defer removeUploads.
+func parseUploadForm(w http.ResponseWriter, r *http.Request, limit int64) error {
+	r.Body = http.MaxBytesReader(w, r.Body, limit)
+	return r.ParseMultipartForm(min(limit, maxFormMemory))
+}
+
+// removeUploads deletes the temporary files of a form parsed by
+// parseUploadForm.
+func removeUploads(r *http.Request) {
+	if r.MultipartForm != nil {
+		_ = r.MultipartForm.RemoveAll()
+	}
+}
+
+// uploadErrorMessage returns the message shown on the form for an image the
+// upload service refused, and false for other errors, which are server faults.
+func uploadErrorMessage(err error, kind media.Kind) (string, bool) {
+	var verr *media.ValidationError
+	if !errors.As(err, &verr) {
+		return "", false
+	}
+	switch {
+	case errors.Is(err, media.ErrUnsupportedType):
+		return "Formato não aceito: envie uma imagem JPEG, PNG ou GIF.", true
+	case errors.Is(err, media.ErrTooLarge):
+		return fmt.Sprintf("Arquivo grande demais: o limite é de %d MB.", kind.MaxMegabytes()), true
+	case errors.Is(err, media.ErrDimensions):
+		return fmt.Sprintf("A imagem precisa ter entre %dx%d e %dx%d pixels.",
+			kind.MinSize.Width, kind.MinSize.Height, kind.MaxSize.Width, kind.MaxSize.Height), true
+	}
+	return "A imagem está corrompida ou incompleta. Tente outro arquivo.", true
+}
+
+// removeReplaced deletes the stored files among old that current no longer
+// uses. Call it after the record pointing at current is saved.
+func removeReplaced(ctx context.Context, images *media.Images, old, current []string) {
+	var unused []string
+	for _, url := range old {
+		if url != "" && !slices.Contains(current, url) {
+			unused = append(unused, url)
+		}
+	}
+	if err := images.Remove(ctx, unused...); err != nil {
+		log.Printf("[media] %v", err)
+	}
+}
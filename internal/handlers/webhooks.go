@@ -0,0 +1,544 @@
+package handlers
+
+import (
+	"html/template"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/cmellojr/modo-locadora/internal/auth"
+	"github.com/cmellojr/modo-locadora/internal/models"
+	"github.com/google/uuid"
+)
+
+// ── Webhook handlers ────────────────────────────────────────────────────────
+
+// Webhook endpoint limits.
+const (
+	maxWebhooks           = 10 // Endpoints per club, and for the staff.
+	maxWebhookURLLen      = 500
+	maxWebhookDescription = 60
+	webhookLogSize        = 50 // Deliveries shown on the endpoint page.
+)
+
+// webhookScope is where endpoints are managed: the staff page or the page
+// of one club. The same handlers serve /admin/webhooks and
+// /clubs/{id}/webhooks; club routes carry the club ID in the path.
+type webhookScope struct {
+	Member   *models.Member
+	ClubID   *uuid.UUID
+	ClubName string
+	BasePath string
+}
+
+// WebhookEventOption is an event checkbox on the webhook forms.
+type WebhookEventOption struct {
+	Value string
+	Label string
+}
+
+// WebhookEndpointView represents a webhook endpoint for display.
+type WebhookEndpointView struct {
+	models.WebhookEndpoint
+	Events []string // Portuguese labels.
+}
+
+// WebhookDeliveryView represents one delivery in the log.
+type WebhookDeliveryView struct {
+	ID          uuid.UUID
+	Event       string
+	Message     string
+	Status      string
+	Attempts    int
+	StatusCode  int
+	Error       string
+	NextAttempt string
+	CreatedAt   string
+}
+
+// webhookScope resolves the scope of the request. Staff routes are already
+// guarded by RequirePermission; club routes require a club admin.
+func (h *Handler) webhookScope(w http.ResponseWriter, r *http.Request) *webhookScope {
+	if r.PathValue("id") == "" {
+		member := h.currentMember(w, r)
+		if member == nil {
+			return nil
+		}
+		return &webhookScope{Member: member, BasePath: "/admin/webhooks"}
+	}
+
+	_, clubID, ok := h.requireClubAdmin(w, r)
+	if !ok {
+		return nil
+	}
+	member := h.currentMember(w, r)
+	if member == nil {
+		return nil
+	}
+	club, err := h.store.GetClubByID(r.Context(), clubID)
+	if err != nil {
+		http.Error(w, "Failed to load club: "+err.Error(), http.StatusInternalServerError)
+		return nil
+	}
+	if club == nil {
+		http.NotFound(w, r)
+		return nil
+	}
+	return &webhookScope{
+		Member:   member,
+		ClubID:   &club.ID,
+		ClubName: club.Name,
+		BasePath: "/clubs/" + club.ID.String() + "/webhooks",
+	}
+}
+
+// scopedWebhook loads the {hookID} endpoint, answering 404 when it belongs
+// to another scope.
+func (h *Handler) scopedWebhook(w http.ResponseWriter, r *http.Request, scope *webhookScope) *models.WebhookEndpoint {
+	id, err := uuid.Parse(r.PathValue("hookID"))
+	if err != nil {
+		http.Error(w, "Invalid webhook ID", http.StatusBadRequest)
+		return nil
+	}
+	e, err := h.store.GetWebhookEndpoint(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Failed to load webhook: "+err.Error(), http.StatusInternalServerError)
+		return nil
+	}
+	if e == nil || !sameClub(e.ClubID, scope.ClubID) {
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+		return nil
+	}
+	return e
+}
+
+func sameClub(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func webhookEventOptions() []WebhookEventOption {
+	opts := make([]WebhookEventOption, 0, len(models.ActivityEventTypes))
+	for _, e := range models.ActivityEventTypes {
+		opts = append(opts, WebhookEventOption{Value: e, Label: models.EventLabel(e)})
+	}
+	return opts
+}
+
+// parseWebhookForm validates the url, description and event fields.
+func parseWebhookForm(r *http.Request) (*models.WebhookEndpoint, FieldErrors) {
+	errs := FieldErrors{}
+	e := &models.WebhookEndpoint{
+		URL:         strings.TrimSpace(r.PostForm.Get("url")),
+		Description: strings.TrimSpace(r.PostForm.Get("description")),
+	}
+
+	u, err := url.Parse(e.URL)
+	switch {
+	case e.URL == "":
+		errs["url"] = "Informe o endereço que vai receber os eventos."
+	case len(e.URL) > maxWebhookURLLen:
+		errs["url"] = "Endereço longo demais."
+	case err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "":
+		errs["url"] = "Use um endereço http:// ou https:// completo."
+	}
+	if utf8.RuneCountInString(e.Description) > maxWebhookDescription {
+		errs["description"] = "Use no máximo " + strconv.Itoa(maxWebhookDescription) + " caracteres."
+	}
+
+	for _, t := range models.ActivityEventTypes {
+		if slices.Contains(r.PostForm["event"], t) {
+			e.EventTypes = append(e.EventTypes, t)
+		}
+	}
+	if len(e.EventTypes) == 0 {
+		errs["event"] = "Marque pelo menos um evento."
+	}
+	return e, errs
+}
+
+// WebhooksPage handles GET /admin/webhooks and GET /clubs/{id}/webhooks.
+func (h *Handler) WebhooksPage(w http.ResponseWriter, r *http.Request, tmpl *template.Template) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	scope := h.webhookScope(w, r)
+	if scope == nil {
+		return
+	}
+	h.renderWebhooks(w, r, tmpl, scope, nil, http.StatusOK)
+}
+
+func (h *Handler) renderWebhooks(w http.ResponseWriter, r *http.Request, tmpl *template.Template, scope *webhookScope, errs FieldErrors, status int) {
+	ld := h.buildLayoutData(r, "Webhooks")
+
+	endpoints, err := h.store.ListWebhookEndpoints(r.Context(), scope.ClubID)
+	if err != nil {
+		http.Error(w, "Failed to list webhooks: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	views := make([]WebhookEndpointView, 0, len(endpoints))
+	for _, e := range endpoints {
+		labels := make([]string, 0, len(e.EventTypes))
+		for _, t := range e.EventTypes {
+			labels = append(labels, models.EventLabel(t))
+		}
+		views = append(views, WebhookEndpointView{WebhookEndpoint: e, Events: labels})
+	}
+
+	form := r.PostForm
+	checked := map[string]bool{}
+	for _, t := range form["event"] {
+		checked[t] = true
+	}
+
+	data := struct {
+		LayoutData
+		Scope           *webhookScope
+		Endpoints       []WebhookEndpointView
+		Events          []WebhookEventOption
+		FormURL         string
+		FormDescription string
+		FormEvents      map[string]bool
+		Errors          FieldErrors
+		Success         string
+		MaxFailures     int
+	}{
+		LayoutData:      ld,
+		Scope:           scope,
+		Endpoints:       views,
+		Events:          webhookEventOptions(),
+		FormURL:         form.Get("url"),
+		FormDescription: form.Get("description"),
+		FormEvents:      checked,
+		Errors:          errs,
+		Success:         r.URL.Query().Get("success"),
+		MaxFailures:     models.WebhookMaxConsecutiveFails,
+	}
+
+	w.WriteHeader(status)
+	if err := tmpl.Execute(w, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// CreateWebhook handles POST /admin/webhooks and POST /clubs/{id}/webhooks.
+// Fields: url, description and event (repeated). The new endpoint's page
+// shows its signing secret once.
+func (h *Handler) CreateWebhook(w http.ResponseWriter, r *http.Request, listTmpl, detailTmpl *template.Template) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	scope := h.webhookScope(w, r)
+	if scope == nil {
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+
+	e, errs := parseWebhookForm(r)
+	if len(errs) == 0 {
+		existing, err := h.store.ListWebhookEndpoints(r.Context(), scope.ClubID)
+		if err != nil {
+			http.Error(w, "Failed to list webhooks: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if len(existing) >= maxWebhooks {
+			errs["url"] = "Limite de " + strconv.Itoa(maxWebhooks) + " webhooks. Apague algum antes de criar outro."
+		}
+	}
+	if len(errs) > 0 {
+		h.renderWebhooks(w, r, listTmpl, scope, errs, http.StatusUnprocessableEntity)
+		return
+	}
+
+	secret, err := auth.NewWebhookSecret()
+	if err != nil {
+		http.Error(w, "Failed to generate secret", http.StatusInternalServerError)
+		return
+	}
+	e.ID = uuid.New()
+	e.ClubID = scope.ClubID
+	e.Secret = secret
+	e.Enabled = true
+	e.CreatedBy = &scope.Member.ID
+	e.CreatedAt = time.Now()
+	if err := h.store.CreateWebhookEndpoint(r.Context(), e); err != nil {
+		http.Error(w, "Failed to create webhook: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.recordSecurityEvent(r, models.SecurityEventWebhookCreated, &scope.Member.ID, scope.Member.ProfileName,
+		webhookAuditDetail(scope, e))
+
+	r.PostForm = nil
+	h.renderWebhook(w, r, detailTmpl, scope, e, nil, secret, http.StatusOK)
+}
+
+func webhookAuditDetail(scope *webhookScope, e *models.WebhookEndpoint) string {
+	if scope.ClubID != nil {
+		return e.URL + " (turma " + scope.ClubName + ")"
+	}
+	return e.URL
+}
+
+// WebhookPage handles GET {base}/{hookID}: settings and delivery log.
+func (h *Handler) WebhookPage(w http.ResponseWriter, r *http.Request, tmpl *template.Template) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	scope := h.webhookScope(w, r)
+	if scope == nil {
+		return
+	}
+	e := h.scopedWebhook(w, r, scope)
+	if e == nil {
+		return
+	}
+	h.renderWebhook(w, r, tmpl, scope, e, nil, "", http.StatusOK)
+}
+
+// renderWebhook renders an endpoint's page. newSecret is only passed right
+// after creation or rotation.
+func (h *Handler) renderWebhook(w http.ResponseWriter, r *http.Request, tmpl *template.Template, scope *webhookScope, e *models.WebhookEndpoint, errs FieldErrors, newSecret string, status int) {
+	ld := h.buildLayoutData(r, "Webhook")
+
+	deliveries, err := h.store.ListWebhookDeliveries(r.Context(), e.ID, webhookLogSize)
+	if err != nil {
+		http.Error(w, "Failed to list deliveries: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	views := make([]WebhookDeliveryView, 0, len(deliveries))
+	for _, d := range deliveries {
+		v := WebhookDeliveryView{
+			ID:         d.Delivery.ID,
+			Event:      models.EventLabel(d.Delivery.EventType),
+			Message:    FormatActivityMessage(d.Activity),
+			Status:     d.Delivery.Status,
+			Attempts:   d.Delivery.Attempts,
+			StatusCode: d.Delivery.LastStatusCode,
+			Error:      d.Delivery.LastError,
+			CreatedAt:  formatTimeAgo(d.Delivery.CreatedAt),
+		}
+		if d.Delivery.Status == models.WebhookPending {
+			v.NextAttempt = d.Delivery.NextAttemptAt.Format("02/01 15:04")
+		}
+		views = append(views, v)
+	}
+
+	// The edit form shows the stored settings unless a failed POST is being
+	// redisplayed.
+	formURL, formDescription := e.URL, e.Description
+	checked := map[string]bool{}
+	if errs != nil {
+		formURL, formDescription = r.PostForm.Get("url"), r.PostForm.Get("description")
+		for _, t := range r.PostForm["event"] {
+			checked[t] = true
+		}
+	} else {
+		for _, t := range e.EventTypes {
+			checked[t] = true
+		}
+	}
+
+	data := struct {
+		LayoutData
+		Scope           *webhookScope
+		Endpoint        *models.WebhookEndpoint
+		NewSecret       string
+		Deliveries      []WebhookDeliveryView
+		Events          []WebhookEventOption
+		FormURL         string
+		FormDescription string
+		FormEvents      map[string]bool
+		Errors          FieldErrors
+		Success         string
+		MaxFailures     int
+	}{
+		LayoutData:      ld,
+		Scope:           scope,
+		Endpoint:        e,
+		NewSecret:       newSecret,
+		Deliveries:      views,
+		Events:          webhookEventOptions(),
+		FormURL:         formURL,
+		FormDescription: formDescription,
+		FormEvents:      checked,
+		Errors:          errs,
+		Success:         r.URL.Query().Get("success"),
+		MaxFailures:     models.WebhookMaxConsecutiveFails,
+	}
+
+	w.WriteHeader(status)
+	if err := tmpl.Execute(w, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// UpdateWebhook handles POST {base}/{hookID}. Fields: url, description and
+// event (repeated).
+func (h *Handler) UpdateWebhook(w http.ResponseWriter, r *http.Request, tmpl *template.Template) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	scope := h.webhookScope(w, r)
+	if scope == nil {
+		return
+	}
+	e := h.scopedWebhook(w, r, scope)
+	if e == nil {
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+
+	update, errs := parseWebhookForm(r)
+	if len(errs) > 0 {
+		h.renderWebhook(w, r, tmpl, scope, e, errs, "", http.StatusUnprocessableEntity)
+		return
+	}
+	update.ID = e.ID
+	if err := h.store.UpdateWebhookEndpoint(r.Context(), update); err != nil {
+		http.Error(w, "Failed to update webhook: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, scope.BasePath+"/"+e.ID.String()+"?success=updated", http.StatusSeeOther)
+}
+
+// ToggleWebhook handles POST {base}/{hookID}/enable and /disable.
+func (h *Handler) ToggleWebhook(w http.ResponseWriter, r *http.Request, enabled bool) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	scope := h.webhookScope(w, r)
+	if scope == nil {
+		return
+	}
+	e := h.scopedWebhook(w, r, scope)
+	if e == nil {
+		return
+	}
+
+	if err := h.store.SetWebhookEndpointEnabled(r.Context(), e.ID, enabled); err != nil {
+		http.Error(w, "Failed to switch webhook: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	success := "disabled"
+	if enabled {
+		success = "enabled"
+	}
+	http.Redirect(w, r, scope.BasePath+"/"+e.ID.String()+"?success="+success, http.StatusSeeOther)
+}
+
+// RotateWebhookSecret handles POST {base}/{hookID}/rotate-secret. The new
+// secret is shown once; the old one stops working immediately.
+func (h *Handler) RotateWebhookSecret(w http.ResponseWriter, r *http.Request, tmpl *template.Template) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	scope := h.webhookScope(w, r)
+	if scope == nil {
+		return
+	}
+	e := h.scopedWebhook(w, r, scope)
+	if e == nil {
+		return
+	}
+
+	secret, err := auth.NewWebhookSecret()
+	if err != nil {
+		http.Error(w, "Failed to generate secret", http.StatusInternalServerError)
+		return
+	}
+	if err := h.store.RotateWebhookSecret(r.Context(), e.ID, secret); err != nil {
+		http.Error(w, "Failed to rotate secret: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	e.Secret = secret
+
+	h.renderWebhook(w, r, tmpl, scope, e, nil, secret, http.StatusOK)
+}
+
+// DeleteWebhook handles POST {base}/{hookID}/delete.
+func (h *Handler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	scope := h.webhookScope(w, r)
+	if scope == nil {
+		return
+	}
+	e := h.scopedWebhook(w, r, scope)
+	if e == nil {
+		return
+	}
+
+	if err := h.store.DeleteWebhookEndpoint(r.Context(), e.ID); err != nil {
+		http.Error(w, "Failed to delete webhook: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.recordSecurityEvent(r, models.SecurityEventWebhookDeleted, &scope.Member.ID, scope.Member.ProfileName,
+		webhookAuditDetail(scope, e))
+
+	http.Redirect(w, r, scope.BasePath+"?success=deleted", http.StatusSeeOther)
+}
+
+// RetryWebhookDelivery handles POST {base}/{hookID}/deliveries/{deliveryID}/retry,
+// queueing a failed delivery again.
+func (h *Handler) RetryWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	if h.store == nil {
+		http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	scope := h.webhookScope(w, r)
+	if scope == nil {
+		return
+	}
+	e := h.scopedWebhook(w, r, scope)
+	if e == nil {
+		return
+	}
+	deliveryID, err := uuid.Parse(r.PathValue("deliveryID"))
+	if err != nil {
+		http.Error(w, "Invalid delivery ID", http.StatusBadRequest)
+		return
+	}
+
+	retried, err := h.store.RetryWebhookDelivery(r.Context(), deliveryID, e.ID)
+	if err != nil {
+		http.Error(w, "Failed to retry delivery: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !retried {
+		http.Error(w, "Delivery not found", http.StatusNotFound)
+		return
+	}
+
+	http.Redirect(w, r, scope.BasePath+"/"+e.ID.String()+"?success=retried", http.StatusSeeOther)
+}
@@ -0,0 +1,144 @@
+// Package ical writes iCalendar (RFC 5545) documents for calendar
+// subscriptions: a VCALENDAR of timed VEVENTs with display alarms.
+package ical
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ContentType is the media type of an iCalendar document.
+const ContentType = "text/calendar; charset=utf-8"
+
+// maxLine is the longest content line allowed, in octets, before folding.
+const maxLine = 75
+
+// Calendar is a subscribable calendar.
+type Calendar struct {
+	ProdID      string // Such as "-//Modo Locadora//Agenda//PT".
+	Name        string
+	Description string
+	Refresh     time.Duration // Suggested polling interval; 0 leaves it to the client.
+	Events      []Event
+}
+
+// Event is a timed event. All times are written in UTC.
+type Event struct {
+	UID         string // Stable across versions so clients update the event in place.
+	Summary     string
+	Description string
+	URL         string
+	Start       time.Time
+	End         time.Time
+	Alarms      []time.Duration // Reminders before End.
+}
+
+// Encode renders c, stamping every event with now.
+func Encode(c Calendar, now time.Time) []byte {
+	var b writer
+	b.line("BEGIN", "VCALENDAR")
+	b.line("VERSION", "2.0")
+	b.line("PRODID", c.ProdID)
+	b.line("CALSCALE", "GREGORIAN")
+	b.line("METHOD", "PUBLISH")
+	if c.Name != "" {
+		b.line("X-WR-CALNAME", escape(c.Name))
+	}
+	if c.Description != "" {
+		b.line("X-WR-CALDESC", escape(c.Description))
+	}
+	if c.Refresh > 0 {
+		b.line("REFRESH-INTERVAL;VALUE=DURATION", duration(c.Refresh))
+		b.line("X-PUBLISHED-TTL", duration(c.Refresh))
+	}
+	for _, e := range c.Events {
+		b.line("BEGIN", "VEVENT")
+		b.line("UID", e.UID)
+		b.line("DTSTAMP", stamp(now))
+		b.line("DTSTART", stamp(e.Start))
+		b.line("DTEND", stamp(e.End))
+		b.line("SUMMARY", escape(e.Summary))
+		if e.Description != "" {
+			b.line("DESCRIPTION", escape(e.Description))
+		}
+		if e.URL != "" {
+			b.line("URL", e.URL)
+		}
+		b.line("TRANSP", "TRANSPARENT")
+		for _, before := range e.Alarms {
+			b.line("BEGIN", "VALARM")
+			b.line("ACTION", "DISPLAY")
+			b.line("DESCRIPTION", escape(e.Summary))
+			b.line("TRIGGER;RELATED=END", "-"+duration(before))
+			b.line("END", "VALARM")
+		}
+		b.line("END", "VEVENT")
+	}
+	b.line("END", "VCALENDAR")
+	return b.Bytes()
+}
+
+type writer struct {
+	bytes.Buffer
+}
+
+// line writes "name:value", folding it into CRLF + space continuations
+// without splitting a UTF-8 sequence.
+func (w *writer) line(name, value string) {
+	s := name + ":" + value
+	width := maxLine
+	for len(s) > width {
+		cut := width
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		w.WriteString(s[:cut])
+		w.WriteString("\r\n ")
+		s = s[cut:]
+		width = maxLine - 1 // The leading space counts.
+	}
+	w.WriteString(s)
+	w.WriteString("\r\n")
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+// escape encodes a TEXT value.
+func escape(s string) string {
+	return textEscaper.Replace(s)
+}
+
+func stamp(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// duration formats d as an RFC 5545 duration, such as P1D or PT2H30M.
+func duration(d time.Duration) string {
+	if d < 0 {
+		d = -d
+	}
+	days := d / (24 * time.Hour)
+	d -= days * 24 * time.Hour
+	var b strings.Builder
+	b.WriteString("P")
+	if days > 0 {
+		b.WriteString(strconv.FormatInt(int64(days), 10) + "D")
+	}
+	if d > 0 || days == 0 {
+		b.WriteString("T")
+		h, m, s := d/time.Hour, (d%time.Hour)/time.Minute, (d%time.Minute)/time.Second
+		if h > 0 {
+			b.WriteString(strconv.FormatInt(int64(h), 10) + "H")
+		}
+		if m > 0 {
+			b.WriteString(strconv.FormatInt(int64(m), 10) + "M")
+		}
+		if s > 0 || (h == 0 && m == 0) {
+			b.WriteString(strconv.FormatInt(int64(s), 10) + "S")
+		}
+	}
+	return b.String()
+}
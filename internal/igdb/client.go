@@ -0,0 +1,358 @@
+package igdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cmellojr/modo-locadora/internal/ratelimit"
+)
+
+// Default endpoints and limits.
+const (
+	DefaultBaseURL  = "https://api.igdb.com/v4"
+	DefaultTokenURL = "https://id.twitch.tv/oauth2/token"
+
+	requestTimeout = 10 * time.Second
+	maxAttempts    = 3
+	retryBase      = 500 * time.Millisecond // Doubles after each failed attempt.
+	maxRetryWait   = 5 * time.Second        // Longer Retry-After hints give up at once.
+	tokenMargin    = time.Minute            // Refresh this long before the token expires.
+	errorBodyLen   = 200                    // Bytes of an error response kept in APIError.
+)
+
+// IGDB allows 4 requests per second per client.
+const (
+	rateBurst  = 4
+	rateRefill = 250 * time.Millisecond
+)
+
+// Errors reported by Client. APIError values unwrap to ErrAuth or
+// ErrRateLimited when those apply.
+var (
+	ErrAuth        = errors.New("igdb: credentials rejected")
+	ErrRateLimited = errors.New("igdb: rate limited")
+)
+
+// APIError is a non-2xx response from Twitch or IGDB.
+type APIError struct {
+	Op         string // "token" or the IGDB endpoint, such as "games".
+	StatusCode int
+	Body       string // Start of the response body.
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("igdb: %s failed with status %d: %s", e.Op, e.StatusCode, e.Body)
+}
+
+// Unwrap classifies the error for errors.Is.
+func (e *APIError) Unwrap() error {
+	switch {
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden,
+		e.Op == "token" && e.StatusCode == http.StatusBadRequest:
+		return ErrAuth
+	}
+	return nil
+}
+
+// retryable reports whether another attempt may succeed.
+func (e *APIError) retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// Client talks to IGDB. It caches the Twitch app token until shortly before
+// it expires, keeps under IGDB's rate limit and retries transient failures.
+// It is safe for concurrent use. Change the exported fields before the
+// first request, for instance to point BaseURL at a local fake server.
+type Client struct {
+	BaseURL    string
+	TokenURL   string
+	HTTPClient *http.Client
+
+	clientID     string
+	clientSecret string
+	limiter      *ratelimit.Limiter
+
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+	fetch       *tokenFetch // The token request in flight, if any.
+}
+
+// tokenFetch is a token request shared by every caller that needs a token
+// while it runs.
+type tokenFetch struct {
+	done  chan struct{} // Closed when token and err are set.
+	token string
+	err   error
+}
+
+// NewClient returns a client for the given Twitch application credentials.
+func NewClient(clientID, clientSecret string) *Client {
+	return &Client{
+		BaseURL:      DefaultBaseURL,
+		TokenURL:     DefaultTokenURL,
+		HTTPClient:   &http.Client{Timeout: requestTimeout},
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		limiter:      ratelimit.New(rateBurst, rateRefill),
+	}
+}
+
+// FromEnv returns a client for TWITCH_CLIENT_ID and TWITCH_CLIENT_SECRET,
+// or nil when either is unset.
+func FromEnv() *Client {
+	id, secret := os.Getenv("TWITCH_CLIENT_ID"), os.Getenv("TWITCH_CLIENT_SECRET")
+	if id == "" || secret == "" {
+		return nil
+	}
+	return NewClient(id, secret)
+}
+
+// gameFields is the Apicalypse field list decoded into GameData.
+const gameFields = `fields name, summary, first_release_date, cover.url, platforms.name, platforms.abbreviation, ` +
+	`genres.name, involved_companies.company.name, involved_companies.developer, involved_companies.publisher, ` +
+	`screenshots.url;`
+
+// Search returns up to 10 games matching query.
+func (c *Client) Search(ctx context.Context, query string) ([]GameData, error) {
+	body := `search "` + Escape(query) + `"; ` + gameFields + ` limit 10;`
+
+	var games []GameData
+	if err := c.query(ctx, "games", body, &games); err != nil {
+		return nil, err
+	}
+	return games, nil
+}
+
+// Game returns the game with the given IGDB ID, or nil if there is none.
+func (c *Client) Game(ctx context.Context, id int) (*GameData, error) {
+	body := gameFields + ` where id = ` + strconv.Itoa(id) + `;`
+
+	var games []GameData
+	if err := c.query(ctx, "games", body, &games); err != nil {
+		return nil, err
+	}
+	if len(games) == 0 {
+		return nil, nil
+	}
+	return &games[0], nil
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", " ", "\r", " ")
+
+// Escape makes s safe inside a double-quoted Apicalypse string.
+func Escape(s string) string {
+	return escaper.Replace(s)
+}
+
+// query POSTs an Apicalypse body to an IGDB endpoint and decodes the JSON
+// response into out. A rejected token is refreshed once.
+func (c *Client) query(ctx context.Context, endpoint, body string, out any) error {
+	refreshed := false
+	for {
+		token, err := c.accessToken(ctx)
+		if err != nil {
+			return err
+		}
+
+		err = c.do(ctx, endpoint, func() (*http.Request, error) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+				strings.TrimRight(c.BaseURL, "/")+"/"+endpoint, strings.NewReader(body))
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set("Client-ID", c.clientID)
+			req.Header.Set("Authorization", "Bearer "+token)
+			req.Header.Set("Content-Type", "text/plain")
+			req.Header.Set("Accept", "application/json")
+			return req, nil
+		}, out)
+
+		var apiErr *APIError
+		if !refreshed && errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized {
+			c.invalidateToken(token)
+			refreshed = true
+			continue
+		}
+		return err
+	}
+}
+
+// accessToken returns the cached app token, fetching a new one when it is
+// missing or about to expire. Concurrent callers share a single fetch, and
+// the lock is not held while it runs.
+func (c *Client) accessToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	if c.token != "" && time.Now().Before(c.tokenExpiry) {
+		token := c.token
+		c.mu.Unlock()
+		return token, nil
+	}
+	f := c.fetch
+	if f == nil {
+		f = &tokenFetch{done: make(chan struct{})}
+		c.fetch = f
+		// The fetch outlives a caller that gives up, so the others waiting
+		// on it still get the token.
+		go c.fetchToken(context.WithoutCancel(ctx), f)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return f.token, f.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// fetchToken runs f and caches the token it gets.
+func (c *Client) fetchToken(ctx context.Context, f *tokenFetch) {
+	token, lifetime, err := c.requestToken(ctx)
+
+	c.mu.Lock()
+	if err == nil {
+		// Refresh tokenMargin before expiry, or halfway through the lifetime
+		// of a token shorter than twice the margin.
+		c.token = token
+		c.tokenExpiry = time.Now().Add(lifetime - min(tokenMargin, lifetime/2))
+	}
+	c.fetch = nil
+	c.mu.Unlock()
+
+	f.token, f.err = token, err
+	close(f.done)
+}
+
+// requestToken asks Twitch for an app token and returns it with its lifetime.
+func (c *Client) requestToken(ctx context.Context) (string, time.Duration, error) {
+	form := url.Values{
+		"client_id":     {c.clientID},
+		"client_secret": {c.clientSecret},
+		"grant_type":    {"client_credentials"},
+	}
+	var tok struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	err := c.do(ctx, "token", func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.TokenURL, strings.NewReader(form.Encode()))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return req, nil
+	}, &tok)
+	if err != nil {
+		return "", 0, err
+	}
+	if tok.AccessToken == "" {
+		return "", 0, fmt.Errorf("igdb: token response without access_token")
+	}
+	return tok.AccessToken, time.Duration(tok.ExpiresIn) * time.Second, nil
+}
+
+// invalidateToken drops the cached token if it is still the rejected one.
+func (c *Client) invalidateToken(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token == token {
+		c.token = ""
+	}
+}
+
+// do sends the request built by newReq, waiting for the rate limiter and
+// retrying network errors, 429 and 5xx with exponential backoff.
+func (c *Client) do(ctx context.Context, op string, newReq func() (*http.Request, error), out any) error {
+	wait := retryBase
+	for attempt := 1; ; attempt++ {
+		if err := c.waitTurn(ctx); err != nil {
+			return err
+		}
+		req, err := newReq()
+		if err != nil {
+			return fmt.Errorf("igdb: failed to create %s request: %w", op, err)
+		}
+
+		retryAfter, err := c.send(req, op, out)
+		if err == nil {
+			return nil
+		}
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && !apiErr.retryable() {
+			return err
+		}
+		if attempt == maxAttempts || ctx.Err() != nil {
+			return err
+		}
+
+		// Searches run while a page is loading, so a long Retry-After is
+		// reported to the caller rather than waited out.
+		if retryAfter > maxRetryWait {
+			return err
+		}
+		wait = min(max(wait, retryAfter), maxRetryWait)
+		if err := sleep(ctx, wait); err != nil {
+			return err
+		}
+		wait *= 2
+	}
+}
+
+// send performs one attempt. retryAfter is the server's Retry-After hint.
+func (c *Client) send(req *http.Request, op string, out any) (retryAfter time.Duration, err error) {
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("igdb: %s request failed: %w", op, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, errorBodyLen))
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs > 0 {
+			retryAfter = time.Duration(secs) * time.Second
+		}
+		return retryAfter, &APIError{Op: op, StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(snippet))}
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return 0, fmt.Errorf("igdb: failed to decode %s response: %w", op, err)
+	}
+	return 0, nil
+}
+
+// waitTurn blocks until the rate limiter grants a request.
+func (c *Client) waitTurn(ctx context.Context) error {
+	for {
+		ok, wait := c.limiter.Allow("igdb")
+		if ok {
+			return nil
+		}
+		if err := sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,227 @@
+package igdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeIGDB serves the Twitch token endpoint at /token and IGDB at /v4.
+// Each games request answers the next status of gameStatuses, then 200.
+type fakeIGDB struct {
+	mu           sync.Mutex
+	tokenCalls   int
+	gamesCalls   int
+	expiresIn    int
+	tokenDelay   time.Duration
+	gameStatuses []int
+	retryAfter   string   // Retry-After header sent with each failure.
+	gotTokens    []string // Authorization header of each games request.
+}
+
+func (f *fakeIGDB) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/token":
+		f.mu.Lock()
+		f.tokenCalls++
+		n, delay := f.tokenCalls, f.tokenDelay
+		f.mu.Unlock()
+		time.Sleep(delay)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"token-%d","expires_in":%d,"token_type":"bearer"}`, n, f.expiresIn)
+	case "/v4/games":
+		f.mu.Lock()
+		f.gamesCalls++
+		f.gotTokens = append(f.gotTokens, r.Header.Get("Authorization"))
+		status := http.StatusOK
+		if len(f.gameStatuses) > 0 {
+			status, f.gameStatuses = f.gameStatuses[0], f.gameStatuses[1:]
+		}
+		f.mu.Unlock()
+		if status != http.StatusOK {
+			if f.retryAfter != "" {
+				w.Header().Set("Retry-After", f.retryAfter)
+			}
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `[{"id":1,"name":"Sonic the Hedgehog"}]`)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeIGDB) calls() (token, games int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.tokenCalls, f.gamesCalls
+}
+
+// newTestClient returns a client pointed at a fake IGDB.
+func newTestClient(t *testing.T, f *fakeIGDB) *Client {
+	t.Helper()
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	c := NewClient("id", "secret")
+	c.BaseURL = srv.URL + "/v4"
+	c.TokenURL = srv.URL + "/token"
+	c.HTTPClient = srv.Client()
+	return c
+}
+
+func TestTokenCached(t *testing.T) {
+	f := &fakeIGDB{expiresIn: 3600}
+	c := newTestClient(t, f)
+
+	for range 3 {
+		if _, err := c.Search(context.Background(), "sonic"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if token, games := f.calls(); token != 1 || games != 3 {
+		t.Errorf("token requests = %d, games requests = %d; want 1 and 3", token, games)
+	}
+}
+
+func TestShortTokenCached(t *testing.T) {
+	// Shorter than tokenMargin: the token must still be reused for a while.
+	f := &fakeIGDB{expiresIn: 30}
+	c := newTestClient(t, f)
+
+	for range 2 {
+		if _, err := c.Search(context.Background(), "sonic"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if token, _ := f.calls(); token != 1 {
+		t.Errorf("token requests = %d, want 1", token)
+	}
+}
+
+func TestConcurrentCallersShareTokenFetch(t *testing.T) {
+	f := &fakeIGDB{expiresIn: 3600, tokenDelay: 100 * time.Millisecond}
+	c := newTestClient(t, f)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 4)
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.accessToken(context.Background())
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if token, _ := f.calls(); token != 1 {
+		t.Errorf("token requests = %d, want 1", token)
+	}
+}
+
+func TestWaitingCallerGivesUp(t *testing.T) {
+	f := &fakeIGDB{expiresIn: 3600, tokenDelay: 200 * time.Millisecond}
+	c := newTestClient(t, f)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.accessToken(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+	// The fetch went on without the caller that started it.
+	token, err := c.accessToken(context.Background())
+	if err != nil || token != "token-1" {
+		t.Errorf("token = %q, %v; want token-1", token, err)
+	}
+}
+
+func TestUnauthorizedRefreshesTokenOnce(t *testing.T) {
+	f := &fakeIGDB{expiresIn: 3600, gameStatuses: []int{http.StatusUnauthorized}}
+	c := newTestClient(t, f)
+
+	if _, err := c.Search(context.Background(), "sonic"); err != nil {
+		t.Fatal(err)
+	}
+	if token, games := f.calls(); token != 2 || games != 2 {
+		t.Errorf("token requests = %d, games requests = %d; want 2 and 2", token, games)
+	}
+	if got := f.gotTokens; got[0] != "Bearer token-1" || got[1] != "Bearer token-2" {
+		t.Errorf("games requests sent %q, want token-1 then token-2", got)
+	}
+
+	f = &fakeIGDB{expiresIn: 3600, gameStatuses: []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusUnauthorized}}
+	c = newTestClient(t, f)
+	if _, err := c.Search(context.Background(), "sonic"); !errors.Is(err, ErrAuth) {
+		t.Errorf("err = %v, want ErrAuth", err)
+	}
+	if token, games := f.calls(); token != 2 || games != 2 {
+		t.Errorf("token requests = %d, games requests = %d; want 2 and 2", token, games)
+	}
+}
+
+func TestRetriesTransientFailures(t *testing.T) {
+	f := &fakeIGDB{expiresIn: 3600, gameStatuses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}}
+	c := newTestClient(t, f)
+
+	games, err := c.Search(context.Background(), "sonic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(games) != 1 || games[0].Name != "Sonic the Hedgehog" {
+		t.Errorf("games = %+v", games)
+	}
+	if _, calls := f.calls(); calls != 3 {
+		t.Errorf("games requests = %d, want 3", calls)
+	}
+}
+
+func TestRetriesGiveUp(t *testing.T) {
+	f := &fakeIGDB{expiresIn: 3600, gameStatuses: []int{
+		http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusTooManyRequests,
+	}}
+	c := newTestClient(t, f)
+
+	if _, err := c.Search(context.Background(), "sonic"); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("err = %v, want ErrRateLimited", err)
+	}
+	if _, calls := f.calls(); calls != maxAttempts {
+		t.Errorf("games requests = %d, want %d", calls, maxAttempts)
+	}
+
+	f = &fakeIGDB{expiresIn: 3600, gameStatuses: []int{http.StatusBadRequest}}
+	c = newTestClient(t, f)
+	var apiErr *APIError
+	if _, err := c.Search(context.Background(), "sonic"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("err = %v, want a 400 APIError", err)
+	}
+	if _, calls := f.calls(); calls != 1 {
+		t.Errorf("games requests = %d, want 1: a 400 is not retried", calls)
+	}
+}
+
+func TestLongRetryAfterGivesUp(t *testing.T) {
+	f := &fakeIGDB{expiresIn: 3600, retryAfter: "3600", gameStatuses: []int{http.StatusTooManyRequests}}
+	c := newTestClient(t, f)
+
+	start := time.Now()
+	if _, err := c.Search(context.Background(), "sonic"); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("err = %v, want ErrRateLimited", err)
+	}
+	if elapsed := time.Since(start); elapsed > maxRetryWait {
+		t.Errorf("Search took %v, want no wait for a one hour Retry-After", elapsed)
+	}
+	if _, calls := f.calls(); calls != 1 {
+		t.Errorf("games requests = %d, want 1", calls)
+	}
+}
@@ -1,30 +1,74 @@
+// Package igdb searches game metadata on IGDB (https://api-docs.igdb.com),
+// authenticating with Twitch client credentials.
 package igdb
 
 import (
-	"bytes"
-	"encoding/json"
-	"fmt"
-	"io"
-	"net/http"
 	"strings"
 	"time"
 )
 
-// TwitchToken represents the OAuth2 response from Twitch.
-type TwitchToken struct {
-	AccessToken string `json:"access_token"`
-	ExpiresIn   int    `json:"expires_in"`
-	TokenType   string `json:"token_type"`
-}
-
 // GameData represents the game metadata from IGDB.
 type GameData struct {
-	ID               int        `json:"id"`
-	Name             string     `json:"name"`
-	Summary          string     `json:"summary"`
-	FirstReleaseDate int64      `json:"first_release_date"`
-	Cover            Cover      `json:"cover"`
-	Platforms        []Platform `json:"platforms"`
+	ID                int               `json:"id"`
+	Name              string            `json:"name"`
+	Summary           string            `json:"summary"`
+	FirstReleaseDate  int64             `json:"first_release_date"`
+	Cover             Cover             `json:"cover"`
+	Platforms         []Platform        `json:"platforms" openapi:"nullable"` // Nil when IGDB omits the field.
+	Genres            []Genre           `json:"genres" openapi:"nullable"`
+	InvolvedCompanies []InvolvedCompany `json:"involved_companies" openapi:"nullable"`
+	Screenshots       []Screenshot      `json:"screenshots" openapi:"nullable"`
+}
+
+// ReleaseDate returns the first release date, or nil when IGDB has none.
+func (g GameData) ReleaseDate() *time.Time {
+	if g.FirstReleaseDate == 0 {
+		return nil
+	}
+	t := time.Unix(g.FirstReleaseDate, 0).UTC()
+	return &t
+}
+
+// GenreNames returns the names of the game's genres.
+func (g GameData) GenreNames() []string {
+	names := make([]string, 0, len(g.Genres))
+	for _, genre := range g.Genres {
+		if genre.Name != "" {
+			names = append(names, genre.Name)
+		}
+	}
+	return names
+}
+
+// Developers returns the names of the companies credited as developer.
+func (g GameData) Developers() []string {
+	return g.companies(func(c InvolvedCompany) bool { return c.Developer })
+}
+
+// Publishers returns the names of the companies credited as publisher.
+func (g GameData) Publishers() []string {
+	return g.companies(func(c InvolvedCompany) bool { return c.Publisher })
+}
+
+func (g GameData) companies(match func(InvolvedCompany) bool) []string {
+	names := make([]string, 0, len(g.InvolvedCompanies))
+	for _, c := range g.InvolvedCompanies {
+		if match(c) && c.Company.Name != "" {
+			names = append(names, c.Company.Name)
+		}
+	}
+	return names
+}
+
+// ScreenshotURLs returns the screenshots resized to t_screenshot_big.
+func (g GameData) ScreenshotURLs() []string {
+	urls := make([]string, 0, len(g.Screenshots))
+	for _, s := range g.Screenshots {
+		if u := s.BigURL(); u != "" {
+			urls = append(urls, u)
+		}
+	}
+	return urls
 }
 
 // ReleaseYear returns the 4-digit year from the Unix timestamp, or "N/A".
@@ -64,69 +108,48 @@ func (c Cover) BigCoverURL() string {
 	return strings.Replace(c.URL, "t_thumb", "t_cover_big", 1)
 }
 
-// Platform represents a game platform from IGDB.
-type Platform struct {
-	ID           int    `json:"id"`
-	Name         string `json:"name"`
-	Abbreviation string `json:"abbreviation"`
+// Genre represents a game genre from IGDB.
+type Genre struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
 }
 
-// GetAccessToken retrieves a new access token from Twitch using client credentials.
-func GetAccessToken(clientID, clientSecret string) (*TwitchToken, error) {
-	url := fmt.Sprintf("https://id.twitch.tv/oauth2/token?client_id=%s&client_secret=%s&grant_type=client_credentials", clientID, clientSecret)
-
-	resp, err := http.Post(url, "application/json", nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to request token: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("token request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	var token TwitchToken
-	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
-		return nil, fmt.Errorf("failed to decode token: %w", err)
-	}
-
-	return &token, nil
+// InvolvedCompany links a game to a company and its role in it.
+type InvolvedCompany struct {
+	ID        int     `json:"id"`
+	Company   Company `json:"company"`
+	Developer bool    `json:"developer"`
+	Publisher bool    `json:"publisher"`
 }
 
-// SearchGame searches for games by name using the IGDB API.
-func SearchGame(clientID, accessToken, query string) ([]GameData, error) {
-	url := "https://api.igdb.com/v4/games"
-
-	// IGDB Query Language (Apex)
-	// We want ID, Name, Summary, Cover (URL), First Release Date
-	q := fmt.Sprintf(`search "%s"; fields name, summary, first_release_date, cover.url, platforms.name, platforms.abbreviation; limit 10;`, query)
-
-	req, err := http.NewRequest("POST", url, bytes.NewBufferString(q))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Client-ID", clientID)
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
-	req.Header.Set("Content-Type", "text/plain")
+// Company represents a developer or publisher from IGDB.
+type Company struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute search: %w", err)
-	}
-	defer resp.Body.Close()
+// Screenshot represents an in-game image from IGDB.
+type Screenshot struct {
+	ID  int    `json:"id"`
+	URL string `json:"url"`
+}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("search failed with status %d: %s", resp.StatusCode, string(body))
+// BigURL returns the screenshot URL resized to t_screenshot_big (889x500),
+// with the scheme IGDB leaves out.
+func (s Screenshot) BigURL() string {
+	if s.URL == "" {
+		return ""
 	}
-
-	var games []GameData
-	if err := json.NewDecoder(resp.Body).Decode(&games); err != nil {
-		return nil, fmt.Errorf("failed to decode games: %w", err)
+	u := strings.Replace(s.URL, "t_thumb", "t_screenshot_big", 1)
+	if strings.HasPrefix(u, "//") {
+		u = "https:" + u
 	}
+	return u
+}
 
-	return games, nil
+// Platform represents a game platform from IGDB.
+type Platform struct {
+	ID           int    `json:"id"`
+	Name         string `json:"name"`
+	Abbreviation string `json:"abbreviation"`
 }
@@ -0,0 +1,72 @@
+package igdb
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/cmellojr/modo-locadora/internal/metadata"
+)
+
+// Client is a metadata.Provider.
+var _ metadata.Provider = (*Client)(nil)
+
+// Source implements metadata.Provider.
+func (c *Client) Source() string { return metadata.SourceIGDB }
+
+// SearchGames implements metadata.Provider.
+func (c *Client) SearchGames(ctx context.Context, query string) ([]metadata.Game, error) {
+	games, err := c.Search(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]metadata.Game, len(games))
+	for i, g := range games {
+		result[i] = g.Metadata()
+	}
+	return result, nil
+}
+
+// GetGame implements metadata.Provider. IDs that are not IGDB game IDs
+// match no game.
+func (c *Client) GetGame(ctx context.Context, id string) (*metadata.Game, error) {
+	n, err := strconv.Atoi(id)
+	if err != nil || n <= 0 {
+		return nil, nil
+	}
+	g, err := c.Game(ctx, n)
+	if err != nil || g == nil {
+		return nil, err
+	}
+	m := g.Metadata()
+	return &m, nil
+}
+
+// Metadata converts the IGDB record to a provider-neutral one.
+func (g GameData) Metadata() metadata.Game {
+	id := strconv.Itoa(g.ID)
+	platforms := make([]string, 0, len(g.Platforms))
+	platformIDs := make([]int, 0, len(g.Platforms))
+	for _, p := range g.Platforms {
+		name := p.Abbreviation
+		if name == "" {
+			name = p.Name
+		}
+		platforms = append(platforms, name)
+		platformIDs = append(platformIDs, p.ID)
+	}
+	return metadata.Game{
+		Source:          metadata.SourceIGDB,
+		ID:              id,
+		IgdbID:          id,
+		Name:            g.Name,
+		Summary:         g.Summary,
+		ReleaseDate:     g.ReleaseDate(),
+		CoverURL:        g.Cover.BigCoverURL(),
+		Platforms:       platforms,
+		IgdbPlatformIDs: platformIDs,
+		Genres:          g.GenreNames(),
+		Developers:      g.Developers(),
+		Publishers:      g.Publishers(),
+		Screenshots:     g.ScreenshotURLs(),
+	}
+}
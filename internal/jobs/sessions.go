@@ -0,0 +1,44 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/cmellojr/modo-locadora/internal/auth"
+	"github.com/cmellojr/modo-locadora/internal/database"
+)
+
+// StartSessionSweeper launches a goroutine that periodically deletes revoked,
+// expired and idle sessions. Stops on ctx cancellation.
+func StartSessionSweeper(ctx context.Context, store database.Store, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		defer ticker.Stop()
+		log.Printf("[session-sweeper] Started. Sweeping every %v", interval)
+
+		sweepSessions(ctx, store)
+
+		for {
+			select {
+			case <-ctx.Done():
+				log.Println("[session-sweeper] Shutting down gracefully.")
+				return
+			case <-ticker.C:
+				sweepSessions(ctx, store)
+			}
+		}
+	}()
+}
+
+func sweepSessions(ctx context.Context, store database.Store) {
+	count, err := store.DeleteExpiredSessions(ctx, auth.SessionIdleTimeout)
+	if err != nil {
+		log.Printf("[session-sweeper] Error deleting expired sessions: %v", err)
+		return
+	}
+	if count > 0 {
+		log.Printf("[session-sweeper] Deleted %d expired session(s).", count)
+	}
+}
@@ -0,0 +1,253 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/cmellojr/modo-locadora/internal/auth"
+	"github.com/cmellojr/modo-locadora/internal/database"
+	"github.com/cmellojr/modo-locadora/internal/models"
+	"github.com/google/uuid"
+)
+
+// Webhook dispatcher settings. The lease must outlast a whole batch:
+// webhookBatchSize / webhookParallel rounds of webhookTimeout each.
+const (
+	webhookBatchSize    = 20
+	webhookParallel     = 5
+	webhookTimeout      = 10 * time.Second
+	webhookLease        = 2 * time.Minute
+	webhookRetryBase    = 30 * time.Second // Wait before the 2nd attempt; doubles after each failure.
+	webhookRetryMax     = 6 * time.Hour
+	webhookLogRetention = 30 * 24 * time.Hour
+	webhookErrorLen     = 200 // Characters of the failure reason kept in the delivery log.
+)
+
+// WebhookPayload is the JSON body of every webhook delivery.
+type WebhookPayload struct {
+	DeliveryID uuid.UUID `json:"delivery_id"`
+	Event      string    `json:"event"`
+	ActivityID uuid.UUID `json:"activity_id"`
+	MemberName string    `json:"member_name"`
+	GameTitle  string    `json:"game_title"`
+	Message    string    `json:"message"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Attempt    int       `json:"attempt"`
+}
+
+// StartWebhookDispatcher launches a goroutine that sends due webhook
+// deliveries every interval and prunes the delivery log hourly. format
+// renders the feed message of an event. Stops on ctx cancellation.
+func StartWebhookDispatcher(ctx context.Context, store database.Store, format func(database.ActivityEntry) string, interval time.Duration) {
+	d := &webhookDispatcher{
+		store:  store,
+		format: format,
+		staff:  newWebhookClient(false),
+		clubs:  newWebhookClient(true),
+	}
+	ticker := time.NewTicker(interval)
+	pruner := time.NewTicker(time.Hour)
+
+	go func() {
+		defer ticker.Stop()
+		defer pruner.Stop()
+		log.Printf("[webhooks] Started. Dispatching every %v", interval)
+
+		d.dispatch(ctx)
+		d.prune(ctx)
+
+		for {
+			select {
+			case <-ctx.Done():
+				log.Println("[webhooks] Shutting down gracefully.")
+				return
+			case <-ticker.C:
+				d.dispatch(ctx)
+			case <-pruner.C:
+				d.prune(ctx)
+			}
+		}
+	}()
+}
+
+type webhookDispatcher struct {
+	store  database.Store
+	format func(database.ActivityEntry) string
+	staff  *http.Client
+	clubs  *http.Client // Only reaches public addresses: club admins are not trusted with the LAN.
+}
+
+// newWebhookClient returns a client that does not follow redirects. With
+// publicOnly it refuses to connect to loopback, private and link-local
+// addresses, checked after DNS resolution, and ignores proxy settings.
+func newWebhookClient(publicOnly bool) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if publicOnly {
+		dialer := &net.Dialer{Timeout: webhookTimeout, Control: refuseNonPublic}
+		transport.DialContext = dialer.DialContext
+		transport.Proxy = nil
+	}
+	return &http.Client{
+		Timeout:   webhookTimeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// cgnat is the carrier-grade NAT range, private in practice.
+var cgnat = netip.MustParsePrefix("100.64.0.0/10")
+
+func refuseNonPublic(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	ip = ip.Unmap()
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsMulticast() || ip.IsUnspecified() || cgnat.Contains(ip) {
+		return fmt.Errorf("refusing to connect to non-public address %s", ip)
+	}
+	return nil
+}
+
+func (d *webhookDispatcher) dispatch(ctx context.Context) {
+	jobs, err := d.store.ClaimWebhookDeliveries(ctx, webhookBatchSize, webhookLease)
+	if err != nil {
+		log.Printf("[webhooks] Error claiming deliveries: %v", err)
+		return
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, webhookParallel)
+	for _, job := range jobs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			d.deliver(ctx, job)
+		}()
+	}
+	wg.Wait()
+}
+
+// deliver sends one delivery and records the outcome.
+func (d *webhookDispatcher) deliver(ctx context.Context, job database.WebhookJob) {
+	attempt := job.Delivery.Attempts + 1
+	body, err := json.Marshal(WebhookPayload{
+		DeliveryID: job.Delivery.ID,
+		Event:      job.Activity.EventType,
+		ActivityID: job.Activity.ID,
+		MemberName: job.Activity.MemberName,
+		GameTitle:  job.Activity.GameTitle,
+		Message:    d.format(job.Activity),
+		OccurredAt: job.Activity.CreatedAt,
+		Attempt:    attempt,
+	})
+	if err != nil {
+		log.Printf("[webhooks] Error encoding delivery %s: %v", job.Delivery.ID, err)
+		return
+	}
+
+	client := d.staff
+	if job.ClubID != nil {
+		client = d.clubs
+	}
+	status, err := postWebhook(ctx, client, job, body)
+	if err == nil {
+		if err := d.store.CompleteWebhookDelivery(ctx, job.Delivery.ID, job.Delivery.EndpointID, status); err != nil {
+			log.Printf("[webhooks] Error recording delivery %s: %v", job.Delivery.ID, err)
+		}
+		return
+	}
+
+	var retryAt *time.Time
+	if attempt < models.WebhookMaxAttempts {
+		t := time.Now().Add(webhookBackoff(attempt))
+		retryAt = &t
+	}
+	// Response bodies may hold anything; Postgres text takes neither NUL
+	// nor invalid UTF-8.
+	reason := err.Error()
+	if len(reason) > webhookErrorLen {
+		reason = reason[:webhookErrorLen]
+	}
+	reason = strings.ToValidUTF8(strings.ReplaceAll(reason, "\x00", ""), "")
+	disabled, err := d.store.FailWebhookDelivery(ctx, job.Delivery.ID, job.Delivery.EndpointID,
+		status, reason, retryAt, models.WebhookMaxConsecutiveFails)
+	if err != nil {
+		log.Printf("[webhooks] Error recording failed delivery %s: %v", job.Delivery.ID, err)
+		return
+	}
+	if disabled {
+		log.Printf("[webhooks] Endpoint %s switched off after %d failures in a row.",
+			job.Delivery.EndpointID, models.WebhookMaxConsecutiveFails)
+	}
+}
+
+// webhookBackoff returns the wait after the given failed attempt.
+func webhookBackoff(attempt int) time.Duration {
+	wait := webhookRetryBase
+	for i := 1; i < attempt && wait < webhookRetryMax; i++ {
+		wait *= 2
+	}
+	return min(wait, webhookRetryMax)
+}
+
+// postWebhook sends a signed delivery. Any response other than 2xx is an
+// error; the returned status is 0 when there was no response.
+func postWebhook(ctx context.Context, client *http.Client, job database.WebhookJob, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, job.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	ts := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ModoLocadora-Webhooks/1.0")
+	req.Header.Set("X-Locadora-Event", job.Activity.EventType)
+	req.Header.Set("X-Locadora-Delivery", job.Delivery.ID.String())
+	req.Header.Set("X-Locadora-Timestamp", strconv.FormatInt(ts, 10))
+	req.Header.Set("X-Locadora-Signature", auth.SignWebhook(job.Secret, ts, body))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, webhookErrorLen))
+		return resp.StatusCode, fmt.Errorf("HTTP %d: %s", resp.StatusCode, bytes.TrimSpace(snippet))
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return resp.StatusCode, nil
+}
+
+func (d *webhookDispatcher) prune(ctx context.Context) {
+	count, err := d.store.DeleteOldWebhookDeliveries(ctx, time.Now().Add(-webhookLogRetention))
+	if err != nil {
+		log.Printf("[webhooks] Error pruning delivery log: %v", err)
+		return
+	}
+	if count > 0 {
+		log.Printf("[webhooks] Pruned %d old deliveries.", count)
+	}
+}
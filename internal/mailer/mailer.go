@@ -0,0 +1,84 @@
+// Package mailer sends transactional e-mail (password reset, e-mail
+// verification) through a pluggable transport: SMTP in production, or a local
+// outbox directory of .eml files for development.
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"os"
+	"strings"
+	"time"
+)
+
+// Message is a plain-text e-mail.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers messages.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// FromEnv builds a Mailer from the environment. MAIL_TRANSPORT selects
+// "smtp" (SMTP_HOST, SMTP_PORT, SMTP_USERNAME, SMTP_PASSWORD) or "outbox"
+// (MAIL_OUTBOX_DIR, default "outbox"), the default. MAIL_FROM sets the sender.
+func FromEnv() (Mailer, error) {
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "Modo Locadora <tio@modolocadora.local>"
+	}
+
+	switch transport := os.Getenv("MAIL_TRANSPORT"); transport {
+	case "", "outbox":
+		dir := os.Getenv("MAIL_OUTBOX_DIR")
+		if dir == "" {
+			dir = "outbox"
+		}
+		return NewOutbox(dir, from), nil
+	case "smtp":
+		host := os.Getenv("SMTP_HOST")
+		if host == "" {
+			return nil, fmt.Errorf("SMTP_HOST is required when MAIL_TRANSPORT=smtp")
+		}
+		port := os.Getenv("SMTP_PORT")
+		if port == "" {
+			port = "587"
+		}
+		return NewSMTP(host, port, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), from), nil
+	default:
+		return nil, fmt.Errorf("unknown MAIL_TRANSPORT %q (want smtp or outbox)", transport)
+	}
+}
+
+// formatMessage renders msg as an RFC 5322 message with UTF-8 headers.
+func formatMessage(from string, msg Message, now time.Time) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + msg.To + "\r\n")
+	b.WriteString("Subject: " + encodeHeader(msg.Subject) + "\r\n")
+	b.WriteString("Date: " + now.Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// validateAddress rejects header injection through the recipient.
+func validateAddress(addr string) error {
+	if addr == "" || strings.ContainsAny(addr, "\r\n") {
+		return fmt.Errorf("invalid recipient address")
+	}
+	return nil
+}
+
+// encodeHeader encodes non-ASCII header text as an RFC 2047 word.
+func encodeHeader(s string) string {
+	return mime.QEncoding.Encode("UTF-8", s)
+}
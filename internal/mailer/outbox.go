@@ -0,0 +1,49 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Outbox writes each message as an .eml file in a directory instead of
+// sending it. Used in development and tests; open the files in any mail client.
+type Outbox struct {
+	dir  string
+	from string
+}
+
+// NewOutbox creates an outbox transport writing into dir.
+func NewOutbox(dir, from string) *Outbox {
+	return &Outbox{dir: dir, from: from}
+}
+
+// Send writes msg to the outbox directory.
+func (o *Outbox) Send(ctx context.Context, msg Message) error {
+	if err := validateAddress(msg.To); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(o.dir, 0o750); err != nil {
+		return fmt.Errorf("failed to create outbox directory: %w", err)
+	}
+
+	now := time.Now()
+	name := now.Format("20060102-150405") + "-" + uuid.New().String()[:8] + ".eml"
+	path := filepath.Join(o.dir, name)
+	if err := os.WriteFile(path, formatMessage(o.from, msg, now), 0o640); err != nil {
+		return fmt.Errorf("failed to write outbox message: %w", err)
+	}
+
+	log.Printf("[mailer] Outbox: %q to %s saved as %s", msg.Subject, strings.ToLower(msg.To), path)
+	return nil
+}
@@ -0,0 +1,51 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"time"
+)
+
+// SMTP sends mail through an SMTP server, using STARTTLS when offered.
+type SMTP struct {
+	host     string
+	port     string
+	username string
+	password string
+	from     string
+}
+
+// NewSMTP creates an SMTP transport. Authentication is skipped when username is empty.
+func NewSMTP(host, port, username, password, from string) *SMTP {
+	return &SMTP{host: host, port: port, username: username, password: password, from: from}
+}
+
+// Send delivers msg. The context bounds only the wait before dialing;
+// net/smtp has no per-call cancellation.
+func (s *SMTP) Send(ctx context.Context, msg Message) error {
+	if err := validateAddress(msg.To); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	sender, err := mail.ParseAddress(s.from)
+	if err != nil {
+		return fmt.Errorf("invalid MAIL_FROM: %w", err)
+	}
+
+	var auth smtp.Auth
+	if s.username != "" {
+		auth = smtp.PlainAuth("", s.username, s.password, s.host)
+	}
+
+	addr := net.JoinHostPort(s.host, s.port)
+	if err := smtp.SendMail(addr, auth, sender.Address, []string{msg.To}, formatMessage(s.from, msg, time.Now())); err != nil {
+		return fmt.Errorf("failed to send mail via smtp: %w", err)
+	}
+	return nil
+}
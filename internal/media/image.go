@@ -0,0 +1,197 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+)
+
+// jpegQuality is used for every JPEG saved.
+const jpegQuality = 88
+
+// Reasons an image is refused, wrapped in a *ValidationError.
+var (
+	ErrUnsupportedType = errors.New("media: only JPEG, PNG and GIF images are accepted")
+	ErrTooLarge        = errors.New("media: image file is too large")
+	ErrDimensions      = errors.New("media: image dimensions are out of range")
+	ErrCorrupt         = errors.New("media: image could not be decoded")
+)
+
+// ValidationError is returned for an image that fails a check. Err is one
+// of ErrUnsupportedType, ErrTooLarge, ErrDimensions or ErrCorrupt, and
+// errors.Is matches it.
+type ValidationError struct {
+	Err    error
+	Detail string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Detail == "" {
+		return e.Err.Error()
+	}
+	return e.Err.Error() + ": " + e.Detail
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// Size is a bounding box in pixels.
+type Size struct {
+	Width  int
+	Height int
+}
+
+// Format is the file format images are saved in.
+type Format string
+
+// Formats images are saved in: JPEG for photos such as covers, PNG for
+// images that need transparency.
+const (
+	FormatJPEG Format = "jpeg"
+	FormatPNG  Format = "png"
+)
+
+// Extension returns the file extension of the format.
+func (f Format) Extension() string {
+	if f == FormatPNG {
+		return ".png"
+	}
+	return ".jpg"
+}
+
+// Encode encodes img with no metadata. JPEG has no transparency, so
+// transparent areas are painted black.
+func (f Format) Encode(img *image.RGBA) ([]byte, error) {
+	var buf bytes.Buffer
+	if f == FormatPNG {
+		enc := png.Encoder{CompressionLevel: png.BestCompression}
+		if err := enc.Encode(&buf, img); err != nil {
+			return nil, fmt.Errorf("failed to encode image: %w", err)
+		}
+		return buf.Bytes(), nil
+	}
+
+	opaque := image.NewRGBA(img.Bounds())
+	draw.Draw(opaque, opaque.Bounds(), image.NewUniform(color.Black), image.Point{}, draw.Src)
+	draw.Draw(opaque, opaque.Bounds(), img, img.Bounds().Min, draw.Over)
+	if err := jpeg.Encode(&buf, opaque, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, fmt.Errorf("failed to encode image: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// sniff returns the image format named by the magic bytes at the start of
+// data, or "" for anything but JPEG, PNG and GIF. The extension and
+// Content-Type sent by the client are never trusted.
+func sniff(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte("\xff\xd8\xff")):
+		return "jpeg"
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return "png"
+	case bytes.HasPrefix(data, []byte("GIF87a")), bytes.HasPrefix(data, []byte("GIF89a")):
+		return "gif"
+	}
+	return ""
+}
+
+// Decode checks data against the kind's limits and decodes it into RGBA
+// pixels. The dimensions are checked from the header before decoding, so
+// a small file cannot expand into gigabytes of pixels. Only the pixels
+// survive: EXIF, color profiles and comments are dropped.
+func (k Kind) Decode(data []byte) (*image.RGBA, error) {
+	if int64(len(data)) > k.MaxBytes {
+		return nil, &ValidationError{Err: ErrTooLarge, Detail: fmt.Sprintf("limit is %d bytes", k.MaxBytes)}
+	}
+	format := sniff(data)
+	if format == "" {
+		return nil, &ValidationError{Err: ErrUnsupportedType}
+	}
+
+	var cfg image.Config
+	var err error
+	switch format {
+	case "jpeg":
+		cfg, err = jpeg.DecodeConfig(bytes.NewReader(data))
+	case "png":
+		cfg, err = png.DecodeConfig(bytes.NewReader(data))
+	case "gif":
+		cfg, err = gif.DecodeConfig(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, &ValidationError{Err: ErrCorrupt, Detail: err.Error()}
+	}
+	if cfg.Width < k.MinSize.Width || cfg.Height < k.MinSize.Height ||
+		cfg.Width > k.MaxSize.Width || cfg.Height > k.MaxSize.Height {
+		return nil, &ValidationError{Err: ErrDimensions, Detail: fmt.Sprintf("%dx%d pixels", cfg.Width, cfg.Height)}
+	}
+
+	var src image.Image
+	switch format {
+	case "jpeg":
+		src, err = jpeg.Decode(bytes.NewReader(data))
+	case "png":
+		src, err = png.Decode(bytes.NewReader(data))
+	case "gif":
+		src, err = gif.Decode(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, &ValidationError{Err: ErrCorrupt, Detail: err.Error()}
+	}
+
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), src, b.Min, draw.Src)
+	return dst, nil
+}
+
+// Fit scales img down to fit inside size, keeping its aspect ratio. Each
+// output pixel averages the block of source pixels it covers. Images that
+// already fit are returned as they are.
+func Fit(img *image.RGBA, size Size) *image.RGBA {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= size.Width && h <= size.Height {
+		return img
+	}
+
+	scale := min(float64(size.Width)/float64(w), float64(size.Height)/float64(h))
+	dw := max(1, int(float64(w)*scale+0.5))
+	dh := max(1, int(float64(h)*scale+0.5))
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := 0; y < dh; y++ {
+		y0 := y * h / dh
+		y1 := max(y0+1, (y+1)*h/dh)
+		for x := 0; x < dw; x++ {
+			x0 := x * w / dw
+			x1 := max(x0+1, (x+1)*w/dw)
+
+			var r, g, bl, a, n uint32
+			for sy := y0; sy < y1; sy++ {
+				off := img.PixOffset(b.Min.X+x0, b.Min.Y+sy)
+				for sx := x0; sx < x1; sx++ {
+					r += uint32(img.Pix[off])
+					g += uint32(img.Pix[off+1])
+					bl += uint32(img.Pix[off+2])
+					a += uint32(img.Pix[off+3])
+					off += 4
+					n++
+				}
+			}
+			i := dst.PixOffset(x, y)
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(bl / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,218 @@
+// Package media keeps uploaded and mirrored images, game covers and club
+// badges, on the shop's own disk or in an S3-compatible bucket. Every image
+// is checked by its magic bytes, size and dimensions, decoded, and
+// re-encoded without its metadata in the sizes the pages show. Remote
+// covers are downloaded once.
+package media
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// Kind describes one kind of image: the checks it must pass and how it is
+// saved.
+type Kind struct {
+	MaxBytes  int64
+	MinSize   Size // Smallest accepted source image.
+	MaxSize   Size // Largest accepted source image.
+	Size      Size // Bounding box of the saved image.
+	ThumbSize Size // Bounding box of the thumbnail; zero for none.
+	Format    Format
+}
+
+// MaxMegabytes returns MaxBytes in whole megabytes, for messages.
+func (k Kind) MaxMegabytes() int64 {
+	return k.MaxBytes >> 20
+}
+
+// Kinds of images. Covers: the detail page shows them 200px wide and shelf
+// cards 120px wide, both doubled for high-density screens. Badges are shown
+// up to 120px square and keep their transparency.
+var (
+	CoverKind = Kind{
+		MaxBytes:  8 << 20,
+		MinSize:   Size{Width: 32, Height: 32},
+		MaxSize:   Size{Width: 5000, Height: 5000},
+		Size:      Size{Width: 400, Height: 560},
+		ThumbSize: Size{Width: 240, Height: 320},
+		Format:    FormatJPEG,
+	}
+	BadgeKind = Kind{
+		MaxBytes: 2 << 20,
+		MinSize:  Size{Width: 32, Height: 32},
+		MaxSize:  Size{Width: 2000, Height: 2000},
+		Size:     Size{Width: 240, Height: 240},
+		Format:   FormatPNG,
+	}
+)
+
+// ErrDownload is returned when a remote image cannot be fetched.
+var ErrDownload = errors.New("media: download failed")
+
+// Image is an image saved by Images.
+type Image struct {
+	URL       string
+	ThumbURL  string // Empty for kinds without a thumbnail.
+	SourceURL string // Remote URL the image was mirrored from; empty for uploads.
+}
+
+// Images saves one kind of image under a namespace of a Storage, such as
+// "covers".
+type Images struct {
+	kind      Kind
+	storage   Storage
+	namespace string
+	client    *http.Client
+}
+
+// NewImages returns images of kind kept in storage under namespace.
+func NewImages(kind Kind, storage Storage, namespace string) *Images {
+	return &Images{
+		kind:      kind,
+		storage:   storage,
+		namespace: strings.Trim(namespace, "/"),
+		client:    &http.Client{Timeout: 20 * time.Second},
+	}
+}
+
+// Kind returns the kind of image saved.
+func (im *Images) Kind() Kind {
+	return im.kind
+}
+
+// IsRemote reports whether url points to another site, including IGDB's
+// scheme-relative "//images.igdb.com/..." URLs.
+func IsRemote(url string) bool {
+	return strings.HasPrefix(url, "https://") || strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "//")
+}
+
+// prefix returns the public URL prefix of the namespace, ending in a slash.
+func (im *Images) prefix() string {
+	return im.storage.URL(im.namespace + "/")
+}
+
+// Owns reports whether url is a file kept by these images in the current
+// storage.
+func (im *Images) Owns(url string) bool {
+	return strings.HasPrefix(url, im.prefix()) && len(url) > len(im.prefix())
+}
+
+// key returns the storage key behind an owned URL. Only the base name is
+// used, so a URL cannot point outside the namespace.
+func (im *Images) key(url string) string {
+	name, err := neturl.PathUnescape(strings.TrimPrefix(url, im.prefix()))
+	if err != nil {
+		name = strings.TrimPrefix(url, im.prefix())
+	}
+	return im.namespace + "/" + path.Base(name)
+}
+
+// Read returns the contents of a file kept by these images.
+func (im *Images) Read(ctx context.Context, url string) ([]byte, error) {
+	if !im.Owns(url) {
+		return nil, fmt.Errorf("media: %q is not a stored image", url)
+	}
+	return im.storage.Get(ctx, im.key(url))
+}
+
+// Remove deletes the files behind owned URLs. Empty and remote URLs are
+// skipped, and files already gone are not an error.
+func (im *Images) Remove(ctx context.Context, urls ...string) error {
+	var errs []error
+	for _, url := range urls {
+		if !im.Owns(url) {
+			continue
+		}
+		if err := im.storage.Delete(ctx, im.key(url)); err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove image: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Upload reads an uploaded file, refusing it as soon as it passes
+// MaxBytes, and saves it as name.
+func (im *Images) Upload(ctx context.Context, name string, file io.Reader) (Image, error) {
+	data, err := io.ReadAll(io.LimitReader(file, im.kind.MaxBytes+1))
+	if err != nil {
+		return Image{}, fmt.Errorf("failed to read upload: %w", err)
+	}
+	return im.Save(ctx, name, data)
+}
+
+// Mirror downloads the image at sourceURL and saves it as name.
+func (im *Images) Mirror(ctx context.Context, name, sourceURL string) (Image, error) {
+	url := sourceURL
+	if strings.HasPrefix(url, "//") {
+		url = "https:" + url
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return Image{}, fmt.Errorf("%w: %v", ErrDownload, err)
+	}
+	resp, err := im.client.Do(req)
+	if err != nil {
+		return Image{}, fmt.Errorf("%w: %v", ErrDownload, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Image{}, fmt.Errorf("%w: %s returned %s", ErrDownload, url, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, im.kind.MaxBytes+1))
+	if err != nil {
+		return Image{}, fmt.Errorf("%w: %v", ErrDownload, err)
+	}
+	img, err := im.Save(ctx, name, data)
+	if err != nil {
+		return Image{}, err
+	}
+	img.SourceURL = sourceURL
+	return img, nil
+}
+
+// Save checks data against the kind's limits and stores it as
+// name-<hash> plus the format's extension, and the thumbnail as
+// name-<hash>-thumb. The hash comes from data, so saving the same image
+// again reuses the same files and a new image gets new URLs.
+func (im *Images) Save(ctx context.Context, name string, data []byte) (Image, error) {
+	src, err := im.kind.Decode(data)
+	if err != nil {
+		return Image{}, err
+	}
+
+	sum := sha256.Sum256(data)
+	base := im.namespace + "/" + name + "-" + hex.EncodeToString(sum[:6])
+	ext := im.kind.Format.Extension()
+
+	out, err := im.kind.Format.Encode(Fit(src, im.kind.Size))
+	if err != nil {
+		return Image{}, err
+	}
+	if err := im.storage.Put(ctx, base+ext, out); err != nil {
+		return Image{}, err
+	}
+	img := Image{URL: im.storage.URL(base + ext)}
+
+	if im.kind.ThumbSize != (Size{}) {
+		thumb, err := im.kind.Format.Encode(Fit(src, im.kind.ThumbSize))
+		if err != nil {
+			return Image{}, err
+		}
+		if err := im.storage.Put(ctx, base+"-thumb"+ext, thumb); err != nil {
+			return Image{}, err
+		}
+		img.ThumbURL = im.storage.URL(base + "-thumb" + ext)
+	}
+	return img, nil
+}
@@ -0,0 +1,313 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3Config configures an S3-compatible bucket.
+type S3Config struct {
+	Endpoint        string // Such as "http://localhost:9000" for MinIO; AWS for the region when empty.
+	Region          string // "us-east-1" when empty.
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	PublicURL       string // Base of public file URLs, such as a CDN; the bucket URL when empty.
+}
+
+// S3Storage keeps files in an S3-compatible bucket (AWS S3, MinIO, R2,
+// Spaces...), addressed path-style and signed with AWS Signature V4. The
+// bucket, or the CDN in front of it, must allow public reads.
+type S3Storage struct {
+	cfg      S3Config
+	endpoint string // Without a trailing slash.
+	client   *http.Client
+}
+
+// S3FromEnv returns S3 storage configured by S3_ENDPOINT, S3_REGION,
+// S3_BUCKET, S3_ACCESS_KEY_ID, S3_SECRET_ACCESS_KEY and S3_PUBLIC_URL.
+func S3FromEnv() (*S3Storage, error) {
+	return NewS3Storage(S3Config{
+		Endpoint:        os.Getenv("S3_ENDPOINT"),
+		Region:          os.Getenv("S3_REGION"),
+		Bucket:          os.Getenv("S3_BUCKET"),
+		AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+		PublicURL:       os.Getenv("S3_PUBLIC_URL"),
+	})
+}
+
+// NewS3Storage returns storage in the configured bucket.
+func NewS3Storage(cfg S3Config) (*S3Storage, error) {
+	if cfg.Bucket == "" || cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, errors.New("media: S3 storage needs S3_BUCKET, S3_ACCESS_KEY_ID and S3_SECRET_ACCESS_KEY")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	endpoint := strings.TrimRight(cfg.Endpoint, "/")
+	if endpoint == "" {
+		endpoint = "https://s3." + cfg.Region + ".amazonaws.com"
+	}
+	if u, err := url.Parse(endpoint); err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("media: invalid S3_ENDPOINT %q", cfg.Endpoint)
+	}
+	if cfg.PublicURL == "" {
+		cfg.PublicURL = endpoint + "/" + cfg.Bucket
+	}
+	cfg.PublicURL = strings.TrimRight(cfg.PublicURL, "/")
+
+	return &S3Storage{
+		cfg:      cfg,
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// Name returns StorageS3.
+func (s *S3Storage) Name() string {
+	return StorageS3
+}
+
+// Put uploads data with its content type. Names carry a content hash, so
+// the files are cached for a year.
+func (s *S3Storage) Put(ctx context.Context, key string, data []byte) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	header := http.Header{}
+	header.Set("Content-Type", contentType(key))
+	header.Set("Cache-Control", "public, max-age=31536000, immutable")
+
+	resp, err := s.do(ctx, http.MethodPut, key, nil, data, header)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Get downloads the file under key.
+func (s *S3Storage) Get(ctx context.Context, key string) ([]byte, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	resp, err := s.do(ctx, http.MethodGet, key, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	return data, nil
+}
+
+// Delete removes the file under key.
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	resp, err := s.do(ctx, http.MethodDelete, key, nil, nil, nil)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// listBucketResult is the body of a ListObjectsV2 response.
+type listBucketResult struct {
+	Contents []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List pages through ListObjectsV2 for keys that start with prefix.
+func (s *S3Storage) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		resp, err := s.do(ctx, http.MethodGet, "", query, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		var page listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode S3 listing: %w", err)
+		}
+
+		for _, c := range page.Contents {
+			keys = append(keys, c.Key)
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return keys, nil
+		}
+		token = page.NextContinuationToken
+	}
+}
+
+// URL returns the public URL of key.
+func (s *S3Storage) URL(key string) string {
+	return s.cfg.PublicURL + "/" + s3Escape(key, true)
+}
+
+// s3Error is the body of an S3 error response.
+type s3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+// do sends a signed request for key in the bucket (the bucket itself when
+// key is empty). Responses other than 2xx become errors, with 404 as
+// ErrNotFound.
+func (s *S3Storage) do(ctx context.Context, method, key string, query url.Values, body []byte, header http.Header) (*http.Response, error) {
+	target := s.endpoint + "/" + s3Escape(s.cfg.Bucket, false)
+	if key != "" {
+		target += "/" + s3Escape(key, true)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build S3 request: %w", err)
+	}
+	req.URL.RawQuery = canonicalQuery(query)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	sum := sha256.Sum256(body)
+	signV4(req, hex.EncodeToString(sum[:]), time.Now(), s.cfg.AccessKeyID, s.cfg.SecretAccessKey, s.cfg.Region)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("s3 %s %s: %w", method, key, err)
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	var e s3Error
+	_ = xml.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&e)
+	if e.Code != "" {
+		return nil, fmt.Errorf("s3 %s %s: %s (%s: %s)", method, key, resp.Status, e.Code, e.Message)
+	}
+	return nil, fmt.Errorf("s3 %s %s: %s", method, key, resp.Status)
+}
+
+// signV4 signs req with AWS Signature Version 4 for S3, covering the host
+// and every header already set on req.
+func signV4(req *http.Request, payloadHash string, t time.Time, accessKey, secretKey, region string) {
+	amzDate := t.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	values := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		values[strings.ToLower(k)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	names := make([]string, 0, len(values))
+	for k := range values {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var headers strings.Builder
+	for _, k := range names {
+		headers.WriteString(k + ":" + values[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		headers.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/s3/aws4_request"
+	hash := sha256.Sum256([]byte(canonical))
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+accessKey+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalQuery encodes query sorted by name, as SigV4 requires. The
+// same string is sent, so the signature matches what the server sees.
+func canonicalQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for k := range query {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var parts []string
+	for _, k := range names {
+		for _, v := range query[k] {
+			parts = append(parts, s3Escape(k, false)+"="+s3Escape(v, false))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// s3Escape percent-encodes everything but RFC 3986 unreserved characters
+// and, with keepSlash, the slashes between key segments.
+func s3Escape(s string, keepSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/' && keepSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
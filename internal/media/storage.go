@@ -0,0 +1,196 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Storage backends, as set in MEDIA_STORAGE.
+const (
+	StorageLocal = "local"
+	StorageS3    = "s3"
+)
+
+// ErrNotFound is returned by Storage.Get for keys that do not exist.
+var ErrNotFound = errors.New("media: file not found")
+
+// Storage keeps media files under slash-separated keys such as
+// "covers/<name>.jpg", and knows the public URL of each.
+type Storage interface {
+	// Name identifies the backend, such as StorageLocal.
+	Name() string
+
+	// Put stores data under key, replacing any file already there.
+	Put(ctx context.Context, key string, data []byte) error
+
+	// Get returns the file under key, or ErrNotFound.
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// Delete removes the file under key. Missing files are not an error.
+	Delete(ctx context.Context, key string) error
+
+	// List returns the keys that start with prefix.
+	List(ctx context.Context, prefix string) ([]string, error)
+
+	// URL returns the public URL of key.
+	URL(key string) string
+}
+
+// StorageFromEnv returns the storage backend named by MEDIA_STORAGE,
+// local disk by default.
+func StorageFromEnv() (Storage, error) {
+	return OpenStorage(os.Getenv("MEDIA_STORAGE"))
+}
+
+// OpenStorage returns the named storage backend, configured from the
+// environment. An empty name is local disk.
+func OpenStorage(name string) (Storage, error) {
+	switch name {
+	case "", StorageLocal:
+		return NewLocalStorage(filepath.Join("web", "static"), "/static"), nil
+	case StorageS3:
+		s, err := S3FromEnv()
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+	return nil, fmt.Errorf("media: unknown storage %q: use local or s3", name)
+}
+
+// contentType returns the MIME type for key's extension.
+func contentType(key string) string {
+	if t := mime.TypeByExtension(path.Ext(key)); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+// checkKey refuses keys that could escape the storage root.
+func checkKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || !filepath.IsLocal(filepath.FromSlash(key)) {
+		return fmt.Errorf("media: invalid key %q", key)
+	}
+	return nil
+}
+
+// LocalStorage keeps files in a directory served by the app itself.
+type LocalStorage struct {
+	dir       string
+	urlPrefix string
+}
+
+// NewLocalStorage returns storage in dir, served under urlPrefix, such as
+// "web/static" and "/static".
+func NewLocalStorage(dir, urlPrefix string) *LocalStorage {
+	return &LocalStorage{dir: dir, urlPrefix: strings.TrimRight(urlPrefix, "/")}
+}
+
+// Name returns StorageLocal.
+func (s *LocalStorage) Name() string {
+	return StorageLocal
+}
+
+// Put writes the file through a temporary file, so pages never serve a
+// half-written image.
+func (s *LocalStorage) Put(_ context.Context, key string, data []byte) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	dst := filepath.Join(s.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return fmt.Errorf("failed to create media directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to save %s: %w", key, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return fmt.Errorf("failed to save %s: %w", key, err)
+	}
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		return fmt.Errorf("failed to save %s: %w", key, err)
+	}
+	return nil
+}
+
+// Get reads the file under key.
+func (s *LocalStorage) Get(_ context.Context, key string) ([]byte, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(s.dir, filepath.FromSlash(key)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	return data, nil
+}
+
+// Delete removes the file under key.
+func (s *LocalStorage) Delete(_ context.Context, key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	err := os.Remove(filepath.Join(s.dir, filepath.FromSlash(key)))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove %s: %w", key, err)
+	}
+	return nil
+}
+
+// List walks the directory for files whose key starts with prefix,
+// skipping hidden files such as .gitkeep and unfinished uploads.
+func (s *LocalStorage) List(_ context.Context, prefix string) ([]string, error) {
+	// Only walk the directory the prefix points into.
+	root := filepath.Join(s.dir, filepath.FromSlash(path.Dir(prefix+"_")))
+
+	var keys []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".") {
+			return nil
+		}
+		rel, err := filepath.Rel(s.dir, p)
+		if err != nil {
+			return err
+		}
+		if key := filepath.ToSlash(rel); strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", prefix, err)
+	}
+	return keys, nil
+}
+
+// URL returns the path the app serves key at.
+func (s *LocalStorage) URL(key string) string {
+	return s.urlPrefix + "/" + key
+}
@@ -0,0 +1,406 @@
+package metadata
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cmellojr/modo-locadora/internal/models"
+)
+
+//go:embed catalog.json
+var bundledCatalog []byte
+
+// BundledFile is the name listed for the catalog shipped with the binary.
+const BundledFile = "catalog.json (embutido)"
+
+// Catalog limits.
+const (
+	MaxCatalogEntries = 10000
+	searchLimit       = 20
+)
+
+// ErrCatalogFile is returned for catalog file names that are not a plain
+// .json or .csv file name.
+var ErrCatalogFile = errors.New("metadata: catalog files must be .json or .csv")
+
+// CatalogFile describes one file loaded into the catalog.
+type CatalogFile struct {
+	Name    string
+	Games   int
+	Bundled bool // Shipped with the binary; cannot be removed.
+}
+
+// Catalog is a Provider backed by local files: the bundled catalog plus the
+// JSON and CSV files in a directory. Entries of later files replace those
+// with the same ID. It is safe for concurrent use.
+type Catalog struct {
+	dir string
+
+	mu    sync.RWMutex
+	games []Game // Sorted by name.
+	byID  map[string]int
+	files []CatalogFile
+}
+
+// LoadCatalog loads the bundled catalog and the files in dir, which may not
+// exist yet. Files that fail to parse are skipped and reported in the error;
+// the catalog is usable either way.
+func LoadCatalog(dir string) (*Catalog, error) {
+	c := &Catalog{dir: dir}
+	return c, c.reload()
+}
+
+// Source implements Provider.
+func (c *Catalog) Source() string { return SourceLocal }
+
+// SearchGames implements Provider. Every word of query must appear in the
+// name, ignoring case and accents.
+func (c *Catalog) SearchGames(ctx context.Context, query string) ([]Game, error) {
+	words := strings.Fields(models.Fold(query))
+	if len(words) == 0 {
+		return nil, nil
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var result []Game
+	for _, g := range c.games {
+		name := models.Fold(g.Name)
+		if allContained(name, words) {
+			result = append(result, g)
+			if len(result) == searchLimit {
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+// GetGame implements Provider.
+func (c *Catalog) GetGame(ctx context.Context, id string) (*Game, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	i, ok := c.byID[id]
+	if !ok {
+		return nil, nil
+	}
+	g := c.games[i]
+	return &g, nil
+}
+
+// Files lists the loaded catalog files, the bundled one first.
+func (c *Catalog) Files() []CatalogFile {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return slices.Clone(c.files)
+}
+
+// Len returns the number of games in the catalog.
+func (c *Catalog) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.games)
+}
+
+// Import validates a JSON or CSV catalog, saves it in the catalog directory
+// under name (replacing a file of the same name) and reloads the catalog.
+// It returns the number of entries in the file.
+func (c *Catalog) Import(name string, data []byte) (int, error) {
+	name, err := catalogFileName(name)
+	if err != nil {
+		return 0, err
+	}
+	games, err := parseCatalog(name, data)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return 0, fmt.Errorf("metadata: failed to create catalog directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(c.dir, ".upload-*")
+	if err != nil {
+		return 0, fmt.Errorf("metadata: failed to save catalog: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return 0, fmt.Errorf("metadata: failed to save catalog: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, fmt.Errorf("metadata: failed to save catalog: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(c.dir, name)); err != nil {
+		return 0, fmt.Errorf("metadata: failed to save catalog: %w", err)
+	}
+
+	return len(games), c.reload()
+}
+
+// Remove deletes an uploaded catalog file and reloads the catalog.
+func (c *Catalog) Remove(name string) error {
+	name, err := catalogFileName(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filepath.Join(c.dir, name)); err != nil {
+		return fmt.Errorf("metadata: failed to remove catalog: %w", err)
+	}
+	return c.reload()
+}
+
+// reload rebuilds the catalog from the bundled file and the directory.
+func (c *Catalog) reload() error {
+	var errs []error
+
+	bundled, err := parseCatalog("catalog.json", bundledCatalog)
+	if err != nil {
+		return fmt.Errorf("metadata: bundled catalog: %w", err)
+	}
+	files := []CatalogFile{{Name: BundledFile, Games: len(bundled), Bundled: true}}
+	all := bundled
+
+	entries, err := os.ReadDir(c.dir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		errs = append(errs, fmt.Errorf("metadata: failed to read catalog directory: %w", err))
+	}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		name, err := catalogFileName(e.Name())
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(c.dir, name))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("metadata: %s: %w", name, err))
+			continue
+		}
+		games, err := parseCatalog(name, data)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("metadata: %s: %w", name, err))
+			continue
+		}
+		files = append(files, CatalogFile{Name: name, Games: len(games)})
+		all = append(all, games...)
+	}
+
+	// Later entries win; keep one per ID, sorted by name.
+	seen := make(map[string]int, len(all))
+	var games []Game
+	for _, g := range all {
+		if i, ok := seen[g.ID]; ok {
+			games[i] = g
+			continue
+		}
+		seen[g.ID] = len(games)
+		games = append(games, g)
+	}
+	sort.SliceStable(games, func(i, j int) bool { return models.Fold(games[i].Name) < models.Fold(games[j].Name) })
+	byID := make(map[string]int, len(games))
+	for i, g := range games {
+		byID[g.ID] = i
+	}
+
+	c.mu.Lock()
+	c.games, c.byID, c.files = games, byID, files
+	c.mu.Unlock()
+
+	return errors.Join(errs...)
+}
+
+// catalogFileName checks that name is a bare .json or .csv file name.
+func catalogFileName(name string) (string, error) {
+	base := filepath.Base(name)
+	if base != name || strings.HasPrefix(base, ".") {
+		return "", ErrCatalogFile
+	}
+	switch strings.ToLower(filepath.Ext(base)) {
+	case ".json", ".csv":
+		return base, nil
+	}
+	return "", ErrCatalogFile
+}
+
+// catalogEntry is one game as written in a catalog file.
+type catalogEntry struct {
+	ID          string   `json:"id"`
+	IgdbID      string   `json:"igdb_id"`
+	Name        string   `json:"name"`
+	Summary     string   `json:"summary"`
+	ReleaseDate string   `json:"release_date"` // YYYY-MM-DD.
+	CoverURL    string   `json:"cover_url"`
+	Platforms   []string `json:"platforms"`
+	Genres      []string `json:"genres"`
+	Developers  []string `json:"developers"`
+	Publishers  []string `json:"publishers"`
+	Screenshots []string `json:"screenshots"`
+}
+
+// parseCatalog parses a catalog file by its extension.
+func parseCatalog(name string, data []byte) ([]Game, error) {
+	var entries []catalogEntry
+	var err error
+	if strings.EqualFold(filepath.Ext(name), ".csv") {
+		entries, err = parseCSV(data)
+	} else {
+		err = json.Unmarshal(data, &entries)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) > MaxCatalogEntries {
+		return nil, fmt.Errorf("more than %d games", MaxCatalogEntries)
+	}
+
+	games := make([]Game, 0, len(entries))
+	for i, e := range entries {
+		g, err := e.game()
+		if err != nil {
+			return nil, fmt.Errorf("entry %d: %w", i+1, err)
+		}
+		games = append(games, g)
+	}
+	return games, nil
+}
+
+// parseCSV reads a catalog with a header row naming the catalogEntry
+// fields. Fields are separated by commas or semicolons, list values by "|".
+func parseCSV(data []byte) ([]catalogEntry, error) {
+	data = bytes.TrimPrefix(data, []byte("\ufeff")) // Spreadsheet exports often start with a BOM.
+	r := csv.NewReader(bytes.NewReader(data))
+	header, _, _ := bytes.Cut(data, []byte("\n"))
+	if bytes.Count(header, []byte(";")) > bytes.Count(header, []byte(",")) {
+		r.Comma = ';'
+	}
+	r.TrimLeadingSpace = true
+
+	cols, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	index := make(map[string]int, len(cols))
+	for i, col := range cols {
+		index[strings.ToLower(strings.TrimSpace(col))] = i
+	}
+	if _, ok := index["name"]; !ok {
+		return nil, errors.New(`CSV header has no "name" column`)
+	}
+
+	var entries []catalogEntry
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		get := func(col string) string {
+			if i, ok := index[col]; ok && i < len(rec) {
+				return strings.TrimSpace(rec[i])
+			}
+			return ""
+		}
+		list := func(col string) []string {
+			var out []string
+			for _, v := range strings.Split(get(col), "|") {
+				if v = strings.TrimSpace(v); v != "" {
+					out = append(out, v)
+				}
+			}
+			return out
+		}
+		entries = append(entries, catalogEntry{
+			ID:          get("id"),
+			IgdbID:      get("igdb_id"),
+			Name:        get("name"),
+			Summary:     get("summary"),
+			ReleaseDate: get("release_date"),
+			CoverURL:    get("cover_url"),
+			Platforms:   list("platforms"),
+			Genres:      list("genres"),
+			Developers:  list("developers"),
+			Publishers:  list("publishers"),
+			Screenshots: list("screenshots"),
+		})
+	}
+	return entries, nil
+}
+
+// game validates the entry. Entries without an ID get one from the name and
+// first platform.
+func (e catalogEntry) game() (Game, error) {
+	g := Game{
+		Source:      SourceLocal,
+		ID:          strings.TrimSpace(e.ID),
+		IgdbID:      strings.TrimSpace(e.IgdbID),
+		Name:        strings.TrimSpace(e.Name),
+		Summary:     strings.TrimSpace(e.Summary),
+		CoverURL:    strings.TrimSpace(e.CoverURL),
+		Platforms:   e.Platforms,
+		Genres:      e.Genres,
+		Developers:  e.Developers,
+		Publishers:  e.Publishers,
+		Screenshots: e.Screenshots,
+	}
+	if g.Name == "" {
+		return Game{}, errors.New("name is required")
+	}
+	if e.ReleaseDate != "" {
+		d, err := time.Parse("2006-01-02", e.ReleaseDate)
+		if err != nil {
+			return Game{}, fmt.Errorf("%s: release_date must be YYYY-MM-DD", g.Name)
+		}
+		g.ReleaseDate = &d
+	}
+	if g.ID == "" {
+		key := g.Name
+		if len(g.Platforms) > 0 {
+			key += " " + g.Platforms[0]
+		}
+		g.ID = slug(key)
+	}
+	return g, nil
+}
+
+func allContained(s string, words []string) bool {
+	for _, w := range words {
+		if !strings.Contains(s, w) {
+			return false
+		}
+	}
+	return true
+}
+
+// slug turns s into a lowercase ASCII identifier such as "sonic-the-hedgehog-mega-drive".
+func slug(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range models.Fold(s) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
@@ -0,0 +1,174 @@
+package metadata
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/cmellojr/modo-locadora/internal/models"
+)
+
+// Confidence levels of a Candidate.
+const (
+	ConfidenceHigh   = "high"
+	ConfidenceMedium = "medium"
+	ConfidenceLow    = "low"
+)
+
+// Score thresholds of the confidence levels.
+const (
+	highScore   = 85
+	mediumScore = 60
+)
+
+// platformMismatchPenalty is taken from the score of a game that is not
+// known on the wanted platform.
+const platformMismatchPenalty = 25
+
+// Candidate is a provider game that may be the title on a stocking list.
+type Candidate struct {
+	Game
+	Score int // 0 to 100.
+}
+
+// Confidence returns ConfidenceHigh, ConfidenceMedium or ConfidenceLow.
+func (c Candidate) Confidence() string {
+	switch {
+	case c.Score >= highScore:
+		return ConfidenceHigh
+	case c.Score >= mediumScore:
+		return ConfidenceMedium
+	}
+	return ConfidenceLow
+}
+
+// Match searches p for title and returns up to limit candidates, best
+// first. A title with typos that finds nothing is searched again by its
+// longest word. platform, when set, lowers the score of games the provider
+// lists only on other platforms; canonical maps provider and list platform
+// names to the platform registry ("Genesis/MegaDrive" to "Mega Drive") and
+// may be nil.
+func Match(ctx context.Context, p Provider, title, platform string, canonical func(string) string, limit int) ([]Candidate, error) {
+	games, err := p.SearchGames(ctx, title)
+	if err != nil {
+		return nil, err
+	}
+	if len(games) == 0 {
+		if word := longestWord(title); word != "" && word != strings.TrimSpace(title) {
+			if games, err = p.SearchGames(ctx, word); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	candidates := make([]Candidate, 0, len(games))
+	for _, g := range games {
+		score := TitleScore(title, g.Name)
+		if platform != "" && len(g.Platforms) > 0 && !slices.ContainsFunc(g.Platforms, func(p string) bool {
+			return samePlatform(p, platform, canonical)
+		}) {
+			score = max(0, score-platformMismatchPenalty)
+		}
+		candidates = append(candidates, Candidate{Game: g, Score: score})
+	}
+	slices.SortStableFunc(candidates, func(a, b Candidate) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	return candidates, nil
+}
+
+// TitleScore rates from 0 to 100 how alike two game titles are, ignoring
+// case, accents, punctuation and Roman numerals written as digits. It takes
+// the better of the edit distance and the share of words in common, so both
+// typos and missing subtitles score well.
+func TitleScore(a, b string) int {
+	wa, wb := titleWords(a), titleWords(b)
+	if len(wa) == 0 || len(wb) == 0 {
+		return 0
+	}
+	ja, jb := strings.Join(wa, " "), strings.Join(wb, " ")
+	if ja == jb {
+		return 100
+	}
+
+	ra, rb := []rune(ja), []rune(jb)
+	edit := 1 - float64(levenshtein(ra, rb))/float64(max(len(ra), len(rb)))
+
+	common := 0
+	rest := slices.Clone(wb)
+	for _, w := range wa {
+		if i := slices.Index(rest, w); i >= 0 {
+			common++
+			rest = slices.Delete(rest, i, i+1)
+		}
+	}
+	words := 2 * float64(common) / float64(len(wa)+len(wb))
+
+	// Never call a near miss a perfect match.
+	return min(99, int(max(edit, words)*100+0.5))
+}
+
+// romanNumerals maps the Roman numerals of sequels to digits. "V" and "X"
+// are left alone: they are letters in titles such as Mega Man X.
+var romanNumerals = map[string]string{
+	"ii": "2", "iii": "3", "iv": "4", "vi": "6", "vii": "7", "viii": "8", "ix": "9",
+}
+
+// titleWords folds a title into words of letters and digits.
+func titleWords(s string) []string {
+	words := strings.FieldsFunc(models.Fold(s), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	})
+	for i, w := range words {
+		if d, ok := romanNumerals[w]; ok && i > 0 {
+			words[i] = d
+		}
+	}
+	return words
+}
+
+// longestWord returns the longest word of s, for a looser second search.
+func longestWord(s string) string {
+	var longest string
+	for _, w := range strings.Fields(s) {
+		if len([]rune(w)) > len([]rune(longest)) {
+			longest = w
+		}
+	}
+	return longest
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// samePlatform reports whether a and b name the same console once
+// canonical has mapped them to their registry names.
+func samePlatform(a, b string, canonical func(string) string) bool {
+	if canonical != nil {
+		a, b = canonical(a), canonical(b)
+	}
+	ka, kb := models.PlatformKey(a), models.PlatformKey(b)
+	return ka != "" && ka == kb
+}
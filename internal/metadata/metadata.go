@@ -0,0 +1,79 @@
+// Package metadata defines where the shop looks up game metadata when
+// stocking the shelves. Providers are interchangeable: IGDB online, or a
+// local catalog of retro games that works without network access.
+package metadata
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Sources of game metadata, as stored with each game.
+const (
+	SourceIGDB  = "igdb"
+	SourceLocal = "local"
+)
+
+// Provider searches and fetches game metadata.
+type Provider interface {
+	// Source identifies the provider, such as SourceIGDB.
+	Source() string
+
+	// SearchGames returns games whose name matches query.
+	SearchGames(ctx context.Context, query string) ([]Game, error)
+
+	// GetGame returns the game with the provider's ID, or nil if there is none.
+	GetGame(ctx context.Context, id string) (*Game, error)
+}
+
+// SourceLabel returns the display name of a metadata source.
+func SourceLabel(source string) string {
+	switch source {
+	case SourceIGDB:
+		return "IGDB"
+	case SourceLocal:
+		return "Catálogo local"
+	case "":
+		return "Manual"
+	}
+	return source
+}
+
+// Game is a provider-neutral game record.
+type Game struct {
+	Source          string     `json:"source"`
+	ID              string     `json:"id"`      // Unique within the source.
+	IgdbID          string     `json:"igdb_id"` // Set by IGDB, and by catalog entries that know it.
+	Name            string     `json:"name"`
+	Summary         string     `json:"summary"`
+	ReleaseDate     *time.Time `json:"release_date"`
+	CoverURL        string     `json:"cover_url"`
+	Platforms       []string   `json:"platforms"`
+	Genres          []string   `json:"genres"`
+	Developers      []string   `json:"developers"`
+	Publishers      []string   `json:"publishers"`
+	Screenshots     []string   `json:"screenshots"`
+	IgdbPlatformIDs []int      `json:"igdb_platform_ids,omitempty"` // Parallel to Platforms; set by IGDB only.
+}
+
+// ReleaseYear returns the 4-digit release year, or "N/A".
+func (g Game) ReleaseYear() string {
+	if g.ReleaseDate == nil {
+		return "N/A"
+	}
+	return g.ReleaseDate.Format("2006")
+}
+
+// PlatformNames returns a comma-separated list of platforms, or "N/A".
+func (g Game) PlatformNames() string {
+	if len(g.Platforms) == 0 {
+		return "N/A"
+	}
+	return strings.Join(g.Platforms, ", ")
+}
+
+// SourceLabel returns the display name of the game's source.
+func (g Game) SourceLabel() string {
+	return SourceLabel(g.Source)
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/cmellojr/modo-locadora/internal/auth"
+	"github.com/cmellojr/modo-locadora/internal/database"
+	"github.com/cmellojr/modo-locadora/internal/models"
+)
+
+const apiTokenKey contextKey = "api_token"
+
+// APITokens authenticates requests to /api/ that carry a personal access
+// token in "Authorization: Bearer <token>". The token is stored in the
+// request context (see CurrentAPIToken); an unknown, revoked or expired
+// token is handed to onInvalid. Requests without the header, and every
+// request outside /api/, pass through untouched.
+func APITokens(store database.Store, onInvalid http.HandlerFunc, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, ok := bearerToken(r)
+		if !ok || !strings.HasPrefix(r.URL.Path, "/api/") || store == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token, err := store.GetActiveAPIToken(r.Context(), auth.HashToken(raw))
+		if err != nil || token == nil {
+			onInvalid(w, r)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiTokenKey, token)))
+	})
+}
+
+// CurrentAPIToken returns the personal access token that authenticated the
+// request, or nil for cookie and anonymous requests.
+func CurrentAPIToken(r *http.Request) *models.APIToken {
+	t, _ := r.Context().Value(apiTokenKey).(*models.APIToken)
+	return t
+}
+
+// bearerToken extracts the token of an "Authorization: Bearer" header.
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
@@ -0,0 +1,143 @@
+package middleware
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/cmellojr/modo-locadora/internal/auth"
+	"github.com/cmellojr/modo-locadora/internal/database"
+	"github.com/cmellojr/modo-locadora/internal/models"
+)
+
+// CSRFFieldName is the form field (and CSRFHeaderName the header) that must
+// carry the CSRF token on every state-changing request. Multipart forms send
+// the field in the query string instead of the body.
+const (
+	CSRFFieldName  = "csrf_token"
+	CSRFHeaderName = "X-CSRF-Token"
+)
+
+const csrfCookieName = "csrf_seed"
+
+const csrfSeedKey contextKey = "csrf_seed"
+
+// CSRF protects every POST, PUT, PATCH and DELETE with a per-session token.
+// Each browser gets a random seed cookie; the token is an HMAC of that seed
+// and the current session ID, so it changes on login and logout and can't be
+// forged without a signing key. Requests to exempt path prefixes and
+// requests authenticated by an API token are passed through unchecked.
+// Failures are handed to onFailure.
+func CSRF(keys *auth.Keyring, store database.Store, onFailure http.HandlerFunc, exempt []string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seed := ""
+		if c, err := r.Cookie(csrfCookieName); err == nil {
+			seed = c.Value
+		}
+		if seed == "" {
+			seed = newCSRFSeed()
+			http.SetCookie(w, &http.Cookie{
+				Name:     csrfCookieName,
+				Value:    seed,
+				Path:     "/",
+				HttpOnly: true,
+				SameSite: http.SameSiteStrictMode,
+			})
+		}
+		r = r.WithContext(context.WithValue(r.Context(), csrfSeedKey, seed))
+
+		// Resolve the session once; RequireAuth and the handlers reuse it.
+		ss := CurrentSession(r, keys, store)
+		r = r.WithContext(context.WithValue(r.Context(), sessionKey, ss))
+
+		// Bearer tokens are not sent by browsers on their own, so requests
+		// authenticated by APITokens need no CSRF token.
+		if !isUnsafeMethod(r.Method) || isExempt(r.URL.Path, exempt) || CurrentAPIToken(r) != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Upload forms carry the token in the query string: parsing a
+		// multipart body here would spool files to disk before the request
+		// is even authenticated.
+		sent := r.Header.Get(CSRFHeaderName)
+		if sent == "" && isMultipart(r) {
+			sent = r.URL.Query().Get(CSRFFieldName)
+		} else if sent == "" {
+			sent = r.PostFormValue(CSRFFieldName)
+		}
+
+		if sent == "" || !validCSRFToken(r, keys, sent) {
+			onFailure(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// CSRFToken returns the CSRF token for the request's browser and session,
+// signed with the keyring's primary key. Must be called on a request that
+// went through the CSRF middleware.
+func CSRFToken(r *http.Request, keys *auth.Keyring) string {
+	return csrfToken(r, keys.Primary().Secret)
+}
+
+// validCSRFToken reports whether sent matches the token under any key in the
+// keyring, so forms rendered before a key rotation still submit.
+func validCSRFToken(r *http.Request, keys *auth.Keyring, sent string) bool {
+	for _, key := range keys.Keys() {
+		if expected := csrfToken(r, key.Secret); expected != "" && hmac.Equal([]byte(sent), []byte(expected)) {
+			return true
+		}
+	}
+	return false
+}
+
+func csrfToken(r *http.Request, secret string) string {
+	seed, _ := r.Context().Value(csrfSeedKey).(string)
+	if seed == "" {
+		return ""
+	}
+
+	sessionID := ""
+	if ss, _ := r.Context().Value(sessionKey).(*models.Session); ss != nil {
+		sessionID = ss.ID.String()
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("csrf|" + seed + "|" + sessionID))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func newCSRFSeed() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func isMultipart(r *http.Request) bool {
+	return strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/")
+}
+
+func isUnsafeMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func isExempt(path string, exempt []string) bool {
+	for _, p := range exempt {
+		if path == p || (strings.HasSuffix(p, "/") && strings.HasPrefix(path, p)) {
+			return true
+		}
+	}
+	return false
+}
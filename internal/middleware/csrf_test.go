@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/cmellojr/modo-locadora/internal/auth"
+)
+
+func TestCSRFMultipartTokenInQuery(t *testing.T) {
+	keys, err := auth.NewKeyring(auth.Key{ID: "k1", Secret: "test-secret-that-is-long-enough-for-hmac"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var token string
+	var parsed bool
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token = CSRFToken(r, keys)
+		parsed = r.MultipartForm != nil
+	})
+	rejected := false
+	onFailure := func(w http.ResponseWriter, r *http.Request) {
+		rejected = true
+		w.WriteHeader(http.StatusForbidden)
+	}
+	h := CSRF(keys, nil, onFailure, nil, next)
+
+	// A first visit sets the seed cookie and renders the token.
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	cookies := rec.Result().Cookies()
+	if token == "" || len(cookies) != 1 {
+		t.Fatalf("token = %q, cookies = %v", token, cookies)
+	}
+
+	upload := func(target string) {
+		t.Helper()
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		_ = mw.WriteField(CSRFFieldName, token)
+		fw, _ := mw.CreateFormFile("cover_file", "cover.png")
+		_, _ = fw.Write(make([]byte, 1<<10))
+		_ = mw.Close()
+
+		req := httptest.NewRequest("POST", target, &body)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		req.AddCookie(cookies[0])
+		rejected, parsed = false, false
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// The token in the body isn't read: that would spool the upload.
+	upload("/admin/update-game")
+	if !rejected {
+		t.Error("multipart POST with the token only in the body was accepted")
+	}
+
+	upload("/admin/update-game?" + url.Values{CSRFFieldName: {token}}.Encode())
+	if rejected {
+		t.Error("multipart POST with the token in the query string was rejected")
+	}
+	if parsed {
+		t.Error("the middleware parsed the multipart body")
+	}
+}
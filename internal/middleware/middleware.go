@@ -6,12 +6,15 @@ import (
 
 	"github.com/cmellojr/modo-locadora/internal/auth"
 	"github.com/cmellojr/modo-locadora/internal/database"
-	"github.com/google/uuid"
+	"github.com/cmellojr/modo-locadora/internal/models"
 )
 
 type contextKey string
 
-const memberIDKey contextKey = "member_id"
+const (
+	memberIDKey contextKey = "member_id"
+	sessionKey  contextKey = "session"
+)
 
 // MemberIDFromContext extracts the authenticated member ID from the request context.
 func MemberIDFromContext(ctx context.Context) string {
@@ -19,53 +22,90 @@ func MemberIDFromContext(ctx context.Context) string {
 	return v
 }
 
-// RequireAuth rejects requests without a valid signed session cookie.
-func RequireAuth(secret string, next http.HandlerFunc) http.HandlerFunc {
+// CurrentSession returns the server-side session behind the request's session
+// cookie, or nil if the cookie is missing, forged, revoked or expired. Requests
+// that already went through RequireAuth or RequirePermission reuse the session
+// stored in their context.
+func CurrentSession(r *http.Request, keys *auth.Keyring, store database.Store) *models.Session {
+	if ss, ok := r.Context().Value(sessionKey).(*models.Session); ok {
+		return ss
+	}
+	if store == nil {
+		return nil
+	}
+
+	token := auth.GetSessionToken(r, keys)
+	if token == "" {
+		return nil
+	}
+
+	ss, err := store.GetActiveSession(r.Context(), auth.HashToken(token), auth.SessionIdleTimeout)
+	if err != nil {
+		return nil
+	}
+	return ss
+}
+
+// withSession stores the session and its member ID in the request context.
+func withSession(r *http.Request, ss *models.Session) *http.Request {
+	ctx := context.WithValue(r.Context(), sessionKey, ss)
+	ctx = context.WithValue(ctx, memberIDKey, ss.MemberID.String())
+	return r.WithContext(ctx)
+}
+
+// RequireAuth rejects requests without a valid server-side session.
+func RequireAuth(keys *auth.Keyring, store database.Store, next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		memberID := auth.GetSessionMemberID(r, secret)
-		if memberID == "" {
+		ss := CurrentSession(r, keys, store)
+		if ss == nil {
+			auth.ClearSessionCookie(w)
 			http.Redirect(w, r, "/", http.StatusSeeOther)
 			return
 		}
 
-		ctx := context.WithValue(r.Context(), memberIDKey, memberID)
-		next(w, r.WithContext(ctx))
+		next(w, withSession(r, ss))
 	}
 }
 
-// RequireAdmin rejects requests unless the authenticated member's email
-// matches the configured admin email.
-func RequireAdmin(secret, adminEmail string, store database.Store, next http.HandlerFunc) http.HandlerFunc {
+// RequirePermission rejects requests unless the authenticated member holds a
+// staff role granting perm (see models.HasPermission). Two-factor
+// authentication is mandatory for staff: members without it are sent to the
+// enrollment page first.
+func RequirePermission(keys *auth.Keyring, store database.Store, perm string, next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		memberID := auth.GetSessionMemberID(r, secret)
-		if memberID == "" {
-			http.Redirect(w, r, "/", http.StatusSeeOther)
+		if store == nil {
+			http.Error(w, "Database not configured", http.StatusServiceUnavailable)
 			return
 		}
 
-		if store == nil {
-			http.Error(w, "Database not configured", http.StatusServiceUnavailable)
+		ss := CurrentSession(r, keys, store)
+		if ss == nil {
+			auth.ClearSessionCookie(w)
+			http.Redirect(w, r, "/", http.StatusSeeOther)
 			return
 		}
 
-		id, err := uuid.Parse(memberID)
+		roles, err := store.ListMemberRoles(r.Context(), ss.MemberID)
 		if err != nil {
-			http.Redirect(w, r, "/", http.StatusSeeOther)
+			http.Error(w, "Failed to load staff roles", http.StatusInternalServerError)
 			return
 		}
 
-		member, err := store.GetMemberByID(r.Context(), id)
-		if err != nil || member == nil {
-			http.Redirect(w, r, "/", http.StatusSeeOther)
+		if !models.HasPermission(roles, perm) {
+			http.Error(w, "Acesso restrito a equipe da locadora", http.StatusForbidden)
 			return
 		}
 
-		if member.Email != adminEmail {
-			http.Error(w, "Acesso restrito ao Tio da Locadora", http.StatusForbidden)
+		member, err := store.GetMemberByID(r.Context(), ss.MemberID)
+		if err != nil || member == nil {
+			http.Error(w, "Failed to load member", http.StatusInternalServerError)
+			return
+		}
+		if !member.HasTwoFactor() {
+			http.Redirect(w, r, "/membership/2fa?required=1", http.StatusSeeOther)
 			return
 		}
 
-		ctx := context.WithValue(r.Context(), memberIDKey, memberID)
-		next(w, r.WithContext(ctx))
+		next(w, withSession(r, ss))
 	}
 }
@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// TrustedProxies names the reverse proxies allowed to report the client
+// address, and the header they report it in.
+type TrustedProxies struct {
+	Header string       // Such as X-Forwarded-For or X-Real-IP; empty trusts no one.
+	Nets   []*net.IPNet // Addresses of the proxies.
+}
+
+// TrustedProxiesFromEnv reads TRUSTED_PROXY_HEADER and TRUSTED_PROXIES, a
+// comma-separated list of proxy IPs or CIDR ranges. The header is ignored
+// when unset; setting it without proxies is an error, since any client could
+// then pick its own address.
+func TrustedProxiesFromEnv() (TrustedProxies, error) {
+	p := TrustedProxies{Header: http.CanonicalHeaderKey(strings.TrimSpace(os.Getenv("TRUSTED_PROXY_HEADER")))}
+	for _, entry := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			if ip := net.ParseIP(entry); ip != nil && ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			return TrustedProxies{}, fmt.Errorf("invalid TRUSTED_PROXIES entry %q: %w", entry, err)
+		}
+		p.Nets = append(p.Nets, ipNet)
+	}
+	if p.Header != "" && len(p.Nets) == 0 {
+		return TrustedProxies{}, errors.New("TRUSTED_PROXY_HEADER needs TRUSTED_PROXIES")
+	}
+	return p, nil
+}
+
+// trusts reports whether ip is one of the proxies.
+func (p TrustedProxies) trusts(ip net.IP) bool {
+	for _, n := range p.Nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientAddr returns the client address a trusted proxy reported in r, or ""
+// when it reported none that parses. The header is read right to left,
+// skipping the proxies themselves, so entries a client sent ahead of the
+// proxy's own are never believed.
+func (p TrustedProxies) clientAddr(r *http.Request) string {
+	var entries []string
+	for _, v := range r.Header.Values(p.Header) {
+		entries = append(entries, strings.Split(v, ",")...)
+	}
+	var addr string
+	for i := len(entries) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(entries[i]))
+		if ip == nil {
+			break
+		}
+		addr = ip.String()
+		if !p.trusts(ip) {
+			break
+		}
+	}
+	return addr
+}
+
+// RealIP replaces the request's RemoteAddr with the client address reported
+// by a trusted proxy, so rate limits and security events see the client
+// rather than the proxy. Requests from any other address are left alone.
+func RealIP(p TrustedProxies, next http.Handler) http.Handler {
+	if p.Header == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, port, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host, port = r.RemoteAddr, "0"
+		}
+		if ip := net.ParseIP(host); ip != nil && p.trusts(ip) {
+			if addr := p.clientAddr(r); addr != "" {
+				r.RemoteAddr = net.JoinHostPort(addr, port)
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// API token scopes.
+const (
+	ScopeCatalogRead  = "catalog:read"  // Catalog, clubs and the activity feed.
+	ScopeRentalsRead  = "rentals:read"  // The member's own rentals.
+	ScopeRentalsWrite = "rentals:write" // Renting and returning games.
+	ScopeClubsAdmin   = "clubs:admin"   // Administering the clubs the member runs.
+)
+
+// APITokenScopes lists every scope in display order.
+var APITokenScopes = []string{ScopeCatalogRead, ScopeRentalsRead, ScopeRentalsWrite, ScopeClubsAdmin}
+
+// ScopeLabel returns the Portuguese display label for an API token scope.
+func ScopeLabel(scope string) string {
+	switch scope {
+	case ScopeCatalogRead:
+		return "Ver acervo, turmas e feed"
+	case ScopeRentalsRead:
+		return "Ver meus aluguéis"
+	case ScopeRentalsWrite:
+		return "Alugar e devolver"
+	case ScopeClubsAdmin:
+		return "Administrar minhas turmas"
+	default:
+		return scope
+	}
+}
+
+// IsAPITokenScope reports whether scope is a known API token scope.
+func IsAPITokenScope(scope string) bool {
+	return slices.Contains(APITokenScopes, scope)
+}
+
+// APIToken is a personal access token for the JSON API. The member sees the
+// raw token once; only its hash is persisted.
+type APIToken struct {
+	ID         uuid.UUID
+	MemberID   uuid.UUID
+	Name       string
+	TokenHash  string
+	Scopes     []string
+	CreatedAt  time.Time
+	ExpiresAt  *time.Time // Nil for tokens that never expire.
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+}
+
+// HasScope reports whether the token grants scope.
+func (t *APIToken) HasScope(scope string) bool {
+	return slices.Contains(t.Scopes, scope)
+}
+
+// IsExpired reports whether the token has passed its expiry at now.
+func (t *APIToken) IsExpired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
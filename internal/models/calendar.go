@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CalendarFeed is a member's private iCalendar subscription of due dates.
+// The URL token itself is never stored, only its hash.
+type CalendarFeed struct {
+	MemberID          uuid.UUID
+	TokenHash         string
+	IncludeChallenges bool // Also list the challenges of the member's clubs.
+	CreatedAt         time.Time
+	LastUsedAt        *time.Time
+}
@@ -23,3 +23,28 @@ type Club struct {
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 }
+
+// Club challenge participant status constants.
+const (
+	ChallengeStatusJoined    = "joined"
+	ChallengeStatusRented    = "rented"
+	ChallengeStatusCompleted = "completed"
+	ChallengeStatusGaveUp    = "gave_up"
+)
+
+// ClubChallenge represents a "clube do jogo" session: every participant
+// plays the same game within a date window.
+type ClubChallenge struct {
+	ID        uuid.UUID
+	ClubID    uuid.UUID
+	GameID    uuid.UUID
+	StartsAt  time.Time
+	EndsAt    time.Time
+	CreatedBy uuid.UUID
+	CreatedAt time.Time
+}
+
+// IsOpen reports whether the challenge window contains t.
+func (c ClubChallenge) IsOpen(t time.Time) bool {
+	return !t.Before(c.StartsAt) && t.Before(c.EndsAt)
+}
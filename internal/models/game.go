@@ -1,6 +1,7 @@
 package models
 
 import (
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,7 +15,61 @@ type Game struct {
 	Platform       string
 	Summary        string
 	CoverURL       string
+	CoverThumbURL  string // Shelf-card size; empty until the cover is mirrored.
+	CoverSourceURL string // Remote URL the cover was mirrored from.
 	SourceMagazine string
 	CoverDisplay   string // CSS object-fit value: "cover", "contain", "fill"
 	AcquiredAt     time.Time
+
+	ReleaseDate      *time.Time
+	Genres           []string
+	Developers       []string
+	Publishers       []string
+	Screenshots      []string
+	EditedFields     []string // Metadata fields changed by staff; a re-sync keeps them.
+	MetadataSyncedAt *time.Time
+	MetadataSource   string // metadata.Source*, or "" for games entered by hand.
+	MetadataID       string // ID of the game in MetadataSource.
+}
+
+// Game metadata fields imported from a metadata provider, as recorded in
+// Game.EditedFields.
+const (
+	GameFieldTitle       = "title"
+	GameFieldSummary     = "summary"
+	GameFieldCover       = "cover"
+	GameFieldReleaseDate = "release_date"
+	GameFieldGenres      = "genres"
+	GameFieldDevelopers  = "developers"
+	GameFieldPublishers  = "publishers"
+	GameFieldScreenshots = "screenshots"
+)
+
+// ReleaseYear returns the release year, or 0 when the date is unknown.
+func (g *Game) ReleaseYear() int {
+	if g.ReleaseDate == nil {
+		return 0
+	}
+	return g.ReleaseDate.Year()
+}
+
+// Thumbnail returns the URL of the small cover, falling back to the
+// full-size cover for covers not yet mirrored.
+func (g *Game) Thumbnail() string {
+	if g.CoverThumbURL != "" {
+		return g.CoverThumbURL
+	}
+	return g.CoverURL
+}
+
+// IsEdited reports whether staff changed the field by hand.
+func (g *Game) IsEdited(field string) bool {
+	return slices.Contains(g.EditedFields, field)
+}
+
+// MarkEdited records that staff changed the field by hand.
+func (g *Game) MarkEdited(field string) {
+	if !g.IsEdited(field) {
+		g.EditedFields = append(g.EditedFields, field)
+	}
 }
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Sign-up modes, chosen with SIGNUP_MODE.
+const (
+	SignupModeOpen     = "open"     // Anyone may make a membership card.
+	SignupModeInvite   = "invite"   // A valid invite code is required.
+	SignupModeApproval = "approval" // New members wait for staff approval before logging in.
+)
+
+// IsSignupMode reports whether mode is a known sign-up mode.
+func IsSignupMode(mode string) bool {
+	switch mode {
+	case SignupModeOpen, SignupModeInvite, SignupModeApproval:
+		return true
+	}
+	return false
+}
+
+// InviteCode lets up to MaxUses people sign up while the store runs in
+// invite mode.
+type InviteCode struct {
+	ID            uuid.UUID
+	Code          string
+	MaxUses       int
+	Uses          int
+	CreatedBy     *uuid.UUID
+	CreatedByName string
+	CreatedAt     time.Time
+	ExpiresAt     *time.Time
+	RevokedAt     *time.Time
+}
+
+// IsUsable reports whether the code can still be redeemed at now.
+func (c *InviteCode) IsUsable(now time.Time) bool {
+	return c.RevokedAt == nil && c.Uses < c.MaxUses && (c.ExpiresAt == nil || now.Before(*c.ExpiresAt))
+}
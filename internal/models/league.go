@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// League season status constants.
+const (
+	SeasonStatusActive   = "active"
+	SeasonStatusArchived = "archived"
+)
+
+// League scoring rule keys.
+const (
+	RuleCompleted          = "completed"
+	RuleOnTimeReturn       = "on_time_return"
+	RuleChallengeCompleted = "challenge_completed"
+	RulePenalty            = "penalty"
+)
+
+// LeagueSeason represents one season of the inter-club league (Gincana das Turmas).
+type LeagueSeason struct {
+	ID         uuid.UUID
+	Name       string
+	StartsAt   time.Time
+	EndsAt     time.Time
+	Status     string // "active" or "archived"
+	ArchivedAt *time.Time
+	CreatedAt  time.Time
+}
+
+// ScoringRule holds how many points a league event is worth.
+type ScoringRule struct {
+	Key    string
+	Label  string // Portuguese display label
+	Points int
+}
@@ -24,9 +24,41 @@ type Member struct {
 	PasswordNotes    string
 	Status           string // "active" or "in_debt"
 	LateCount        int
+	PrimaryClubID    *uuid.UUID // League points go only to this club when set.
+	EmailVerifiedAt  *time.Time // Nil until the member confirms their e-mail.
+	FailedLogins     int        // Consecutive failed logins since the last success.
+	LockedUntil      *time.Time // Logins are refused until this time.
+	PendingApproval  bool       // Signed up in approval mode and not approved yet.
+	DeletedAt        *time.Time // Set when the member deleted their account; the row is anonymized.
+	TOTPSecret       string     // Base32 TOTP secret; set when enrollment starts.
+	TOTPEnabledAt    *time.Time // Nil until the member confirms their first code.
 	JoinedAt         time.Time
 }
 
+// IsEmailVerified reports whether the member has confirmed their e-mail address.
+func (m *Member) IsEmailVerified() bool {
+	return m.EmailVerifiedAt != nil
+}
+
+// DeletedMemberName replaces a deleted member's name in the activities feed.
+const DeletedMemberName = "Ex-sócio"
+
+// IsDeleted reports whether the member deleted their account.
+func (m *Member) IsDeleted() bool {
+	return m.DeletedAt != nil
+}
+
+// HasTwoFactor reports whether the member completed TOTP enrollment, so logins
+// ask for a code after the password.
+func (m *Member) HasTwoFactor() bool {
+	return m.TOTPEnabledAt != nil
+}
+
+// IsLocked reports whether logins for the member are refused at now.
+func (m *Member) IsLocked(now time.Time) bool {
+	return m.LockedUntil != nil && now.Before(*m.LockedUntil)
+}
+
 // MemberTitle represents a member's earned progression title.
 type MemberTitle struct {
 	Label    string // Portuguese display label
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"slices"
+	"strings"
+)
+
+// Platform is a console in the platform registry. Games are stored under
+// its canonical Name.
+type Platform struct {
+	Slug          string
+	Name          string
+	Aliases       []string // Other names for it, such as "Genesis" for Mega Drive.
+	IgdbIDs       []int
+	Manufacturer  string
+	BrDistributor string // Official Brazilian distributor, such as TecToy.
+	ReleaseYear   int    // 0 when unknown.
+	LogoURL       string
+	OnShelf       bool // Shown on the shelf even with no games.
+	SortOrder     int
+}
+
+// Platforms is the platform registry, in shelf order.
+type Platforms []Platform
+
+var accentFolder = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "õ", "o", "ö", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ç", "c", "ñ", "n",
+)
+
+// Fold lowercases s and strips the accents common in Portuguese.
+func Fold(s string) string {
+	return accentFolder.Replace(strings.ToLower(s))
+}
+
+// PlatformKey folds a platform name for comparison: lower case without
+// accents, letters and digits only, so "Genesis/MegaDrive" and
+// "genesis megadrive" are equal. Every platform comparison goes through it.
+func PlatformKey(name string) string {
+	var b strings.Builder
+	for _, r := range Fold(name) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Resolve returns the platform whose name, slug or alias is name, or nil.
+func (ps Platforms) Resolve(name string) *Platform {
+	key := PlatformKey(name)
+	if key == "" {
+		return nil
+	}
+	for i, p := range ps {
+		if PlatformKey(p.Name) == key || PlatformKey(p.Slug) == key ||
+			slices.ContainsFunc(p.Aliases, func(a string) bool { return PlatformKey(a) == key }) {
+			return &ps[i]
+		}
+	}
+	return nil
+}
+
+// ByIGDBID returns the platform with the given IGDB platform ID, or nil.
+func (ps Platforms) ByIGDBID(id int) *Platform {
+	for i, p := range ps {
+		if slices.Contains(p.IgdbIDs, id) {
+			return &ps[i]
+		}
+	}
+	return nil
+}
+
+// Canonical returns the canonical name for name, or name itself when the
+// registry does not know it.
+func (ps Platforms) Canonical(name string) string {
+	if p := ps.Resolve(name); p != nil {
+		return p.Name
+	}
+	return name
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Security event kinds.
+const (
+	SecurityEventLoginLockout    = "login_lockout"
+	SecurityEventAccountUnlocked = "account_unlocked"
+	SecurityEventTwoFactorOn     = "two_factor_enabled"
+	SecurityEventTwoFactorOff    = "two_factor_disabled"
+	SecurityEventRecoveryUsed    = "recovery_code_used"
+	SecurityEventAPITokenCreated = "api_token_created"
+	SecurityEventAPITokenRevoked = "api_token_revoked"
+	SecurityEventWebhookCreated  = "webhook_created"
+	SecurityEventWebhookDeleted  = "webhook_deleted"
+)
+
+// SecurityEvent records something the staff should know about, such as an
+// account locked after repeated failed logins.
+type SecurityEvent struct {
+	ID          uuid.UUID
+	Kind        string
+	MemberID    *uuid.UUID
+	ProfileName string
+	IP          string
+	Detail      string
+	CreatedAt   time.Time
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session represents a server-side login session. The cookie holds the raw
+// token; only its hash is persisted.
+type Session struct {
+	ID         uuid.UUID
+	MemberID   uuid.UUID
+	TokenHash  string
+	UserAgent  string
+	IPAddress  string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	ExpiresAt  time.Time
+	RevokedAt  *time.Time
+}
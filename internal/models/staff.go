@@ -0,0 +1,62 @@
+package models
+
+// Staff role constants.
+const (
+	RoleOwner     = "owner"     // The Tio: every permission, manages staff.
+	RoleAttendant = "attendant" // Checks out and returns tapes.
+	RoleCurator   = "curator"   // Stocks and edits the catalog.
+	RoleModerator = "moderator" // Moderates the feed and runs the league.
+)
+
+// Staff permission constants.
+const (
+	PermRentals    = "rentals"
+	PermCatalog    = "catalog"
+	PermModeration = "moderation"
+	PermStaff      = "staff"
+)
+
+// StaffRoles lists every role in display order.
+var StaffRoles = []string{RoleOwner, RoleAttendant, RoleCurator, RoleModerator}
+
+// rolePermissions maps each role to the permissions it grants.
+var rolePermissions = map[string][]string{
+	RoleOwner:     {PermRentals, PermCatalog, PermModeration, PermStaff},
+	RoleAttendant: {PermRentals},
+	RoleCurator:   {PermCatalog},
+	RoleModerator: {PermModeration},
+}
+
+// IsStaffRole reports whether role is a known staff role.
+func IsStaffRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// HasPermission reports whether any of the given roles grants perm.
+func HasPermission(roles []string, perm string) bool {
+	for _, role := range roles {
+		for _, p := range rolePermissions[role] {
+			if p == perm {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RoleLabel returns the Portuguese display label for a staff role.
+func RoleLabel(role string) string {
+	switch role {
+	case RoleOwner:
+		return "Tio da Locadora"
+	case RoleAttendant:
+		return "Atendente"
+	case RoleCurator:
+		return "Curador"
+	case RoleModerator:
+		return "Moderador"
+	default:
+		return role
+	}
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Member token purposes.
+const (
+	TokenPurposeVerifyEmail   = "verify_email"
+	TokenPurposePasswordReset = "password_reset"
+)
+
+// MemberToken is a single-use, expiring token sent to a member by e-mail.
+// Only the hash of the token is persisted.
+type MemberToken struct {
+	ID        uuid.UUID
+	MemberID  uuid.UUID
+	Purpose   string
+	TokenHash string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
@@ -0,0 +1,127 @@
+package models
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Activity event types, as stored in activities.event_type.
+const (
+	EventPenalty              = "penalty"
+	EventRedemption           = "redemption"
+	EventNewGame              = "new_game"
+	EventPrestige             = "prestige"
+	EventVerdictCompleted     = "verdict_completed"
+	EventVerdictEnjoyed       = "verdict_enjoyed"
+	EventVerdictQuickPlay     = "verdict_quick_play"
+	EventVerdictNotForMe      = "verdict_not_for_me"
+	EventVerdictGaveUp        = "verdict_gave_up"
+	EventRelic                = "relic"
+	EventClubCreated          = "club_created"
+	EventClubJoined           = "club_joined"
+	EventChallengeCreated     = "challenge_created"
+	EventChallengeFirstFinish = "challenge_first_finish"
+	EventLeagueChampion       = "league_champion"
+)
+
+// ActivityEventTypes lists every feed event type in display order.
+var ActivityEventTypes = []string{
+	EventVerdictCompleted, EventVerdictEnjoyed, EventVerdictQuickPlay, EventVerdictNotForMe, EventVerdictGaveUp,
+	EventNewGame, EventRelic, EventPenalty, EventRedemption, EventPrestige,
+	EventClubCreated, EventClubJoined, EventChallengeCreated, EventChallengeFirstFinish, EventLeagueChampion,
+}
+
+// EventLabel returns the Portuguese display label for a feed event type.
+func EventLabel(eventType string) string {
+	switch eventType {
+	case EventPenalty:
+		return "Penalidade por atraso"
+	case EventRedemption:
+		return "Sócio redimido"
+	case EventNewGame:
+		return "Fita nova no acervo"
+	case EventPrestige:
+		return "Sócio de prestígio"
+	case EventVerdictCompleted:
+		return "Zerou"
+	case EventVerdictEnjoyed:
+		return "Curtiu"
+	case EventVerdictQuickPlay:
+		return "Partidinha rápida"
+	case EventVerdictNotForMe:
+		return "Não era pra mim"
+	case EventVerdictGaveUp:
+		return "Desistiu"
+	case EventRelic:
+		return "Relíquia da Casa"
+	case EventClubCreated:
+		return "Turma criada"
+	case EventClubJoined:
+		return "Entrou na turma"
+	case EventChallengeCreated:
+		return "Desafio de turma"
+	case EventChallengeFirstFinish:
+		return "Primeiro a zerar o desafio"
+	case EventLeagueChampion:
+		return "Campeã da Gincana"
+	default:
+		return eventType
+	}
+}
+
+// IsActivityEventType reports whether eventType is a known feed event type.
+func IsActivityEventType(eventType string) bool {
+	return slices.Contains(ActivityEventTypes, eventType)
+}
+
+// Webhook delivery statuses.
+const (
+	WebhookPending   = "pending"
+	WebhookDelivered = "delivered"
+	WebhookFailed    = "failed" // Gave up after the last retry.
+)
+
+// Webhook delivery limits.
+const (
+	WebhookMaxAttempts         = 8  // Attempts per delivery before it fails for good.
+	WebhookMaxConsecutiveFails = 10 // Failed attempts in a row that disable an endpoint.
+)
+
+// WebhookEndpoint is a URL that receives signed feed events. Staff endpoints
+// see every event; club endpoints only see events of their club's members,
+// events naming the club and catalog-wide events.
+type WebhookEndpoint struct {
+	ID                  uuid.UUID
+	ClubID              *uuid.UUID // Nil for endpoints configured by staff.
+	URL                 string
+	Description         string
+	Secret              string // HMAC-SHA256 key for the X-Locadora-Signature header.
+	EventTypes          []string
+	Enabled             bool
+	ConsecutiveFailures int
+	DisabledAt          *time.Time // Set when the endpoint was switched off.
+	CreatedBy           *uuid.UUID
+	CreatedAt           time.Time
+}
+
+// Subscribes reports whether the endpoint wants eventType.
+func (e *WebhookEndpoint) Subscribes(eventType string) bool {
+	return slices.Contains(e.EventTypes, eventType)
+}
+
+// WebhookDelivery is one event queued for one endpoint.
+type WebhookDelivery struct {
+	ID             uuid.UUID
+	EndpointID     uuid.UUID
+	ActivityID     uuid.UUID
+	EventType      string
+	Status         string // One of Webhook{Pending,Delivered,Failed}.
+	Attempts       int
+	LastStatusCode int    // HTTP status of the last attempt; 0 when it got no response.
+	LastError      string // Why the last attempt failed.
+	NextAttemptAt  time.Time
+	DeliveredAt    *time.Time
+	CreatedAt      time.Time
+}
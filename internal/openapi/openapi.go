@@ -0,0 +1,363 @@
+// Package openapi builds an OpenAPI 3.0 document whose schemas are derived
+// from Go types by reflection, and validates JSON values against it.
+//
+// Struct fields follow encoding/json: the json tag names the property,
+// "-" skips it, omitempty makes it optional and embedded structs are
+// flattened. A field tagged openapi:"nullable" may also be null, for
+// slices and maps that can be nil. Named struct types become components
+// under #/components/schemas; generic and anonymous types are inlined.
+package openapi
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// Version is the OpenAPI version of the generated documents.
+const Version = "3.0.3"
+
+// Document is an OpenAPI document.
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+
+	names map[reflect.Type]string // Component name of each registered type.
+}
+
+// Info describes the API.
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Components holds the reusable schemas and security schemes.
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme describes one way to authenticate.
+type SecurityScheme struct {
+	Type        string `json:"type"`
+	Scheme      string `json:"scheme,omitempty"`
+	In          string `json:"in,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem maps lower-case HTTP methods to operations.
+type PathItem map[string]*Operation
+
+// Operation describes one method on one path.
+type Operation struct {
+	OperationID string                `json:"operationId,omitempty"`
+	Summary     string                `json:"summary"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	Scopes      []string              `json:"x-required-scopes,omitempty"`
+}
+
+// Parameter describes a path or query parameter.
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody describes the body of a request.
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response describes one response status.
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType holds the schema of a body.
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Schema is the subset of the OpenAPI schema object the generator emits.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties any                `json:"additionalProperties,omitempty"` // *Schema, or false for closed objects.
+}
+
+// New returns an empty document.
+func New(title, version, description string) *Document {
+	return &Document{
+		OpenAPI: Version,
+		Info:    Info{Title: title, Version: version, Description: description},
+		Paths:   map[string]*PathItem{},
+		Components: Components{
+			Schemas:         map[string]*Schema{},
+			SecuritySchemes: map[string]*SecurityScheme{},
+		},
+		names: map[reflect.Type]string{},
+	}
+}
+
+// Add registers an operation. It fails if the method is already defined
+// for the path.
+func (d *Document) Add(method, path string, op *Operation) error {
+	item := d.Paths[path]
+	if item == nil {
+		item = &PathItem{}
+		d.Paths[path] = item
+	}
+	m := strings.ToLower(method)
+	if _, dup := (*item)[m]; dup {
+		return fmt.Errorf("%s %s is defined twice", method, path)
+	}
+	(*item)[m] = op
+	return nil
+}
+
+// Operation returns the operation for method and path, or nil.
+func (d *Document) Operation(method, path string) *Operation {
+	item := d.Paths[path]
+	if item == nil {
+		return nil
+	}
+	return (*item)[strings.ToLower(method)]
+}
+
+// ErrIncomplete is wrapped by Check for operations missing required parts.
+var ErrIncomplete = errors.New("incomplete operation")
+
+// Check verifies that every operation has a summary, at least one response,
+// a schema for each JSON response and a documented parameter for each
+// {name} in its path.
+func (d *Document) Check() error {
+	paths := make([]string, 0, len(d.Paths))
+	for p := range d.Paths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	for _, p := range paths {
+		for method, op := range *d.Paths[p] {
+			where := strings.ToUpper(method) + " " + p
+			if op.Summary == "" {
+				return fmt.Errorf("%s: %w: no summary", where, ErrIncomplete)
+			}
+			if len(op.Responses) == 0 {
+				return fmt.Errorf("%s: %w: no responses", where, ErrIncomplete)
+			}
+			for status, resp := range op.Responses {
+				if media, ok := resp.Content["application/json"]; ok && media.Schema == nil {
+					return fmt.Errorf("%s: %w: response %s has no schema", where, ErrIncomplete, status)
+				}
+			}
+			for _, name := range pathParams(p) {
+				if !hasParam(op.Parameters, name, "path") {
+					return fmt.Errorf("%s: %w: path parameter %q is not documented", where, ErrIncomplete, name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// pathParams returns the {name} segments of a path.
+func pathParams(path string) []string {
+	var names []string
+	for _, seg := range strings.Split(path, "/") {
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			names = append(names, strings.TrimSuffix(strings.TrimPrefix(seg, "{"), "}"))
+		}
+	}
+	return names
+}
+
+func hasParam(params []Parameter, name, in string) bool {
+	for _, p := range params {
+		if p.Name == name && p.In == in {
+			return true
+		}
+	}
+	return false
+}
+
+// SchemaOf returns the schema of v's type, registering named struct types
+// as components. A nil v yields nil.
+func (d *Document) SchemaOf(v any) *Schema {
+	if v == nil {
+		return nil
+	}
+	return d.schemaFor(reflect.TypeOf(v))
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	uuidType          = reflect.TypeOf(uuid.UUID{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+func (d *Document) schemaFor(t reflect.Type) *Schema {
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case uuidType:
+		return &Schema{Type: "string", Format: "uuid"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		s := d.schemaFor(t.Elem())
+		if s.Ref != "" {
+			// $ref siblings are ignored in OpenAPI 3.0, so wrap it.
+			return &Schema{AllOf: []*Schema{s}, Nullable: true}
+		}
+		s.Nullable = true
+		return s
+	case reflect.Struct:
+		if t.Implements(textMarshalerType) {
+			return &Schema{Type: "string"}
+		}
+		return d.structSchema(t)
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: d.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: d.schemaFor(t.Elem())}
+	case reflect.Interface:
+		return &Schema{}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	}
+	panic(fmt.Sprintf("openapi: unsupported type %s", t))
+}
+
+// structSchema returns a $ref to the component for named types and the
+// inline object schema for generic and anonymous ones.
+func (d *Document) structSchema(t reflect.Type) *Schema {
+	if t.Name() == "" || strings.Contains(t.Name(), "[") {
+		return d.objectSchema(t)
+	}
+	if name, ok := d.names[t]; ok {
+		return &Schema{Ref: "#/components/schemas/" + name}
+	}
+
+	name := d.componentName(t)
+	d.names[t] = name
+	d.Components.Schemas[name] = &Schema{} // Placeholder for recursive types.
+	*d.Components.Schemas[name] = *d.objectSchema(t)
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// componentName strips the "api" prefix of handler view types and falls
+// back to a package-qualified name when two types share a name.
+func (d *Document) componentName(t reflect.Type) string {
+	name := strings.TrimPrefix(t.Name(), "api")
+	name = upperFirst(name)
+	if _, taken := d.Components.Schemas[name]; taken {
+		pkg := t.PkgPath()
+		if i := strings.LastIndex(pkg, "/"); i >= 0 {
+			pkg = pkg[i+1:]
+		}
+		name = upperFirst(pkg) + name
+	}
+	return name
+}
+
+func (d *Document) objectSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}, AdditionalProperties: false}
+	d.addFields(s, t)
+	sort.Strings(s.Required)
+	return s
+}
+
+// addFields adds the JSON properties of struct t to s, flattening
+// embedded structs the way encoding/json does.
+func (d *Document) addFields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				d.addFields(s, ft)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		prop := d.schemaFor(f.Type)
+		if f.Tag.Get("openapi") == "nullable" {
+			if prop.Ref != "" {
+				prop = &Schema{AllOf: []*Schema{prop}}
+			}
+			prop.Nullable = true
+		}
+		s.Properties[name] = prop
+		if !strings.Contains(","+opts+",", ",omitempty,") {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+func upperFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	r := []rune(s)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
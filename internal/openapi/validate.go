@@ -0,0 +1,300 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Validate checks a decoded JSON value (as produced by json.Unmarshal into
+// an any) against s. The error names the path of the first mismatch.
+func (d *Document) Validate(s *Schema, v any) error {
+	return d.validate(s, v, "$")
+}
+
+func (d *Document) validate(s *Schema, v any, path string) error {
+	if s == nil {
+		return nil
+	}
+	if s.Ref != "" {
+		target, err := d.resolve(s.Ref)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		return d.validate(target, v, path)
+	}
+	if v == nil {
+		if s.Nullable || (s.Type == "" && len(s.AllOf) == 0) {
+			return nil
+		}
+		return fmt.Errorf("%s: is null", path)
+	}
+	for _, sub := range s.AllOf {
+		if err := d.validate(sub, v, path); err != nil {
+			return err
+		}
+	}
+
+	switch s.Type {
+	case "":
+		return nil
+	case "object":
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: expected object, got %s", path, kindOf(v))
+		}
+		return d.validateObject(s, obj, path)
+	case "array":
+		arr, ok := v.([]any)
+		if !ok {
+			return fmt.Errorf("%s: expected array, got %s", path, kindOf(v))
+		}
+		for i, item := range arr {
+			if err := d.validate(s.Items, item, path+"["+strconv.Itoa(i)+"]"); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("%s: expected string, got %s", path, kindOf(v))
+		}
+		return validateString(s, str, path)
+	case "integer":
+		n, ok := v.(float64)
+		if !ok || n != float64(int64(n)) {
+			return fmt.Errorf("%s: expected integer, got %s", path, kindOf(v))
+		}
+		return validateRange(s, n, path)
+	case "number":
+		n, ok := v.(float64)
+		if !ok {
+			return fmt.Errorf("%s: expected number, got %s", path, kindOf(v))
+		}
+		return validateRange(s, n, path)
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return fmt.Errorf("%s: expected boolean, got %s", path, kindOf(v))
+		}
+		return nil
+	}
+	return fmt.Errorf("%s: unknown schema type %q", path, s.Type)
+}
+
+func (d *Document) validateObject(s *Schema, obj map[string]any, path string) error {
+	for _, name := range s.Required {
+		if _, ok := obj[name]; !ok {
+			return fmt.Errorf("%s: missing required property %q", path, name)
+		}
+	}
+
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		sub := path + "." + k
+		if prop, ok := s.Properties[k]; ok {
+			if err := d.validate(prop, obj[k], sub); err != nil {
+				return err
+			}
+			continue
+		}
+		switch extra := s.AdditionalProperties.(type) {
+		case *Schema:
+			if err := d.validate(extra, obj[k], sub); err != nil {
+				return err
+			}
+		case bool:
+			if !extra {
+				return fmt.Errorf("%s: unexpected property", sub)
+			}
+		}
+	}
+	return nil
+}
+
+func validateString(s *Schema, str, path string) error {
+	if len(s.Enum) > 0 {
+		found := false
+		for _, e := range s.Enum {
+			if e == str {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: %q is not one of %s", path, str, strings.Join(s.Enum, ", "))
+		}
+	}
+	switch s.Format {
+	case "uuid":
+		if _, err := uuid.Parse(str); err != nil {
+			return fmt.Errorf("%s: %q is not a UUID", path, str)
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339Nano, str); err != nil {
+			return fmt.Errorf("%s: %q is not an RFC 3339 date-time", path, str)
+		}
+	case "date":
+		if _, err := time.Parse("2006-01-02", str); err != nil {
+			return fmt.Errorf("%s: %q is not a date", path, str)
+		}
+	}
+	return nil
+}
+
+func validateRange(s *Schema, n float64, path string) error {
+	if s.Minimum != nil && n < *s.Minimum {
+		return fmt.Errorf("%s: %v is below the minimum %v", path, n, *s.Minimum)
+	}
+	if s.Maximum != nil && n > *s.Maximum {
+		return fmt.Errorf("%s: %v is above the maximum %v", path, n, *s.Maximum)
+	}
+	return nil
+}
+
+func (d *Document) resolve(ref string) (*Schema, error) {
+	name, ok := strings.CutPrefix(ref, "#/components/schemas/")
+	if !ok {
+		return nil, fmt.Errorf("unsupported $ref %q", ref)
+	}
+	s := d.Components.Schemas[name]
+	if s == nil {
+		return nil, fmt.Errorf("unknown schema %q", name)
+	}
+	return s, nil
+}
+
+func kindOf(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// ValidateResponse checks a response body against the operation documented
+// for method and path.
+func (d *Document) ValidateResponse(method, path string, status int, contentType string, body []byte) error {
+	op := d.Operation(method, path)
+	if op == nil {
+		return fmt.Errorf("%s %s is not in the spec", method, path)
+	}
+	resp := op.Responses[strconv.Itoa(status)]
+	if resp == nil {
+		resp = op.Responses["default"]
+	}
+	if resp == nil {
+		return fmt.Errorf("status %d is not documented", status)
+	}
+
+	media, ok := resp.Content["application/json"]
+	if !ok || media.Schema == nil {
+		return nil
+	}
+	mt, _, _ := mime.ParseMediaType(contentType)
+	if mt != "application/json" {
+		return fmt.Errorf("status %d: expected application/json, got %q", status, contentType)
+	}
+
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return fmt.Errorf("status %d: invalid JSON: %w", status, err)
+	}
+	if err := d.Validate(media.Schema, v); err != nil {
+		return fmt.Errorf("status %d: %w", status, err)
+	}
+	return nil
+}
+
+// ErrUndocumented is reported for a JSON response served by a route that
+// has no operation in the spec.
+var ErrUndocumented = errors.New("answers JSON but is not in the spec")
+
+// Mismatch is told about each response that does not match the spec. Tests
+// fail on it; the server logs it with LogMismatch.
+type Mismatch func(r *http.Request, err error)
+
+// LogMismatch is a Mismatch that writes to the log.
+func LogMismatch(r *http.Request, err error) {
+	log.Printf("[openapi] %s %s does not match the spec: %v", r.Method, r.URL.Path, err)
+}
+
+// ValidateResponses wraps the handler of a documented route and reports
+// every response that does not match the spec to mismatch. It buffers each
+// response, so it is meant for tests and development rather than
+// production traffic.
+func (d *Document) ValidateResponses(method, path string, next http.HandlerFunc, mismatch Mismatch) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rec := &recorder{ResponseWriter: w, status: http.StatusOK}
+		next(rec, r)
+		err := d.ValidateResponse(method, path, rec.status, w.Header().Get("Content-Type"), rec.body.Bytes())
+		if err != nil {
+			mismatch(r, err)
+		}
+	}
+}
+
+// ValidateRoutes wraps a ServeMux and reports to mismatch, as
+// ErrUndocumented, every JSON response served by a method pattern that has
+// no operation in the spec. It must wrap the mux directly, since it reads
+// the Pattern the mux stores on the request.
+func (d *Document) ValidateRoutes(mux http.Handler, mismatch Mismatch) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mux.ServeHTTP(w, r)
+		method, path, ok := strings.Cut(r.Pattern, " ")
+		if !ok {
+			return // Method-less patterns are catch-alls such as /api/.
+		}
+		mt, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+		if mt == "application/json" && d.Operation(method, path) == nil {
+			mismatch(r, fmt.Errorf("%s %w", r.Pattern, ErrUndocumented))
+		}
+	})
+}
+
+// recorder copies a response while passing it through.
+type recorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (rec *recorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.status = status
+		rec.wroteHeader = true
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *recorder) Write(b []byte) (int, error) {
+	rec.wroteHeader = true
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
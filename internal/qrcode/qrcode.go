@@ -0,0 +1,503 @@
+// Package qrcode encodes short byte strings, such as otpauth:// URIs, as QR
+// codes (ISO/IEC 18004) without any external service. It supports byte mode
+// at error correction level M in versions 1 to 10, which holds up to 213
+// bytes, and renders the result as SVG.
+package qrcode
+
+import (
+	"fmt"
+	"strings"
+)
+
+// quietZone is the light border, in modules, required around the symbol.
+const quietZone = 4
+
+// maxVersion is the largest symbol version this package can produce.
+const maxVersion = 10
+
+// blockLayout describes how the codewords of one version are split into
+// Reed-Solomon blocks at error correction level M.
+type blockLayout struct {
+	ecPerBlock int // Error correction codewords per block.
+	g1Blocks   int // Blocks in group 1.
+	g1Data     int // Data codewords per group 1 block.
+	g2Blocks   int // Blocks in group 2, one data codeword longer.
+}
+
+// layoutsM is the level M block structure for versions 1 to 10 (index 0 is unused).
+var layoutsM = [maxVersion + 1]blockLayout{
+	{},
+	{10, 1, 16, 0},
+	{16, 1, 28, 0},
+	{26, 1, 44, 0},
+	{18, 2, 32, 0},
+	{24, 2, 43, 0},
+	{16, 4, 27, 0},
+	{18, 4, 31, 0},
+	{22, 2, 38, 2},
+	{22, 3, 36, 2},
+	{26, 4, 43, 1},
+}
+
+// alignmentCenters lists the alignment pattern coordinates for versions 1 to 10.
+var alignmentCenters = [maxVersion + 1][]int{
+	{},
+	{},
+	{6, 18},
+	{6, 22},
+	{6, 26},
+	{6, 30},
+	{6, 34},
+	{6, 22, 38},
+	{6, 24, 42},
+	{6, 26, 46},
+	{6, 28, 50},
+}
+
+func (l blockLayout) dataCodewords() int {
+	return l.g1Blocks*l.g1Data + l.g2Blocks*(l.g1Data+1)
+}
+
+// Code is an encoded QR symbol.
+type Code struct {
+	Version  int
+	Size     int // Modules per side, without the quiet zone.
+	modules  [][]bool
+	function [][]bool // Finder, timing, alignment, format and version modules.
+}
+
+// Encode returns the smallest QR code holding data in byte mode at error
+// correction level M, with the mask that scores lowest on the standard
+// penalty rules.
+func Encode(data []byte) (*Code, error) {
+	version := 0
+	for v := 1; v <= maxVersion; v++ {
+		if 4+countBits(v)+8*len(data) <= 8*layoutsM[v].dataCodewords() {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, fmt.Errorf("qrcode: %d bytes do not fit in version %d", len(data), maxVersion)
+	}
+
+	c := newCode(version)
+	c.drawFunctionPatterns()
+	c.drawCodewords(addErrorCorrection(version, encodeData(version, data)))
+
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		c.applyMask(mask)
+		c.drawFormatBits(mask)
+		if p := c.penalty(); bestPenalty < 0 || p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+		c.applyMask(mask) // XOR again to undo.
+	}
+	c.applyMask(best)
+	c.drawFormatBits(best)
+	return c, nil
+}
+
+// Dark reports whether the module at column x, row y is dark. Coordinates
+// outside the symbol, such as the quiet zone, are light.
+func (c *Code) Dark(x, y int) bool {
+	if x < 0 || y < 0 || x >= c.Size || y >= c.Size {
+		return false
+	}
+	return c.modules[y][x]
+}
+
+// SVG renders the code, quiet zone included, as a standalone SVG image with
+// one unit per module. Scale it with CSS; crisp edges keep it sharp.
+func (c *Code) SVG() string {
+	full := c.Size + 2*quietZone
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, full, full)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, full, full)
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if c.modules[y][x] {
+				fmt.Fprintf(&b, "M%d %dh1v1h-1z", x+quietZone, y+quietZone)
+			}
+		}
+	}
+	b.WriteString(`"/></svg>`)
+	return b.String()
+}
+
+func newCode(version int) *Code {
+	size := 17 + 4*version
+	c := &Code{Version: version, Size: size}
+	c.modules = make([][]bool, size)
+	c.function = make([][]bool, size)
+	for i := range c.modules {
+		c.modules[i] = make([]bool, size)
+		c.function[i] = make([]bool, size)
+	}
+	return c
+}
+
+// countBits is the width of the byte mode character count field.
+func countBits(version int) int {
+	if version <= 9 {
+		return 8
+	}
+	return 16
+}
+
+// ── Data encoding ──────────────────────────────────────────────────────────
+
+// encodeData builds the data codewords: mode indicator, length, payload,
+// terminator and pad bytes.
+func encodeData(version int, data []byte) []byte {
+	capacity := layoutsM[version].dataCodewords() * 8
+
+	var bits bitBuffer
+	bits.append(0x4, 4) // Byte mode.
+	bits.append(len(data), countBits(version))
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+	bits.append(0, min(4, capacity-len(bits)))
+	bits.append(0, (8-len(bits)%8)%8)
+	for pad := 0xEC; len(bits) < capacity; pad ^= 0xEC ^ 0x11 {
+		bits.append(pad, 8)
+	}
+
+	out := make([]byte, len(bits)/8)
+	for i, bit := range bits {
+		if bit {
+			out[i/8] |= 1 << (7 - i%8)
+		}
+	}
+	return out
+}
+
+type bitBuffer []bool
+
+func (b *bitBuffer) append(value, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*b = append(*b, (value>>i)&1 == 1)
+	}
+}
+
+// addErrorCorrection splits data into blocks, appends each block's
+// Reed-Solomon codewords and interleaves the result.
+func addErrorCorrection(version int, data []byte) []byte {
+	l := layoutsM[version]
+	divisor := rsDivisor(l.ecPerBlock)
+
+	var blocks, ecc [][]byte
+	for i, off := 0, 0; i < l.g1Blocks+l.g2Blocks; i++ {
+		n := l.g1Data
+		if i >= l.g1Blocks {
+			n++
+		}
+		block := data[off : off+n]
+		off += n
+		blocks = append(blocks, block)
+		ecc = append(ecc, rsRemainder(block, divisor))
+	}
+
+	var out []byte
+	for i := 0; i <= l.g1Data; i++ {
+		for _, block := range blocks {
+			if i < len(block) {
+				out = append(out, block[i])
+			}
+		}
+	}
+	for i := 0; i < l.ecPerBlock; i++ {
+		for _, e := range ecc {
+			out = append(out, e[i])
+		}
+	}
+	return out
+}
+
+// rsDivisor returns the Reed-Solomon generator polynomial of the given
+// degree, highest coefficient first and the leading 1 omitted.
+func rsDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMul(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMul(root, 0x02)
+	}
+	return result
+}
+
+// rsRemainder returns the error correction codewords for data.
+func rsRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, d := range divisor {
+			result[i] ^= gfMul(d, factor)
+		}
+	}
+	return result
+}
+
+// gfMul multiplies in GF(2^8) modulo x^8 + x^4 + x^3 + x^2 + 1.
+func gfMul(x, y byte) byte {
+	var z int
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>i)&1) * int(x)
+	}
+	return byte(z)
+}
+
+// ── Module placement ───────────────────────────────────────────────────────
+
+func (c *Code) setFunction(x, y int, dark bool) {
+	c.modules[y][x] = dark
+	c.function[y][x] = true
+}
+
+func (c *Code) drawFunctionPatterns() {
+	for i := 0; i < c.Size; i++ {
+		c.setFunction(6, i, i%2 == 0)
+		c.setFunction(i, 6, i%2 == 0)
+	}
+
+	c.drawFinder(3, 3)
+	c.drawFinder(c.Size-4, 3)
+	c.drawFinder(3, c.Size-4)
+
+	centers := alignmentCenters[c.Version]
+	last := len(centers) - 1
+	for i, cx := range centers {
+		for j, cy := range centers {
+			// Skip the three corners taken by finder patterns.
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			c.drawAlignment(cx, cy)
+		}
+	}
+
+	c.drawFormatBits(0) // Reserve the area; the real mask is drawn later.
+	c.drawVersion()
+}
+
+// drawFinder draws a finder pattern and its separator centred on (cx, cy).
+func (c *Code) drawFinder(cx, cy int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			x, y := cx+dx, cy+dy
+			if x < 0 || y < 0 || x >= c.Size || y >= c.Size {
+				continue
+			}
+			dist := max(abs(dx), abs(dy))
+			c.setFunction(x, y, dist != 2 && dist != 4)
+		}
+	}
+}
+
+func (c *Code) drawAlignment(cx, cy int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			c.setFunction(cx+dx, cy+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// drawFormatBits draws both copies of the format information for level M
+// and the given mask, plus the dark module.
+func (c *Code) drawFormatBits(mask int) {
+	data := 0<<3 | mask // Level M is 00.
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+	bit := func(i int) bool { return (bits>>i)&1 == 1 }
+
+	for i := 0; i <= 5; i++ {
+		c.setFunction(8, i, bit(i))
+	}
+	c.setFunction(8, 7, bit(6))
+	c.setFunction(8, 8, bit(7))
+	c.setFunction(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		c.setFunction(14-i, 8, bit(i))
+	}
+
+	for i := 0; i < 8; i++ {
+		c.setFunction(c.Size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		c.setFunction(8, c.Size-15+i, bit(i))
+	}
+	c.setFunction(8, c.Size-8, true)
+}
+
+// drawVersion draws both copies of the version information (version 7 and up).
+func (c *Code) drawVersion() {
+	if c.Version < 7 {
+		return
+	}
+	rem := c.Version
+	for i := 0; i < 12; i++ {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+	}
+	bits := c.Version<<12 | rem
+	for i := 0; i < 18; i++ {
+		dark := (bits>>i)&1 == 1
+		a, b := c.Size-11+i%3, i/3
+		c.setFunction(a, b, dark)
+		c.setFunction(b, a, dark)
+	}
+}
+
+// drawCodewords places the codewords in the two-column zigzag, skipping
+// function modules. Remainder bits stay light.
+func (c *Code) drawCodewords(data []byte) {
+	i := 0
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < c.Size; vert++ {
+			y := vert
+			if upward {
+				y = c.Size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if c.function[y][x] || i >= len(data)*8 {
+					continue
+				}
+				c.modules[y][x] = (data[i/8]>>(7-i%8))&1 == 1
+				i++
+			}
+		}
+	}
+}
+
+// applyMask XORs the data modules with the given mask pattern.
+func (c *Code) applyMask(mask int) {
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if c.function[y][x] {
+				continue
+			}
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert {
+				c.modules[y][x] = !c.modules[y][x]
+			}
+		}
+	}
+}
+
+// ── Mask penalty ───────────────────────────────────────────────────────────
+
+// penalty scores the symbol with the four rules of ISO/IEC 18004 section
+// 7.8.3; lower is easier to scan.
+func (c *Code) penalty() int {
+	total := 0
+	for i := 0; i < c.Size; i++ {
+		row := make([]bool, c.Size)
+		col := make([]bool, c.Size)
+		for j := 0; j < c.Size; j++ {
+			row[j] = c.modules[i][j]
+			col[j] = c.modules[j][i]
+		}
+		total += linePenalty(row) + linePenalty(col)
+	}
+
+	dark := 0
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if c.modules[y][x] {
+				dark++
+			}
+			if x+1 < c.Size && y+1 < c.Size {
+				v := c.modules[y][x]
+				if c.modules[y][x+1] == v && c.modules[y+1][x] == v && c.modules[y+1][x+1] == v {
+					total += 3
+				}
+			}
+		}
+	}
+
+	cells := c.Size * c.Size
+	k := (abs(dark*20-cells*10)+cells-1)/cells - 1
+	return total + k*10
+}
+
+// finderLike is the 1:1:3:1:1 finder ratio followed by four light modules.
+var finderLike = []bool{true, false, true, true, true, false, true, false, false, false, false}
+
+// linePenalty scores runs of five or more same-colour modules (rule 1) and
+// finder-like patterns (rule 3) along one row or column.
+func linePenalty(line []bool) int {
+	total := 0
+	run := 1
+	for i := 1; i <= len(line); i++ {
+		if i < len(line) && line[i] == line[i-1] {
+			run++
+			continue
+		}
+		if run >= 5 {
+			total += 3 + run - 5
+		}
+		run = 1
+	}
+
+	for i := 0; i+len(finderLike) <= len(line); i++ {
+		forward, backward := true, true
+		for j, want := range finderLike {
+			if line[i+j] != want {
+				forward = false
+			}
+			if line[i+len(finderLike)-1-j] != want {
+				backward = false
+			}
+		}
+		if forward {
+			total += 40
+		}
+		if backward {
+			total += 40
+		}
+	}
+	return total
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
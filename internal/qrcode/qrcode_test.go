@@ -0,0 +1,165 @@
+package qrcode
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRSRemainder(t *testing.T) {
+	// Version 1-M blocks from ISO/IEC 18004 Annex I ("01234567") and the
+	// common "HELLO WORLD" walkthrough.
+	tests := []struct {
+		data, want []byte
+	}{
+		{
+			[]byte{0x10, 0x20, 0x0C, 0x56, 0x61, 0x80, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11},
+			[]byte{0xA5, 0x24, 0xD4, 0xC1, 0xED, 0x36, 0xC7, 0x87, 0x2C, 0x55},
+		},
+		{
+			[]byte{0x20, 0x5B, 0x0B, 0x78, 0xD1, 0x72, 0xDC, 0x4D, 0x43, 0x40, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11},
+			[]byte{0xC4, 0x23, 0x27, 0x77, 0xEB, 0xD7, 0xE7, 0xE2, 0x5D, 0x17},
+		},
+	}
+	for _, tt := range tests {
+		if got := rsRemainder(tt.data, rsDivisor(len(tt.want))); !bytes.Equal(got, tt.want) {
+			t.Errorf("rsRemainder(% X) = % X, want % X", tt.data, got, tt.want)
+		}
+	}
+}
+
+// formatM is the format information for level M with masks 0 to 7.
+var formatM = [8]int{0x5412, 0x5125, 0x5E7C, 0x5B4B, 0x45F9, 0x40CE, 0x4F97, 0x4AA0}
+
+// readFormat returns both copies of the format information, most
+// significant bit first.
+func readFormat(c *Code) (first, second int) {
+	var a, b []bool
+	for x := 0; x <= 5; x++ {
+		a = append(a, c.Dark(x, 8))
+	}
+	a = append(a, c.Dark(7, 8), c.Dark(8, 8), c.Dark(8, 7))
+	for y := 5; y >= 0; y-- {
+		a = append(a, c.Dark(8, y))
+	}
+	for y := c.Size - 1; y >= c.Size-7; y-- {
+		b = append(b, c.Dark(8, y))
+	}
+	for x := c.Size - 8; x < c.Size; x++ {
+		b = append(b, c.Dark(x, 8))
+	}
+	return bitsValue(a), bitsValue(b)
+}
+
+func bitsValue(bits []bool) int {
+	v := 0
+	for _, bit := range bits {
+		v <<= 1
+		if bit {
+			v |= 1
+		}
+	}
+	return v
+}
+
+func TestFormatBits(t *testing.T) {
+	c := newCode(1)
+	for mask, want := range formatM {
+		c.drawFormatBits(mask)
+		if first, second := readFormat(c); first != want || second != want {
+			t.Errorf("mask %d: format bits %015b and %015b, want %015b", mask, first, second, want)
+		}
+	}
+	if !c.Dark(8, c.Size-8) {
+		t.Error("dark module is light")
+	}
+}
+
+func TestVersionBits(t *testing.T) {
+	tests := []struct{ version, want int }{
+		{7, 0x07C94},
+		{8, 0x085BC},
+		{9, 0x09A99},
+		{10, 0x0A4D3},
+	}
+	for _, tt := range tests {
+		c := newCode(tt.version)
+		c.drawVersion()
+		var topRight, bottomLeft int
+		for i := 17; i >= 0; i-- {
+			a, b := c.Size-11+i%3, i/3
+			topRight = topRight<<1 | btoi(c.Dark(a, b))
+			bottomLeft = bottomLeft<<1 | btoi(c.Dark(b, a))
+		}
+		if topRight != tt.want || bottomLeft != tt.want {
+			t.Errorf("version %d: bits %018b and %018b, want %018b", tt.version, topRight, bottomLeft, tt.want)
+		}
+	}
+}
+
+func btoi(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func TestEncodeVersion(t *testing.T) {
+	// Byte mode capacities at level M.
+	tests := []struct {
+		n, version int
+	}{
+		{14, 1},
+		{15, 2},
+		{122, 7},
+		{123, 8},
+		{213, 10},
+	}
+	for _, tt := range tests {
+		c, err := Encode(bytes.Repeat([]byte("a"), tt.n))
+		if err != nil {
+			t.Fatalf("Encode(%d bytes): %v", tt.n, err)
+		}
+		if c.Version != tt.version || c.Size != 17+4*tt.version {
+			t.Errorf("Encode(%d bytes) = version %d, size %d; want version %d", tt.n, c.Version, c.Size, tt.version)
+		}
+	}
+	if _, err := Encode(bytes.Repeat([]byte("a"), 214)); err == nil {
+		t.Error("Encode(214 bytes) succeeded, want an error")
+	}
+}
+
+func TestEncodeOTPAuthURI(t *testing.T) {
+	uri := "otpauth://totp/Modo%20Locadora:tester?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ" +
+		"&issuer=Modo%20Locadora&algorithm=SHA1&digits=6&period=30"
+	c, err := Encode([]byte(uri))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Version != 8 {
+		t.Errorf("version = %d, want 8 for %d bytes", c.Version, len(uri))
+	}
+
+	// Both copies of the format information name the same level M mask.
+	first, second := readFormat(c)
+	found := false
+	for _, f := range formatM {
+		found = found || f == first
+	}
+	if !found || first != second {
+		t.Errorf("format bits %015b and %015b, want one level M entry twice", first, second)
+	}
+
+	// Finder pattern corners and the quiet zone.
+	for _, p := range [][2]int{{0, 0}, {c.Size - 1, 0}, {0, c.Size - 1}, {3, 3}} {
+		if !c.Dark(p[0], p[1]) {
+			t.Errorf("module %v is light, want a finder pattern", p)
+		}
+	}
+	if c.Dark(-1, 0) || c.Dark(c.Size, c.Size) {
+		t.Error("quiet zone is dark")
+	}
+	if svg := c.SVG(); !strings.HasPrefix(svg, "<svg") || !strings.Contains(svg, `viewBox="0 0 57 57"`) {
+		t.Errorf("SVG header = %.80s", svg)
+	}
+}
@@ -0,0 +1,96 @@
+// Package ratelimit provides in-memory token bucket limiters keyed by an
+// arbitrary string, such as a client IP or a profile name.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often idle, full buckets are dropped from memory.
+const sweepInterval = 10 * time.Minute
+
+// bucket tracks the tokens left for one key.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter hands out up to burst tokens per key, refilling one token every
+// refill interval. It is safe for concurrent use.
+type Limiter struct {
+	mu        sync.Mutex
+	burst     float64
+	refill    time.Duration
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// New creates a Limiter that allows burst requests at once and one more
+// request every refill interval after that.
+func New(burst int, refill time.Duration) *Limiter {
+	return &Limiter{
+		burst:     float64(burst),
+		refill:    refill,
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// Allow takes one token from the bucket for key. When the bucket is empty it
+// returns false and how long the caller must wait for the next token.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	} else {
+		b.tokens = l.refilled(b, now)
+		b.last = now
+	}
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) * float64(l.refill))
+		return false, wait.Truncate(time.Second) + time.Second
+	}
+	b.tokens--
+	return true, 0
+}
+
+// Reset refills the bucket for key, e.g. after a successful login.
+func (l *Limiter) Reset(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.buckets, key)
+}
+
+// refilled returns the token count of b at now, capped at burst.
+func (l *Limiter) refilled(b *bucket, now time.Time) float64 {
+	tokens := b.tokens + float64(now.Sub(b.last))/float64(l.refill)
+	if tokens > l.burst {
+		return l.burst
+	}
+	return tokens
+}
+
+// sweep drops buckets that have refilled completely, so memory stays bounded
+// by the number of recently active keys. Callers must hold l.mu.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if l.refilled(b, now) >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 key of RFC 6238 Appendix B, "12345678901234567890".
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCodeAtRFC6238(t *testing.T) {
+	// Appendix B lists 8-digit codes; 6-digit codes are their last six digits.
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},          // 94287082
+		{1111111109, "081804"},  // 07081804
+		{1111111111, "050471"},  // 14050471
+		{1234567890, "005924"},  // 89005924
+		{2000000000, "279037"},  // 69279037
+		{20000000000, "353130"}, // 65353130
+	}
+	for _, tt := range tests {
+		got, err := CodeAt(rfcSecret, Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("CodeAt(T=%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidateWindow(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := Step(now)
+	code, err := CodeAt(rfcSecret, step)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A code stays valid one step either side of its own, and always
+	// reports its own step so a replay inside the window is recognised.
+	for _, offset := range []int64{-1, 0, 1} {
+		at := now.Add(time.Duration(offset) * Period)
+		got, ok := Validate(rfcSecret, code, at)
+		if !ok || got != step {
+			t.Errorf("Validate at step %+d = %d, %v; want %d, true", offset, got, ok, step)
+		}
+	}
+	for _, offset := range []int64{-2, 2} {
+		at := now.Add(time.Duration(offset) * Period)
+		if got, ok := Validate(rfcSecret, code, at); ok {
+			t.Errorf("Validate at step %+d = %d, true; want false", offset, got)
+		}
+	}
+
+	// The edges of the step: its first and last second.
+	first := time.Unix(step*int64(Period/time.Second), 0)
+	last := first.Add(Period - time.Second)
+	next, _ := CodeAt(rfcSecret, step+2)
+	if _, ok := Validate(rfcSecret, next, last); ok {
+		t.Error("code two steps ahead accepted on the last second of the step")
+	}
+	if got, ok := Validate(rfcSecret, next, last.Add(time.Second)); !ok || got != step+2 {
+		t.Errorf("code one step ahead on the next step's first second = %d, %v; want %d, true", got, ok, step+2)
+	}
+	prev, _ := CodeAt(rfcSecret, step-1)
+	if got, ok := Validate(rfcSecret, prev, last); !ok || got != step-1 {
+		t.Errorf("previous code on the last second of the step = %d, %v; want %d, true", got, ok, step-1)
+	}
+	if _, ok := Validate(rfcSecret, prev, last.Add(time.Second)); ok {
+		t.Error("code two steps old accepted")
+	}
+}
+
+func TestValidateInput(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	tests := []struct {
+		code string
+		want bool
+	}{
+		{"050471", true},
+		{"050 471", true},
+		{"050-471", true},
+		{"50471", false},
+		{"0504710", false},
+		{"050472", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if _, ok := Validate(rfcSecret, tt.code, now); ok != tt.want {
+			t.Errorf("Validate(%q) = %v, want %v", tt.code, ok, tt.want)
+		}
+	}
+	if _, ok := Validate("not base32!", "050471", now); ok {
+		t.Error("invalid secret accepted")
+	}
+}